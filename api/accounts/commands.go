@@ -0,0 +1,157 @@
+package accounts
+
+import (
+	"net/mail"
+	"strings"
+	"time"
+)
+
+//==============================================================================
+
+// MinPasswordLength defines the minimum length accepted for passwords.
+const MinPasswordLength = 8
+
+// RegisterUser defines a struct for requesting the registration of a new user
+// account. The UUID identifies the view which issued the request.
+type RegisterUser struct {
+	UUID     string
+	Email    string
+	Password string
+}
+
+// Validate returns the field errors found within the registration request.
+func (r RegisterUser) Validate() FieldErrors {
+	var fields FieldErrors
+
+	if addr, err := mail.ParseAddress(r.Email); err != nil || addr.Address != strings.TrimSpace(r.Email) {
+		fields = append(fields, FieldError{Name: "email", Error: "Provide a valid email address"})
+	}
+
+	if len(r.Password) < MinPasswordLength {
+		fields = append(fields, FieldError{Name: "password", Error: "Password must have at least 8 characters"})
+	}
+
+	return fields
+}
+
+// LoginUser defines a struct for requesting a new session for a user account.
+// The UUID identifies the view which issued the request.
+type LoginUser struct {
+	UUID     string
+	Email    string
+	Password string
+}
+
+// Validate returns the field errors found within the login request.
+func (l LoginUser) Validate() FieldErrors {
+	var fields FieldErrors
+
+	if strings.TrimSpace(l.Email) == "" {
+		fields = append(fields, FieldError{Name: "email", Error: "Provide your email address"})
+	}
+
+	if l.Password == "" {
+		fields = append(fields, FieldError{Name: "password", Error: "Provide your password"})
+	}
+
+	return fields
+}
+
+// SetCurrency defines a struct for requesting the change of the home currency
+// which the totals of a user's pockets are converted into.
+type SetCurrency struct {
+	Currency string
+}
+
+// SetPreferences defines a struct for requesting the change of how the user is
+// reached outside the app.
+type SetPreferences struct {
+	Preferences
+}
+
+// RequestReset defines a struct for requesting the mail of a password reset
+// token to the account with the giving email.
+type RequestReset struct {
+	Email string
+}
+
+// ResetPassword defines a struct for requesting the password of the account
+// with the giving email be replaced, proven by the reset token mailed to it.
+type ResetPassword struct {
+	Email    string
+	Token    string
+	Password string
+}
+
+// Validate returns the field errors found within the reset request.
+func (r ResetPassword) Validate() FieldErrors {
+	var fields FieldErrors
+
+	if strings.TrimSpace(r.Email) == "" {
+		fields = append(fields, FieldError{Name: "email", Error: "Provide your email address"})
+	}
+
+	if r.Token == "" {
+		fields = append(fields, FieldError{Name: "token", Error: "Provide the reset token mailed to you"})
+	}
+
+	if len(r.Password) < MinPasswordLength {
+		fields = append(fields, FieldError{Name: "password", Error: "Password must have at least 8 characters"})
+	}
+
+	return fields
+}
+
+// LogoutUser defines a struct for requesting the end of the current session.
+type LogoutUser struct {
+	Token string
+}
+
+// NormalizeEmail returns the form of the email which user records are stored
+// and looked up with.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+//==============================================================================
+
+// Session defines the session details handed to a user after a successful
+// registration or login.
+type Session struct {
+	Email   string    `json:"email"`
+	Token   string    `json:"token"`
+	Expires time.Time `json:"expires"`
+}
+
+//==============================================================================
+
+// FieldError defines the error for a specific form field, it matches the
+// field errors sent by the server within its JSON error responses.
+type FieldError struct {
+	Name  string `json:"field_name"`
+	Error string `json:"field_error"`
+}
+
+// FieldErrors defines a lists of FieldError.
+type FieldErrors []FieldError
+
+// Get returns the error message for the giving field if any.
+func (f FieldErrors) Get(name string) string {
+	for _, field := range f {
+		if field.Name == name {
+			return field.Error
+		}
+	}
+
+	return ""
+}
+
+// AccountErrors defines a notification sent to an account view identified by
+// UUID when its request fails.
+type AccountErrors struct {
+	UUID    string
+	Message string      `json:"error"`
+	Fields  FieldErrors `json:"fields"`
+}
+
+//==============================================================================
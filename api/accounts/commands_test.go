@@ -0,0 +1,75 @@
+package accounts
+
+import "testing"
+
+//==============================================================================
+
+// TestRegisterUserValidate checks registrations are refused for a malformed
+// email or a short password, naming the fields at fault.
+func TestRegisterUserValidate(t *testing.T) {
+	tests := []struct {
+		email    string
+		password string
+		fields   []string
+	}{
+		{"owner@pocket.io", "pocket-password", nil},
+		{"Owner <owner@pocket.io>", "pocket-password", []string{"email"}},
+		{"owner", "pocket-password", []string{"email"}},
+		{"", "pocket-password", []string{"email"}},
+		{"owner@pocket.io", "short", []string{"password"}},
+		{"", "", []string{"email", "password"}},
+	}
+
+	for _, test := range tests {
+		fields := RegisterUser{Email: test.email, Password: test.password}.Validate()
+		checkFields(t, "registration of "+test.email, fields, test.fields)
+	}
+}
+
+// TestLoginUserValidate checks logins are refused without an email or a
+// password, while a short password is left to the password check.
+func TestLoginUserValidate(t *testing.T) {
+	tests := []struct {
+		email    string
+		password string
+		fields   []string
+	}{
+		{"owner@pocket.io", "pocket-password", nil},
+		{"owner@pocket.io", "short", nil},
+		{"  ", "pocket-password", []string{"email"}},
+		{"owner@pocket.io", "", []string{"password"}},
+		{"", "", []string{"email", "password"}},
+	}
+
+	for _, test := range tests {
+		fields := LoginUser{Email: test.email, Password: test.password}.Validate()
+		checkFields(t, "login of "+test.email, fields, test.fields)
+	}
+}
+
+// TestNormalizeEmail checks emails differing by case or surrounding spaces
+// resolve to the same account.
+func TestNormalizeEmail(t *testing.T) {
+	for _, email := range []string{"owner@pocket.io", " Owner@Pocket.io ", "OWNER@POCKET.IO"} {
+		if normal := NormalizeEmail(email); normal != "owner@pocket.io" {
+			t.Errorf("Expected %q to normalize to owner@pocket.io, got %q", email, normal)
+		}
+	}
+}
+
+// checkFields fails the test unless the field errors name exactly the giving
+// fields, in order.
+func checkFields(t *testing.T, request string, fields FieldErrors, names []string) {
+	t.Helper()
+
+	if len(fields) != len(names) {
+		t.Errorf("Expected %d field errors for the %s, got %+v", len(names), request, fields)
+		return
+	}
+
+	for index, name := range names {
+		if fields[index].Name != name || fields.Get(name) == "" {
+			t.Errorf("Expected a field error for %s in the %s, got %+v", name, request, fields)
+		}
+	}
+}
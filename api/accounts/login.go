@@ -0,0 +1,119 @@
+package accounts
+
+import (
+	"fmt"
+
+	"github.com/gopherjs/gopherjs/js"
+	"github.com/influx6/gu/gudispatch"
+	"github.com/influx6/gu/guevents"
+	"github.com/influx6/gu/gutrees"
+	"github.com/influx6/gu/gutrees/attrs"
+	"github.com/influx6/gu/gutrees/elems"
+	"github.com/influx6/gu/guviews"
+)
+
+//==============================================================================
+
+func init() {
+	guviews.Register("pocket/user-login", func(ao AccountOptions) guviews.Renderable {
+		return NewLogin(ao)
+	})
+}
+
+//==============================================================================
+
+// UserLogin defines a struct for logging into an existing pocket user account.
+type UserLogin struct {
+	AccountOptions
+	errors AccountErrors
+}
+
+// NewLogin returns a new UserLogin instance which renders the sign-in form.
+func NewLogin(ao AccountOptions) *UserLogin {
+	ul := UserLogin{AccountOptions: ao}
+
+	gudispatch.Subscribe(func(ae *AccountErrors) {
+		if ae.UUID != ao.UUID {
+			return
+		}
+
+		ul.errors = *ae
+		gudispatch.Dispatch(&guviews.ViewUpdate{ID: ao.UUID})
+	})
+
+	return &ul
+}
+
+// Render returns the markup which renders the sign-in form.
+func (ul *UserLogin) Render() gutrees.Markup {
+	root := elems.Form(attrs.Class("account", "account-login"))
+
+	gutrees.NewEvent("submit", "", func(ev guevents.Event, _ gutrees.Markup) {
+		form := ev.Target()
+
+		gudispatch.Dispatch(&LoginUser{
+			UUID:     ul.UUID,
+			Email:    formValue(form, "email"),
+			Password: formValue(form, "password"),
+		})
+	}).PreventDefault().Apply(root)
+
+	renderMessage(ul.errors.Message).Apply(root)
+	renderField("email", "Email", attrs.TypeEmail, ul.errors.Fields).Apply(root)
+	renderField("password", "Password", attrs.TypePassword, ul.errors.Fields).Apply(root)
+
+	elems.Button(
+		attrs.Type("submit"),
+		attrs.Class("account-submit"),
+		elems.Text("Sign In"),
+	).Apply(root)
+
+	return root
+}
+
+//==============================================================================
+
+// renderField returns the markup for a labelled form input, alongside the
+// error reported for that field if any.
+func renderField(name string, label string, kind attrs.InputType, fields FieldErrors) gutrees.Markup {
+	id := fmt.Sprintf("account-%s", name)
+	msg := fields.Get(name)
+
+	classes := []string{"account-field"}
+	if msg != "" {
+		classes = append(classes, "account-field-invalid")
+	}
+
+	root := elems.Div(
+		attrs.Class(classes...),
+		elems.Label(attrs.HTMLFor(id), elems.Text(label)),
+		elems.Input(attrs.ID(id), attrs.Name(name), attrs.IType(kind)),
+	)
+
+	if msg != "" {
+		elems.Label(attrs.Class("account-field-error"), elems.Text(msg)).Apply(root)
+	}
+
+	return root
+}
+
+// renderMessage returns the markup for the general error of a form.
+func renderMessage(msg string) gutrees.Markup {
+	if msg == "" {
+		return elems.Div(attrs.Class("account-message"))
+	}
+
+	return elems.Div(attrs.Class("account-message", "account-message-error"), elems.Text(msg))
+}
+
+// formValue returns the value of the named input within the form element.
+func formValue(form *js.Object, name string) string {
+	input := form.Call("querySelector", fmt.Sprintf("[name=%q]", name))
+	if input == nil || input == js.Undefined {
+		return ""
+	}
+
+	return input.Get("value").String()
+}
+
+//==============================================================================
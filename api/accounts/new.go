@@ -1,21 +1,162 @@
 package accounts
 
 import (
+	"time"
+
+	"github.com/influx6/gu/gudispatch"
+	"github.com/influx6/gu/guevents"
 	"github.com/influx6/gu/gutrees"
+	"github.com/influx6/gu/gutrees/attrs"
 	"github.com/influx6/gu/gutrees/elems"
+	"github.com/influx6/gu/guviews"
 )
 
-// UserRecord defines a struct which details  a pocket user account.
+//==============================================================================
+
+func init() {
+	guviews.Register("pocket/new-user", func(ao AccountOptions) guviews.Renderable {
+		return NewSignUp(ao)
+	})
+}
+
+//==============================================================================
+
+// UserRecord defines a struct which details  a pocket user account. A user
+// holds a session for each device logged in, and a user who requested a
+// password reset holds the hash of the reset token until it is used or
+// expires.
 type UserRecord struct {
-	Email string `json:"email"`
-	Token string `json:"token"`
+	ID           string          `json:"id"`
+	Email        string          `json:"email"`
+	Hash         string          `json:"hash"`
+	Sessions     []SessionRecord `json:"sessions"`
+	Currency     string          `json:"currency"`
+	Preferences  Preferences     `json:"preferences"`
+	ResetHash    string          `json:"reset_hash"`
+	ResetExpires time.Time       `json:"reset_expires"`
+	Created      time.Time       `json:"created"`
+}
+
+// MaxSessions defines the number of sessions a user holds at most, the oldest
+// being ended when a login goes past it.
+const MaxSessions = 20
+
+// SessionRecord defines a session token held by a user and the time it
+// expires.
+type SessionRecord struct {
+	Token   string    `json:"token"`
+	Expires time.Time `json:"expires"`
+}
+
+// Session returns the session of the user holding the giving token and true if
+// it has not expired by the giving time, else false.
+func (u UserRecord) Session(token string, now time.Time) (SessionRecord, bool) {
+	if token == "" {
+		return SessionRecord{}, false
+	}
+
+	for _, session := range u.Sessions {
+		if session.Token == token {
+			return session, now.Before(session.Expires)
+		}
+	}
+
+	return SessionRecord{}, false
+}
+
+// AddSession adds the giving session to the user, dropping the sessions which
+// expired by the giving time and the oldest ones past MaxSessions.
+func (u *UserRecord) AddSession(session SessionRecord, now time.Time) {
+	var sessions []SessionRecord
+
+	for _, held := range u.Sessions {
+		if now.Before(held.Expires) {
+			sessions = append(sessions, held)
+		}
+	}
+
+	sessions = append(sessions, session)
+
+	if len(sessions) > MaxSessions {
+		sessions = sessions[len(sessions)-MaxSessions:]
+	}
+
+	u.Sessions = sessions
+}
+
+// EndSession removes the session holding the giving token from the user.
+func (u *UserRecord) EndSession(token string) {
+	var sessions []SessionRecord
+
+	for _, held := range u.Sessions {
+		if held.Token != token {
+			sessions = append(sessions, held)
+		}
+	}
+
+	u.Sessions = sessions
+}
+
+// Preferences defines how a user wishes to be reached outside the app. Alerts
+// at or above the MailAlerts severity, as in "warning", are mailed to the
+// user, and none are mailed when it is empty.
+type Preferences struct {
+	MailAlerts string `json:"mail_alerts"`
+}
+
+// AccountOptions defines a configuration struct passed into account view
+// initializers.
+type AccountOptions struct {
+	UUID string
 }
 
 // NewUser defines a struct for creating a new pocket user account.
-type NewUser struct{}
+type NewUser struct {
+	AccountOptions
+	errors AccountErrors
+}
+
+// NewSignUp returns a new NewUser instance which renders the sign-up form.
+func NewSignUp(ao AccountOptions) *NewUser {
+	nu := NewUser{AccountOptions: ao}
+
+	gudispatch.Subscribe(func(ae *AccountErrors) {
+		if ae.UUID != ao.UUID {
+			return
+		}
+
+		nu.errors = *ae
+		gudispatch.Dispatch(&guviews.ViewUpdate{ID: ao.UUID})
+	})
+
+	return &nu
+}
 
 // Render returns the markup which renders the new-user account markup.
 func (nu *NewUser) Render() gutrees.Markup {
-	root := elems.Div()
+	root := elems.Form(attrs.Class("account", "account-new-user"))
+
+	gutrees.NewEvent("submit", "", func(ev guevents.Event, _ gutrees.Markup) {
+		form := ev.Target()
+
+		gudispatch.Dispatch(&RegisterUser{
+			UUID:     nu.UUID,
+			Email:    formValue(form, "email"),
+			Password: formValue(form, "password"),
+		})
+	}).PreventDefault().Apply(root)
+
+	renderMessage(nu.errors.Message).Apply(root)
+	renderField("email", "Email", attrs.TypeEmail, nu.errors.Fields).Apply(root)
+	renderField("password", "Password", attrs.TypePassword, nu.errors.Fields).Apply(root)
+
+	elems.Button(
+		attrs.Type("submit"),
+		attrs.Class("account-submit"),
+		elems.Text("Sign Up"),
+	).Apply(root)
+
 	return root
 }
+
+//==============================================================================
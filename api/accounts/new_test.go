@@ -0,0 +1,75 @@
+package accounts
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+//==============================================================================
+
+// TestUserSessions checks a user holds a session per login, each expiring on
+// its own, and that ending one leaves the others.
+func TestUserSessions(t *testing.T) {
+	now := time.Now()
+
+	var user UserRecord
+	user.AddSession(SessionRecord{Token: "stale", Expires: now.Add(-time.Minute)}, now.Add(-time.Hour))
+	user.AddSession(SessionRecord{Token: "laptop", Expires: now.Add(time.Hour)}, now)
+	user.AddSession(SessionRecord{Token: "phone", Expires: now.Add(2 * time.Hour)}, now)
+
+	if len(user.Sessions) != 2 {
+		t.Fatalf("Expected the expired session to be dropped, got %+v", user.Sessions)
+	}
+
+	for _, token := range []string{"laptop", "phone"} {
+		if _, ok := user.Session(token, now); !ok {
+			t.Fatalf("Expected session %q to be valid", token)
+		}
+	}
+
+	if _, ok := user.Session("laptop", now.Add(90*time.Minute)); ok {
+		t.Fatal("Expected the laptop session to expire on its own")
+	}
+
+	if _, ok := user.Session("phone", now.Add(90*time.Minute)); !ok {
+		t.Fatal("Expected the phone session to outlive the laptop session")
+	}
+
+	if _, ok := user.Session("", now); ok {
+		t.Fatal("Expected an empty token to match no session")
+	}
+
+	user.EndSession("laptop")
+
+	if _, ok := user.Session("laptop", now); ok {
+		t.Fatal("Expected the ended session to be invalid")
+	}
+
+	if _, ok := user.Session("phone", now); !ok {
+		t.Fatal("Expected ending a session to leave the others")
+	}
+}
+
+// TestUserSessionsLimit checks a user holds at most MaxSessions sessions, the
+// oldest being ended first.
+func TestUserSessionsLimit(t *testing.T) {
+	now := time.Now()
+
+	var user UserRecord
+	for index := 0; index <= MaxSessions; index++ {
+		user.AddSession(SessionRecord{Token: fmt.Sprintf("device-%d", index), Expires: now.Add(time.Hour)}, now)
+	}
+
+	if len(user.Sessions) != MaxSessions {
+		t.Fatalf("Expected %d sessions, got %d", MaxSessions, len(user.Sessions))
+	}
+
+	if _, ok := user.Session("device-0", now); ok {
+		t.Fatal("Expected the oldest session to be ended")
+	}
+
+	if _, ok := user.Session(fmt.Sprintf("device-%d", MaxSessions), now); !ok {
+		t.Fatal("Expected the newest session to be kept")
+	}
+}
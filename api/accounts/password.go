@@ -0,0 +1,143 @@
+package accounts
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"strconv"
+	"strings"
+)
+
+//==============================================================================
+
+// HashIterations defines the number of PBKDF2 rounds used when hashing new
+// passwords. Stored hashes carry their own iteration count so this can be
+// raised without invalidating existing accounts.
+var HashIterations = 100000
+
+const (
+	hashScheme  = "pbkdf2-sha256"
+	hashSaltLen = 16
+	hashKeyLen  = 32
+	tokenLen    = 32
+)
+
+// ErrInvalidHash is returned when a stored password hash can not be parsed.
+var ErrInvalidHash = errors.New("Invalid Password Hash")
+
+// HashPassword returns a salted PBKDF2-SHA256 hash of the password, encoded
+// with its scheme, iteration count and salt as
+// `pbkdf2-sha256$<iterations>$<salt>$<key>`.
+func HashPassword(password string) (string, error) {
+	salt := make([]byte, hashSaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := pbkdf2(sha256.New, []byte(password), salt, HashIterations, hashKeyLen)
+
+	return fmt.Sprintf("%s$%d$%s$%s", hashScheme, HashIterations,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// CheckPassword returns true/false if the password matches the giving hash
+// produced by HashPassword. A hash missing its salt or key, or whose iteration
+// count does not parse, returns ErrInvalidHash rather than being compared.
+func CheckPassword(hash string, password string) (bool, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 4 || parts[0] != hashScheme {
+		return false, ErrInvalidHash
+	}
+
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil || iterations < 1 {
+		return false, ErrInvalidHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil || len(salt) == 0 {
+		return false, ErrInvalidHash
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil || len(key) == 0 {
+		return false, ErrInvalidHash
+	}
+
+	derived := pbkdf2(sha256.New, []byte(password), salt, iterations, len(key))
+	return subtle.ConstantTimeCompare(derived, key) == 1, nil
+}
+
+// NewToken returns a new random session token.
+func NewToken() (string, error) {
+	token := make([]byte, tokenLen)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(token), nil
+}
+
+// HashToken returns the hash of the token which is stored in its place, so a
+// leaked record does not leak the token.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// CheckToken returns true/false if the token matches the giving hash produced
+// by HashToken.
+func CheckToken(hash string, token string) bool {
+	if hash == "" {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(HashToken(token)), []byte(hash)) == 1
+}
+
+// pbkdf2 derives a key of keyLen bytes from the password and salt using
+// PBKDF2 with the HMAC of the giving hash as described in RFC 2898.
+func pbkdf2(h func() hash.Hash, password, salt []byte, iterations, keyLen int) []byte {
+	prf := hmac.New(h, password)
+	hashLen := prf.Size()
+	blocks := (keyLen + hashLen - 1) / hashLen
+
+	var counter [4]byte
+	key := make([]byte, 0, blocks*hashLen)
+	u := make([]byte, hashLen)
+
+	for block := 1; block <= blocks; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		binary.BigEndian.PutUint32(counter[:], uint32(block))
+		prf.Write(counter[:])
+
+		u = prf.Sum(u[:0])
+		t := make([]byte, hashLen)
+		copy(t, u)
+
+		for n := 1; n < iterations; n++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+
+			for i := range t {
+				t[i] ^= u[i]
+			}
+		}
+
+		key = append(key, t...)
+	}
+
+	return key[:keyLen]
+}
+
+//==============================================================================
@@ -0,0 +1,115 @@
+package accounts
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"testing"
+)
+
+//==============================================================================
+
+// TestPBKDF2Vectors checks the derived keys against the test vectors of
+// RFC 6070 for HMAC-SHA1 and of RFC 7914 for HMAC-SHA256. The 16777216
+// iteration vector of RFC 6070 is left out for the time it takes.
+func TestPBKDF2Vectors(t *testing.T) {
+	tests := []struct {
+		hash       func() hash.Hash
+		password   string
+		salt       string
+		iterations int
+		key        string
+	}{
+		{sha1.New, "password", "salt", 1, "0c60c80f961f0e71f3a9b524af6012062fe037a6"},
+		{sha1.New, "password", "salt", 2, "ea6c014dc72d6f8ccd1ed92ace1d41f0d8de8957"},
+		{sha1.New, "password", "salt", 4096, "4b007901b765489abead49d926f721d065a429c1"},
+		{sha1.New, "passwordPASSWORDpassword", "saltSALTsaltSALTsaltSALTsaltSALTsalt", 4096, "3d2eec4fe41c849b80c8d83662c0e44a8b291a964cf2f07038"},
+		{sha1.New, "pass\x00word", "sa\x00lt", 4096, "56fa6aa75548099dcc37d7f03425e0c3"},
+		{sha256.New, "passwd", "salt", 1, "55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc49ca9cccf179b645991664b39d77ef317c71b845b1e30bd509112041d3a19783"},
+	}
+
+	for _, test := range tests {
+		want, err := hex.DecodeString(test.key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		key := pbkdf2(test.hash, []byte(test.password), []byte(test.salt), test.iterations, len(want))
+		if hex.EncodeToString(key) != test.key {
+			t.Errorf("Expected key %s for %q salted %q over %d iterations, got %x", test.key, test.password, test.salt, test.iterations, key)
+		}
+	}
+}
+
+// TestCheckPassword checks a hash produced by HashPassword matches only its
+// own password.
+func TestCheckPassword(t *testing.T) {
+	defer func(iterations int) { HashIterations = iterations }(HashIterations)
+	HashIterations = 10
+
+	hash, err := HashPassword("pocket-password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ok, err := CheckPassword(hash, "pocket-password"); err != nil || !ok {
+		t.Fatalf("Expected the password to match its hash, got %t: %v", ok, err)
+	}
+
+	if ok, err := CheckPassword(hash, "pocket-passwore"); err != nil || ok {
+		t.Fatalf("Expected another password not to match the hash, got %t: %v", ok, err)
+	}
+}
+
+// TestCheckPasswordInvalid checks hashes which do not parse, or miss their
+// salt or key, are refused with ErrInvalidHash rather than compared.
+func TestCheckPasswordInvalid(t *testing.T) {
+	if _, err := CheckPassword("pbkdf2-sha256$10$c2FsdA$a2V5", ""); err != nil {
+		t.Fatalf("Expected a well formed hash to parse, got %v", err)
+	}
+
+	tests := []string{
+		"",
+		"pbkdf2-sha1$10$c2FsdA$a2V5",
+		"pbkdf2-sha256$ten$c2FsdA$a2V5",
+		"pbkdf2-sha256$0$c2FsdA$a2V5",
+		"pbkdf2-sha256$-1$c2FsdA$a2V5",
+		"pbkdf2-sha256$10$$a2V5",
+		"pbkdf2-sha256$10$c2FsdA$",
+		"pbkdf2-sha256$10$!!$a2V5",
+		"pbkdf2-sha256$10$c2FsdA$!!",
+		"pbkdf2-sha256$10$c2FsdA",
+	}
+
+	for _, test := range tests {
+		if ok, err := CheckPassword(test, ""); err != ErrInvalidHash || ok {
+			t.Errorf("Expected ErrInvalidHash for hash %q, got %t: %v", test, ok, err)
+		}
+	}
+}
+
+// TestTokens checks a token matches only its own hash, and never an empty one.
+func TestTokens(t *testing.T) {
+	token, err := NewToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other, err := NewToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if token == other || len(token) != tokenLen*2 {
+		t.Fatalf("Expected distinct tokens of %d characters, got %q and %q", tokenLen*2, token, other)
+	}
+
+	if !CheckToken(HashToken(token), token) {
+		t.Fatal("Expected the token to match its hash")
+	}
+
+	if CheckToken(HashToken(token), other) || CheckToken("", "") {
+		t.Fatal("Expected another token or an empty hash not to match")
+	}
+}
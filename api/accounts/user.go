@@ -19,7 +19,7 @@ type UserCurrency struct {
 func (u *UserCurrency) Render() gutrees.Markup {
 	root := elems.Div(attrs.Class("user-currency"))
 	elems.Label(attrs.Class("user-currency-name"), elems.Text(u.Currency.Name)).Apply(root)
-	elems.Label(attrs.Class("user-currency-sign"), elems.Text(u.Currency.Name)).Apply(root)
+	elems.Label(attrs.Class("user-currency-sign"), elems.Text(u.Currency.Symbol)).Apply(root)
 	return root
 }
 
@@ -0,0 +1,35 @@
+package bootstrap
+
+import "github.com/influx6/pocket/api/budgets"
+
+//==============================================================================
+
+// Global defines the name of the javascript global which holds the bootstrap
+// data rendered into the index page by the pocket-server.
+const Global = "pocketBootstrap"
+
+// User defines the details of the user whose session served the index page.
+type User struct {
+	ID    string `json:"id"`
+	Email string `json:"email"`
+}
+
+// Pocket defines a pocket rendered into the index page along with the budgets
+// it was rendered with, which the client hydrates its views from.
+type Pocket struct {
+	Pocket  budgets.PocketRecord   `json:"pocket"`
+	Budgets []budgets.BudgetRecord `json:"budgets"`
+}
+
+// Data defines the details handed to the pocket-client by the index page,
+// which it needs before it can make any request.
+type Data struct {
+	API        string             `json:"api"`
+	User       *User              `json:"user"`
+	Currency   string             `json:"currency"`
+	Locale     string             `json:"locale"`
+	Pockets    []Pocket           `json:"pockets"`
+	Categories budgets.Categories `json:"categories"`
+}
+
+//==============================================================================
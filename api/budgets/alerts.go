@@ -1,5 +1,11 @@
 package budgets
 
+import (
+	"time"
+
+	"github.com/influx6/pocket/api/currency"
+)
+
 //==============================================================================
 
 // Alert defines the types of alerts defined within the pocket app.
@@ -13,14 +19,41 @@ const (
 	// BadCurrency is defined for when a unknown/invalid currency is provided for
 	// use.
 	BadCurrency
+
+	// FailedSync is defined for when a pocket fails to sync its records with
+	// the server.
+	FailedSync
+
+	// SpendingThreshold is defined for when a budget spends past the share of
+	// its price set by a rule.
+	SpendingThreshold
+
+	// ProjectedOverrun is defined for when the spending of a budget projects
+	// past its price by the end of its period.
+	ProjectedOverrun
+
+	// LargeItem is defined for when an item is priced above the amount set by
+	// a rule.
+	LargeItem
+
+	// NoActivity is defined for when no item was written against a budget for
+	// the days set by a rule.
+	NoActivity
 )
 
 //==============================================================================
 
 // Notify defines the struct for sending notifications for budget actions.
+// Notifications raised by alert rules carry the Severity set by the Rule, the
+// Budget and any Item they concern, and a Link to the budget.
 type Notify struct {
-	Message string
-	Type    Alert
+	Message  string   `json:"message"`
+	Type     Alert    `json:"type"`
+	Severity Severity `json:"severity,omitempty"`
+	Budget   string   `json:"budget,omitempty"`
+	Item     string   `json:"item,omitempty"`
+	Rule     string   `json:"rule,omitempty"`
+	Link     string   `json:"link,omitempty"`
 }
 
 //==============================================================================
@@ -31,20 +64,238 @@ type SyncBudget struct {
 	UUID string
 }
 
-// NewBudget defines a struct for requesting the creation of a new budget item.
-type NewBudget struct {
-	By    string
+// SyncNotifications is used to send a sync signal that the notifications of
+// the user with the giving UUID should be resynced.
+type SyncNotifications struct {
+	UUID string
+}
+
+// SyncUpcoming is used to send a sync signal that the upcoming items of the
+// pocket with the giving UUID should be resynced.
+type SyncUpcoming struct {
+	UUID string
+}
+
+// DismissToast is used to request the notification centre with the giving
+// UUID stop showing the toast with the giving Toast number.
+type DismissToast struct {
 	UUID  string
-	Title string
-	Price float64
+	Toast int
+}
+
+// ShowPeriod is used to request the budget with the giving UUID show the
+// period Offset periods before its current one.
+type ShowPeriod struct {
+	UUID   string
+	Offset int
+}
+
+// ViewAsOf is used to request the pocket with the giving UUID be shown as it
+// stood at the end of the giving Date, as in "2016-03-01", or be shown as it
+// stands now if no Date is given.
+type ViewAsOf struct {
+	UUID string
+	Date string
 }
 
-// AmendBudget defines a struct for requesting the amendation of a budget item.
+// ReadNotification defines a struct for requesting the notification with the
+// giving UUID be marked as read.
+type ReadNotification struct {
+	By   string
+	UUID string
+}
+
+// ReadAllNotifications defines a struct for requesting every notification of
+// the user be marked as read.
+type ReadAllNotifications struct {
+	By string
+}
+
+// NewPocket defines a struct for requesting the creation of a new pocket.
+type NewPocket struct {
+	By       string
+	Title    string
+	Currency string
+}
+
+// ReplayPocket defines a struct for requesting the budgets and items of the
+// pocket with the giving UUID be rebuilt by replaying its event log.
+type ReplayPocket struct {
+	By   string
+	UUID string
+}
+
+// NewBudget defines a struct for requesting the creation of a new budget item,
+// rolling over within the giving Period if any.
+type NewBudget struct {
+	By     string
+	UUID   string
+	Title  string
+	Price  currency.Money
+	Period Period
+}
+
+// AmendBudget defines a struct for requesting the amendation of the title,
+// price and period of the budget with the giving UUID. The budget keeps its
+// title if none is given, and its price and period unless a Price or Period
+// is given.
 type AmendBudget struct {
-	By    string
-	UUID  string
-	Title string
-	Price float64
+	By     string
+	UUID   string
+	Title  string
+	Price  *currency.Money
+	Period *Period
+}
+
+// DeleteBudget defines a struct for requesting the budget with the giving UUID
+// be moved into the trash, from which it can be restored.
+type DeleteBudget struct {
+	By   string
+	UUID string
+}
+
+// RestoreBudget defines a struct for requesting the budget with the giving
+// UUID be restored from the trash.
+type RestoreBudget struct {
+	By   string
+	UUID string
+}
+
+// ArchiveBudget defines a struct for requesting the budget with the giving UUID
+// be archived, hiding it while keeping it within the totals, or be unarchived
+// if Archived is false.
+type ArchiveBudget struct {
+	By       string
+	UUID     string
+	Archived bool
+}
+
+// NewBudgetItem defines a struct for requesting the addition of a cost item
+// into the budget with the giving UUID, filed under the Category with the
+// giving id if any. The item is written at Time when given, else when the
+// command is applied.
+type NewBudgetItem struct {
+	By       string
+	UUID     string
+	Title    string
+	Desc     string
+	Price    currency.Money
+	Category string
+	Tags     []string
+	Time     time.Time
+}
+
+// NewRule defines a struct for requesting the addition of an alert rule of the
+// giving Kind watching the budget with the giving UUID.
+type NewRule struct {
+	By       string
+	UUID     string
+	Kind     string
+	Percent  int
+	Amount   currency.Money
+	Days     int
+	Severity Severity
+}
+
+// DeleteRule defines a struct for requesting the removal of the alert rule
+// with the giving UUID.
+type DeleteRule struct {
+	By   string
+	UUID string
+}
+
+// NewWebhook defines a struct for requesting the addition of a webhook which
+// receives the giving Events of the pocket with the giving UUID at URL, or
+// every event when none is given.
+type NewWebhook struct {
+	By     string
+	UUID   string
+	URL    string
+	Events []string
+}
+
+// DeleteWebhook defines a struct for requesting the removal of the webhook
+// with the giving UUID.
+type DeleteWebhook struct {
+	By   string
+	UUID string
+}
+
+// NewRecurring defines a struct for requesting the addition of a recurring
+// item written against the budget with the giving UUID on every day of its
+// Schedule, which starts today when no start is given.
+type NewRecurring struct {
+	By       string
+	UUID     string
+	Title    string
+	Desc     string
+	Price    currency.Money
+	Category string
+	Tags     []string
+	Schedule Schedule
+}
+
+// DeleteRecurring defines a struct for requesting the removal of the recurring
+// item with the giving UUID, leaving the items it wrote in place.
+type DeleteRecurring struct {
+	By   string
+	UUID string
+}
+
+// SkipOccurrence defines a struct for requesting the occurrence of the
+// recurring item with the giving UUID on the day of Date not be written.
+type SkipOccurrence struct {
+	By   string
+	UUID string
+	Date time.Time
+}
+
+// PostponeOccurrence defines a struct for requesting the occurrence of the
+// recurring item with the giving UUID on the day of Date be written on the
+// day of To instead.
+type PostponeOccurrence struct {
+	By   string
+	UUID string
+	Date time.Time
+	To   time.Time
+}
+
+//==============================================================================
+
+// AmendBudgetItem defines a struct for requesting the amendation of the cost
+// item with the giving UUID, replacing the details given and keeping the rest.
+// The item keeps its title if none is given, and an empty Category files it
+// under none.
+type AmendBudgetItem struct {
+	By       string
+	UUID     string
+	Title    string
+	Desc     *string
+	Price    *currency.Money
+	Category *string
+	Tags     *[]string
+}
+
+// DeleteBudgetItem defines a struct for requesting the cost item with the
+// giving UUID be moved into the trash, from which it can be restored.
+type DeleteBudgetItem struct {
+	By   string
+	UUID string
+}
+
+// RestoreBudgetItem defines a struct for requesting the cost item with the
+// giving UUID be restored from the trash.
+type RestoreBudgetItem struct {
+	By   string
+	UUID string
+}
+
+// ArchiveBudgetItem defines a struct for requesting the cost item with the
+// giving UUID be archived, or be unarchived if Archived is false.
+type ArchiveBudgetItem struct {
+	By       string
+	UUID     string
+	Archived bool
 }
 
 //==============================================================================
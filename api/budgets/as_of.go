@@ -0,0 +1,149 @@
+package budgets
+
+import (
+	"fmt"
+
+	"github.com/influx6/coquery/data"
+	"github.com/influx6/gu/gudispatch"
+	"github.com/influx6/gu/guevents"
+	"github.com/influx6/gu/gutrees"
+	"github.com/influx6/gu/gutrees/attrs"
+	"github.com/influx6/gu/gutrees/elems"
+	"github.com/influx6/gu/guviews"
+)
+
+//==============================================================================
+
+// viewAsOf switches the pocket to showing its budgets as they stood at the end
+// of the giving date, requesting them from the server, or back to showing them
+// as they stand now if the date is empty.
+func (p *PocketBudget) viewAsOf(date string) {
+	p.rl.Lock()
+	p.asOf = date
+	p.past = nil
+	p.rl.Unlock()
+
+	if date == "" || p.Server == nil {
+		gudispatch.Dispatch(&guviews.ViewUpdate{ID: p.UUID})
+		return
+	}
+
+	query := fmt.Sprintf("budgets?pocket=%s&at=%s&archived=true", p.UUID, date)
+
+	p.Server.Request(query, func(err error, meta data.ResponseMeta, records data.Parameters) {
+		if err != nil {
+			gudispatch.Dispatch(&Notify{
+				Message: err.Error(),
+				Type:    FailedSync,
+			})
+			return
+		}
+
+		var past []*Budget
+
+		for _, record := range records {
+			budget, err := p.parseBudget(record)
+			if err != nil {
+				gudispatch.Dispatch(&Notify{
+					Message: err.Error(),
+					Type:    FailedSync,
+				})
+				continue
+			}
+
+			budget.readonly = true
+			past = append(past, budget)
+		}
+
+		// Another date may have been picked while the budgets were requested.
+		p.rl.Lock()
+		if p.asOf == date {
+			p.past = past
+		}
+		p.rl.Unlock()
+
+		for _, budget := range past {
+			p.requestPastItems(date, budget.ID)
+		}
+
+		gudispatch.Dispatch(&guviews.ViewUpdate{ID: p.UUID})
+	})
+}
+
+// requestPastItems requests the items of the budget with the giving id as
+// they stood at the end of the date, adding them into the budget while the
+// pocket still shows the date.
+func (p *PocketBudget) requestPastItems(date string, budget string) {
+	query := fmt.Sprintf("items?budget=%s&at=%s&archived=true", budget, date)
+
+	p.Server.Request(query, func(err error, meta data.ResponseMeta, records data.Parameters) {
+		if err != nil {
+			gudispatch.Dispatch(&Notify{
+				Message: err.Error(),
+				Type:    FailedSync,
+			})
+			return
+		}
+
+		p.rl.RLock()
+		for _, past := range p.past {
+			if p.asOf != date || past.ID != budget {
+				continue
+			}
+
+			for _, record := range records {
+				item, err := p.parseItem(record)
+				if err != nil {
+					continue
+				}
+
+				past.syncItem(item)
+			}
+		}
+		p.rl.RUnlock()
+
+		gudispatch.Dispatch(&guviews.ViewUpdate{ID: p.UUID})
+	})
+}
+
+// renderAsOf returns the markup for the date picker which switches the pocket
+// between showing its budgets now and as they stood at a past date. The caller
+// must hold the lock of the pocket.
+func (p *PocketBudget) renderAsOf() gutrees.Markup {
+	id := fmt.Sprintf("pocket-as-of-%s", p.UUID)
+
+	input := elems.Input(
+		attrs.ID(id),
+		attrs.Class("pocket-as-of-date"),
+		attrs.IType(attrs.TypeDate),
+		attrs.Value(p.asOf),
+	)
+
+	gutrees.NewEvent("change", "", func(ev guevents.Event, _ gutrees.Markup) {
+		gudispatch.Dispatch(&ViewAsOf{
+			UUID: p.UUID,
+			Date: ev.Target().Get("value").String(),
+		})
+	}).Apply(input)
+
+	return elems.Div(
+		attrs.Class("pocket-as-of"),
+		elems.Label(attrs.HTMLFor(id), elems.Text("As of")),
+		input,
+	)
+}
+
+// renderPast returns the markup for the budgets of the pocket as they stood at
+// the date picked, which can not be changed. The caller must hold the lock of
+// the pocket.
+func (p *PocketBudget) renderPast() gutrees.Markup {
+	root := elems.Div(attrs.Class("pocket-past"))
+
+	for _, budget := range p.past {
+		budget.Render().Apply(root)
+	}
+
+	return root
+}
+
+//==============================================================================
@@ -2,7 +2,7 @@ package budgets
 
 import (
 	"fmt"
-	"sync/atomic"
+	"sync"
 	"time"
 
 	"github.com/influx6/gu/gutrees"
@@ -12,56 +12,201 @@ import (
 )
 
 // Budget defines a collection of cost items writting against a given pocket
-// budget, it hosts the central items for a budget.
+// budget, it hosts the central items for a budget. Archived budgets and those
+// in the trash are shown apart from the rest, and readonly budgets, as shown
+// for a past date, are rendered without any way to change them. The items of
+// a budget are guarded by its lock. Budgets with a Period show a single period
+// at a time, offset from their current period.
 type Budget struct {
-	Title            string  `json:"title"`
-	Price            float64 `json:"price"`
+	ID               string         `json:"id"`
+	Title            string         `json:"title"`
+	Price            currency.Money `json:"price"`
+	Prices           []PriceChange  `json:"prices"`
+	Period           Period         `json:"period"`
+	Archived         bool           `json:"archived"`
+	Deleted          bool           `json:"deleted"`
+	rl               sync.RWMutex
 	items            []BudgetItem
 	currency         currency.Currency
-	action           int64
+	locale           currency.Locale
+	categories       Categories
+	readonly         bool
+	offset           int
 	activeBudgetItem int
 }
 
-// AddItem adds a new budget item into the lists of Budgets.
-func (b *Budget) AddItem(title string, desc string, price float64) {
-	bi := BudgetItem{
-		Title:  title,
-		Desc:   desc,
-		Price:  price,
-		Time:   time.Now(),
-		Budget: b,
+// AddItem adds a new budget item into the lists of Budgets, its price held in
+// the currency of the budget, filed under the category with the giving id if
+// any and labelled with the tags. The item is written at the giving time, or
+// now when it is zero.
+func (b *Budget) AddItem(title string, desc string, price currency.Money, category string, tags []string, at time.Time) {
+	if cp, err := price.In(b.currency); err == nil {
+		price = cp
+	}
+
+	if at.IsZero() {
+		at = time.Now()
 	}
 
-	atomic.AddInt64(&b.action, 1)
-	{
-		b.items = append(b.items, bi)
+	bi := BudgetItem{
+		Title:      title,
+		Desc:       desc,
+		Price:      price,
+		Categories: b.categories.Path(category),
+		Tags:       NormalizeTags(tags),
+		Time:       at,
+		Budget:     b,
 	}
-	atomic.AddInt64(&b.action, -1)
+
+	b.rl.Lock()
+	defer b.rl.Unlock()
+
+	b.items = append(b.items, bi)
 }
 
 // RenderBase returns a markup to render the basic view of a Budget.
 func (b *Budget) RenderBase() gutrees.Markup {
+	b.rl.RLock()
+	defer b.rl.RUnlock()
+
+	var count int
+	for _, item := range b.items {
+		if !item.Archived && !item.Deleted {
+			count++
+		}
+	}
+
 	root := elems.Div(
 		attrs.Class("budget"),
-		elems.Label(attrs.Class("budget-item-price"), elems.Text(fmt.Sprintf("%s%.2f", b.currency, b.Price))),
-		elems.Label(attrs.Class("budget-item-count"), elems.Text(fmt.Sprintf("%d", len(b.items)))),
+		elems.Label(attrs.Class("budget-item-price"), elems.Text(b.Price.Format(b.locale))),
+		elems.Label(attrs.Class("budget-item-count"), elems.Text(fmt.Sprintf("%d", count))),
 	)
+
+	// Budgets are only known to the server once they have an id, which links
+	// to them from the notifications they raise.
+	if b.ID != "" {
+		attrs.ID(budgetAnchor(b.ID)).Apply(root)
+	}
+
+	if b.ID != "" && !b.readonly {
+		renderAction("Archive", &ArchiveBudget{UUID: b.ID, Archived: true}).Apply(root)
+		renderAction("Delete", &DeleteBudget{UUID: b.ID}).Apply(root)
+	}
+
 	return root
 }
 
 // Render returns the markup defined for a budget item which is to be rendered.
 func (b *Budget) Render() gutrees.Markup {
+	b.rl.RLock()
+	defer b.rl.RUnlock()
+
 	root := elems.Div(attrs.Class("budget"))
 
 	barView := elems.Div(attrs.Class("budget-bar", "side-left"))
 	barItems := elems.Div(attrs.Class("budget-items", "side-right"))
 
+	archived := elems.Div(attrs.Class("budget-archived"))
+	trash := elems.Div(attrs.Class("budget-trash"))
+
+	// Budgets with a period only show the items of the period shown.
+	total, index, count := b.periodTotal(time.Now())
+	if count > 0 {
+		b.renderPeriod(total, index, count).Apply(barView)
+	}
+
 	for _, item := range b.items {
-		item.Render().Apply(barItems)
+		if count > 0 && (item.Time.Before(total.Start) || !item.Time.Before(total.End)) {
+			continue
+		}
+
+		switch {
+		case item.Deleted:
+			renderShelved(item.Title, &RestoreBudgetItem{UUID: item.ID}, "Restore").Apply(trash)
+		case item.Archived && b.readonly:
+			renderShelved(item.Title, nil, "").Apply(archived)
+		case item.Archived:
+			renderShelved(item.Title, &ArchiveBudgetItem{UUID: item.ID}, "Unarchive").Apply(archived)
+		default:
+			item.Render().Apply(barItems)
+		}
 	}
 
 	barView.Apply(root)
 	barItems.Apply(root)
+	archived.Apply(root)
+	trash.Apply(root)
+
+	if b.readonly {
+		return root
+	}
+
+	form := ItemForm{Budget: b.ID, Currency: b.currency, Categories: b.categories}
+	form.Render().Apply(root)
+
+	return root
+}
+
+// periodTotal returns the totals of the budget within the period it shows,
+// along with the index of the period and the count of periods through the one
+// holding the giving time. The count is 0 for budgets without a period. The
+// caller must hold the lock of the budget.
+func (b *Budget) periodTotal(now time.Time) (PeriodTotal, int, int) {
+	if b.Period.IsZero() {
+		return PeriodTotal{}, 0, 0
+	}
+
+	var spends []Spend
+	for _, item := range b.items {
+		if !item.Deleted {
+			spends = append(spends, Spend{Time: item.Time, Amount: item.Price})
+		}
+	}
+
+	history := BudgetRecord{Price: b.Price, Prices: b.Prices}.PriceHistory()
+
+	totals, err := b.Period.Totals(b.ID, history, spends, now)
+	if err != nil || len(totals) == 0 {
+		return PeriodTotal{}, 0, 0
+	}
+
+	index := len(totals) - 1 + b.offset
+	if index < 0 {
+		index = 0
+	}
+
+	if index > len(totals)-1 {
+		index = len(totals) - 1
+	}
+
+	return totals[index], index, len(totals)
+}
+
+// renderPeriod returns the markup for the totals of the period shown by the
+// budget, with the actions which move it to the periods before and after.
+func (b *Budget) renderPeriod(total PeriodTotal, index int, count int) gutrees.Markup {
+	root := elems.Div(attrs.Class("budget-period"))
+
+	if index > 0 && !b.readonly {
+		renderAction("Previous", &ShowPeriod{UUID: b.ID, Offset: index - count}).Apply(root)
+	}
+
+	// Periods end where the next starts, so show the last day held.
+	dates := fmt.Sprintf("%s - %s", total.Start.Format(periodLayout), total.End.AddDate(0, 0, -1).Format(periodLayout))
+	elems.Label(attrs.Class("budget-period-dates"), elems.Text(dates)).Apply(root)
+
+	if index < count-1 && !b.readonly {
+		renderAction("Next", &ShowPeriod{UUID: b.ID, Offset: index + 2 - count}).Apply(root)
+	}
+
+	elems.Label(attrs.Class("budget-period-budgeted"), elems.Text(total.Budgeted.Format(b.locale))).Apply(root)
+
+	if b.Period.CarryOver {
+		elems.Label(attrs.Class("budget-period-carried"), elems.Text(total.Carried.Format(b.locale))).Apply(root)
+	}
+
+	elems.Label(attrs.Class("budget-period-spent"), elems.Text(total.Spent.Format(b.locale))).Apply(root)
+	elems.Label(attrs.Class("budget-period-remaining"), elems.Text(total.Remaining.Format(b.locale))).Apply(root)
 
 	return root
 }
@@ -7,16 +7,23 @@ import (
 	"github.com/influx6/gu/gutrees"
 	"github.com/influx6/gu/gutrees/attrs"
 	"github.com/influx6/gu/gutrees/elems"
+	"github.com/influx6/pocket/api/currency"
 )
 
 // BudgetItem defines a price item which defines a subcost to a given Budget in
-// a pocket.
+// a pocket. Categories holds the path of the category the item is filed under,
+// from the outermost category inward.
 type BudgetItem struct {
-	Title  string    `json:"title"`
-	Desc   string    `json:"desc"`
-	Price  float64   `json:"price"`
-	Time   time.Time `json:"time"`
-	Budget *Budget   `json:"budget"`
+	ID         string           `json:"id"`
+	Title      string           `json:"title"`
+	Desc       string           `json:"desc"`
+	Price      currency.Money   `json:"price"`
+	Categories []CategoryRecord `json:"categories"`
+	Tags       []string         `json:"tags"`
+	Time       time.Time        `json:"time"`
+	Archived   bool             `json:"archived"`
+	Deleted    bool             `json:"deleted"`
+	Budget     *Budget          `json:"budget"`
 }
 
 // Render returns the markup defined for a BudgetItem which is to be rendered.
@@ -29,9 +36,35 @@ func (b *BudgetItem) Render() gutrees.Markup {
 		tag = b.Title
 	}
 
-	return elems.Div(
+	root := elems.Div(
 		attrs.Class("budget-item"),
-		elems.Label(attrs.Class("budget-item-price"), elems.Text(fmt.Sprintf("%s%.2f", b.Budget.currency, b.Price))),
+		elems.Label(attrs.Class("budget-item-price"), elems.Text(b.Price.Format(b.Budget.locale))),
 		elems.Label(attrs.Class("budget-item-name"), elems.Text(fmt.Sprintf("%s..", tag))),
 	)
+
+	// Items are only known to the server once they have an id, and can not be
+	// changed within readonly budgets.
+	if b.ID != "" && !b.Budget.readonly {
+		renderAction("Archive", &ArchiveBudgetItem{UUID: b.ID, Archived: true}).Apply(root)
+		renderAction("Delete", &DeleteBudgetItem{UUID: b.ID}).Apply(root)
+	}
+
+	if len(b.Categories) == 0 && len(b.Tags) == 0 {
+		return root
+	}
+
+	chips := elems.Div(attrs.Class("budget-item-chips"))
+
+	for _, category := range b.Categories {
+		chip := elems.Span(attrs.Class("budget-item-category"), elems.Text(category.Name))
+		gutrees.NewStyle("background-color", category.Color).Apply(chip)
+		chip.Apply(chips)
+	}
+
+	for _, tag := range b.Tags {
+		elems.Span(attrs.Class("budget-item-tag"), elems.Text("#"+tag)).Apply(chips)
+	}
+
+	chips.Apply(root)
+	return root
 }
@@ -0,0 +1,52 @@
+package budgets
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influx6/pocket/api/currency"
+)
+
+// TestPeriodTotalOffset checks the period shown by a budget stays within its
+// periods, however far its offset moves past the first or the current one.
+func TestPeriodTotalOffset(t *testing.T) {
+	usd, err := currency.ISO4217.Find("USD")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := start.AddDate(0, 3, 5)
+
+	tests := []struct {
+		offset int
+		index  int
+	}{
+		{0, 3},
+		{-1, 2},
+		{-3, 0},
+		{-10, 0},
+		{1, 3},
+		{10, 3},
+	}
+
+	for _, test := range tests {
+		budget := Budget{
+			ID:       "rent",
+			Price:    currency.NewMoney(100000, usd),
+			Prices:   []PriceChange{{From: start, Price: currency.NewMoney(100000, usd)}},
+			Period:   Period{Kind: PeriodMonthly, Anchor: start},
+			currency: usd,
+			offset:   test.offset,
+		}
+
+		total, index, count := budget.periodTotal(now)
+		if index != test.index || count != 4 {
+			t.Errorf("Expected period %d of 4 at offset %d, got %d of %d", test.index, test.offset, index, count)
+		}
+
+		if total.Budgeted.Amount != 100000 {
+			t.Errorf("Expected the period at offset %d to budget 100000, got %d", test.offset, total.Budgeted.Amount)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package budgets
+
+import (
+	"hash/fnv"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/influx6/pocket/api/currency"
+)
+
+//==============================================================================
+
+// CategoryRecord defines the stored details of a user defined category which
+// items are filed under. Categories nest under their Parent, as in Groceries
+// under Food, and are shown with their Color.
+type CategoryRecord struct {
+	ID     string `json:"id" bson:"_id"`
+	Owner  string `json:"owner"`
+	Name   string `json:"name"`
+	Parent string `json:"parent"`
+	Color  string `json:"color"`
+}
+
+// NewCategory defines a struct for requesting the creation of a new category,
+// nested under the Parent category if provided.
+type NewCategory struct {
+	By     string
+	Name   string
+	Parent string
+	Color  string
+}
+
+// Spending defines the total of the items filed under a category or tag, the
+// total of a category including the items of all categories nested under it.
+type Spending struct {
+	ID     string         `json:"id"`
+	Name   string         `json:"name"`
+	Parent string         `json:"parent,omitempty"`
+	Color  string         `json:"color,omitempty"`
+	Count  int            `json:"count"`
+	Total  currency.Money `json:"total"`
+}
+
+//==============================================================================
+
+// categoryColors defines the colors handed to categories created without one.
+var categoryColors = []string{
+	"#e57373", "#f06292", "#ba68c8", "#7986cb", "#4fc3f7",
+	"#4db6ac", "#81c784", "#dce775", "#ffb74d", "#a1887f",
+}
+
+// colorPattern matches the hex colors accepted for categories.
+var colorPattern = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// CategoryColor returns the color used for a category with the giving name when
+// none is chosen, the same name always receiving the same color.
+func CategoryColor(name string) string {
+	hash := fnv.New32a()
+	hash.Write([]byte(strings.ToLower(name)))
+	return categoryColors[hash.Sum32()%uint32(len(categoryColors))]
+}
+
+// ValidColor returns true/false if the color is a hex color, as in "#4db6ac".
+func ValidColor(color string) bool {
+	return colorPattern.MatchString(color)
+}
+
+// NormalizeTags returns the tags trimmed, lower cased, sorted and without
+// duplicates or empty tags.
+func NormalizeTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	normal := make([]string, 0, len(tags))
+
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(tag), "#")))
+		if tag == "" || seen[tag] {
+			continue
+		}
+
+		seen[tag] = true
+		normal = append(normal, tag)
+	}
+
+	sort.Strings(normal)
+	return normal
+}
+
+// ParseTags returns the normalized tags of the comma separated list.
+func ParseTags(list string) []string {
+	return NormalizeTags(strings.Split(list, ","))
+}
+
+//==============================================================================
+
+// Categories defines a list of categories forming a hierarchy through their
+// parents.
+type Categories []CategoryRecord
+
+// Find returns the category with the giving id.
+func (c Categories) Find(id string) (CategoryRecord, bool) {
+	for _, category := range c {
+		if category.ID == id {
+			return category, true
+		}
+	}
+
+	return CategoryRecord{}, false
+}
+
+// Path returns the categories from the root of the hierarchy down to the
+// category with the giving id, or nil if it is unknown.
+func (c Categories) Path(id string) []CategoryRecord {
+	var path []CategoryRecord
+
+	seen := make(map[string]bool)
+
+	for id != "" && !seen[id] {
+		category, ok := c.Find(id)
+		if !ok {
+			break
+		}
+
+		seen[id] = true
+		path = append([]CategoryRecord{category}, path...)
+		id = category.Parent
+	}
+
+	return path
+}
+
+// Label returns the names of the path of the category joined together, as in
+// "Food / Groceries".
+func (c Categories) Label(id string) string {
+	var names []string
+
+	for _, category := range c.Path(id) {
+		names = append(names, category.Name)
+	}
+
+	return strings.Join(names, " / ")
+}
+
+// Within returns true/false if the category with the giving id is the ancestor
+// category or nested anywhere under it.
+func (c Categories) Within(id string, ancestor string) bool {
+	for _, category := range c.Path(id) {
+		if category.ID == ancestor {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Walk calls the function for every category ordered by name, each followed by
+// the categories nested under it, alongside the depth of its nesting.
+func (c Categories) Walk(fn func(category CategoryRecord, depth int)) {
+	children := make(map[string][]CategoryRecord)
+
+	for _, category := range c {
+		parent := category.Parent
+		if _, ok := c.Find(parent); !ok {
+			parent = ""
+		}
+
+		children[parent] = append(children[parent], category)
+	}
+
+	seen := make(map[string]bool)
+
+	var walk func(parent string, depth int)
+	walk = func(parent string, depth int) {
+		list := children[parent]
+		sort.Sort(categoriesByName(list))
+
+		for _, category := range list {
+			if seen[category.ID] {
+				continue
+			}
+
+			seen[category.ID] = true
+			fn(category, depth)
+			walk(category.ID, depth+1)
+		}
+	}
+
+	walk("", 0)
+}
+
+// categoriesByName implements sort.Interface to order categories by name.
+type categoriesByName []CategoryRecord
+
+func (c categoriesByName) Len() int           { return len(c) }
+func (c categoriesByName) Swap(i, j int)      { c[i], c[j] = c[j], c[i] }
+func (c categoriesByName) Less(i, j int) bool { return c[i].Name < c[j].Name }
+
+//==============================================================================
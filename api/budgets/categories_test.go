@@ -0,0 +1,129 @@
+package budgets
+
+import (
+	"reflect"
+	"testing"
+)
+
+//==============================================================================
+
+// TestNormalizeTags checks tags are trimmed of spaces and hashes, lower cased,
+// sorted and rid of duplicates and empty tags.
+func TestNormalizeTags(t *testing.T) {
+	tests := []struct {
+		list     string
+		expected []string
+	}{
+		{"", []string{}},
+		{" , ,#", []string{}},
+		{"Weekly", []string{"weekly"}},
+		{"weekly, #Treat, WEEKLY,  # treat ", []string{"treat", "weekly"}},
+		{"b,a,c", []string{"a", "b", "c"}},
+	}
+
+	for _, test := range tests {
+		if tags := ParseTags(test.list); !reflect.DeepEqual(tags, test.expected) {
+			t.Errorf("Expected tags %q of %q, got %q", test.expected, test.list, tags)
+		}
+	}
+}
+
+// TestCategoriesHierarchy checks the path, label and ancestry of nested
+// categories, including categories whose parents form a cycle or are unknown.
+func TestCategoriesHierarchy(t *testing.T) {
+	categories := Categories{
+		{ID: "food", Name: "Food"},
+		{ID: "groceries", Name: "Groceries", Parent: "food"},
+		{ID: "fruit", Name: "Fruit", Parent: "groceries"},
+		{ID: "transport", Name: "Transport"},
+		{ID: "orphan", Name: "Orphan", Parent: "removed"},
+		{ID: "ping", Name: "Ping", Parent: "pong"},
+		{ID: "pong", Name: "Pong", Parent: "ping"},
+	}
+
+	labels := map[string]string{
+		"food":    "Food",
+		"fruit":   "Food / Groceries / Fruit",
+		"orphan":  "Orphan",
+		"ping":    "Pong / Ping",
+		"unknown": "",
+	}
+
+	for id, label := range labels {
+		if found := categories.Label(id); found != label {
+			t.Errorf("Expected label %q of %s, got %q", label, id, found)
+		}
+	}
+
+	tests := []struct {
+		id       string
+		ancestor string
+		within   bool
+	}{
+		{"fruit", "food", true},
+		{"fruit", "groceries", true},
+		{"fruit", "fruit", true},
+		{"food", "fruit", false},
+		{"transport", "food", false},
+		{"", "food", false},
+		{"ping", "pong", true},
+	}
+
+	for _, test := range tests {
+		if within := categories.Within(test.id, test.ancestor); within != test.within {
+			t.Errorf("Expected %s within %s to be %t", test.id, test.ancestor, test.within)
+		}
+	}
+}
+
+// TestCategoriesWalk checks categories are walked by name with each followed
+// by those nested under it, and categories of unknown parents at the root.
+func TestCategoriesWalk(t *testing.T) {
+	categories := Categories{
+		{ID: "transport", Name: "Transport"},
+		{ID: "fruit", Name: "Fruit", Parent: "groceries"},
+		{ID: "groceries", Name: "Groceries", Parent: "food"},
+		{ID: "dining", Name: "Dining", Parent: "food"},
+		{ID: "food", Name: "Food"},
+		{ID: "orphan", Name: "Orphan", Parent: "removed"},
+	}
+
+	var walked []string
+	var depths []int
+
+	categories.Walk(func(category CategoryRecord, depth int) {
+		walked = append(walked, category.ID)
+		depths = append(depths, depth)
+	})
+
+	expected := []string{"food", "dining", "groceries", "fruit", "orphan", "transport"}
+	if !reflect.DeepEqual(walked, expected) {
+		t.Fatalf("Expected categories walked as %v, got %v", expected, walked)
+	}
+
+	if !reflect.DeepEqual(depths, []int{0, 1, 1, 2, 0, 0}) {
+		t.Fatalf("Expected depths of 0, 1, 1, 2, 0, 0, got %v", depths)
+	}
+}
+
+// TestCategoryColor checks categories created without a color always receive
+// the same valid color for the same name.
+func TestCategoryColor(t *testing.T) {
+	color := CategoryColor("Groceries")
+
+	if !ValidColor(color) || CategoryColor("groceries") != color {
+		t.Fatalf("Expected a valid color kept across cases, got %q", color)
+	}
+
+	for _, color := range []string{"#fff", "#4DB6AC"} {
+		if !ValidColor(color) {
+			t.Errorf("Expected %q to be a valid color", color)
+		}
+	}
+
+	for _, color := range []string{"", "fff", "#ffff", "#ggg", "red"} {
+		if ValidColor(color) {
+			t.Errorf("Expected %q to be an invalid color", color)
+		}
+	}
+}
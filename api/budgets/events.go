@@ -0,0 +1,374 @@
+package budgets
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/influx6/pocket/api/currency"
+)
+
+//==============================================================================
+
+// ErrUnknownEvent is returned when an event of an unknown kind is applied.
+var ErrUnknownEvent = errors.New("Unknown Event")
+
+// ErrUnknownTarget is returned when an event changes a budget or item which
+// no earlier event created.
+var ErrUnknownTarget = errors.New("Unknown Event Target")
+
+// Event kinds recorded into the event log, named after the command each
+// event holds.
+const (
+	EventNewBudget         = "NewBudget"
+	EventAmendBudget       = "AmendBudget"
+	EventDeleteBudget      = "DeleteBudget"
+	EventRestoreBudget     = "RestoreBudget"
+	EventArchiveBudget     = "ArchiveBudget"
+	EventNewBudgetItem     = "NewBudgetItem"
+	EventAmendBudgetItem   = "AmendBudgetItem"
+	EventDeleteBudgetItem  = "DeleteBudgetItem"
+	EventRestoreBudgetItem = "RestoreBudgetItem"
+	EventArchiveBudgetItem = "ArchiveBudgetItem"
+)
+
+//==============================================================================
+
+// EventRecord defines a command applied to a budget, or to an item when Item
+// is set, stored within the append-only event log of its pocket. Seq orders
+// the events as they were appended and Command holds the command as JSON.
+type EventRecord struct {
+	ID      string          `json:"id" bson:"_id"`
+	Seq     int64           `json:"seq"`
+	Kind    string          `json:"kind"`
+	Pocket  string          `json:"pocket"`
+	Budget  string          `json:"budget"`
+	Item    string          `json:"item,omitempty"`
+	By      string          `json:"by"`
+	Time    time.Time       `json:"time"`
+	Command json.RawMessage `json:"command"`
+}
+
+// NewEvent returns the event recording the command, made by the user with the
+// giving id, against the budget and item of the pocket. The By field of the
+// command is set to the user as well.
+func NewEvent(id string, by string, pocket string, budget string, item string, command interface{}) (EventRecord, error) {
+	var kind string
+
+	switch cmd := command.(type) {
+	case NewBudget:
+		cmd.By = by
+		kind, command = EventNewBudget, cmd
+	case AmendBudget:
+		cmd.By = by
+		kind, command = EventAmendBudget, cmd
+	case DeleteBudget:
+		cmd.By = by
+		kind, command = EventDeleteBudget, cmd
+	case RestoreBudget:
+		cmd.By = by
+		kind, command = EventRestoreBudget, cmd
+	case ArchiveBudget:
+		cmd.By = by
+		kind, command = EventArchiveBudget, cmd
+	case NewBudgetItem:
+		cmd.By = by
+		kind, command = EventNewBudgetItem, cmd
+	case AmendBudgetItem:
+		cmd.By = by
+		kind, command = EventAmendBudgetItem, cmd
+	case DeleteBudgetItem:
+		cmd.By = by
+		kind, command = EventDeleteBudgetItem, cmd
+	case RestoreBudgetItem:
+		cmd.By = by
+		kind, command = EventRestoreBudgetItem, cmd
+	case ArchiveBudgetItem:
+		cmd.By = by
+		kind, command = EventArchiveBudgetItem, cmd
+	default:
+		return EventRecord{}, fmt.Errorf("%s: %T", ErrUnknownEvent, command)
+	}
+
+	data, err := json.Marshal(command)
+	if err != nil {
+		return EventRecord{}, err
+	}
+
+	event := EventRecord{
+		ID:      id,
+		Kind:    kind,
+		Pocket:  pocket,
+		Budget:  budget,
+		Item:    item,
+		By:      by,
+		Time:    time.Now(),
+		Command: data,
+	}
+
+	return event, nil
+}
+
+// Decode returns the command held by the event. Amounts within the command are
+// decoded in the currency they name.
+func (e EventRecord) Decode() (interface{}, error) {
+	var command interface{}
+
+	switch e.Kind {
+	case EventNewBudget:
+		command = &NewBudget{}
+	case EventAmendBudget:
+		command = &AmendBudget{}
+	case EventDeleteBudget:
+		command = &DeleteBudget{}
+	case EventRestoreBudget:
+		command = &RestoreBudget{}
+	case EventArchiveBudget:
+		command = &ArchiveBudget{}
+	case EventNewBudgetItem:
+		command = &NewBudgetItem{}
+	case EventAmendBudgetItem:
+		command = &AmendBudgetItem{}
+	case EventDeleteBudgetItem:
+		command = &DeleteBudgetItem{}
+	case EventRestoreBudgetItem:
+		command = &RestoreBudgetItem{}
+	case EventArchiveBudgetItem:
+		command = &ArchiveBudgetItem{}
+	default:
+		return nil, fmt.Errorf("%s: %q", ErrUnknownEvent, e.Kind)
+	}
+
+	if err := json.Unmarshal(e.Command, command); err != nil {
+		return nil, err
+	}
+
+	return command, nil
+}
+
+//==============================================================================
+
+// Ledger defines the budgets and items of a pocket as built by applying the
+// events of its log, holding every amount in the currency of the pocket.
+type Ledger struct {
+	Currency currency.Currency
+	Budgets  map[string]BudgetRecord
+	Items    map[string]ItemRecord
+}
+
+// NewLedger returns a new empty Ledger for a pocket of the giving currency.
+func NewLedger(cu currency.Currency) *Ledger {
+	ledger := Ledger{
+		Currency: cu,
+		Budgets:  make(map[string]BudgetRecord),
+		Items:    make(map[string]ItemRecord),
+	}
+
+	return &ledger
+}
+
+// Replay returns the Ledger built by applying the events in order to an empty
+// ledger of the giving currency.
+func Replay(cu currency.Currency, events []EventRecord) (*Ledger, error) {
+	ledger := NewLedger(cu)
+
+	for _, event := range events {
+		if err := ledger.Apply(event); err != nil {
+			return nil, err
+		}
+	}
+
+	return ledger, nil
+}
+
+// Until returns the leading events of the log which were applied on or before
+// the giving time.
+func Until(events []EventRecord, at time.Time) []EventRecord {
+	for index, event := range events {
+		if event.Time.After(at) {
+			return events[:index]
+		}
+	}
+
+	return events
+}
+
+// Apply applies the command held by the event to the budget or item it
+// targets. Commands other than those creating a budget or item return
+// ErrUnknownTarget if their target is not within the ledger.
+func (l *Ledger) Apply(event EventRecord) error {
+	command, err := event.Decode()
+	if err != nil {
+		return err
+	}
+
+	switch cmd := command.(type) {
+	case *NewBudget:
+		price, err := cmd.Price.In(l.Currency)
+		if err != nil {
+			return err
+		}
+
+		l.Budgets[event.Budget] = BudgetRecord{
+			ID:     event.Budget,
+			Pocket: event.Pocket,
+			Title:  cmd.Title,
+			Price:  price,
+			Prices: []PriceChange{{From: event.Time, Price: price}},
+			Period: anchorPeriod(cmd.Period, Period{}, event.Time),
+		}
+
+		return nil
+
+	case *NewBudgetItem:
+		price, err := cmd.Price.In(l.Currency)
+		if err != nil {
+			return err
+		}
+
+		at := event.Time
+		if !cmd.Time.IsZero() {
+			at = cmd.Time
+		}
+
+		l.Items[event.Item] = ItemRecord{
+			ID:       event.Item,
+			Budget:   event.Budget,
+			Title:    cmd.Title,
+			Desc:     cmd.Desc,
+			Price:    price,
+			Category: cmd.Category,
+			Tags:     NormalizeTags(cmd.Tags),
+			Time:     at,
+		}
+
+		return nil
+	}
+
+	if event.Item != "" {
+		return l.applyItem(event, command)
+	}
+
+	return l.applyBudget(event, command)
+}
+
+// applyBudget applies the command of the event to the budget it targets.
+func (l *Ledger) applyBudget(event EventRecord, command interface{}) error {
+	budget, ok := l.Budgets[event.Budget]
+	if !ok {
+		return ErrUnknownTarget
+	}
+
+	switch cmd := command.(type) {
+	case *AmendBudget:
+		if cmd.Price != nil {
+			price, err := cmd.Price.In(l.Currency)
+			if err != nil {
+				return err
+			}
+
+			budget.Price = price
+			// Copy the history, which may share its array with a stored record.
+			budget.Prices = append(append([]PriceChange(nil), budget.PriceHistory()...), PriceChange{From: event.Time, Price: price})
+		}
+
+		if cmd.Title != "" {
+			budget.Title = cmd.Title
+		}
+
+		if cmd.Period != nil {
+			budget.Period = anchorPeriod(*cmd.Period, budget.Period, event.Time)
+		}
+
+	case *DeleteBudget:
+		if budget.Deleted == nil {
+			deleted := event.Time
+			budget.Deleted = &deleted
+		}
+
+	case *RestoreBudget:
+		budget.Deleted = nil
+
+	case *ArchiveBudget:
+		budget.Archived = cmd.Archived
+
+	default:
+		return fmt.Errorf("%s: %q", ErrUnknownEvent, event.Kind)
+	}
+
+	l.Budgets[event.Budget] = budget
+	return nil
+}
+
+// anchorPeriod returns the period anchored to the anchor of the period it
+// replaces, else to the giving time, unless it carries its own anchor.
+func anchorPeriod(period Period, replaced Period, at time.Time) Period {
+	if period.IsZero() || !period.Anchor.IsZero() {
+		return period
+	}
+
+	if !replaced.IsZero() {
+		period.Anchor = replaced.Anchor
+		return period
+	}
+
+	period.Anchor = at
+	return period
+}
+
+// applyItem applies the command of the event to the item it targets.
+func (l *Ledger) applyItem(event EventRecord, command interface{}) error {
+	item, ok := l.Items[event.Item]
+	if !ok {
+		return ErrUnknownTarget
+	}
+
+	switch cmd := command.(type) {
+	case *AmendBudgetItem:
+		if cmd.Price != nil {
+			price, err := cmd.Price.In(l.Currency)
+			if err != nil {
+				return err
+			}
+
+			item.Price = price
+		}
+
+		if cmd.Title != "" {
+			item.Title = cmd.Title
+		}
+
+		if cmd.Desc != nil {
+			item.Desc = *cmd.Desc
+		}
+
+		if cmd.Category != nil {
+			item.Category = *cmd.Category
+		}
+
+		if cmd.Tags != nil {
+			item.Tags = NormalizeTags(*cmd.Tags)
+		}
+
+	case *DeleteBudgetItem:
+		if item.Deleted == nil {
+			deleted := event.Time
+			item.Deleted = &deleted
+		}
+
+	case *RestoreBudgetItem:
+		item.Deleted = nil
+
+	case *ArchiveBudgetItem:
+		item.Archived = cmd.Archived
+
+	default:
+		return fmt.Errorf("%s: %q", ErrUnknownEvent, event.Kind)
+	}
+
+	l.Items[event.Item] = item
+	return nil
+}
+
+//==============================================================================
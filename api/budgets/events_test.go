@@ -0,0 +1,179 @@
+package budgets
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influx6/pocket/api/currency"
+)
+
+//==============================================================================
+
+// event returns the event recording the command against the budget and item,
+// applied at the giving time.
+func event(t *testing.T, id string, budget string, item string, at time.Time, command interface{}) EventRecord {
+	t.Helper()
+
+	ev, err := NewEvent(id, "owner", "pocket", budget, item, command)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ev.Time = at
+	return ev
+}
+
+//==============================================================================
+
+// TestLedgerPartialAmend checks amends change only the fields they were given,
+// leaving every other field of the budget or item as it was.
+func TestLedgerPartialAmend(t *testing.T) {
+	usd, err := currency.ISO4217.Find("USD")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ledger := NewLedger(usd)
+
+	apply := func(id string, budget string, item string, command interface{}) {
+		event, err := NewEvent(id, "owner", "pocket", budget, item, command)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := ledger.Apply(event); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	price := currency.NewMoney(120000, usd)
+
+	apply("1", "rent", "", NewBudget{Title: "Rent", Price: price})
+	apply("2", "rent", "march", NewBudgetItem{Title: "March", Desc: "Paid", Price: price, Category: "home", Tags: []string{"flat"}})
+
+	apply("3", "rent", "", AmendBudget{Title: "Housing"})
+
+	budget := ledger.Budgets["rent"]
+	if budget.Title != "Housing" || budget.Price != price {
+		t.Fatalf("Expected a title-only amend to keep the price, got %+v", budget)
+	}
+
+	raised := currency.NewMoney(130000, usd)
+	apply("4", "rent", "", AmendBudget{Price: &raised})
+
+	budget = ledger.Budgets["rent"]
+	if budget.Title != "Housing" || budget.Price != raised {
+		t.Fatalf("Expected a price-only amend to keep the title, got %+v", budget)
+	}
+
+	desc := "Paid late"
+	apply("5", "rent", "march", AmendBudgetItem{Desc: &desc})
+
+	item := ledger.Items["march"]
+	if item.Title != "March" || item.Desc != desc || item.Price != price || item.Category != "home" || len(item.Tags) != 1 {
+		t.Fatalf("Expected a description-only amend to keep the item's other fields, got %+v", item)
+	}
+}
+
+// TestReplay checks replaying the log rebuilds budgets and items as the events
+// left them, with trashing, restoring and archiving applied in order, and that
+// Until keeps only the events applied by a time.
+func TestReplay(t *testing.T) {
+	usd, err := currency.ISO4217.Find("USD")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2016, 3, 1, 9, 0, 0, 0, time.UTC)
+	at := func(days int) time.Time { return start.AddDate(0, 0, days) }
+
+	price := currency.NewMoney(120000, usd)
+	raised := currency.NewMoney(35050, usd)
+
+	events := []EventRecord{
+		event(t, "1", "rent", "", at(0), NewBudget{Title: "Rent", Price: price}),
+		event(t, "2", "rent", "march", at(1), NewBudgetItem{Title: "March", Price: currency.NewMoney(35000, usd), Tags: []string{"Flat", "flat"}}),
+		event(t, "3", "rent", "march", at(2), AmendBudgetItem{Price: &raised}),
+		event(t, "4", "rent", "march", at(3), DeleteBudgetItem{}),
+		event(t, "5", "rent", "", at(4), ArchiveBudget{Archived: true}),
+		event(t, "6", "rent", "march", at(5), RestoreBudgetItem{}),
+		event(t, "7", "rent", "", at(6), DeleteBudget{}),
+		event(t, "8", "rent", "", at(7), DeleteBudget{}),
+	}
+
+	ledger, err := Replay(usd, events)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	budget := ledger.Budgets["rent"]
+	if budget.Title != "Rent" || budget.Price != price || budget.Pocket != "pocket" || !budget.Archived {
+		t.Fatalf("Expected the archived rent budget, got %+v", budget)
+	}
+
+	if budget.Deleted == nil || !budget.Deleted.Equal(at(6)) {
+		t.Fatalf("Expected the budget trashed by the first delete, got %v", budget.Deleted)
+	}
+
+	item := ledger.Items["march"]
+	if item.Budget != "rent" || item.Price != raised || item.Deleted != nil || !item.Time.Equal(at(1)) || len(item.Tags) != 1 {
+		t.Fatalf("Expected the restored item at its amended price, got %+v", item)
+	}
+
+	past, err := Replay(usd, Until(events, at(3).Add(time.Hour)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if item := past.Items["march"]; item.Deleted == nil || past.Budgets["rent"].Archived {
+		t.Fatalf("Expected the item trashed in an unarchived budget, got %+v and %+v", item, past.Budgets["rent"])
+	}
+
+	if until := Until(events, at(0).Add(-time.Second)); len(until) != 0 {
+		t.Fatalf("Expected no events before the first, got %d", len(until))
+	}
+
+	if until := Until(events, at(30)); len(until) != len(events) {
+		t.Fatalf("Expected every event by the end, got %d", len(until))
+	}
+}
+
+// TestReplayInvalid checks events changing records no event created, events of
+// unknown kinds and amounts of another currency are refused.
+func TestReplayInvalid(t *testing.T) {
+	usd, err := currency.ISO4217.Find("USD")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	eur, err := currency.ISO4217.Find("EUR")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2016, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	if _, err := Replay(usd, []EventRecord{event(t, "1", "rent", "", start, DeleteBudget{})}); err != ErrUnknownTarget {
+		t.Fatalf("Expected ErrUnknownTarget for an unknown budget, got %v", err)
+	}
+
+	if _, err := Replay(usd, []EventRecord{event(t, "1", "rent", "march", start, ArchiveBudgetItem{Archived: true})}); err != ErrUnknownTarget {
+		t.Fatalf("Expected ErrUnknownTarget for an unknown item, got %v", err)
+	}
+
+	if _, err := Replay(usd, []EventRecord{event(t, "1", "rent", "", start, NewBudget{Title: "Rent", Price: currency.NewMoney(100, eur)})}); err != currency.ErrCurrencyMismatch {
+		t.Fatalf("Expected ErrCurrencyMismatch for an amount in euros, got %v", err)
+	}
+
+	unknown := event(t, "1", "rent", "", start, DeleteBudget{})
+	unknown.Kind = "RenameBudget"
+
+	if _, err := Replay(usd, []EventRecord{unknown}); err == nil || !strings.HasPrefix(err.Error(), ErrUnknownEvent.Error()) {
+		t.Fatalf("Expected ErrUnknownEvent for an unknown kind, got %v", err)
+	}
+
+	if _, err := NewEvent("1", "owner", "pocket", "rent", "", ReplayPocket{}); err == nil || !strings.HasPrefix(err.Error(), ErrUnknownEvent.Error()) {
+		t.Fatalf("Expected ErrUnknownEvent recording a command outside the log, got %v", err)
+	}
+}
@@ -0,0 +1,119 @@
+package budgets
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gopherjs/gopherjs/js"
+	"github.com/influx6/gu/gudispatch"
+	"github.com/influx6/gu/guevents"
+	"github.com/influx6/gu/gutrees"
+	"github.com/influx6/gu/gutrees/attrs"
+	"github.com/influx6/gu/gutrees/elems"
+	"github.com/influx6/pocket/api/currency"
+)
+
+//==============================================================================
+
+// ItemForm defines the form for adding a cost item into the budget with the
+// giving id, letting the user file it under one of the Categories and label it
+// with tags.
+type ItemForm struct {
+	Budget     string
+	Currency   currency.Currency
+	Categories Categories
+}
+
+// Render returns the markup which renders the item form.
+func (f *ItemForm) Render() gutrees.Markup {
+	root := elems.Form(attrs.Class("budget-item-form"))
+
+	gutrees.NewEvent("submit", "", func(ev guevents.Event, _ gutrees.Markup) {
+		form := ev.Target()
+
+		price, err := currency.ParseMoney(itemFormValue(form, "price"), f.Currency)
+		if err != nil {
+			gudispatch.Dispatch(&Notify{
+				Message: err.Error(),
+				Type:    BadCurrency,
+			})
+			return
+		}
+
+		gudispatch.Dispatch(&NewBudgetItem{
+			UUID:     f.Budget,
+			Title:    itemFormValue(form, "title"),
+			Desc:     itemFormValue(form, "desc"),
+			Price:    price,
+			Category: itemFormValue(form, "category"),
+			Tags:     ParseTags(itemFormValue(form, "tags")),
+		})
+	}).PreventDefault().Apply(root)
+
+	f.renderInput("title", "Title", attrs.TypeText).Apply(root)
+	f.renderInput("desc", "Description", attrs.TypeText).Apply(root)
+	f.renderInput("price", "Price", attrs.TypeText).Apply(root)
+	f.renderCategories().Apply(root)
+
+	f.renderInput("tags", "Tags", attrs.TypeText, attrs.Placeholder("groceries, weekly")).Apply(root)
+
+	elems.Button(
+		attrs.Type("submit"),
+		attrs.Class("budget-item-submit"),
+		elems.Text("Add Item"),
+	).Apply(root)
+
+	return root
+}
+
+// renderInput returns the markup for a labelled input of the form, with any
+// extra attributes applied to the input.
+func (f *ItemForm) renderInput(name string, label string, kind attrs.InputType, extra ...gutrees.Appliable) gutrees.Markup {
+	id := fmt.Sprintf("budget-item-%s-%s", f.Budget, name)
+
+	input := elems.Input(attrs.ID(id), attrs.Name(name), attrs.IType(kind))
+	for _, attr := range extra {
+		attr.Apply(input)
+	}
+
+	return elems.Div(
+		attrs.Class("budget-item-field"),
+		elems.Label(attrs.HTMLFor(id), elems.Text(label)),
+		input,
+	)
+}
+
+// renderCategories returns the markup for the category selection, listing
+// nested categories indented under their parents.
+func (f *ItemForm) renderCategories() gutrees.Markup {
+	id := fmt.Sprintf("budget-item-%s-category", f.Budget)
+
+	choices := elems.Select(
+		attrs.ID(id),
+		attrs.Name("category"),
+		elems.Option(attrs.Value(""), elems.Text("No Category")),
+	)
+
+	f.Categories.Walk(func(category CategoryRecord, depth int) {
+		indent := strings.Repeat("\u00a0\u00a0", depth)
+		elems.Option(attrs.Value(category.ID), elems.Text(indent+category.Name)).Apply(choices)
+	})
+
+	return elems.Div(
+		attrs.Class("budget-item-field"),
+		elems.Label(attrs.HTMLFor(id), elems.Text("Category")),
+		choices,
+	)
+}
+
+// itemFormValue returns the value of the named field within the form element.
+func itemFormValue(form *js.Object, name string) string {
+	input := form.Call("querySelector", fmt.Sprintf("[name=%q]", name))
+	if input == nil || input == js.Undefined {
+		return ""
+	}
+
+	return input.Get("value").String()
+}
+
+//==============================================================================
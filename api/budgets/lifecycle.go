@@ -0,0 +1,434 @@
+package budgets
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/influx6/coquery/data"
+	"github.com/influx6/gu/gudispatch"
+	"github.com/influx6/gu/guevents"
+	"github.com/influx6/gu/gutrees"
+	"github.com/influx6/gu/gutrees/attrs"
+	"github.com/influx6/gu/gutrees/elems"
+	"github.com/influx6/gu/guviews"
+	"github.com/influx6/pocket/api/currency"
+)
+
+//==============================================================================
+
+// subscribeLifecycle subscribes the pocket to the commands which amend,
+// delete, restore and archive its budgets and items, updating its view after
+// each command which concerns it.
+func subscribeLifecycle(pocket *PocketBudget) {
+	update := func(changed bool) {
+		if changed {
+			gudispatch.Dispatch(&guviews.ViewUpdate{ID: pocket.UUID})
+		}
+	}
+
+	gudispatch.Subscribe(func(ab *AmendBudget) {
+		update(pocket.updateBudget(ab.UUID, func(bu *Budget) {
+			if ab.Title != "" {
+				bu.Title = ab.Title
+			}
+
+			if ab.Price == nil {
+				return
+			}
+
+			if price, err := ab.Price.In(pocket.Currency); err == nil {
+				history := BudgetRecord{Price: bu.Price, Prices: bu.Prices}.PriceHistory()
+
+				bu.Price = price
+				bu.Prices = append(append([]PriceChange(nil), history...), PriceChange{From: time.Now(), Price: price})
+			}
+		}))
+	})
+
+	gudispatch.Subscribe(func(db *DeleteBudget) {
+		update(pocket.updateBudget(db.UUID, func(bu *Budget) { bu.Deleted = true }))
+	})
+
+	gudispatch.Subscribe(func(rb *RestoreBudget) {
+		update(pocket.updateBudget(rb.UUID, func(bu *Budget) { bu.Deleted = false }))
+	})
+
+	gudispatch.Subscribe(func(ab *ArchiveBudget) {
+		update(pocket.updateBudget(ab.UUID, func(bu *Budget) { bu.Archived = ab.Archived }))
+	})
+
+	gudispatch.Subscribe(func(ai *AmendBudgetItem) {
+		update(pocket.updateItem(ai.UUID, func(bu *Budget, item *BudgetItem) {
+			if ai.Title != "" {
+				item.Title = ai.Title
+			}
+
+			if ai.Price != nil {
+				if price, err := ai.Price.In(pocket.Currency); err == nil {
+					item.Price = price
+				}
+			}
+
+			if ai.Desc != nil {
+				item.Desc = *ai.Desc
+			}
+
+			if ai.Category != nil {
+				item.Categories = bu.categories.Path(*ai.Category)
+			}
+
+			if ai.Tags != nil {
+				item.Tags = NormalizeTags(*ai.Tags)
+			}
+		}))
+	})
+
+	gudispatch.Subscribe(func(di *DeleteBudgetItem) {
+		update(pocket.updateItem(di.UUID, func(_ *Budget, item *BudgetItem) { item.Deleted = true }))
+	})
+
+	gudispatch.Subscribe(func(ri *RestoreBudgetItem) {
+		update(pocket.updateItem(ri.UUID, func(_ *Budget, item *BudgetItem) { item.Deleted = false }))
+	})
+
+	gudispatch.Subscribe(func(ai *ArchiveBudgetItem) {
+		update(pocket.updateItem(ai.UUID, func(_ *Budget, item *BudgetItem) { item.Archived = ai.Archived }))
+	})
+}
+
+//==============================================================================
+
+// updateBudget applies the change to the budget of the pocket with the giving
+// id, returning false if the pocket holds no such budget.
+func (p *PocketBudget) updateBudget(id string, change func(*Budget)) bool {
+	if id == "" {
+		return false
+	}
+
+	p.rl.Lock()
+	defer p.rl.Unlock()
+
+	for title, bu := range p.items {
+		if bu.ID != id {
+			continue
+		}
+
+		bu.rl.Lock()
+		change(bu)
+		bu.rl.Unlock()
+
+		// Budgets are held by title, so move renamed budgets along.
+		if bu.Title != title {
+			delete(p.items, title)
+			p.items[bu.Title] = bu
+		}
+
+		return true
+	}
+
+	return false
+}
+
+// updateItem applies the change to the item of the pocket with the giving id,
+// returning false if no budget of the pocket holds such item.
+func (p *PocketBudget) updateItem(id string, change func(*Budget, *BudgetItem)) bool {
+	if id == "" {
+		return false
+	}
+
+	p.rl.RLock()
+	defer p.rl.RUnlock()
+
+	for _, bu := range p.items {
+		if bu.updateItem(id, change) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// updateItem applies the change to the item of the budget with the giving id,
+// returning false if the budget holds no such item.
+func (b *Budget) updateItem(id string, change func(*Budget, *BudgetItem)) bool {
+	b.rl.Lock()
+	defer b.rl.Unlock()
+
+	index := b.itemIndex(id)
+	if index == -1 {
+		return false
+	}
+
+	change(b, &b.items[index])
+	return true
+}
+
+// itemIndex returns the index of the item with the giving id, or -1. The
+// caller must hold the lock of the budget.
+func (b *Budget) itemIndex(id string) int {
+	for index, item := range b.items {
+		if item.ID == id {
+			return index
+		}
+	}
+
+	return -1
+}
+
+//==============================================================================
+
+// trashQuery returns the coquery query which retrieves the budgets of the
+// pocket within the trash.
+func (p *PocketBudget) trashQuery() string {
+	return fmt.Sprintf("budgets?pocket=%s&trash=true", p.UUID)
+}
+
+// itemQueries returns the coquery queries which retrieve the items of the
+// budget with the giving id, both those listed and those within the trash.
+func itemQueries(budget string) []string {
+	return []string{
+		fmt.Sprintf("items?budget=%s&archived=true", budget),
+		fmt.Sprintf("items?budget=%s&trash=true", budget),
+	}
+}
+
+// syncBudget updates the pocket with the budget record received from the
+// server, adding the budget if not known, and requests its items.
+func (p *PocketBudget) syncBudget(record data.Parameter) error {
+	budget, err := p.parseBudget(record)
+	if err != nil {
+		return err
+	}
+
+	p.AddBudget(budget.ID, budget.Title, budget.Price)
+
+	p.updateBudget(budget.ID, func(bu *Budget) {
+		bu.Title = budget.Title
+		bu.Price = budget.Price
+		bu.Prices = budget.Prices
+		bu.Period = budget.Period
+		bu.Archived = budget.Archived
+		bu.Deleted = budget.Deleted
+	})
+
+	for _, query := range itemQueries(budget.ID) {
+		p.Server.Request(query, func(err error, meta data.ResponseMeta, records data.Parameters) {
+			if err != nil {
+				gudispatch.Dispatch(&Notify{
+					Message: err.Error(),
+					Type:    FailedSync,
+				})
+				return
+			}
+
+			for _, record := range records {
+				if err := p.syncItem(budget.ID, record); err != nil {
+					gudispatch.Dispatch(&Notify{
+						Message: err.Error(),
+						Type:    FailedSync,
+					})
+				}
+			}
+
+			gudispatch.Dispatch(&guviews.ViewUpdate{ID: p.UUID})
+		})
+	}
+
+	return nil
+}
+
+// syncItem updates the budget with the giving id with the item record received
+// from the server, replacing any item added locally under the same title.
+func (p *PocketBudget) syncItem(budget string, record data.Parameter) error {
+	item, err := p.parseItem(record)
+	if err != nil {
+		return err
+	}
+
+	p.rl.RLock()
+	defer p.rl.RUnlock()
+
+	for _, bu := range p.items {
+		if bu.ID == budget {
+			bu.syncItem(item)
+			break
+		}
+	}
+
+	return nil
+}
+
+// parseBudget returns the budget described by the budget record received from
+// the server, without any of its items.
+func (p *PocketBudget) parseBudget(record data.Parameter) (*Budget, error) {
+	id, _ := record.Get("id").(string)
+	title, _ := record.Get("title").(string)
+	amount, _ := record.Get("price").(string)
+	archived, _ := record.Get("archived").(bool)
+
+	price, err := currency.ParseMoney(amount, p.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	budget := p.newBudget(id, title, price)
+	budget.Prices = p.parsePrices(record.Get("prices"))
+	budget.Period = parsePeriod(record.Get("period"))
+	budget.Archived = archived
+	budget.Deleted = record.Get("deleted") != nil
+
+	return budget, nil
+}
+
+// parsePeriod returns the period described by the period of a budget record
+// received from the server, or an empty period if it describes none.
+func parsePeriod(value interface{}) Period {
+	fields, ok := value.(map[string]interface{})
+	if !ok {
+		return Period{}
+	}
+
+	var period Period
+
+	period.Kind, _ = fields["kind"].(string)
+	period.CarryOver, _ = fields["carry_over"].(bool)
+
+	if day, ok := fields["start_day"].(float64); ok {
+		period.StartDay = int(day)
+	}
+
+	if anchor, ok := fields["anchor"].(string); ok {
+		period.Anchor, _ = time.Parse(time.RFC3339Nano, anchor)
+	}
+
+	return period
+}
+
+// parsePrices returns the price history described by the prices of a budget
+// record received from the server, skipping prices which can not be parsed.
+func (p *PocketBudget) parsePrices(value interface{}) []PriceChange {
+	list, _ := value.([]interface{})
+
+	var prices []PriceChange
+
+	for _, entry := range list {
+		fields, ok := entry.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		amount, _ := fields["price"].(string)
+		stamp, _ := fields["from"].(string)
+
+		price, err := currency.ParseMoney(amount, p.Currency)
+		if err != nil {
+			continue
+		}
+
+		from, _ := time.Parse(time.RFC3339Nano, stamp)
+		prices = append(prices, PriceChange{From: from, Price: price})
+	}
+
+	return prices
+}
+
+// parseItem returns the item described by the item record received from the
+// server.
+func (p *PocketBudget) parseItem(record data.Parameter) (BudgetItem, error) {
+	id, _ := record.Get("id").(string)
+	title, _ := record.Get("title").(string)
+	desc, _ := record.Get("desc").(string)
+	amount, _ := record.Get("price").(string)
+	category, _ := record.Get("category").(string)
+	stamp, _ := record.Get("time").(string)
+	archived, _ := record.Get("archived").(bool)
+
+	price, err := currency.ParseMoney(amount, p.Currency)
+	if err != nil {
+		return BudgetItem{}, err
+	}
+
+	var tags []string
+	if list, ok := record.Get("tags").([]interface{}); ok {
+		for _, tag := range list {
+			if text, ok := tag.(string); ok {
+				tags = append(tags, text)
+			}
+		}
+	}
+
+	when, _ := time.Parse(time.RFC3339Nano, stamp)
+
+	item := BudgetItem{
+		ID:         id,
+		Title:      title,
+		Desc:       desc,
+		Price:      price,
+		Categories: p.Categories.Path(category),
+		Tags:       tags,
+		Time:       when,
+		Archived:   archived,
+		Deleted:    record.Get("deleted") != nil,
+	}
+
+	return item, nil
+}
+
+// syncItem replaces the item with the same id as the giving item, or the item
+// added locally under its title which is yet to receive an id, else adds it.
+func (b *Budget) syncItem(item BudgetItem) {
+	b.rl.Lock()
+	defer b.rl.Unlock()
+
+	item.Budget = b
+
+	if index := b.itemIndex(item.ID); index != -1 {
+		b.items[index] = item
+		return
+	}
+
+	for index, local := range b.items {
+		if local.ID == "" && local.Title == item.Title {
+			b.items[index] = item
+			return
+		}
+	}
+
+	b.items = append(b.items, item)
+}
+
+//==============================================================================
+
+// renderAction returns the markup for a button which dispatches the command
+// when clicked.
+func renderAction(label string, command interface{}) gutrees.Markup {
+	button := elems.Button(
+		attrs.Type("button"),
+		attrs.Class("budget-action"),
+		elems.Text(label),
+	)
+
+	gutrees.NewEvent("click", "", func(ev guevents.Event, _ gutrees.Markup) {
+		gudispatch.Dispatch(command)
+	}).Apply(button)
+
+	return button
+}
+
+// renderShelved returns the markup for a budget or item which is archived or
+// in the trash, along with the action which brings it back if a command is
+// given.
+func renderShelved(title string, command interface{}, label string) gutrees.Markup {
+	root := elems.Div(
+		attrs.Class("budget-shelved"),
+		elems.Label(attrs.Class("budget-shelved-title"), elems.Text(title)),
+	)
+
+	if command != nil {
+		renderAction(label, command).Apply(root)
+	}
+
+	return root
+}
+
+//==============================================================================
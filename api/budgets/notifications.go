@@ -0,0 +1,322 @@
+package budgets
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/influx6/coquery/client"
+	"github.com/influx6/coquery/data"
+	"github.com/influx6/gu/gudispatch"
+	"github.com/influx6/gu/gutrees"
+	"github.com/influx6/gu/gutrees/attrs"
+	"github.com/influx6/gu/gutrees/elems"
+	"github.com/influx6/gu/guviews"
+)
+
+//==============================================================================
+
+func init() {
+	guviews.Register("pocket/notifications", func(no NotificationOptions) guviews.Renderable {
+		return NewNotificationCentre(no)
+	})
+}
+
+//==============================================================================
+
+// toastLife defines how long a toast is shown before it is dismissed.
+const toastLife = 8 * time.Second
+
+// maxToasts defines the count of toasts shown at once, the oldest being
+// dismissed to make room for new ones.
+const maxToasts = 5
+
+// notificationsQuery defines the coquery query which retrieves the
+// notifications of the user.
+const notificationsQuery = "notifications"
+
+// NotificationOptions defines a configuration struct passed into the notification
+// centre initializer. The UUID is that of the user whose notifications are
+// shown, which also identifies the view.
+type NotificationOptions struct {
+	UUID   string
+	Server client.Server
+}
+
+// toast defines a notification shown briefly, numbered to be dismissed.
+type toast struct {
+	number int
+	notice Notify
+}
+
+// NotificationCentre provides the view which shows every Notify dispatched as
+// a toast, along with the inbox of the notifications stored for the user by
+// the server. Unread notifications are shown as toasts the first time they
+// are received, so those raised while the user was away show up once they
+// return.
+type NotificationCentre struct {
+	NotificationOptions
+	rl     sync.RWMutex
+	toasts []toast
+	last   int
+	inbox  []NotificationRecord
+	shown  map[string]bool
+}
+
+// NewNotificationCentre returns a new NotificationCentre instance.
+func NewNotificationCentre(no NotificationOptions) *NotificationCentre {
+	nc := NotificationCentre{
+		NotificationOptions: no,
+		shown:               make(map[string]bool),
+	}
+
+	gudispatch.Subscribe(func(n *Notify) {
+		nc.Toast(*n)
+	})
+
+	gudispatch.Subscribe(func(dt *DismissToast) {
+		if dt.UUID != no.UUID {
+			return
+		}
+
+		nc.dismiss(dt.Toast)
+	})
+
+	gudispatch.Subscribe(func(rn *ReadNotification) {
+		nc.markRead(func(record NotificationRecord) bool { return record.ID == rn.UUID })
+	})
+
+	gudispatch.Subscribe(func(*ReadAllNotifications) {
+		nc.markRead(func(NotificationRecord) bool { return true })
+	})
+
+	gudispatch.Subscribe(func(sn *SyncNotifications) {
+		if sn.UUID != no.UUID {
+			return
+		}
+
+		nc.Sync()
+	})
+
+	if no.Server != nil {
+		nc.Sync()
+	}
+
+	return &nc
+}
+
+// Toast shows the notification as a toast, dismissed once its time is up.
+func (n *NotificationCentre) Toast(notice Notify) {
+	n.rl.Lock()
+
+	n.last++
+	number := n.last
+
+	n.toasts = append(n.toasts, toast{number: number, notice: notice})
+	if len(n.toasts) > maxToasts {
+		n.toasts = n.toasts[len(n.toasts)-maxToasts:]
+	}
+
+	n.rl.Unlock()
+
+	time.AfterFunc(toastLife, func() {
+		gudispatch.Dispatch(&DismissToast{UUID: n.UUID, Toast: number})
+	})
+
+	gudispatch.Dispatch(&guviews.ViewUpdate{ID: n.UUID})
+}
+
+// dismiss stops showing the toast with the giving number.
+func (n *NotificationCentre) dismiss(number int) {
+	n.rl.Lock()
+
+	var changed bool
+
+	for index, item := range n.toasts {
+		if item.number == number {
+			n.toasts = append(n.toasts[:index], n.toasts[index+1:]...)
+			changed = true
+			break
+		}
+	}
+
+	n.rl.Unlock()
+
+	if changed {
+		gudispatch.Dispatch(&guviews.ViewUpdate{ID: n.UUID})
+	}
+}
+
+// markRead marks the notifications of the inbox matched by the giving function
+// as read.
+func (n *NotificationCentre) markRead(match func(NotificationRecord) bool) {
+	n.rl.Lock()
+
+	var changed bool
+
+	for index, record := range n.inbox {
+		if !record.Read && match(record) {
+			n.inbox[index].Read = true
+			changed = true
+		}
+	}
+
+	n.rl.Unlock()
+
+	// The view takes the lock to render, so it is only updated once released.
+	if changed {
+		gudispatch.Dispatch(&guviews.ViewUpdate{ID: n.UUID})
+	}
+}
+
+// Sync requests the notifications of the user from the server, replacing the
+// inbox and showing the unread notifications not yet shown as toasts.
+func (n *NotificationCentre) Sync() {
+	if n.Server == nil {
+		return
+	}
+
+	n.Server.Request(notificationsQuery, func(err error, meta data.ResponseMeta, records data.Parameters) {
+		if err != nil {
+			gudispatch.Dispatch(&Notify{
+				Message: err.Error(),
+				Type:    FailedSync,
+			})
+			return
+		}
+
+		inbox := make([]NotificationRecord, 0, len(records))
+		for _, record := range records {
+			inbox = append(inbox, parseNotification(record))
+		}
+
+		n.rl.Lock()
+
+		n.inbox = inbox
+
+		var fresh []Notify
+		for _, record := range inbox {
+			if !record.Read && !n.shown[record.ID] {
+				fresh = append(fresh, record.Notify)
+			}
+
+			n.shown[record.ID] = true
+		}
+
+		n.rl.Unlock()
+
+		// Toasts are shown once the lock is released, as they take it again.
+		for _, notice := range fresh {
+			n.Toast(notice)
+		}
+
+		gudispatch.Dispatch(&guviews.ViewUpdate{ID: n.UUID})
+	})
+}
+
+// parseNotification returns the notification described by the notification
+// record received from the server.
+func parseNotification(record data.Parameter) NotificationRecord {
+	var nr NotificationRecord
+
+	nr.ID, _ = record.Get("id").(string)
+	nr.Owner, _ = record.Get("owner").(string)
+	nr.Read, _ = record.Get("read").(bool)
+	nr.Message, _ = record.Get("message").(string)
+	nr.Budget, _ = record.Get("budget").(string)
+	nr.Item, _ = record.Get("item").(string)
+	nr.Rule, _ = record.Get("rule").(string)
+	nr.Link, _ = record.Get("link").(string)
+
+	if severity, ok := record.Get("severity").(string); ok {
+		nr.Severity = Severity(severity)
+	}
+
+	if kind, ok := record.Get("type").(float64); ok {
+		nr.Type = Alert(kind)
+	}
+
+	if stamp, ok := record.Get("time").(string); ok {
+		nr.Time, _ = time.Parse(time.RFC3339Nano, stamp)
+	}
+
+	return nr
+}
+
+// Render returns the markup for the toasts and inbox of the notification
+// centre.
+func (n *NotificationCentre) Render() gutrees.Markup {
+	n.rl.RLock()
+	defer n.rl.RUnlock()
+
+	toasts := elems.Div(attrs.Class("notification-toasts"))
+	for _, item := range n.toasts {
+		notice := renderNotice(item.notice, false)
+		renderAction("Dismiss", &DismissToast{UUID: n.UUID, Toast: item.number}).Apply(notice)
+		notice.Apply(toasts)
+	}
+
+	var unread int
+	for _, record := range n.inbox {
+		if !record.Read {
+			unread++
+		}
+	}
+
+	inbox := elems.Div(
+		attrs.Class("notification-inbox"),
+		elems.Header(
+			elems.Label(attrs.Class("notification-unread-count"), elems.Text(fmt.Sprintf("%d unread", unread))),
+		),
+	)
+
+	if unread > 0 {
+		renderAction("Mark all read", &ReadAllNotifications{}).Apply(inbox)
+	}
+
+	for _, record := range n.inbox {
+		notice := renderNotice(record.Notify, !record.Read)
+		elems.Label(attrs.Class("notification-time"), elems.Text(record.Time.Format(periodLayout))).Apply(notice)
+
+		if !record.Read {
+			renderAction("Mark read", &ReadNotification{UUID: record.ID}).Apply(notice)
+		}
+
+		notice.Apply(inbox)
+	}
+
+	return elems.Div(attrs.Class("pocket-notifications"), toasts, inbox)
+}
+
+// renderNotice returns the markup for a single notification, classed by its
+// severity and whether it is unread, and linking to the budget it concerns if
+// any.
+func renderNotice(notice Notify, unread bool) gutrees.Markup {
+	classes := []string{"notification", "notification-" + string(severity(notice))}
+	if unread {
+		classes = append(classes, "notification-unread")
+	}
+
+	root := elems.Div(
+		attrs.Class(classes...),
+		elems.Label(attrs.Class("notification-message"), elems.Text(notice.Message)),
+	)
+
+	if notice.Link != "" {
+		elems.Anchor(attrs.Class("notification-link"), attrs.Href(notice.Link), elems.Text("View")).Apply(root)
+	}
+
+	return root
+}
+
+// severity returns the severity of the notification, notifications without one
+// being raised by failures within the app and so warnings.
+func severity(notice Notify) Severity {
+	if notice.Severity == "" {
+		return SeverityWarning
+	}
+
+	return notice.Severity
+}
+
+//==============================================================================
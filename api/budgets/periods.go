@@ -0,0 +1,261 @@
+package budgets
+
+import (
+	"errors"
+	"time"
+
+	"github.com/influx6/pocket/api/currency"
+)
+
+//==============================================================================
+
+// ErrInvalidPeriod is returned when a budget period of an unknown kind, or
+// with a start day outside its kind, is given.
+var ErrInvalidPeriod = errors.New("Invalid Budget Period")
+
+// ErrNoPrice is returned when the totals of a budget are requested without any
+// of its prices.
+var ErrNoPrice = errors.New("Budget has no price")
+
+// periodLayout defines the layout of the dates periods are shown with.
+const periodLayout = "2006-01-02"
+
+// Kinds of budget periods.
+const (
+	PeriodNone      = ""
+	PeriodWeekly    = "weekly"
+	PeriodBiWeekly  = "bi-weekly"
+	PeriodMonthly   = "monthly"
+	PeriodQuarterly = "quarterly"
+	PeriodYearly    = "yearly"
+)
+
+//==============================================================================
+
+// Period defines the window of time the price of a budget covers, after which
+// the budget rolls over into its next period. Monthly, quarterly and yearly
+// periods start on StartDay of the month, from 1 to 28 with 0 standing for 1,
+// while weekly and bi-weekly periods start on the weekday StartDay, from 0 for
+// Sunday to 6 for Saturday. Periods are counted from the one holding the
+// Anchor, and with CarryOver what remains of a period, or was overspent, is
+// carried into the next one.
+type Period struct {
+	Kind      string    `json:"kind"`
+	StartDay  int       `json:"start_day"`
+	CarryOver bool      `json:"carry_over"`
+	Anchor    time.Time `json:"anchor"`
+}
+
+// IsZero returns true/false if the period is empty, as for budgets which never
+// roll over.
+func (p Period) IsZero() bool {
+	return p.Kind == PeriodNone
+}
+
+// Validate returns ErrInvalidPeriod if the period is of an unknown kind or its
+// start day is outside its kind.
+func (p Period) Validate() error {
+	switch p.Kind {
+	case PeriodNone:
+		return nil
+	case PeriodWeekly, PeriodBiWeekly:
+		if p.StartDay < 0 || p.StartDay > 6 {
+			return ErrInvalidPeriod
+		}
+	case PeriodMonthly, PeriodQuarterly, PeriodYearly:
+		if p.StartDay < 0 || p.StartDay > 28 {
+			return ErrInvalidPeriod
+		}
+	default:
+		return ErrInvalidPeriod
+	}
+
+	return nil
+}
+
+// Window returns the start of the period holding the giving time and the start
+// of the period after it, which ends it.
+func (p Period) Window(at time.Time) (time.Time, time.Time) {
+	first := p.first()
+	at = at.UTC()
+
+	// Estimate the count of periods since the first, then settle on the one
+	// holding the time.
+	var n int
+	if months := p.months(); months > 0 {
+		n = ((at.Year()-first.Year())*12 + int(at.Month()) - int(first.Month())) / months
+	} else {
+		n = int(at.Sub(first).Hours()/24) / p.days()
+	}
+
+	for p.shift(first, n).After(at) {
+		n--
+	}
+
+	for !p.shift(first, n+1).After(at) {
+		n++
+	}
+
+	return p.shift(first, n), p.shift(first, n+1)
+}
+
+// first returns the start of the period holding the anchor.
+func (p Period) first() time.Time {
+	anchor := p.Anchor.UTC()
+
+	if p.months() > 0 {
+		day := p.StartDay
+		if day == 0 {
+			day = 1
+		}
+
+		start := time.Date(anchor.Year(), anchor.Month(), day, 0, 0, 0, 0, time.UTC)
+		if start.After(anchor) {
+			start = start.AddDate(0, -1, 0)
+		}
+
+		return start
+	}
+
+	day := time.Date(anchor.Year(), anchor.Month(), anchor.Day(), 0, 0, 0, 0, time.UTC)
+	return day.AddDate(0, 0, -((int(day.Weekday()) - p.StartDay + 7) % 7))
+}
+
+// shift returns the start of the period n periods after the one starting at
+// the giving time.
+func (p Period) shift(start time.Time, n int) time.Time {
+	if months := p.months(); months > 0 {
+		return start.AddDate(0, n*months, 0)
+	}
+
+	return start.AddDate(0, 0, n*p.days())
+}
+
+// months returns the count of months a period spans, or 0 for periods counted
+// in days.
+func (p Period) months() int {
+	switch p.Kind {
+	case PeriodMonthly:
+		return 1
+	case PeriodQuarterly:
+		return 3
+	case PeriodYearly:
+		return 12
+	}
+
+	return 0
+}
+
+// days returns the count of days a period counted in days spans.
+func (p Period) days() int {
+	if p.Kind == PeriodBiWeekly {
+		return 14
+	}
+
+	return 7
+}
+
+//==============================================================================
+
+// Spend defines an amount spent against a budget at the giving time.
+type Spend struct {
+	Time   time.Time
+	Amount currency.Money
+}
+
+// PeriodTotal defines the totals of a budget within one of its periods, which
+// runs from Start up to End. Carried is what remained of the period before, or
+// was overspent when negative, and Remaining is what is left of the Budgeted
+// and Carried amounts after what was Spent.
+type PeriodTotal struct {
+	Budget    string         `json:"budget"`
+	Start     time.Time      `json:"start"`
+	End       time.Time      `json:"end"`
+	Budgeted  currency.Money `json:"budgeted"`
+	Carried   currency.Money `json:"carried"`
+	Spent     currency.Money `json:"spent"`
+	Remaining currency.Money `json:"remaining"`
+}
+
+// Totals returns the totals of the budget with the giving id within every
+// period from the one holding the anchor through the one holding the giving
+// time. Each period budgets the last of the prices, ordered oldest first, which
+// was given before the period ended, or the first price for periods ended
+// before any was given. Spends outside those periods are not counted, and all
+// amounts must be of the same currency.
+func (p Period) Totals(budget string, prices []PriceChange, spends []Spend, through time.Time) ([]PeriodTotal, error) {
+	if p.IsZero() {
+		return nil, ErrInvalidPeriod
+	}
+
+	if len(prices) == 0 {
+		return nil, ErrNoPrice
+	}
+
+	cu := prices[0].Price.Currency
+
+	carried := currency.NewMoney(0, cu)
+	last, _ := p.Window(through)
+
+	var totals []PeriodTotal
+
+	for start := p.first(); !start.After(last); start = p.shift(start, 1) {
+		end := p.shift(start, 1)
+
+		total := PeriodTotal{
+			Budget:   budget,
+			Start:    start,
+			End:      end,
+			Budgeted: priceBefore(prices, end),
+			Carried:  currency.NewMoney(0, cu),
+			Spent:    currency.NewMoney(0, cu),
+		}
+
+		if p.CarryOver {
+			total.Carried = carried
+		}
+
+		for _, spend := range spends {
+			if spend.Time.Before(total.Start) || !spend.Time.Before(total.End) {
+				continue
+			}
+
+			var err error
+			if total.Spent, err = total.Spent.Add(spend.Amount); err != nil {
+				return nil, err
+			}
+		}
+
+		available, err := total.Budgeted.Add(total.Carried)
+		if err != nil {
+			return nil, err
+		}
+
+		if total.Remaining, err = available.Sub(total.Spent); err != nil {
+			return nil, err
+		}
+
+		carried = total.Remaining
+		totals = append(totals, total)
+	}
+
+	return totals, nil
+}
+
+// priceBefore returns the last of the prices, ordered oldest first, which was
+// given before the giving time, or the first price if none was.
+func priceBefore(prices []PriceChange, at time.Time) currency.Money {
+	price := prices[0].Price
+
+	for _, change := range prices[1:] {
+		if !change.From.Before(at) {
+			break
+		}
+
+		price = change.Price
+	}
+
+	return price
+}
+
+//==============================================================================
@@ -0,0 +1,66 @@
+package budgets
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influx6/pocket/api/currency"
+)
+
+// TestTotalsPriceHistory checks every period budgets the price the budget held
+// within it, as applied from its event log, rather than its current price.
+func TestTotalsPriceHistory(t *testing.T) {
+	usd, err := currency.ISO4217.Find("USD")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	ledger := NewLedger(usd)
+
+	apply := func(id string, at time.Time, command interface{}) {
+		event, err := NewEvent(id, "owner", "pocket", "rent", "", command)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		event.Time = at
+
+		if err := ledger.Apply(event); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	raised := currency.NewMoney(150000, usd)
+
+	apply("1", start, NewBudget{Title: "Rent", Price: currency.NewMoney(100000, usd), Period: Period{Kind: PeriodMonthly}})
+	apply("2", start.AddDate(0, 2, 10), AmendBudget{Price: &raised})
+
+	budget := ledger.Budgets["rent"]
+
+	totals, err := budget.Period.Totals(budget.ID, budget.PriceHistory(), nil, start.AddDate(0, 3, 5))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []int64{100000, 100000, 150000, 150000}
+
+	if len(totals) != len(expected) {
+		t.Fatalf("Expected %d periods, got %d", len(expected), len(totals))
+	}
+
+	for index, total := range totals {
+		if total.Budgeted.Amount != expected[index] {
+			t.Errorf("Expected period %d to budget %d, got %d", index, expected[index], total.Budgeted.Amount)
+		}
+	}
+
+	// Budgets stored before their prices were recorded hold their current
+	// price throughout.
+	budget.Prices = nil
+
+	if totals, err = budget.Period.Totals(budget.ID, budget.PriceHistory(), nil, start.AddDate(0, 3, 5)); err != nil || totals[0].Budgeted != raised {
+		t.Fatalf("Expected the current price to be budgeted without a history: %v", err)
+	}
+}
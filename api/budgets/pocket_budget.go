@@ -4,9 +4,13 @@
 package budgets
 
 import (
-	"sync/atomic"
+	"fmt"
+	"html/template"
+	"sort"
+	"sync"
 
 	"github.com/influx6/coquery/client"
+	"github.com/influx6/coquery/data"
 	"github.com/influx6/gu/gudispatch"
 	"github.com/influx6/gu/gutrees"
 	"github.com/influx6/gu/gutrees/attrs"
@@ -26,86 +30,291 @@ func init() {
 //==============================================================================
 
 // BudgetOptions defines a configuration struct passed into build initializers.
+// The UUID identifies both the pocket and the view rendering it, Records holds
+// the budgets the pocket starts with, such as those already rendered by the
+// server, Locale decides how its amounts are written and Categories holds the
+// categories its items can be filed under.
 type BudgetOptions struct {
-	UUID     string
-	Server   client.Server
-	Currency currency.Currency
+	UUID       string
+	Server     client.Server
+	Currency   currency.Currency
+	Locale     currency.Locale
+	Records    []BudgetRecord
+	Categories Categories
 }
 
 // PocketBudget provides the central repository for creating a pocket instance.
+// While a past date is picked the pocket shows its budgets as they stood then,
+// held apart from its current budgets.
+//
+// The budgets of the pocket are guarded by its lock, which is always taken
+// before the lock of any of its budgets. The fields of a budget are only
+// changed while holding both locks, so either lock allows them to be read.
 type PocketBudget struct {
 	BudgetOptions
-	action int64
+	rl     sync.RWMutex
 	active *Budget
-	items  map[string]Budget
+	items  map[string]*Budget
+	asOf   string
+	past   []*Budget
 }
 
-// NewPocketBudget returns a new PocketBudget instance.
-func NewPocketBudget(bc BudgetOptions) *PocketBudget {
+// newPocket returns a new PocketBudget instance holding the budget records of
+// the options.
+func newPocket(bc BudgetOptions) *PocketBudget {
+	if bc.Locale.Tag == "" {
+		bc.Locale = currency.DefaultLocale
+	}
+
 	pocket := PocketBudget{
 		BudgetOptions: bc,
-		items:         make(map[string]Budget),
+		items:         make(map[string]*Budget),
+	}
+
+	// The pocket is not yet shared, so its budgets can be changed directly.
+	for _, record := range bc.Records {
+		bu := pocket.AddBudget(record.ID, record.Title, record.Price)
+		bu.Prices = record.Prices
+		bu.Period = record.Period
 	}
 
+	return &pocket
+}
+
+// NewPocketBudget returns a new PocketBudget instance.
+func NewPocketBudget(bc BudgetOptions) *PocketBudget {
+	pocket := newPocket(bc)
+
 	gudispatch.Subscribe(func(bn *NewBudget) {
 		if bc.UUID != bn.UUID {
 			return
 		}
 
 		// Add new budget into the app list.
-		pocket.AddBudget(bn.Title, bn.Price)
+		pocket.AddBudget("", bn.Title, bn.Price)
 
 		// Dispatch to the view which got registered to update itself.
-		gudispatch.Dispatch(guviews.ViewUpdate{ID: bc.UUID})
+		gudispatch.Dispatch(&guviews.ViewUpdate{ID: bc.UUID})
 	})
 
-	return &pocket
+	gudispatch.Subscribe(func(ni *NewBudgetItem) {
+		if !pocket.AddItem(ni) {
+			return
+		}
+
+		gudispatch.Dispatch(&guviews.ViewUpdate{ID: bc.UUID})
+	})
+
+	subscribeLifecycle(pocket)
+
+	gudispatch.Subscribe(func(sp *ShowPeriod) {
+		if !pocket.updateBudget(sp.UUID, func(bu *Budget) { bu.offset = sp.Offset }) {
+			return
+		}
+
+		gudispatch.Dispatch(&guviews.ViewUpdate{ID: bc.UUID})
+	})
+
+	gudispatch.Subscribe(func(va *ViewAsOf) {
+		if bc.UUID != va.UUID {
+			return
+		}
+
+		pocket.viewAsOf(va.Date)
+	})
+
+	gudispatch.Subscribe(func(sb *SyncBudget) {
+		if bc.UUID != sb.UUID {
+			return
+		}
+
+		pocket.Sync()
+	})
+
+	if bc.Server != nil {
+
+		// Resync the pocket when the server reports changes to its budgets.
+		bc.Server.Updates(pocket.query(), func() {
+			gudispatch.Dispatch(&SyncBudget{UUID: bc.UUID})
+		})
+
+		pocket.Sync()
+	}
+
+	return pocket
 }
 
-// AddBudget returns the giving budget with the provided title.
-func (p *PocketBudget) AddBudget(title string, budgetPrice float64) *Budget {
-	var bu Budget
-
-	atomic.AddInt64(&p.action, 1)
-	{
-		if bux, ok := p.items[title]; ok {
-			bu = bux
-		} else {
-			bu = Budget{
-				Title:    title,
-				Price:    budgetPrice,
-				currency: p.Currency,
+// RenderPocket returns the html of the pocket holding the budget records of the
+// options, identified as the view created for the same options would be. This
+// allows servers to render pockets which the client can then hydrate.
+func RenderPocket(bc BudgetOptions) (template.HTML, error) {
+	markup := newPocket(bc).Render()
+
+	if backdoor, ok := markup.(gutrees.SwappableIdentity); ok {
+		backdoor.SwapUID(bc.UUID)
+	}
+
+	html, err := gutrees.SimpleMarkupWriter.Write(markup)
+	if err != nil {
+		return "", err
+	}
+
+	return template.HTML(html), nil
+}
+
+// query returns the coquery query which retrieves the budgets of the pocket,
+// including those archived.
+func (p *PocketBudget) query() string {
+	return fmt.Sprintf("budgets?pocket=%s&archived=true", p.UUID)
+}
+
+// Sync requests the budgets of the pocket from the server, both those listed
+// and those in the trash, along with their items, adding any budget not yet
+// known to the pocket and updating the rest.
+func (p *PocketBudget) Sync() {
+	if p.Server == nil {
+		return
+	}
+
+	for _, query := range []string{p.query(), p.trashQuery()} {
+		p.Server.Request(query, func(err error, meta data.ResponseMeta, records data.Parameters) {
+			if err != nil {
+				gudispatch.Dispatch(&Notify{
+					Message: err.Error(),
+					Type:    FailedSync,
+				})
+				return
+			}
+
+			for _, record := range records {
+				if err := p.syncBudget(record); err != nil {
+					gudispatch.Dispatch(&Notify{
+						Message: err.Error(),
+						Type:    FailedSync,
+					})
+				}
 			}
 
-			p.items[title] = bu
+			gudispatch.Dispatch(&guviews.ViewUpdate{ID: p.UUID})
+		})
+	}
+}
+
+// AddBudget returns the budget of the pocket with the provided title, adding it
+// with the giving price, held in the currency of the pocket, if the pocket has
+// no such budget. The id of the budget is recorded once known.
+func (p *PocketBudget) AddBudget(id string, title string, budgetPrice currency.Money) *Budget {
+	p.rl.Lock()
+	defer p.rl.Unlock()
+
+	if bu, ok := p.items[title]; ok {
+		if id != "" {
+			bu.rl.Lock()
+			if bu.ID == "" {
+				bu.ID = id
+			}
+			bu.rl.Unlock()
 		}
+
+		return bu
+	}
+
+	bu := p.newBudget(id, title, budgetPrice)
+	p.items[title] = bu
+
+	return bu
+}
+
+// newBudget returns a new Budget of the pocket, its price held in the currency
+// of the pocket.
+func (p *PocketBudget) newBudget(id string, title string, budgetPrice currency.Money) *Budget {
+	if price, err := budgetPrice.In(p.Currency); err == nil {
+		budgetPrice = price
+	}
+
+	bu := Budget{
+		ID:         id,
+		Title:      title,
+		Price:      budgetPrice,
+		currency:   p.Currency,
+		locale:     p.Locale,
+		categories: p.Categories,
 	}
-	atomic.AddInt64(&p.action, -1)
 
 	return &bu
 }
 
+// AddItem adds the item requested into the budget of the pocket it is written
+// against, returning false if the budget is not within the pocket.
+func (p *PocketBudget) AddItem(ni *NewBudgetItem) bool {
+	p.rl.RLock()
+	defer p.rl.RUnlock()
+
+	for _, bu := range p.items {
+		if bu.ID == "" || bu.ID != ni.UUID {
+			continue
+		}
+
+		bu.AddItem(ni.Title, ni.Desc, ni.Price, ni.Category, ni.Tags, ni.Time)
+		return true
+	}
+
+	return false
+}
+
 // Render returns the markup defined for a budget.
 func (p *PocketBudget) Render() gutrees.Markup {
-	var m gutrees.Markup
+	p.rl.RLock()
+	defer p.rl.RUnlock()
+
+	if p.active != nil {
+		return p.active.Render()
+	}
 
-	atomic.AddInt64(&p.action, 1)
-	{
+	if p.asOf != "" {
+		return elems.Div(attrs.Class("pocket-budget"), p.renderAsOf(), p.renderPast())
+	}
 
-		if p.active != nil {
-			m = p.active.Render()
-		} else {
+	m := elems.Div(attrs.Class("pocket-budget"), p.renderAsOf())
 
-			m = elems.Div(attrs.Class("pocket-budget"))
+	// Render the budgets by title, keeping the markup the same across renders.
+	titles := make([]string, 0, len(p.items))
+	for title := range p.items {
+		titles = append(titles, title)
+	}
 
-			for _, item := range p.items {
-				item.RenderBase().Apply(m)
-			}
+	sort.Strings(titles)
+
+	var archived, trash []*Budget
+
+	for _, title := range titles {
+		item := p.items[title]
 
+		switch {
+		case item.Deleted:
+			trash = append(trash, item)
+		case item.Archived:
+			archived = append(archived, item)
+		default:
+			item.RenderBase().Apply(m)
 		}
+	}
 
+	if len(archived) > 0 {
+		section := elems.Div(attrs.Class("pocket-archived"))
+		for _, item := range archived {
+			renderShelved(item.Title, &ArchiveBudget{UUID: item.ID}, "Unarchive").Apply(section)
+		}
+		section.Apply(m)
+	}
+
+	if len(trash) > 0 {
+		section := elems.Div(attrs.Class("pocket-trash"))
+		for _, item := range trash {
+			renderShelved(item.Title, &RestoreBudget{UUID: item.ID}, "Restore").Apply(section)
+		}
+		section.Apply(m)
 	}
-	atomic.AddInt64(&p.action, -1)
 
 	return m
 }
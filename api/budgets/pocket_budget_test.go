@@ -0,0 +1,177 @@
+package budgets
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/influx6/pocket/api/currency"
+)
+
+// TestPocketBudgetConcurrency hammers a pocket with budgets and items being
+// added, amended and rendered at once, to be run with the race detector.
+func TestPocketBudgetConcurrency(t *testing.T) {
+	usd, err := currency.ISO4217.Find("USD")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	price := currency.NewMoney(1250, usd)
+
+	pocket := newPocket(BudgetOptions{
+		UUID:     "pocket",
+		Currency: usd,
+		Records:  []BudgetRecord{{ID: "rent", Title: "Rent", Price: price}},
+	})
+
+	const workers, rounds = 8, 50
+
+	var wg sync.WaitGroup
+
+	for worker := 0; worker < workers; worker++ {
+		wg.Add(1)
+
+		go func(worker int) {
+			defer wg.Done()
+
+			own := fmt.Sprintf("budget-%d", worker)
+			pocket.AddBudget(own, own, price)
+
+			for round := 0; round < rounds; round++ {
+				id := fmt.Sprintf("item-%d-%d", worker, round)
+
+				if !pocket.AddItem(&NewBudgetItem{UUID: "rent", Title: id, Price: price}) {
+					t.Errorf("Expected item %s to be added", id)
+					return
+				}
+
+				// Items receive their id once synced from the server.
+				pocket.AddBudget("rent", "Rent", price).syncItem(BudgetItem{ID: id, Title: id, Price: price})
+
+				pocket.updateItem(id, func(_ *Budget, item *BudgetItem) {
+					item.Desc = "amended"
+				})
+
+				// Renaming moves the budget within the pocket, racing with
+				// every other worker's lookups.
+				pocket.updateBudget(own, func(bu *Budget) {
+					bu.Title = fmt.Sprintf("%s round %d", own, round)
+					bu.Price = currency.NewMoney(int64(round), usd)
+				})
+
+				pocket.updateBudget("rent", func(bu *Budget) {
+					bu.Archived = round%2 == 0
+				})
+
+				pocket.Render()
+			}
+		}(worker)
+	}
+
+	// Render alongside the workers as a view would on every update.
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		for round := 0; round < rounds; round++ {
+			pocket.Render()
+		}
+	}()
+
+	wg.Wait()
+	<-done
+
+	rent := pocket.AddBudget("rent", "Rent", price)
+
+	rent.rl.RLock()
+	defer rent.rl.RUnlock()
+
+	if len(rent.items) != workers*rounds {
+		t.Fatalf("Expected %d items within the budget, got %d", workers*rounds, len(rent.items))
+	}
+
+	for _, item := range rent.items {
+		if item.ID == "" || item.Desc != "amended" {
+			t.Fatalf("Expected every item to be synced and amended, got %+v", item)
+		}
+	}
+
+	if len(pocket.items) != workers+1 {
+		t.Fatalf("Expected %d budgets within the pocket, got %d", workers+1, len(pocket.items))
+	}
+}
+
+// TestRenderPocket checks pockets rendered on the server carry the id of their
+// view, for the client to hydrate, and show every budget of the options with
+// its price in the currency and locale of the options.
+func TestRenderPocket(t *testing.T) {
+	usd, err := currency.ISO4217.Find("USD")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	de, err := currency.FindLocale("de-DE")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		options  BudgetOptions
+		expected []string
+		missing  []string
+	}{
+		{
+			name: "budgets",
+			options: BudgetOptions{
+				UUID:     "pocket-1",
+				Currency: usd,
+				Locale:   currency.DefaultLocale,
+				Records: []BudgetRecord{
+					{ID: "rent", Title: "Rent", Price: currency.NewMoney(120000, usd)},
+					{ID: "food", Title: "Food", Price: currency.NewMoney(45050, usd)},
+				},
+			},
+			expected: []string{`uid="pocket-1"`, `id="budget-rent"`, `id="budget-food"`, "$1,200.00", "$450.50", `id="pocket-as-of-pocket-1"`},
+		},
+		{
+			name: "locale",
+			options: BudgetOptions{
+				UUID:     "pocket-2",
+				Currency: usd,
+				Locale:   de,
+				Records:  []BudgetRecord{{ID: "rent", Title: "Rent", Price: currency.NewMoney(120000, usd)}},
+			},
+			expected: []string{`uid="pocket-2"`, "1.200,00\u00a0$"},
+			missing:  []string{"$1,200.00"},
+		},
+		{
+			name:     "empty",
+			options:  BudgetOptions{UUID: "pocket-3", Currency: usd},
+			expected: []string{`uid="pocket-3"`, `class="pocket-budget"`},
+			missing:  []string{`class="budget"`},
+		},
+	}
+
+	for _, test := range tests {
+		html, err := RenderPocket(test.options)
+		if err != nil {
+			t.Errorf("Expected the %s pocket to render, got %s", test.name, err)
+			continue
+		}
+
+		for _, expected := range test.expected {
+			if !strings.Contains(string(html), expected) {
+				t.Errorf("Expected the %s pocket to hold %q, got %s", test.name, expected, html)
+			}
+		}
+
+		for _, missing := range test.missing {
+			if strings.Contains(string(html), missing) {
+				t.Errorf("Expected the %s pocket not to hold %q, got %s", test.name, missing, html)
+			}
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package budgets
+
+import (
+	"time"
+
+	"github.com/influx6/pocket/api/currency"
+)
+
+//==============================================================================
+
+// PocketRecord defines the stored details of a pocket, which groups a series
+// of budgets under a single currency.
+type PocketRecord struct {
+	ID       string `json:"id" bson:"_id"`
+	Owner    string `json:"owner"`
+	Title    string `json:"title"`
+	Currency string `json:"currency"`
+}
+
+// BudgetRecord defines the stored details of a budget line within a pocket.
+// Archived budgets are hidden from listings but kept within totals, while
+// budgets in the trash carry the time they were Deleted. Budgets with a Period
+// budget their price anew within every period, at the price it held then as
+// recorded by Prices.
+type BudgetRecord struct {
+	ID       string         `json:"id" bson:"_id"`
+	Pocket   string         `json:"pocket"`
+	Title    string         `json:"title"`
+	Price    currency.Money `json:"price"`
+	Prices   []PriceChange  `json:"prices,omitempty"`
+	Period   Period         `json:"period"`
+	Archived bool           `json:"archived"`
+	Deleted  *time.Time     `json:"deleted,omitempty"`
+}
+
+// Active returns true/false if the budget is neither archived nor in the trash.
+func (b BudgetRecord) Active() bool {
+	return !b.Archived && b.Deleted == nil
+}
+
+// PriceHistory returns the prices the budget held over time, the oldest first.
+// Budgets stored before their prices were recorded held their current price
+// throughout.
+func (b BudgetRecord) PriceHistory() []PriceChange {
+	if len(b.Prices) == 0 {
+		return []PriceChange{{Price: b.Price}}
+	}
+
+	return b.Prices
+}
+
+// PriceChange defines the price a budget was given at the time From, as
+// applied from the events of its log.
+type PriceChange struct {
+	From  time.Time      `json:"from"`
+	Price currency.Money `json:"price"`
+}
+
+// ItemRecord defines the stored details of a cost item written against a
+// budget, filed under a category and labelled with tags. Items are archived
+// and moved into the trash as budgets are.
+type ItemRecord struct {
+	ID       string         `json:"id" bson:"_id"`
+	Budget   string         `json:"budget"`
+	Title    string         `json:"title"`
+	Desc     string         `json:"desc"`
+	Price    currency.Money `json:"price"`
+	Category string         `json:"category"`
+	Tags     []string       `json:"tags"`
+	Time     time.Time      `json:"time"`
+	Archived bool           `json:"archived"`
+	Deleted  *time.Time     `json:"deleted,omitempty"`
+}
+
+// Active returns true/false if the item is neither archived nor in the trash.
+func (i ItemRecord) Active() bool {
+	return !i.Archived && i.Deleted == nil
+}
+
+// NotificationRecord defines a notification raised for the user who owns the
+// budget it concerns, kept until read and after.
+type NotificationRecord struct {
+	Notify `bson:",inline"`
+	ID     string    `json:"id" bson:"_id"`
+	Owner  string    `json:"owner"`
+	Time   time.Time `json:"time"`
+	Read   bool      `json:"read"`
+}
+
+// NotificationsKey returns the key changed whenever the notifications of the
+// user with the giving id change.
+func NotificationsKey(owner string) string {
+	return "notifications/" + owner
+}
+
+//==============================================================================
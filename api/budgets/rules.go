@@ -0,0 +1,301 @@
+package budgets
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/influx6/pocket/api/currency"
+)
+
+//==============================================================================
+
+// ErrInvalidRule is returned when an alert rule of an unknown kind, or with
+// settings outside its kind, is given.
+var ErrInvalidRule = errors.New("Invalid Alert Rule")
+
+// MaxRulePercent defines the highest percentage of the amount budgeted a
+// spent-percent rule can watch for.
+const MaxRulePercent = 1000
+
+// Kinds of alert rules.
+const (
+	RuleSpentPercent     = "spent-percent"
+	RuleProjectedOverrun = "projected-overrun"
+	RuleLargeItem        = "large-item"
+	RuleNoActivity       = "no-activity"
+)
+
+// Severity defines how pressing the notification raised by a rule is.
+type Severity string
+
+// Severities of notifications, from the least to the most pressing.
+const (
+	SeverityInfo     Severity = "info"
+	SeverityWarning  Severity = "warning"
+	SeverityCritical Severity = "critical"
+)
+
+// AtLeast returns true/false if the severity is as pressing as the giving
+// severity or more, unknown severities being less pressing than any other.
+func (s Severity) AtLeast(min Severity) bool {
+	return s.rank() >= min.rank() && min.rank() > 0
+}
+
+// rank returns the rank of the severity, from 1 for the least pressing, or 0
+// for an unknown severity.
+func (s Severity) rank() int {
+	switch s {
+	case SeverityInfo:
+		return 1
+	case SeverityWarning:
+		return 2
+	case SeverityCritical:
+		return 3
+	}
+
+	return 0
+}
+
+// BudgetLink returns the link to the budget with the giving id within the
+// pocket app.
+func BudgetLink(id string) string {
+	return "/#" + budgetAnchor(id)
+}
+
+// budgetAnchor returns the id of the markup of the budget with the giving id.
+func budgetAnchor(id string) string {
+	return "budget-" + id
+}
+
+//==============================================================================
+
+// RuleRecord defines the stored details of an alert rule watching a budget.
+// Spent-percent rules raise once Percent of the amount budgeted is spent, from
+// 1 up to MaxRulePercent,
+// projected-overrun rules once the spending so far projects past the amount
+// budgeted by the end of the period, large-item rules for every item priced
+// above Amount and no-activity rules once no item was written for Days days.
+type RuleRecord struct {
+	ID       string         `json:"id" bson:"_id"`
+	Budget   string         `json:"budget"`
+	Kind     string         `json:"kind"`
+	Percent  int            `json:"percent,omitempty"`
+	Amount   currency.Money `json:"amount"`
+	Days     int            `json:"days,omitempty"`
+	Severity Severity       `json:"severity"`
+}
+
+// Validate returns ErrInvalidRule if the rule is of an unknown kind or its
+// settings are outside its kind.
+func (r RuleRecord) Validate() error {
+	switch r.Severity {
+	case SeverityInfo, SeverityWarning, SeverityCritical:
+	default:
+		return ErrInvalidRule
+	}
+
+	switch r.Kind {
+	case RuleSpentPercent:
+		if r.Percent < 1 || r.Percent > MaxRulePercent {
+			return ErrInvalidRule
+		}
+	case RuleProjectedOverrun:
+		return nil
+	case RuleLargeItem:
+		if r.Amount.Amount <= 0 {
+			return ErrInvalidRule
+		}
+	case RuleNoActivity:
+		if r.Days <= 0 {
+			return ErrInvalidRule
+		}
+	default:
+		return ErrInvalidRule
+	}
+
+	return nil
+}
+
+// AlertRecord defines the notifications last raised by the rules watching the
+// Budget with the giving id, which tells newly raised notifications apart from
+// those raised before.
+type AlertRecord struct {
+	Budget  string   `json:"budget" bson:"_id"`
+	Notices []Notify `json:"notices"`
+}
+
+//==============================================================================
+
+// Evaluation defines the state of a budget its rules are evaluated against.
+// Budgeted is the amount the budget holds from Start up to End, the window of
+// its current period, and Items holds the items of the budget which are not
+// in the trash. Budgets without a period have a zero window, which holds all
+// their items. All amounts must be of the currency of Budgeted.
+type Evaluation struct {
+	Budget   BudgetRecord
+	Items    []ItemRecord
+	Budgeted currency.Money
+	Start    time.Time
+	End      time.Time
+	Now      time.Time
+}
+
+// within returns true/false if the giving time is within the window of the
+// evaluation.
+func (e Evaluation) within(at time.Time) bool {
+	if e.End.IsZero() {
+		return true
+	}
+
+	return !at.Before(e.Start) && at.Before(e.End)
+}
+
+// spent returns the total of the items within the window of the evaluation.
+func (e Evaluation) spent() (currency.Money, error) {
+	spent := currency.NewMoney(0, e.Budgeted.Currency)
+
+	for _, item := range e.Items {
+		if !e.within(item.Time) {
+			continue
+		}
+
+		var err error
+		if spent, err = spent.Add(item.Price); err != nil {
+			return spent, err
+		}
+	}
+
+	return spent, nil
+}
+
+// Evaluate returns the notifications raised by the rules against the budget
+// of the evaluation, in the order of the rules.
+func Evaluate(rules []RuleRecord, ev Evaluation) ([]Notify, error) {
+	spent, err := ev.spent()
+	if err != nil {
+		return nil, err
+	}
+
+	var notices []Notify
+
+	for _, rule := range rules {
+		raised, err := rule.evaluate(ev, spent)
+		if err != nil {
+			return nil, err
+		}
+
+		notices = append(notices, raised...)
+	}
+
+	return notices, nil
+}
+
+// evaluate returns the notifications raised by the rule against the budget of
+// the evaluation, which spent the giving amount within its window.
+func (r RuleRecord) evaluate(ev Evaluation, spent currency.Money) ([]Notify, error) {
+	budget := ev.Budget
+
+	notice := Notify{
+		Severity: r.Severity,
+		Budget:   budget.ID,
+		Rule:     r.ID,
+		Link:     BudgetLink(budget.ID),
+	}
+
+	switch r.Kind {
+	case RuleSpentPercent:
+		if ev.Budgeted.Amount <= 0 {
+			return nil, nil
+		}
+
+		// The percentage is truncated, so it reaches the rule's whole percent
+		// exactly when the spending does. Spending too far past the amount
+		// budgeted for its percentage to be held is past any rule.
+		percent, err := spent.Scale(100, ev.Budgeted.Amount)
+		switch {
+		case err == currency.ErrOverflow:
+			notice.Message = fmt.Sprintf("%s has spent %s, far past the %s budgeted", budget.Title, spent, ev.Budgeted)
+		case err != nil:
+			return nil, err
+		case percent.Amount < int64(r.Percent):
+			return nil, nil
+		default:
+			notice.Message = fmt.Sprintf("%s has spent %s, %d%% of the %s budgeted", budget.Title, spent, percent.Amount, ev.Budgeted)
+		}
+
+		notice.Type = SpendingThreshold
+
+	case RuleProjectedOverrun:
+		// Spending can only be projected within a period, once some is made.
+		if ev.End.IsZero() || spent.Amount <= 0 || !ev.Now.After(ev.Start) {
+			return nil, nil
+		}
+
+		elapsed := ev.Now.Sub(ev.Start)
+		if total := ev.End.Sub(ev.Start); elapsed > total {
+			elapsed = total
+		}
+
+		projected, err := spent.Scale(int64(ev.End.Sub(ev.Start)), int64(elapsed))
+		if err != nil {
+			return nil, err
+		}
+
+		if projected.Amount <= ev.Budgeted.Amount {
+			return nil, nil
+		}
+
+		notice.Type = ProjectedOverrun
+		notice.Message = fmt.Sprintf("%s is projected to spend %s of the %s budgeted by %s", budget.Title, projected, ev.Budgeted, ev.End.Format(periodLayout))
+
+	case RuleLargeItem:
+		amount, err := r.Amount.In(ev.Budgeted.Currency)
+		if err != nil {
+			return nil, err
+		}
+
+		var notices []Notify
+
+		for _, item := range ev.Items {
+			if !ev.within(item.Time) || item.Price.Amount <= amount.Amount {
+				continue
+			}
+
+			notice.Type = LargeItem
+			notice.Item = item.ID
+			notice.Message = fmt.Sprintf("%s of %s is priced at %s, above %s", item.Title, budget.Title, item.Price, amount)
+			notices = append(notices, notice)
+		}
+
+		return notices, nil
+
+	case RuleNoActivity:
+		var last time.Time
+		for _, item := range ev.Items {
+			if item.Time.After(last) {
+				last = item.Time
+			}
+		}
+
+		// Budgets without items are idle since their period began, if any.
+		since := last
+		if since.IsZero() {
+			since = ev.Start
+		}
+
+		if since.IsZero() || ev.Now.Sub(since) < time.Duration(r.Days)*24*time.Hour {
+			return nil, nil
+		}
+
+		notice.Type = NoActivity
+		notice.Message = fmt.Sprintf("%s has had no items written since %s", budget.Title, since.Format(periodLayout))
+
+	default:
+		return nil, ErrInvalidRule
+	}
+
+	return []Notify{notice}, nil
+}
+
+//==============================================================================
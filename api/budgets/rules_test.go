@@ -0,0 +1,120 @@
+package budgets
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influx6/pocket/api/currency"
+)
+
+// TestProjectedOverrunExact checks spending is projected over its period with
+// exact integer arithmetic, even for amounts a float64 can not hold.
+func TestProjectedOverrunExact(t *testing.T) {
+	usd, err := currency.ISO4217.Find("USD")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2016, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	// 2^53+1 is the first integer a float64 rounds, so spending it over half
+	// the period projects to 2^54+2, which a float64 rounds to 2^54.
+	spent := int64(1<<53 + 1)
+
+	rule := RuleRecord{ID: "rule", Kind: RuleProjectedOverrun, Severity: SeverityWarning}
+
+	ev := Evaluation{
+		Budget:   BudgetRecord{ID: "budget", Title: "Rent"},
+		Items:    []ItemRecord{{ID: "item", Price: currency.NewMoney(spent, usd), Time: start}},
+		Budgeted: currency.NewMoney(2*spent-1, usd),
+		Start:    start,
+		End:      start.AddDate(0, 0, 30),
+		Now:      start.AddDate(0, 0, 15),
+	}
+
+	notices, err := Evaluate([]RuleRecord{rule}, ev)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(notices) != 1 || notices[0].Type != ProjectedOverrun {
+		t.Fatalf("Expected a projected overrun of one minor unit to raise, got %+v", notices)
+	}
+
+	ev.Budgeted = currency.NewMoney(2*spent, usd)
+
+	if notices, err = Evaluate([]RuleRecord{rule}, ev); err != nil || len(notices) != 0 {
+		t.Fatalf("Expected spending projected to the amount budgeted not to raise, got %+v: %v", notices, err)
+	}
+}
+
+// TestSpentPercentLarge checks spent-percent rules hold for amounts whose
+// product with the percentage overflows an int64.
+func TestSpentPercentLarge(t *testing.T) {
+	usd, err := currency.ISO4217.Find("USD")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A budget of about MaxInt64/10 overflows once multiplied by a percent of
+	// 80, and spending at 80% of it overflows once multiplied by 100.
+	budgeted := int64(math.MaxInt64/1000) * 100
+	spent := budgeted / 100 * 80
+
+	rule := RuleRecord{ID: "rule", Kind: RuleSpentPercent, Percent: 80, Severity: SeverityWarning}
+
+	ev := Evaluation{
+		Budget:   BudgetRecord{ID: "budget", Title: "Rent"},
+		Items:    []ItemRecord{{ID: "item", Price: currency.NewMoney(spent, usd)}},
+		Budgeted: currency.NewMoney(budgeted, usd),
+	}
+
+	notices, err := Evaluate([]RuleRecord{rule}, ev)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(notices) != 1 || notices[0].Type != SpendingThreshold || !strings.Contains(notices[0].Message, " 80% ") {
+		t.Fatalf("Expected spending at 80%% of a large budget to raise, got %+v", notices)
+	}
+
+	ev.Items[0].Price = currency.NewMoney(spent-budgeted/100, usd)
+
+	if notices, err = Evaluate([]RuleRecord{rule}, ev); err != nil || len(notices) != 0 {
+		t.Fatalf("Expected spending at 79%% of a large budget not to raise, got %+v: %v", notices, err)
+	}
+
+	// Spending whose percentage of the budget can not be held is past any rule.
+	ev.Budgeted = currency.NewMoney(1, usd)
+	ev.Items[0].Price = currency.NewMoney(math.MaxInt64, usd)
+
+	if notices, err = Evaluate([]RuleRecord{rule}, ev); err != nil || len(notices) != 1 {
+		t.Fatalf("Expected spending far past the budget to raise, got %+v: %v", notices, err)
+	}
+}
+
+// TestRuleValidatePercent checks spent-percent rules are only saved with a
+// percent from 1 up to MaxRulePercent.
+func TestRuleValidatePercent(t *testing.T) {
+	tests := []struct {
+		percent int
+		err     error
+	}{
+		{-1, ErrInvalidRule},
+		{0, ErrInvalidRule},
+		{1, nil},
+		{80, nil},
+		{MaxRulePercent, nil},
+		{MaxRulePercent + 1, ErrInvalidRule},
+		{math.MaxInt32, ErrInvalidRule},
+	}
+
+	for _, test := range tests {
+		rule := RuleRecord{Kind: RuleSpentPercent, Percent: test.percent, Severity: SeverityWarning}
+		if err := rule.Validate(); err != test.err {
+			t.Errorf("Expected %v for a percent of %d, got %v", test.err, test.percent, err)
+		}
+	}
+}
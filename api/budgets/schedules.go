@@ -0,0 +1,420 @@
+package budgets
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/influx6/pocket/api/currency"
+)
+
+//==============================================================================
+
+// ErrInvalidSchedule is returned when a schedule of an unknown kind, or with a
+// day outside its kind, is given.
+var ErrInvalidSchedule = errors.New("Invalid Schedule")
+
+// ErrInvalidOccurrence is returned when an occurrence which is not pending is
+// skipped or postponed, or one is postponed to a day which is not after it.
+var ErrInvalidOccurrence = errors.New("Invalid Occurrence")
+
+// Kinds of schedules.
+const (
+	ScheduleDaily           = "daily"
+	ScheduleWeekly          = "weekly"
+	ScheduleMonthly         = "monthly"
+	ScheduleLastBusinessDay = "last-business-day"
+)
+
+//==============================================================================
+
+// Schedule defines the days a recurring item repeats on, in the manner of the
+// RRULEs of RFC 5545. Daily schedules repeat every Interval days, weekly
+// schedules every Interval weeks on the weekday Day, from 0 for Sunday to 6
+// for Saturday, monthly schedules every Interval months on the day Day of the
+// month, or its last day for shorter months, and last-business-day schedules
+// every Interval months on the last weekday of the month. Schedules repeat
+// from the day of Start through the day of Until, if any, and an Interval of
+// 0 stands for 1. Days are those of UTC.
+type Schedule struct {
+	Kind     string    `json:"kind"`
+	Interval int       `json:"interval"`
+	Day      int       `json:"day"`
+	Start    time.Time `json:"start"`
+	Until    time.Time `json:"until"`
+}
+
+// Validate returns ErrInvalidSchedule if the schedule is of an unknown kind,
+// its day is outside its kind or it ends before it starts.
+func (s Schedule) Validate() error {
+	if s.Interval < 0 || s.Start.IsZero() {
+		return ErrInvalidSchedule
+	}
+
+	if !s.Until.IsZero() && s.Until.Before(s.Start) {
+		return ErrInvalidSchedule
+	}
+
+	switch s.Kind {
+	case ScheduleDaily, ScheduleLastBusinessDay:
+		return nil
+	case ScheduleWeekly:
+		if s.Day < 0 || s.Day > 6 {
+			return ErrInvalidSchedule
+		}
+	case ScheduleMonthly:
+		if s.Day < 1 || s.Day > 31 {
+			return ErrInvalidSchedule
+		}
+	default:
+		return ErrInvalidSchedule
+	}
+
+	return nil
+}
+
+// Describe returns the schedule as a sentence, as in "Every 2 weeks on
+// Monday".
+func (s Schedule) Describe() string {
+	every := func(unit string) string {
+		if s.interval() == 1 {
+			return "Every " + unit
+		}
+
+		return fmt.Sprintf("Every %d %ss", s.interval(), unit)
+	}
+
+	switch s.Kind {
+	case ScheduleDaily:
+		return every("day")
+	case ScheduleWeekly:
+		return every("week") + " on " + time.Weekday(s.Day).String()
+	case ScheduleMonthly:
+		return every("month") + fmt.Sprintf(" on day %d", s.Day)
+	case ScheduleLastBusinessDay:
+		return every("month") + " on the last business day"
+	}
+
+	return s.Kind
+}
+
+// Next returns the first day of the schedule on or after the day of the
+// giving time, or a zero time if the schedule ends before it.
+func (s Schedule) Next(from time.Time) time.Time {
+	start := dateOf(s.Start)
+	from = dateOf(from)
+
+	if from.Before(start) {
+		from = start
+	}
+
+	// Estimate the count of repetitions up to the day, then settle on the
+	// first one on or after it.
+	n := s.cycles(start, from) - 1
+	if n < 0 {
+		n = 0
+	}
+
+	for {
+		day := s.occurrence(start, n)
+		n++
+
+		if day.Before(from) {
+			continue
+		}
+
+		if !s.Until.IsZero() && day.After(dateOf(s.Until)) {
+			return time.Time{}
+		}
+
+		return day
+	}
+}
+
+// Holds returns true/false if the day of the giving time is a day of the
+// schedule.
+func (s Schedule) Holds(at time.Time) bool {
+	day := dateOf(at)
+	return s.Next(day).Equal(day)
+}
+
+// occurrence returns the day of the n-th repetition of the schedule counted
+// from the one of the day it starts, which for monthly schedules may fall
+// before that day.
+func (s Schedule) occurrence(start time.Time, n int) time.Time {
+	switch s.Kind {
+	case ScheduleWeekly:
+		first := start.AddDate(0, 0, (s.Day-int(start.Weekday())+7)%7)
+		return first.AddDate(0, 0, 7*n*s.interval())
+
+	case ScheduleMonthly, ScheduleLastBusinessDay:
+		month := time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, n*s.interval(), 0)
+		last := month.AddDate(0, 1, -1)
+
+		if s.Kind == ScheduleMonthly {
+			if s.Day < last.Day() {
+				return month.AddDate(0, 0, s.Day-1)
+			}
+
+			return last
+		}
+
+		for last.Weekday() == time.Saturday || last.Weekday() == time.Sunday {
+			last = last.AddDate(0, 0, -1)
+		}
+
+		return last
+	}
+
+	return start.AddDate(0, 0, n*s.interval())
+}
+
+// cycles returns the count of whole repetitions of the schedule between the
+// two days.
+func (s Schedule) cycles(start time.Time, day time.Time) int {
+	switch s.Kind {
+	case ScheduleMonthly, ScheduleLastBusinessDay:
+		return ((day.Year()-start.Year())*12 + int(day.Month()) - int(start.Month())) / s.interval()
+	case ScheduleWeekly:
+		return int(day.Sub(start).Hours()/24) / (7 * s.interval())
+	}
+
+	return int(day.Sub(start).Hours()/24) / s.interval()
+}
+
+// interval returns the interval of the schedule, 0 standing for 1.
+func (s Schedule) interval() int {
+	if s.Interval <= 0 {
+		return 1
+	}
+
+	return s.Interval
+}
+
+// dateOf returns the start of the UTC day of the giving time.
+func dateOf(at time.Time) time.Time {
+	at = at.UTC()
+	return time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+//==============================================================================
+
+// Postponement defines an occurrence of a recurring item moved from its Date
+// to a later day.
+type Postponement struct {
+	Date time.Time `json:"date"`
+	To   time.Time `json:"to"`
+}
+
+// Occurrence defines a single item a recurring item writes, on the day of
+// Date, or of Due when postponed.
+type Occurrence struct {
+	Recurring string         `json:"recurring"`
+	Budget    string         `json:"budget"`
+	Title     string         `json:"title"`
+	Price     currency.Money `json:"price"`
+	Date      time.Time      `json:"date"`
+	Due       time.Time      `json:"due"`
+	Postponed bool           `json:"postponed"`
+}
+
+// RecurringRecord defines the stored details of a recurring item, a template
+// of the items written against a budget on every day of its schedule. Next
+// holds the first day of the schedule not yet written, and is zero once the
+// schedule ends. Skipped holds the days from Next on which are not written,
+// either skipped or postponed into Postponed. Due holds the day the next
+// item is due on, and is zero once none are.
+type RecurringRecord struct {
+	ID        string         `json:"id" bson:"_id"`
+	Budget    string         `json:"budget"`
+	Title     string         `json:"title"`
+	Desc      string         `json:"desc"`
+	Price     currency.Money `json:"price"`
+	Category  string         `json:"category"`
+	Tags      []string       `json:"tags"`
+	Schedule  Schedule       `json:"schedule"`
+	Next      time.Time      `json:"next"`
+	Skipped   []time.Time    `json:"skipped"`
+	Postponed []Postponement `json:"postponed"`
+	Due       time.Time      `json:"due"`
+}
+
+// Begin sets the recurring item to write items from the first day of its
+// schedule.
+func (r *RecurringRecord) Begin() {
+	r.Next = r.Schedule.Next(r.Schedule.Start)
+	r.Skipped = nil
+	r.Postponed = nil
+	r.refresh()
+}
+
+// Upcoming returns the occurrences of the recurring item due through the day
+// of the giving time, ordered by the day they are due on.
+func (r RecurringRecord) Upcoming(through time.Time) []Occurrence {
+	through = dateOf(through)
+
+	var upcoming []Occurrence
+
+	for day := r.Next; !day.IsZero() && !day.After(through); day = r.Schedule.Next(day.AddDate(0, 0, 1)) {
+		if !r.skips(day) {
+			upcoming = append(upcoming, r.occurrence(day, day))
+		}
+	}
+
+	for _, moved := range r.Postponed {
+		if !moved.To.After(through) {
+			occurrence := r.occurrence(moved.Date, moved.To)
+			occurrence.Postponed = true
+			upcoming = append(upcoming, occurrence)
+		}
+	}
+
+	sort.Sort(occurrencesByDue(upcoming))
+	return upcoming
+}
+
+// Advance marks every occurrence due through the day of the giving time as
+// written.
+func (r *RecurringRecord) Advance(through time.Time) {
+	through = dateOf(through)
+
+	if !r.Next.IsZero() && !r.Next.After(through) {
+		r.Next = r.Schedule.Next(through.AddDate(0, 0, 1))
+	}
+
+	skipped := r.Skipped[:0]
+	for _, day := range r.Skipped {
+		if !r.Next.IsZero() && !day.Before(r.Next) {
+			skipped = append(skipped, day)
+		}
+	}
+
+	postponed := r.Postponed[:0]
+	for _, moved := range r.Postponed {
+		if moved.To.After(through) {
+			postponed = append(postponed, moved)
+		}
+	}
+
+	r.Skipped, r.Postponed = skipped, postponed
+	r.refresh()
+}
+
+// Skip stops the pending occurrence of the giving day, as scheduled or
+// postponed, from being written.
+func (r *RecurringRecord) Skip(date time.Time) error {
+	date = dateOf(date)
+
+	for index, moved := range r.Postponed {
+		if moved.Date.Equal(date) {
+			r.Postponed = append(r.Postponed[:index], r.Postponed[index+1:]...)
+			r.refresh()
+			return nil
+		}
+	}
+
+	if !r.pending(date) {
+		return ErrInvalidOccurrence
+	}
+
+	r.Skipped = append(r.Skipped, date)
+	r.refresh()
+	return nil
+}
+
+// Postpone moves the pending occurrence of the giving day, as scheduled or
+// already postponed, to the day of to, which must be after it.
+func (r *RecurringRecord) Postpone(date time.Time, to time.Time) error {
+	date, to = dateOf(date), dateOf(to)
+
+	if !to.After(date) {
+		return ErrInvalidOccurrence
+	}
+
+	for index, moved := range r.Postponed {
+		if moved.Date.Equal(date) {
+			r.Postponed[index].To = to
+			r.refresh()
+			return nil
+		}
+	}
+
+	if !r.pending(date) {
+		return ErrInvalidOccurrence
+	}
+
+	r.Skipped = append(r.Skipped, date)
+	r.Postponed = append(r.Postponed, Postponement{Date: date, To: to})
+	r.refresh()
+	return nil
+}
+
+// pending returns true/false if the day is a day of the schedule which is yet
+// to be written, skipped or postponed.
+func (r RecurringRecord) pending(date time.Time) bool {
+	if r.Next.IsZero() || date.Before(r.Next) || !r.Schedule.Holds(date) {
+		return false
+	}
+
+	return !r.skips(date)
+}
+
+// skips returns true/false if the day of the schedule is skipped or postponed.
+func (r RecurringRecord) skips(date time.Time) bool {
+	for _, day := range r.Skipped {
+		if day.Equal(date) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// refresh sets the day the next item of the recurring item is due on.
+func (r *RecurringRecord) refresh() {
+	var due time.Time
+
+	for day := r.Next; !day.IsZero(); day = r.Schedule.Next(day.AddDate(0, 0, 1)) {
+		if !r.skips(day) {
+			due = day
+			break
+		}
+	}
+
+	for _, moved := range r.Postponed {
+		if due.IsZero() || moved.To.Before(due) {
+			due = moved.To
+		}
+	}
+
+	r.Due = due
+}
+
+// occurrence returns the occurrence of the giving day, due on the day of due.
+func (r RecurringRecord) occurrence(date time.Time, due time.Time) Occurrence {
+	return Occurrence{
+		Recurring: r.ID,
+		Budget:    r.Budget,
+		Title:     r.Title,
+		Price:     r.Price,
+		Date:      date,
+		Due:       due,
+	}
+}
+
+// occurrencesByDue implements sort.Interface to order occurrences by the day
+// they are due on.
+type occurrencesByDue []Occurrence
+
+func (o occurrencesByDue) Len() int      { return len(o) }
+func (o occurrencesByDue) Swap(i, j int) { o[i], o[j] = o[j], o[i] }
+func (o occurrencesByDue) Less(i, j int) bool {
+	if !o[i].Due.Equal(o[j].Due) {
+		return o[i].Due.Before(o[j].Due)
+	}
+
+	return o[i].Title < o[j].Title
+}
+
+//==============================================================================
@@ -0,0 +1,227 @@
+package budgets
+
+import (
+	"testing"
+	"time"
+)
+
+// day returns the start of the UTC day of the giving date.
+func day(year int, month time.Month, date int) time.Time {
+	return time.Date(year, month, date, 0, 0, 0, 0, time.UTC)
+}
+
+// days returns the days of the schedule from the giving day on, up to count.
+func days(s Schedule, from time.Time, count int) []time.Time {
+	var found []time.Time
+
+	for next := s.Next(from); !next.IsZero() && len(found) < count; next = s.Next(next.AddDate(0, 0, 1)) {
+		found = append(found, next)
+	}
+
+	return found
+}
+
+//==============================================================================
+
+// TestScheduleNext checks the days of schedules of every kind, monthly ones
+// falling on the last day of shorter months and last-business-day ones on the
+// Friday before months ending on a weekend.
+func TestScheduleNext(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule Schedule
+		expected []time.Time
+	}{
+		{
+			name:     "monthly on the 31st",
+			schedule: Schedule{Kind: ScheduleMonthly, Day: 31, Start: day(2016, 1, 31)},
+			expected: []time.Time{day(2016, 1, 31), day(2016, 2, 29), day(2016, 3, 31), day(2016, 4, 30)},
+		},
+		{
+			name:     "monthly on the 31st outside a leap year",
+			schedule: Schedule{Kind: ScheduleMonthly, Day: 31, Start: day(2017, 1, 31)},
+			expected: []time.Time{day(2017, 1, 31), day(2017, 2, 28), day(2017, 3, 31)},
+		},
+		{
+			name:     "monthly on the 30th every 2 months",
+			schedule: Schedule{Kind: ScheduleMonthly, Interval: 2, Day: 30, Start: day(2015, 12, 1)},
+			expected: []time.Time{day(2015, 12, 30), day(2016, 2, 29), day(2016, 4, 30)},
+		},
+		{
+			name:     "monthly starting after the day",
+			schedule: Schedule{Kind: ScheduleMonthly, Day: 5, Start: day(2016, 1, 20)},
+			expected: []time.Time{day(2016, 2, 5), day(2016, 3, 5)},
+		},
+		{
+			name:     "last business day",
+			schedule: Schedule{Kind: ScheduleLastBusinessDay, Start: day(2016, 4, 1)},
+			expected: []time.Time{day(2016, 4, 29), day(2016, 5, 31), day(2016, 6, 30), day(2016, 7, 29), day(2016, 8, 31)},
+		},
+		{
+			name:     "last business day every 4 months",
+			schedule: Schedule{Kind: ScheduleLastBusinessDay, Interval: 4, Start: day(2016, 4, 1)},
+			expected: []time.Time{day(2016, 4, 29), day(2016, 8, 31), day(2016, 12, 30)},
+		},
+		{
+			name:     "weekly on monday",
+			schedule: Schedule{Kind: ScheduleWeekly, Day: int(time.Monday), Start: day(2016, 3, 1)},
+			expected: []time.Time{day(2016, 3, 7), day(2016, 3, 14), day(2016, 3, 21)},
+		},
+		{
+			name:     "daily every 3 days until the 7th",
+			schedule: Schedule{Kind: ScheduleDaily, Interval: 3, Start: day(2016, 3, 1), Until: day(2016, 3, 7)},
+			expected: []time.Time{day(2016, 3, 1), day(2016, 3, 4), day(2016, 3, 7)},
+		},
+	}
+
+	for _, test := range tests {
+		if err := test.schedule.Validate(); err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+
+		found := days(test.schedule, test.schedule.Start, len(test.expected)+1)
+
+		if test.schedule.Until.IsZero() {
+			found = found[:len(test.expected)]
+		}
+
+		if len(found) != len(test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, found)
+			continue
+		}
+
+		for index, expected := range test.expected {
+			if !found[index].Equal(expected) {
+				t.Errorf("%s: expected %v, got %v", test.name, test.expected, found)
+				break
+			}
+
+			if !test.schedule.Holds(expected.Add(9 * time.Hour)) {
+				t.Errorf("%s: expected the schedule to hold %v", test.name, expected)
+			}
+		}
+	}
+}
+
+// TestRecurringSkipPostpone checks skipped occurrences are not written and
+// postponed ones are written on the day they were moved to, while occurrences
+// which are not pending can be neither.
+func TestRecurringSkipPostpone(t *testing.T) {
+	recurring := RecurringRecord{
+		ID:       "rent",
+		Title:    "Rent",
+		Schedule: Schedule{Kind: ScheduleMonthly, Day: 1, Start: day(2016, 1, 1)},
+	}
+
+	recurring.Begin()
+
+	if !recurring.Due.Equal(day(2016, 1, 1)) {
+		t.Fatalf("Expected the first occurrence to be due on its first day, got %v", recurring.Due)
+	}
+
+	if err := recurring.Skip(day(2016, 1, 1)); err != nil {
+		t.Fatal(err)
+	}
+
+	if !recurring.Due.Equal(day(2016, 2, 1)) {
+		t.Fatalf("Expected the skipped occurrence to pass the due day on, got %v", recurring.Due)
+	}
+
+	if err := recurring.Postpone(day(2016, 2, 1), day(2016, 2, 10)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Moving a postponed occurrence again moves it from the day it was due.
+	if err := recurring.Postpone(day(2016, 2, 1), day(2016, 2, 15)); err != nil {
+		t.Fatal(err)
+	}
+
+	if !recurring.Due.Equal(day(2016, 2, 15)) {
+		t.Fatalf("Expected the postponed occurrence to be due on the 15th, got %v", recurring.Due)
+	}
+
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{"skipping a skipped occurrence", recurring.Skip(day(2016, 1, 1))},
+		{"skipping a day off the schedule", recurring.Skip(day(2016, 3, 2))},
+		{"postponing to the same day", recurring.Postpone(day(2016, 3, 1), day(2016, 3, 1))},
+		{"postponing to an earlier day", recurring.Postpone(day(2016, 3, 1), day(2016, 2, 20))},
+		{"postponing a day off the schedule", recurring.Postpone(day(2016, 3, 2), day(2016, 3, 5))},
+	}
+
+	for _, test := range tests {
+		if test.err != ErrInvalidOccurrence {
+			t.Errorf("Expected ErrInvalidOccurrence %s, got %v", test.name, test.err)
+		}
+	}
+
+	upcoming := recurring.Upcoming(day(2016, 3, 31))
+	if len(upcoming) != 2 {
+		t.Fatalf("Expected the postponed and the march occurrences, got %+v", upcoming)
+	}
+
+	if !upcoming[0].Postponed || !upcoming[0].Date.Equal(day(2016, 2, 1)) || !upcoming[0].Due.Equal(day(2016, 2, 15)) {
+		t.Errorf("Expected the february occurrence due on the 15th, got %+v", upcoming[0])
+	}
+
+	if upcoming[1].Postponed || !upcoming[1].Due.Equal(day(2016, 3, 1)) {
+		t.Errorf("Expected the march occurrence due as scheduled, got %+v", upcoming[1])
+	}
+
+	// Skipping a postponed occurrence drops it altogether.
+	if err := recurring.Skip(day(2016, 2, 1)); err != nil {
+		t.Fatal(err)
+	}
+
+	if upcoming := recurring.Upcoming(day(2016, 3, 31)); len(upcoming) != 1 || !upcoming[0].Due.Equal(day(2016, 3, 1)) {
+		t.Fatalf("Expected only the march occurrence, got %+v", upcoming)
+	}
+}
+
+// TestRecurringMissed checks every occurrence missed while the scheduler was
+// not running is listed once, in order, and that advancing past them leaves
+// only those yet to come.
+func TestRecurringMissed(t *testing.T) {
+	recurring := RecurringRecord{
+		ID:       "rent",
+		Title:    "Rent",
+		Schedule: Schedule{Kind: ScheduleMonthly, Day: 31, Start: day(2016, 1, 1)},
+	}
+
+	recurring.Begin()
+
+	if err := recurring.Postpone(day(2016, 2, 29), day(2016, 3, 3)); err != nil {
+		t.Fatal(err)
+	}
+
+	now := day(2016, 4, 15).Add(12 * time.Hour)
+
+	upcoming := recurring.Upcoming(now)
+
+	expected := []time.Time{day(2016, 1, 31), day(2016, 3, 3), day(2016, 3, 31)}
+	if len(upcoming) != len(expected) {
+		t.Fatalf("Expected %d missed occurrences, got %+v", len(expected), upcoming)
+	}
+
+	for index, due := range expected {
+		if !upcoming[index].Due.Equal(due) {
+			t.Errorf("Expected occurrence %d due on %v, got %v", index, due, upcoming[index].Due)
+		}
+	}
+
+	recurring.Advance(now)
+
+	if upcoming := recurring.Upcoming(now); len(upcoming) != 0 {
+		t.Fatalf("Expected no occurrence once advanced, got %+v", upcoming)
+	}
+
+	if !recurring.Next.Equal(day(2016, 4, 30)) || !recurring.Due.Equal(day(2016, 4, 30)) {
+		t.Fatalf("Expected the april occurrence to be next, got %v due %v", recurring.Next, recurring.Due)
+	}
+
+	if len(recurring.Skipped) != 0 || len(recurring.Postponed) != 0 {
+		t.Fatalf("Expected the written occurrences to be forgotten, got %v and %v", recurring.Skipped, recurring.Postponed)
+	}
+}
@@ -0,0 +1,35 @@
+package budgets
+
+import (
+	"time"
+
+	"github.com/influx6/pocket/api/currency"
+)
+
+//==============================================================================
+
+// PocketTotal defines the totals of a pocket converted into a home currency.
+// Net is what remains of the Budgeted amount after what was Spent.
+type PocketTotal struct {
+	ID       string         `json:"id"`
+	Title    string         `json:"title"`
+	Currency string         `json:"currency"`
+	Budgeted currency.Money `json:"budgeted"`
+	Spent    currency.Money `json:"spent"`
+	Net      currency.Money `json:"net"`
+}
+
+// NetTotal defines the totals of all the pockets of a user converted into
+// their home currency as of the giving date. Items are converted at the rate
+// valid on the day they were spent and budgets at the rate valid on the Date.
+type NetTotal struct {
+	ID       string         `json:"id"`
+	Currency string         `json:"currency"`
+	Date     time.Time      `json:"date"`
+	Budgeted currency.Money `json:"budgeted"`
+	Spent    currency.Money `json:"spent"`
+	Net      currency.Money `json:"net"`
+	Pockets  []PocketTotal  `json:"pockets"`
+}
+
+//==============================================================================
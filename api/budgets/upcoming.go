@@ -0,0 +1,234 @@
+package budgets
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/influx6/coquery/client"
+	"github.com/influx6/coquery/data"
+	"github.com/influx6/gu/gudispatch"
+	"github.com/influx6/gu/gutrees"
+	"github.com/influx6/gu/gutrees/attrs"
+	"github.com/influx6/gu/gutrees/elems"
+	"github.com/influx6/gu/guviews"
+	"github.com/influx6/pocket/api/currency"
+)
+
+//==============================================================================
+
+func init() {
+	guviews.Register("pocket/upcoming", func(uo UpcomingOptions) guviews.Renderable {
+		return NewUpcoming(uo)
+	})
+}
+
+//==============================================================================
+
+// postponeDays defines the count of days an occurrence is postponed by from
+// the upcoming view.
+const postponeDays = 7
+
+// UpcomingID returns the id of the upcoming view of the pocket with the giving
+// id.
+func UpcomingID(pocket string) string {
+	return "upcoming:" + pocket
+}
+
+// UpcomingOptions defines a configuration struct passed into the upcoming view
+// initializer. The UUID is that of the pocket whose upcoming items are shown,
+// its amounts held in the Currency and written for the Locale.
+type UpcomingOptions struct {
+	UUID     string
+	Server   client.Server
+	Currency currency.Currency
+	Locale   currency.Locale
+}
+
+// Upcoming provides the view which lists the items the recurring items of a
+// pocket are due to write, allowing each to be skipped or postponed.
+type Upcoming struct {
+	UpcomingOptions
+	rl          sync.RWMutex
+	occurrences []Occurrence
+}
+
+// NewUpcoming returns a new Upcoming instance.
+func NewUpcoming(uo UpcomingOptions) *Upcoming {
+	if uo.Locale.Tag == "" {
+		uo.Locale = currency.DefaultLocale
+	}
+
+	up := Upcoming{UpcomingOptions: uo}
+
+	gudispatch.Subscribe(func(so *SkipOccurrence) {
+		up.change(so.UUID, so.Date, func(occurrences []Occurrence, index int) []Occurrence {
+			return append(occurrences[:index], occurrences[index+1:]...)
+		})
+	})
+
+	gudispatch.Subscribe(func(po *PostponeOccurrence) {
+		up.change(po.UUID, po.Date, func(occurrences []Occurrence, index int) []Occurrence {
+			occurrences[index].Due = po.To
+			occurrences[index].Postponed = true
+			return occurrences
+		})
+	})
+
+	gudispatch.Subscribe(func(su *SyncUpcoming) {
+		if su.UUID != uo.UUID {
+			return
+		}
+
+		up.Sync()
+	})
+
+	if uo.Server != nil {
+		up.Sync()
+	}
+
+	return &up
+}
+
+// change applies the change to the listed occurrence of the recurring item
+// with the giving id on the giving day, if any, ahead of the server applying
+// the command.
+func (u *Upcoming) change(recurring string, date time.Time, change func([]Occurrence, int) []Occurrence) {
+	u.rl.Lock()
+
+	var changed bool
+
+	for index, occurrence := range u.occurrences {
+		if occurrence.Recurring == recurring && occurrence.Date.Equal(date) {
+			u.occurrences = change(u.occurrences, index)
+			sort.Sort(occurrencesByDue(u.occurrences))
+			changed = true
+			break
+		}
+	}
+
+	u.rl.Unlock()
+
+	// The view takes the lock to render, so it is only updated once released.
+	if changed {
+		gudispatch.Dispatch(&guviews.ViewUpdate{ID: UpcomingID(u.UUID)})
+	}
+}
+
+// Sync requests the upcoming items of the pocket from the server, replacing
+// those listed.
+func (u *Upcoming) Sync() {
+	if u.Server == nil {
+		return
+	}
+
+	query := fmt.Sprintf("upcoming?pocket=%s", u.UUID)
+
+	u.Server.Request(query, func(err error, meta data.ResponseMeta, records data.Parameters) {
+		if err != nil {
+			gudispatch.Dispatch(&Notify{
+				Message: err.Error(),
+				Type:    FailedSync,
+			})
+			return
+		}
+
+		occurrences := make([]Occurrence, 0, len(records))
+		for _, record := range records {
+			occurrence, err := u.parseOccurrence(record)
+			if err != nil {
+				gudispatch.Dispatch(&Notify{
+					Message: err.Error(),
+					Type:    FailedSync,
+				})
+				continue
+			}
+
+			occurrences = append(occurrences, occurrence)
+		}
+
+		u.rl.Lock()
+		u.occurrences = occurrences
+		u.rl.Unlock()
+
+		gudispatch.Dispatch(&guviews.ViewUpdate{ID: UpcomingID(u.UUID)})
+	})
+}
+
+// parseOccurrence returns the occurrence described by the occurrence record
+// received from the server.
+func (u *Upcoming) parseOccurrence(record data.Parameter) (Occurrence, error) {
+	var occurrence Occurrence
+
+	occurrence.Recurring, _ = record.Get("recurring").(string)
+	occurrence.Budget, _ = record.Get("budget").(string)
+	occurrence.Title, _ = record.Get("title").(string)
+	occurrence.Postponed, _ = record.Get("postponed").(bool)
+
+	if stamp, ok := record.Get("date").(string); ok {
+		occurrence.Date, _ = time.Parse(time.RFC3339Nano, stamp)
+	}
+
+	if stamp, ok := record.Get("due").(string); ok {
+		occurrence.Due, _ = time.Parse(time.RFC3339Nano, stamp)
+	}
+
+	amount, _ := record.Get("price").(string)
+
+	price, err := currency.ParseMoney(amount, u.Currency)
+	if err != nil {
+		return occurrence, err
+	}
+
+	occurrence.Price = price
+	return occurrence, nil
+}
+
+// Render returns the markup for the upcoming items of the pocket, each with
+// the actions which skip it or postpone it by a week.
+func (u *Upcoming) Render() gutrees.Markup {
+	u.rl.RLock()
+	defer u.rl.RUnlock()
+
+	root := elems.Div(
+		attrs.Class("pocket-upcoming"),
+		elems.Header(elems.Label(attrs.Class("upcoming-title"), elems.Text("Upcoming"))),
+	)
+
+	if len(u.occurrences) == 0 {
+		elems.Label(attrs.Class("upcoming-empty"), elems.Text("Nothing due")).Apply(root)
+		return root
+	}
+
+	for _, occurrence := range u.occurrences {
+		classes := []string{"upcoming-item"}
+		if occurrence.Postponed {
+			classes = append(classes, "upcoming-postponed")
+		}
+
+		row := elems.Div(
+			attrs.Class(classes...),
+			elems.Label(attrs.Class("upcoming-due"), elems.Text(occurrence.Due.Format(periodLayout))),
+			elems.Label(attrs.Class("upcoming-item-title"), elems.Text(occurrence.Title)),
+			elems.Label(attrs.Class("upcoming-item-price"), elems.Text(occurrence.Price.Format(u.Locale))),
+		)
+
+		renderAction("Skip", &SkipOccurrence{
+			UUID: occurrence.Recurring,
+			Date: occurrence.Date,
+		}).Apply(row)
+
+		renderAction("Postpone a week", &PostponeOccurrence{
+			UUID: occurrence.Recurring,
+			Date: occurrence.Date,
+			To:   occurrence.Due.AddDate(0, 0, postponeDays),
+		}).Apply(row)
+
+		row.Apply(root)
+	}
+
+	return root
+}
+
+//==============================================================================
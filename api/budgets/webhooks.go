@@ -0,0 +1,125 @@
+package budgets
+
+import (
+	"errors"
+	"net/url"
+	"time"
+)
+
+//==============================================================================
+
+// ErrInvalidWebhook is returned when a webhook with an invalid URL, or
+// subscribing to an unknown event, is given.
+var ErrInvalidWebhook = errors.New("Invalid Webhook")
+
+// HookAlert names the webhook event delivered when an alert rule raises a
+// notification, such as a spending threshold being crossed. Changes to
+// budgets and items are delivered under the kind of their event, such as
+// NewBudgetItem or AmendBudget.
+const HookAlert = "Alert"
+
+// HookEvents lists the events webhooks can subscribe to.
+var HookEvents = []string{
+	EventNewBudget,
+	EventAmendBudget,
+	EventDeleteBudget,
+	EventRestoreBudget,
+	EventArchiveBudget,
+	EventNewBudgetItem,
+	EventAmendBudgetItem,
+	EventDeleteBudgetItem,
+	EventRestoreBudgetItem,
+	EventArchiveBudgetItem,
+	HookAlert,
+}
+
+//==============================================================================
+
+// WebhookRecord defines the stored details of a URL receiving the events of a
+// pocket, every delivery being signed with its Secret. Webhooks without
+// Events receive every event.
+type WebhookRecord struct {
+	ID      string    `json:"id" bson:"_id"`
+	Pocket  string    `json:"pocket"`
+	URL     string    `json:"url"`
+	Secret  string    `json:"secret"`
+	Events  []string  `json:"events"`
+	Created time.Time `json:"created"`
+}
+
+// Validate returns ErrInvalidWebhook if the URL of the webhook is not an
+// absolute http or https URL, or it subscribes to an unknown event.
+func (w WebhookRecord) Validate() error {
+	link, err := url.Parse(w.URL)
+	if err != nil || link.Host == "" || (link.Scheme != "http" && link.Scheme != "https") {
+		return ErrInvalidWebhook
+	}
+
+	for _, event := range w.Events {
+		if !knownHookEvent(event) {
+			return ErrInvalidWebhook
+		}
+	}
+
+	return nil
+}
+
+// Wants returns true/false if the webhook receives the giving event.
+func (w WebhookRecord) Wants(event string) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+
+	for _, wanted := range w.Events {
+		if wanted == event {
+			return true
+		}
+	}
+
+	return false
+}
+
+// knownHookEvent returns true/false if webhooks can subscribe to the event.
+func knownHookEvent(event string) bool {
+	for _, known := range HookEvents {
+		if known == event {
+			return true
+		}
+	}
+
+	return false
+}
+
+//==============================================================================
+
+// DeliveryRecord defines a single attempt at delivering an event to a webhook,
+// as shown within its delivery log. Status holds the status the receiver
+// responded with, or zero when Error prevented a response. Attempts at the
+// same delivery share its Delivery id.
+type DeliveryRecord struct {
+	ID       string        `json:"id" bson:"_id"`
+	Webhook  string        `json:"webhook"`
+	Delivery string        `json:"delivery"`
+	Event    string        `json:"event"`
+	Attempt  int           `json:"attempt"`
+	Time     time.Time     `json:"time"`
+	Duration time.Duration `json:"duration"`
+	Status   int           `json:"status"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// HookPayload defines the JSON body delivered to webhooks. Changes carry the
+// budget or item as it stands after them, and alerts carry the notification
+// raised.
+type HookPayload struct {
+	ID     string        `json:"id"`
+	Event  string        `json:"event"`
+	Pocket string        `json:"pocket"`
+	By     string        `json:"by,omitempty"`
+	Time   time.Time     `json:"time"`
+	Budget *BudgetRecord `json:"budget,omitempty"`
+	Item   *ItemRecord   `json:"item,omitempty"`
+	Alert  *Notify       `json:"alert,omitempty"`
+}
+
+//==============================================================================
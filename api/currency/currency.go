@@ -1,3 +1,6 @@
+// Package currency provides the ISO 4217 currencies pockets are kept in, the
+// Money type holding exact amounts of them and the locales amounts are
+// formatted for.
 package currency
 
 import (
@@ -7,15 +10,29 @@ import (
 
 //==============================================================================
 
-// Currency defines the currency type for a budget.
+// Currency defines the currency type for a budget, as listed by ISO 4217. The
+// Exponent defines the number of digits of its minor unit, as 2 for the cents
+// of a dollar.
 type Currency struct {
-	Name string `json:"name"`
-	Sign string `json:"sign"`
+	Code     string `json:"code"`
+	Numeric  string `json:"numeric"`
+	Name     string `json:"name"`
+	Symbol   string `json:"symbol"`
+	Exponent int    `json:"exponent"`
 }
 
-// String returns the sign for the given currency.
+// String returns the code and name of the currency, as in "NGN (Naira)".
 func (c Currency) String() string {
-	return c.Sign
+	if c.Code == "" {
+		return ""
+	}
+
+	return fmt.Sprintf("%s (%s)", c.Code, c.Name)
+}
+
+// Same returns true/false if both currencies are the same.
+func (c Currency) Same(o Currency) bool {
+	return c.Code == o.Code
 }
 
 //==============================================================================
@@ -23,37 +40,35 @@ func (c Currency) String() string {
 // Currencies declares a lists of currency slice.
 type Currencies []Currency
 
-// Find returns a currency by its giving name else returns an error if
+// legacyNames maps the currency names stored by pockets created before the
+// ISO 4217 table to their codes.
+var legacyNames = map[string]string{
+	"dollars": "USD",
+	"naira":   "NGN",
+}
+
+// Find returns a currency by its giving code or name else returns an error if
 // not found.
 func (c Currencies) Find(cm string) (Currency, error) {
-	var fc Currency
-	var found bool
+	name := strings.TrimSpace(cm)
+
+	if code, ok := legacyNames[strings.ToLower(name)]; ok {
+		name = code
+	}
 
 	for _, cu := range c {
-		if strings.ToLower(cu.Name) == strings.ToLower(cm) {
-			fc = cu
-			found = true
-			break
+		if strings.EqualFold(cu.Code, name) {
+			return cu, nil
 		}
 	}
 
-	if !found {
-		return fc, fmt.Errorf("Unknown Currency[%s]", cm)
+	for _, cu := range c {
+		if strings.EqualFold(cu.Name, name) {
+			return cu, nil
+		}
 	}
 
-	return fc, nil
-}
-
-// BudgetCurrency defines a lists of currency types and their respective signs.
-var BudgetCurrency = Currencies{
-	Currency{
-		Name: "Dollars",
-		Sign: "$",
-	},
-	Currency{
-		Name: "Naira",
-		Sign: "#",
-	},
+	return Currency{}, fmt.Errorf("Unknown Currency[%s]", cm)
 }
 
 //==============================================================================
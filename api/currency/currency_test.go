@@ -0,0 +1,125 @@
+package currency
+
+import (
+	"encoding/csv"
+	"os"
+	"strconv"
+	"testing"
+)
+
+//==============================================================================
+
+// TestISO4217 checks the generated catalogue lists every currency of
+// iso4217.csv as it is written there, each code and numeric code once.
+func TestISO4217(t *testing.T) {
+	source, err := os.Open("iso4217.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer source.Close()
+
+	rows, err := csv.NewReader(source).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ISO4217) != len(rows)-1 {
+		t.Fatalf("Expected %d currencies generated from iso4217.csv, got %d", len(rows)-1, len(ISO4217))
+	}
+
+	codes := make(map[string]bool)
+	numerics := make(map[string]bool)
+
+	for index, row := range rows[1:] {
+		digits, err := strconv.Atoi(row[2])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := Currency{Code: row[0], Numeric: row[1], Exponent: digits, Symbol: row[3], Name: row[4]}
+		if ISO4217[index] != expected {
+			t.Errorf("Expected currency %d to be %+v, got %+v", index, expected, ISO4217[index])
+		}
+
+		if codes[row[0]] || numerics[row[1]] {
+			t.Errorf("Expected %s (%s) to be listed once", row[0], row[1])
+		}
+
+		codes[row[0]], numerics[row[1]] = true, true
+	}
+}
+
+// TestISO4217Currencies checks the minor units and symbols of currencies of
+// each precision.
+func TestISO4217Currencies(t *testing.T) {
+	tests := []struct {
+		code     string
+		numeric  string
+		exponent int
+		symbol   string
+		name     string
+	}{
+		{"USD", "840", 2, "$", "US Dollar"},
+		{"EUR", "978", 2, "€", "Euro"},
+		{"GBP", "826", 2, "£", "Pound Sterling"},
+		{"NGN", "566", 2, "₦", "Naira"},
+		{"JPY", "392", 0, "¥", "Yen"},
+		{"BHD", "048", 3, "BHD", "Bahraini Dinar"},
+		{"KWD", "414", 3, "KWD", "Kuwaiti Dinar"},
+	}
+
+	for _, test := range tests {
+		cu, err := ISO4217.Find(test.code)
+		if err != nil {
+			t.Errorf("Expected %s to be found: %s", test.code, err)
+			continue
+		}
+
+		if cu.Numeric != test.numeric || cu.Exponent != test.exponent || cu.Symbol != test.symbol || cu.Name != test.name {
+			t.Errorf("Expected %s to be %s %s with %d digits as %s, got %+v", test.code, test.numeric, test.name, test.exponent, test.symbol, cu)
+		}
+	}
+}
+
+// TestCurrenciesFind checks currencies are found by their code or name in any
+// case, and by the names stored by pockets before the ISO 4217 table.
+func TestCurrenciesFind(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+	}{
+		{"USD", "USD"},
+		{"usd", "USD"},
+		{" ngn ", "NGN"},
+		{"Naira", "NGN"},
+		{"pound sterling", "GBP"},
+		{"dollars", "USD"},
+		{"naira", "NGN"},
+	}
+
+	for _, test := range tests {
+		cu, err := ISO4217.Find(test.name)
+		if err != nil || cu.Code != test.code {
+			t.Errorf("Expected %q to find %s, got %q: %v", test.name, test.code, cu.Code, err)
+		}
+	}
+
+	for _, name := range []string{"", "XYZ", "dollar bills"} {
+		if cu, err := ISO4217.Find(name); err == nil {
+			t.Errorf("Expected no currency for %q, got %s", name, cu.Code)
+		}
+	}
+}
+
+// TestCurrencyString checks currencies are written as their code and name.
+func TestCurrencyString(t *testing.T) {
+	ngn, err := ISO4217.Find("NGN")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ngn.String() != "NGN (Naira)" || (Currency{}).String() != "" {
+		t.Fatalf("Expected \"NGN (Naira)\" and an empty string, got %q and %q", ngn.String(), Currency{}.String())
+	}
+}
@@ -0,0 +1,62 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"os"
+	"strconv"
+)
+
+// main generates iso4217.gen.go from the ISO 4217 table in iso4217.csv, which
+// lists the code, numeric code, minor unit digits, symbol and name of each
+// active currency.
+func main() {
+	source, err := os.Open("iso4217.csv")
+	if err != nil {
+		panic(err)
+	}
+	defer source.Close()
+
+	rows, err := csv.NewReader(source).ReadAll()
+	if err != nil {
+		panic(err)
+	}
+
+	var buf bytes.Buffer
+
+	fmt.Fprint(&buf, `// Code generated by generate.go from iso4217.csv. DO NOT EDIT.
+
+//go:generate go run generate.go
+
+package currency
+
+// ISO4217 defines the active currencies of the ISO 4217 standard.
+var ISO4217 = Currencies{
+`)
+
+	for _, row := range rows[1:] {
+		digits, err := strconv.Atoi(row[2])
+		if err != nil {
+			panic(fmt.Errorf("Invalid minor unit digits for %s: %s", row[0], err))
+		}
+
+		fmt.Fprintf(&buf, "\tCurrency{Code: %q, Numeric: %q, Exponent: %d, Symbol: %q, Name: %q},\n", row[0], row[1], digits, row[3], row[4])
+	}
+
+	fmt.Fprint(&buf, "}\n")
+
+	content, err := format.Source(buf.Bytes())
+	if err != nil {
+		panic(err)
+	}
+
+	if err := ioutil.WriteFile("iso4217.gen.go", content, 0644); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,165 @@
+// Code generated by generate.go from iso4217.csv. DO NOT EDIT.
+
+//go:generate go run generate.go
+
+package currency
+
+// ISO4217 defines the active currencies of the ISO 4217 standard.
+var ISO4217 = Currencies{
+	Currency{Code: "AED", Numeric: "784", Exponent: 2, Symbol: "AED", Name: "UAE Dirham"},
+	Currency{Code: "AFN", Numeric: "971", Exponent: 2, Symbol: "؋", Name: "Afghani"},
+	Currency{Code: "ALL", Numeric: "008", Exponent: 2, Symbol: "L", Name: "Lek"},
+	Currency{Code: "AMD", Numeric: "051", Exponent: 2, Symbol: "֏", Name: "Armenian Dram"},
+	Currency{Code: "AOA", Numeric: "973", Exponent: 2, Symbol: "Kz", Name: "Kwanza"},
+	Currency{Code: "ARS", Numeric: "032", Exponent: 2, Symbol: "$", Name: "Argentine Peso"},
+	Currency{Code: "AUD", Numeric: "036", Exponent: 2, Symbol: "A$", Name: "Australian Dollar"},
+	Currency{Code: "AWG", Numeric: "533", Exponent: 2, Symbol: "ƒ", Name: "Aruban Florin"},
+	Currency{Code: "AZN", Numeric: "944", Exponent: 2, Symbol: "₼", Name: "Azerbaijan Manat"},
+	Currency{Code: "BAM", Numeric: "977", Exponent: 2, Symbol: "KM", Name: "Convertible Mark"},
+	Currency{Code: "BBD", Numeric: "052", Exponent: 2, Symbol: "Bds$", Name: "Barbados Dollar"},
+	Currency{Code: "BDT", Numeric: "050", Exponent: 2, Symbol: "৳", Name: "Taka"},
+	Currency{Code: "BGN", Numeric: "975", Exponent: 2, Symbol: "лв", Name: "Bulgarian Lev"},
+	Currency{Code: "BHD", Numeric: "048", Exponent: 3, Symbol: "BHD", Name: "Bahraini Dinar"},
+	Currency{Code: "BIF", Numeric: "108", Exponent: 0, Symbol: "FBu", Name: "Burundi Franc"},
+	Currency{Code: "BMD", Numeric: "060", Exponent: 2, Symbol: "BD$", Name: "Bermudian Dollar"},
+	Currency{Code: "BND", Numeric: "096", Exponent: 2, Symbol: "B$", Name: "Brunei Dollar"},
+	Currency{Code: "BOB", Numeric: "068", Exponent: 2, Symbol: "Bs", Name: "Boliviano"},
+	Currency{Code: "BRL", Numeric: "986", Exponent: 2, Symbol: "R$", Name: "Brazilian Real"},
+	Currency{Code: "BSD", Numeric: "044", Exponent: 2, Symbol: "B$", Name: "Bahamian Dollar"},
+	Currency{Code: "BTN", Numeric: "064", Exponent: 2, Symbol: "Nu.", Name: "Ngultrum"},
+	Currency{Code: "BWP", Numeric: "072", Exponent: 2, Symbol: "P", Name: "Pula"},
+	Currency{Code: "BYN", Numeric: "933", Exponent: 2, Symbol: "Br", Name: "Belarusian Ruble"},
+	Currency{Code: "BZD", Numeric: "084", Exponent: 2, Symbol: "BZ$", Name: "Belize Dollar"},
+	Currency{Code: "CAD", Numeric: "124", Exponent: 2, Symbol: "CA$", Name: "Canadian Dollar"},
+	Currency{Code: "CDF", Numeric: "976", Exponent: 2, Symbol: "FC", Name: "Congolese Franc"},
+	Currency{Code: "CHF", Numeric: "756", Exponent: 2, Symbol: "CHF", Name: "Swiss Franc"},
+	Currency{Code: "CLP", Numeric: "152", Exponent: 0, Symbol: "CLP$", Name: "Chilean Peso"},
+	Currency{Code: "CNY", Numeric: "156", Exponent: 2, Symbol: "CN¥", Name: "Yuan Renminbi"},
+	Currency{Code: "COP", Numeric: "170", Exponent: 2, Symbol: "COL$", Name: "Colombian Peso"},
+	Currency{Code: "CRC", Numeric: "188", Exponent: 2, Symbol: "₡", Name: "Costa Rican Colon"},
+	Currency{Code: "CUP", Numeric: "192", Exponent: 2, Symbol: "CUP", Name: "Cuban Peso"},
+	Currency{Code: "CVE", Numeric: "132", Exponent: 2, Symbol: "Esc", Name: "Cabo Verde Escudo"},
+	Currency{Code: "CZK", Numeric: "203", Exponent: 2, Symbol: "Kč", Name: "Czech Koruna"},
+	Currency{Code: "DJF", Numeric: "262", Exponent: 0, Symbol: "Fdj", Name: "Djibouti Franc"},
+	Currency{Code: "DKK", Numeric: "208", Exponent: 2, Symbol: "kr.", Name: "Danish Krone"},
+	Currency{Code: "DOP", Numeric: "214", Exponent: 2, Symbol: "RD$", Name: "Dominican Peso"},
+	Currency{Code: "DZD", Numeric: "012", Exponent: 2, Symbol: "DA", Name: "Algerian Dinar"},
+	Currency{Code: "EGP", Numeric: "818", Exponent: 2, Symbol: "E£", Name: "Egyptian Pound"},
+	Currency{Code: "ERN", Numeric: "232", Exponent: 2, Symbol: "Nfk", Name: "Nakfa"},
+	Currency{Code: "ETB", Numeric: "230", Exponent: 2, Symbol: "Br", Name: "Ethiopian Birr"},
+	Currency{Code: "EUR", Numeric: "978", Exponent: 2, Symbol: "€", Name: "Euro"},
+	Currency{Code: "FJD", Numeric: "242", Exponent: 2, Symbol: "FJ$", Name: "Fiji Dollar"},
+	Currency{Code: "FKP", Numeric: "238", Exponent: 2, Symbol: "FK£", Name: "Falkland Islands Pound"},
+	Currency{Code: "GBP", Numeric: "826", Exponent: 2, Symbol: "£", Name: "Pound Sterling"},
+	Currency{Code: "GEL", Numeric: "981", Exponent: 2, Symbol: "₾", Name: "Lari"},
+	Currency{Code: "GHS", Numeric: "936", Exponent: 2, Symbol: "GH₵", Name: "Ghana Cedi"},
+	Currency{Code: "GIP", Numeric: "292", Exponent: 2, Symbol: "£", Name: "Gibraltar Pound"},
+	Currency{Code: "GMD", Numeric: "270", Exponent: 2, Symbol: "D", Name: "Dalasi"},
+	Currency{Code: "GNF", Numeric: "324", Exponent: 0, Symbol: "FG", Name: "Guinean Franc"},
+	Currency{Code: "GTQ", Numeric: "320", Exponent: 2, Symbol: "Q", Name: "Quetzal"},
+	Currency{Code: "GYD", Numeric: "328", Exponent: 2, Symbol: "G$", Name: "Guyana Dollar"},
+	Currency{Code: "HKD", Numeric: "344", Exponent: 2, Symbol: "HK$", Name: "Hong Kong Dollar"},
+	Currency{Code: "HNL", Numeric: "340", Exponent: 2, Symbol: "L", Name: "Lempira"},
+	Currency{Code: "HTG", Numeric: "332", Exponent: 2, Symbol: "G", Name: "Gourde"},
+	Currency{Code: "HUF", Numeric: "348", Exponent: 2, Symbol: "Ft", Name: "Forint"},
+	Currency{Code: "IDR", Numeric: "360", Exponent: 2, Symbol: "Rp", Name: "Rupiah"},
+	Currency{Code: "ILS", Numeric: "376", Exponent: 2, Symbol: "₪", Name: "New Israeli Sheqel"},
+	Currency{Code: "INR", Numeric: "356", Exponent: 2, Symbol: "₹", Name: "Indian Rupee"},
+	Currency{Code: "IQD", Numeric: "368", Exponent: 3, Symbol: "IQD", Name: "Iraqi Dinar"},
+	Currency{Code: "IRR", Numeric: "364", Exponent: 2, Symbol: "IRR", Name: "Iranian Rial"},
+	Currency{Code: "ISK", Numeric: "352", Exponent: 0, Symbol: "kr", Name: "Iceland Krona"},
+	Currency{Code: "JMD", Numeric: "388", Exponent: 2, Symbol: "J$", Name: "Jamaican Dollar"},
+	Currency{Code: "JOD", Numeric: "400", Exponent: 3, Symbol: "JOD", Name: "Jordanian Dinar"},
+	Currency{Code: "JPY", Numeric: "392", Exponent: 0, Symbol: "¥", Name: "Yen"},
+	Currency{Code: "KES", Numeric: "404", Exponent: 2, Symbol: "KSh", Name: "Kenyan Shilling"},
+	Currency{Code: "KGS", Numeric: "417", Exponent: 2, Symbol: "сом", Name: "Som"},
+	Currency{Code: "KHR", Numeric: "116", Exponent: 2, Symbol: "៛", Name: "Riel"},
+	Currency{Code: "KMF", Numeric: "174", Exponent: 0, Symbol: "CF", Name: "Comorian Franc"},
+	Currency{Code: "KPW", Numeric: "408", Exponent: 2, Symbol: "₩", Name: "North Korean Won"},
+	Currency{Code: "KRW", Numeric: "410", Exponent: 0, Symbol: "₩", Name: "Won"},
+	Currency{Code: "KWD", Numeric: "414", Exponent: 3, Symbol: "KWD", Name: "Kuwaiti Dinar"},
+	Currency{Code: "KYD", Numeric: "136", Exponent: 2, Symbol: "CI$", Name: "Cayman Islands Dollar"},
+	Currency{Code: "KZT", Numeric: "398", Exponent: 2, Symbol: "₸", Name: "Tenge"},
+	Currency{Code: "LAK", Numeric: "418", Exponent: 2, Symbol: "₭", Name: "Lao Kip"},
+	Currency{Code: "LBP", Numeric: "422", Exponent: 2, Symbol: "LBP", Name: "Lebanese Pound"},
+	Currency{Code: "LKR", Numeric: "144", Exponent: 2, Symbol: "Rs", Name: "Sri Lanka Rupee"},
+	Currency{Code: "LRD", Numeric: "430", Exponent: 2, Symbol: "L$", Name: "Liberian Dollar"},
+	Currency{Code: "LSL", Numeric: "426", Exponent: 2, Symbol: "L", Name: "Loti"},
+	Currency{Code: "LYD", Numeric: "434", Exponent: 3, Symbol: "LD", Name: "Libyan Dinar"},
+	Currency{Code: "MAD", Numeric: "504", Exponent: 2, Symbol: "MAD", Name: "Moroccan Dirham"},
+	Currency{Code: "MDL", Numeric: "498", Exponent: 2, Symbol: "L", Name: "Moldovan Leu"},
+	Currency{Code: "MGA", Numeric: "969", Exponent: 2, Symbol: "Ar", Name: "Malagasy Ariary"},
+	Currency{Code: "MKD", Numeric: "807", Exponent: 2, Symbol: "ден", Name: "Denar"},
+	Currency{Code: "MMK", Numeric: "104", Exponent: 2, Symbol: "K", Name: "Kyat"},
+	Currency{Code: "MNT", Numeric: "496", Exponent: 2, Symbol: "₮", Name: "Tugrik"},
+	Currency{Code: "MOP", Numeric: "446", Exponent: 2, Symbol: "MOP$", Name: "Pataca"},
+	Currency{Code: "MRU", Numeric: "929", Exponent: 2, Symbol: "UM", Name: "Ouguiya"},
+	Currency{Code: "MUR", Numeric: "480", Exponent: 2, Symbol: "Rs", Name: "Mauritius Rupee"},
+	Currency{Code: "MVR", Numeric: "462", Exponent: 2, Symbol: "Rf", Name: "Rufiyaa"},
+	Currency{Code: "MWK", Numeric: "454", Exponent: 2, Symbol: "MK", Name: "Malawi Kwacha"},
+	Currency{Code: "MXN", Numeric: "484", Exponent: 2, Symbol: "MX$", Name: "Mexican Peso"},
+	Currency{Code: "MYR", Numeric: "458", Exponent: 2, Symbol: "RM", Name: "Malaysian Ringgit"},
+	Currency{Code: "MZN", Numeric: "943", Exponent: 2, Symbol: "MT", Name: "Mozambique Metical"},
+	Currency{Code: "NAD", Numeric: "516", Exponent: 2, Symbol: "N$", Name: "Namibia Dollar"},
+	Currency{Code: "NGN", Numeric: "566", Exponent: 2, Symbol: "₦", Name: "Naira"},
+	Currency{Code: "NIO", Numeric: "558", Exponent: 2, Symbol: "C$", Name: "Cordoba Oro"},
+	Currency{Code: "NOK", Numeric: "578", Exponent: 2, Symbol: "kr", Name: "Norwegian Krone"},
+	Currency{Code: "NPR", Numeric: "524", Exponent: 2, Symbol: "Rs", Name: "Nepalese Rupee"},
+	Currency{Code: "NZD", Numeric: "554", Exponent: 2, Symbol: "NZ$", Name: "New Zealand Dollar"},
+	Currency{Code: "OMR", Numeric: "512", Exponent: 3, Symbol: "OMR", Name: "Rial Omani"},
+	Currency{Code: "PAB", Numeric: "590", Exponent: 2, Symbol: "B/.", Name: "Balboa"},
+	Currency{Code: "PEN", Numeric: "604", Exponent: 2, Symbol: "S/", Name: "Sol"},
+	Currency{Code: "PGK", Numeric: "598", Exponent: 2, Symbol: "K", Name: "Kina"},
+	Currency{Code: "PHP", Numeric: "608", Exponent: 2, Symbol: "₱", Name: "Philippine Peso"},
+	Currency{Code: "PKR", Numeric: "586", Exponent: 2, Symbol: "Rs", Name: "Pakistan Rupee"},
+	Currency{Code: "PLN", Numeric: "985", Exponent: 2, Symbol: "zł", Name: "Zloty"},
+	Currency{Code: "PYG", Numeric: "600", Exponent: 0, Symbol: "₲", Name: "Guarani"},
+	Currency{Code: "QAR", Numeric: "634", Exponent: 2, Symbol: "QAR", Name: "Qatari Rial"},
+	Currency{Code: "RON", Numeric: "946", Exponent: 2, Symbol: "lei", Name: "Romanian Leu"},
+	Currency{Code: "RSD", Numeric: "941", Exponent: 2, Symbol: "RSD", Name: "Serbian Dinar"},
+	Currency{Code: "RUB", Numeric: "643", Exponent: 2, Symbol: "₽", Name: "Russian Ruble"},
+	Currency{Code: "RWF", Numeric: "646", Exponent: 0, Symbol: "FRw", Name: "Rwanda Franc"},
+	Currency{Code: "SAR", Numeric: "682", Exponent: 2, Symbol: "SAR", Name: "Saudi Riyal"},
+	Currency{Code: "SBD", Numeric: "090", Exponent: 2, Symbol: "SI$", Name: "Solomon Islands Dollar"},
+	Currency{Code: "SCR", Numeric: "690", Exponent: 2, Symbol: "SR", Name: "Seychelles Rupee"},
+	Currency{Code: "SDG", Numeric: "938", Exponent: 2, Symbol: "SDG", Name: "Sudanese Pound"},
+	Currency{Code: "SEK", Numeric: "752", Exponent: 2, Symbol: "kr", Name: "Swedish Krona"},
+	Currency{Code: "SGD", Numeric: "702", Exponent: 2, Symbol: "S$", Name: "Singapore Dollar"},
+	Currency{Code: "SHP", Numeric: "654", Exponent: 2, Symbol: "£", Name: "Saint Helena Pound"},
+	Currency{Code: "SLE", Numeric: "925", Exponent: 2, Symbol: "Le", Name: "Leone"},
+	Currency{Code: "SOS", Numeric: "706", Exponent: 2, Symbol: "Sh.So.", Name: "Somali Shilling"},
+	Currency{Code: "SRD", Numeric: "968", Exponent: 2, Symbol: "Sr$", Name: "Surinam Dollar"},
+	Currency{Code: "SSP", Numeric: "728", Exponent: 2, Symbol: "SSP", Name: "South Sudanese Pound"},
+	Currency{Code: "STN", Numeric: "930", Exponent: 2, Symbol: "Db", Name: "Dobra"},
+	Currency{Code: "SVC", Numeric: "222", Exponent: 2, Symbol: "₡", Name: "El Salvador Colon"},
+	Currency{Code: "SYP", Numeric: "760", Exponent: 2, Symbol: "SYP", Name: "Syrian Pound"},
+	Currency{Code: "SZL", Numeric: "748", Exponent: 2, Symbol: "E", Name: "Lilangeni"},
+	Currency{Code: "THB", Numeric: "764", Exponent: 2, Symbol: "฿", Name: "Baht"},
+	Currency{Code: "TJS", Numeric: "972", Exponent: 2, Symbol: "SM", Name: "Somoni"},
+	Currency{Code: "TMT", Numeric: "934", Exponent: 2, Symbol: "m", Name: "Turkmenistan New Manat"},
+	Currency{Code: "TND", Numeric: "788", Exponent: 3, Symbol: "DT", Name: "Tunisian Dinar"},
+	Currency{Code: "TOP", Numeric: "776", Exponent: 2, Symbol: "T$", Name: "Pa'anga"},
+	Currency{Code: "TRY", Numeric: "949", Exponent: 2, Symbol: "₺", Name: "Turkish Lira"},
+	Currency{Code: "TTD", Numeric: "780", Exponent: 2, Symbol: "TT$", Name: "Trinidad and Tobago Dollar"},
+	Currency{Code: "TWD", Numeric: "901", Exponent: 2, Symbol: "NT$", Name: "New Taiwan Dollar"},
+	Currency{Code: "TZS", Numeric: "834", Exponent: 2, Symbol: "TSh", Name: "Tanzanian Shilling"},
+	Currency{Code: "UAH", Numeric: "980", Exponent: 2, Symbol: "₴", Name: "Hryvnia"},
+	Currency{Code: "UGX", Numeric: "800", Exponent: 0, Symbol: "USh", Name: "Uganda Shilling"},
+	Currency{Code: "USD", Numeric: "840", Exponent: 2, Symbol: "$", Name: "US Dollar"},
+	Currency{Code: "UYU", Numeric: "858", Exponent: 2, Symbol: "$U", Name: "Peso Uruguayo"},
+	Currency{Code: "UZS", Numeric: "860", Exponent: 2, Symbol: "soʻm", Name: "Uzbekistan Sum"},
+	Currency{Code: "VED", Numeric: "926", Exponent: 2, Symbol: "Bs.D", Name: "Bolívar Soberano"},
+	Currency{Code: "VES", Numeric: "928", Exponent: 2, Symbol: "Bs.S", Name: "Bolívar Soberano"},
+	Currency{Code: "VND", Numeric: "704", Exponent: 0, Symbol: "₫", Name: "Dong"},
+	Currency{Code: "VUV", Numeric: "548", Exponent: 0, Symbol: "VT", Name: "Vatu"},
+	Currency{Code: "WST", Numeric: "882", Exponent: 2, Symbol: "WS$", Name: "Tala"},
+	Currency{Code: "XAF", Numeric: "950", Exponent: 0, Symbol: "FCFA", Name: "CFA Franc BEAC"},
+	Currency{Code: "XCD", Numeric: "951", Exponent: 2, Symbol: "EC$", Name: "East Caribbean Dollar"},
+	Currency{Code: "XCG", Numeric: "532", Exponent: 2, Symbol: "Cg", Name: "Caribbean Guilder"},
+	Currency{Code: "XOF", Numeric: "952", Exponent: 0, Symbol: "CFA", Name: "CFA Franc BCEAO"},
+	Currency{Code: "XPF", Numeric: "953", Exponent: 0, Symbol: "CFPF", Name: "CFP Franc"},
+	Currency{Code: "YER", Numeric: "886", Exponent: 2, Symbol: "YER", Name: "Yemeni Rial"},
+	Currency{Code: "ZAR", Numeric: "710", Exponent: 2, Symbol: "R", Name: "Rand"},
+	Currency{Code: "ZMW", Numeric: "967", Exponent: 2, Symbol: "ZK", Name: "Zambian Kwacha"},
+	Currency{Code: "ZWG", Numeric: "924", Exponent: 2, Symbol: "ZiG", Name: "Zimbabwe Gold"},
+}
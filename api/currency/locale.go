@@ -0,0 +1,135 @@
+package currency
+
+import (
+	"fmt"
+	"strings"
+)
+
+//==============================================================================
+
+// Locale defines how amounts are written for a region: the separators of the
+// decimal point and digit groups, the sizes of the groups counted from the
+// decimal point, where the last size repeats, and the placement of the
+// currency symbol.
+type Locale struct {
+	Tag         string
+	Decimal     string
+	Group       string
+	Grouping    []int
+	SymbolFirst bool
+	SymbolSpace bool
+}
+
+// nbsp defines the no-break space used as a separator by several locales.
+const nbsp = "\u00a0"
+
+// Locales defines the locales amounts can be formatted for.
+var Locales = []Locale{
+	{Tag: "en-US", Decimal: ".", Group: ",", Grouping: []int{3}, SymbolFirst: true},
+	{Tag: "en-GB", Decimal: ".", Group: ",", Grouping: []int{3}, SymbolFirst: true},
+	{Tag: "en-NG", Decimal: ".", Group: ",", Grouping: []int{3}, SymbolFirst: true},
+	{Tag: "en-IN", Decimal: ".", Group: ",", Grouping: []int{3, 2}, SymbolFirst: true},
+	{Tag: "de-DE", Decimal: ",", Group: ".", Grouping: []int{3}, SymbolSpace: true},
+	{Tag: "de-CH", Decimal: ".", Group: "\u2019", Grouping: []int{3}, SymbolFirst: true, SymbolSpace: true},
+	{Tag: "es-ES", Decimal: ",", Group: ".", Grouping: []int{3}, SymbolSpace: true},
+	{Tag: "fr-FR", Decimal: ",", Group: "\u202f", Grouping: []int{3}, SymbolSpace: true},
+	{Tag: "it-IT", Decimal: ",", Group: ".", Grouping: []int{3}, SymbolSpace: true},
+	{Tag: "nl-NL", Decimal: ",", Group: ".", Grouping: []int{3}, SymbolFirst: true, SymbolSpace: true},
+	{Tag: "pt-BR", Decimal: ",", Group: ".", Grouping: []int{3}, SymbolFirst: true, SymbolSpace: true},
+	{Tag: "ru-RU", Decimal: ",", Group: nbsp, Grouping: []int{3}, SymbolSpace: true},
+	{Tag: "sv-SE", Decimal: ",", Group: nbsp, Grouping: []int{3}, SymbolSpace: true},
+	{Tag: "ja-JP", Decimal: ".", Group: ",", Grouping: []int{3}, SymbolFirst: true},
+	{Tag: "zh-CN", Decimal: ".", Group: ",", Grouping: []int{3}, SymbolFirst: true},
+}
+
+// DefaultLocale defines the locale used when none is provided.
+var DefaultLocale = Locales[0]
+
+// FindLocale returns the locale with the giving tag, as in "en-US", else
+// returns an error if not found.
+func FindLocale(tag string) (Locale, error) {
+	tag = strings.Replace(strings.TrimSpace(tag), "_", "-", -1)
+
+	for _, locale := range Locales {
+		if strings.EqualFold(locale.Tag, tag) {
+			return locale, nil
+		}
+	}
+
+	return Locale{}, fmt.Errorf("Unknown Locale[%s]", tag)
+}
+
+// group returns the whole digits of an amount separated into the groups of
+// the locale.
+func (l Locale) group(digits string) string {
+	if len(l.Grouping) == 0 || l.Group == "" {
+		return digits
+	}
+
+	var groups []string
+
+	for index := 0; len(digits) > 0; index++ {
+		size := l.Grouping[len(l.Grouping)-1]
+		if index < len(l.Grouping) {
+			size = l.Grouping[index]
+		}
+
+		if size <= 0 || size >= len(digits) {
+			groups = append(groups, digits)
+			break
+		}
+
+		groups = append(groups, digits[len(digits)-size:])
+		digits = digits[:len(digits)-size]
+	}
+
+	// The groups were collected from the decimal point outward.
+	for i, j := 0, len(groups)-1; i < j; i, j = i+1, j-1 {
+		groups[i], groups[j] = groups[j], groups[i]
+	}
+
+	return strings.Join(groups, l.Group)
+}
+
+//==============================================================================
+
+// Format returns the amount written for the giving locale, with the symbol of
+// its currency placed and its digits separated as the locale requires, as in
+// "-$1,234.50" for en-US or "-1.234,50 €" for de-DE.
+func (m Money) Format(l Locale) string {
+	decimal := m.Decimal()
+
+	var sign string
+	if strings.HasPrefix(decimal, "-") {
+		sign = "-"
+		decimal = decimal[1:]
+	}
+
+	whole, frac := decimal, ""
+	if index := strings.Index(decimal, "."); index != -1 {
+		whole, frac = decimal[:index], decimal[index+1:]
+	}
+
+	number := l.group(whole)
+	if frac != "" {
+		number += l.Decimal + frac
+	}
+
+	symbol := m.Currency.Symbol
+	if symbol == "" {
+		return sign + number
+	}
+
+	space := ""
+	if l.SymbolSpace {
+		space = nbsp
+	}
+
+	if l.SymbolFirst {
+		return sign + symbol + space + number
+	}
+
+	return sign + number + space + symbol
+}
+
+//==============================================================================
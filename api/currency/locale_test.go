@@ -0,0 +1,104 @@
+package currency
+
+import "testing"
+
+//==============================================================================
+
+// TestMoneyFormat checks amounts are written with the separators, grouping and
+// symbol placement of each locale.
+func TestMoneyFormat(t *testing.T) {
+	tests := []struct {
+		tag    string
+		amount string
+		code   string
+		text   string
+	}{
+		{"en-US", "1234567.50", "USD", "$1,234,567.50"},
+		{"en-US", "-1234.50", "USD", "-$1,234.50"},
+		{"en-US", "0", "USD", "$0.00"},
+		{"en-US", "0.05", "USD", "$0.05"},
+		{"en-US", "999", "USD", "$999.00"},
+		{"en-US", "1000", "JPY", "¥1,000"},
+		{"en-US", "1234.567", "BHD", "BHD1,234.567"},
+		{"en-GB", "1234.5", "GBP", "£1,234.50"},
+		{"en-NG", "2500000", "NGN", "₦2,500,000.00"},
+		{"en-IN", "12345678.90", "INR", "₹1,23,45,678.90"},
+		{"en-IN", "-123456", "INR", "-₹1,23,456.00"},
+		{"de-DE", "1234567.50", "EUR", "1.234.567,50\u00a0€"},
+		{"de-DE", "-1234.50", "EUR", "-1.234,50\u00a0€"},
+		{"de-CH", "1234.50", "CHF", "CHF\u00a01\u2019234.50"},
+		{"fr-FR", "1234.50", "EUR", "1\u202f234,50\u00a0€"},
+		{"nl-NL", "1234.50", "EUR", "€\u00a01.234,50"},
+		{"ru-RU", "1234567", "EUR", "1\u00a0234\u00a0567,00\u00a0€"},
+		{"ja-JP", "1234567", "JPY", "¥1,234,567"},
+	}
+
+	for _, test := range tests {
+		locale, err := FindLocale(test.tag)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cu, err := ISO4217.Find(test.code)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		money, err := ParseMoney(test.amount, cu)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if text := money.Format(locale); text != test.text {
+			t.Errorf("Expected %s %s to be written %q for %s, got %q", test.amount, test.code, test.text, test.tag, text)
+		}
+	}
+}
+
+// TestLocaleGroup checks digits are grouped from the decimal point outward,
+// the last group size repeating.
+func TestLocaleGroup(t *testing.T) {
+	tests := []struct {
+		grouping []int
+		digits   string
+		text     string
+	}{
+		{[]int{3}, "1", "1"},
+		{[]int{3}, "123", "123"},
+		{[]int{3}, "1234", "1,234"},
+		{[]int{3}, "123456789", "123,456,789"},
+		{[]int{3, 2}, "1234", "1,234"},
+		{[]int{3, 2}, "123456", "1,23,456"},
+		{[]int{3, 2}, "1234567890", "1,23,45,67,890"},
+		{[]int{4}, "123456789", "1,2345,6789"},
+		{nil, "123456789", "123456789"},
+		{[]int{0}, "123456789", "123456789"},
+	}
+
+	for _, test := range tests {
+		locale := Locale{Group: ",", Grouping: test.grouping}
+		if text := locale.group(test.digits); text != test.text {
+			t.Errorf("Expected %s grouped by %v to be %q, got %q", test.digits, test.grouping, test.text, text)
+		}
+	}
+}
+
+// TestFindLocale checks locales are found by their tag in any case and with
+// underscores, and that unknown tags are refused.
+func TestFindLocale(t *testing.T) {
+	for _, tag := range []string{"en-US", "en_us", " DE-de ", "pt_BR"} {
+		if _, err := FindLocale(tag); err != nil {
+			t.Errorf("Expected locale %q to be found: %s", tag, err)
+		}
+	}
+
+	for _, tag := range []string{"", "en", "xx-XX"} {
+		if locale, err := FindLocale(tag); err == nil {
+			t.Errorf("Expected no locale for %q, got %s", tag, locale.Tag)
+		}
+	}
+
+	if DefaultLocale.Tag != "en-US" {
+		t.Fatalf("Expected en-US as the default locale, got %s", DefaultLocale.Tag)
+	}
+}
@@ -0,0 +1,360 @@
+package currency
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+//==============================================================================
+
+// ErrCurrencyMismatch is returned when amounts of different currencies are
+// combined or compared.
+var ErrCurrencyMismatch = errors.New("Currency Mismatch")
+
+// ErrOverflow is returned when an amount exceeds the range of Money.
+var ErrOverflow = errors.New("Amount Overflow")
+
+// ErrPrecision is returned when an amount holds more decimal places than its
+// currency has minor units.
+var ErrPrecision = errors.New("Amount exceeds currency precision")
+
+// ErrInvalidAmount is returned when a decimal amount can not be parsed.
+var ErrInvalidAmount = errors.New("Invalid Amount")
+
+// ErrNoCurrency is returned when an amount is parsed without a currency, being
+// neither given one nor naming one.
+var ErrNoCurrency = errors.New("Amount names no currency")
+
+// ErrInvalidRate is returned when an amount is exchanged at a rate which is not
+// above zero.
+var ErrInvalidRate = errors.New("Invalid Exchange Rate")
+
+//==============================================================================
+
+// Money defines an exact amount of a currency, held as an integer count of the
+// currency's minor units, such as cents for dollars. Money is encoded to JSON
+// as a decimal string followed by the code of its currency, as in "12.50 USD",
+// so amounts of every precision are decoded as they were encoded.
+type Money struct {
+	Amount   int64    `bson:"amount"`
+	Currency Currency `bson:"currency"`
+}
+
+// NewMoney returns a Money of the giving count of minor units of the currency.
+func NewMoney(minor int64, cu Currency) Money {
+	return Money{Amount: minor, Currency: cu}
+}
+
+// ParseMoney returns the Money for the decimal amount, as in "-12.50", in the
+// giving currency. The amount may be followed by the code of its currency, as
+// in "-12.50 USD", which must match the giving currency if it has a code. It
+// returns ErrNoCurrency if neither names a currency, and ErrPrecision if the
+// amount holds more decimal places than the currency has minor units.
+func ParseMoney(amount string, cu Currency) (Money, error) {
+	amount = strings.TrimSpace(amount)
+
+	if index := strings.LastIndex(amount, " "); index != -1 {
+		found, err := ISO4217.Find(amount[index+1:])
+		if err != nil {
+			return Money{Currency: cu}, err
+		}
+
+		if cu.Code != "" && cu.Code != found.Code {
+			return Money{Currency: cu}, ErrCurrencyMismatch
+		}
+
+		cu, amount = found, strings.TrimSpace(amount[:index])
+	}
+
+	money := Money{Currency: cu}
+
+	if cu.Code == "" {
+		return money, ErrNoCurrency
+	}
+
+	var negative bool
+
+	switch {
+	case strings.HasPrefix(amount, "-"):
+		negative = true
+		amount = amount[1:]
+	case strings.HasPrefix(amount, "+"):
+		amount = amount[1:]
+	}
+
+	whole, frac := amount, ""
+	if index := strings.Index(amount, "."); index != -1 {
+		whole, frac = amount[:index], amount[index+1:]
+	}
+
+	if (whole == "" && frac == "") || !isDigits(whole) || !isDigits(frac) {
+		return money, fmt.Errorf("%s: %q", ErrInvalidAmount, amount)
+	}
+
+	// Trailing zeros carry no value, so drop them before checking precision.
+	frac = strings.TrimRight(frac, "0")
+
+	exp := cu.Exponent
+	if len(frac) > exp {
+		return money, ErrPrecision
+	}
+
+	digits := whole + frac + strings.Repeat("0", exp-len(frac))
+	digits = strings.TrimLeft(digits, "0")
+	if digits == "" {
+		return money, nil
+	}
+
+	minor, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return money, ErrOverflow
+	}
+
+	if negative {
+		minor = -minor
+	}
+
+	money.Amount = minor
+	return money, nil
+}
+
+// isDigits returns true/false if the string holds only ascii digits.
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// IsZero returns true/false if the amount is zero.
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// Neg returns the negated amount.
+func (m Money) Neg() Money {
+	m.Amount = -m.Amount
+	return m
+}
+
+// Add returns the sum of both amounts, which must share the same currency.
+func (m Money) Add(o Money) (Money, error) {
+	if !m.Currency.Same(o.Currency) {
+		return m, ErrCurrencyMismatch
+	}
+
+	sum := m.Amount + o.Amount
+	if (o.Amount > 0 && sum < m.Amount) || (o.Amount < 0 && sum > m.Amount) {
+		return m, ErrOverflow
+	}
+
+	m.Amount = sum
+	return m, nil
+}
+
+// Sub returns the difference of both amounts, which must share the same
+// currency.
+func (m Money) Sub(o Money) (Money, error) {
+	if o.Amount == math.MinInt64 {
+		return m, ErrOverflow
+	}
+
+	return m.Add(o.Neg())
+}
+
+// Cmp compares both amounts, which must share the same currency, returning -1
+// if the amount is less than the other, 0 if equal and 1 if greater.
+func (m Money) Cmp(o Money) (int, error) {
+	if !m.Currency.Same(o.Currency) {
+		return 0, ErrCurrencyMismatch
+	}
+
+	switch {
+	case m.Amount < o.Amount:
+		return -1, nil
+	case m.Amount > o.Amount:
+		return 1, nil
+	default:
+		return 0, nil
+	}
+}
+
+// Scale returns the amount multiplied by num and divided by den, truncated
+// towards zero, without the product overflowing in between. It returns
+// ErrOverflow if the result can not be held, and ErrInvalidAmount if den is
+// zero.
+func (m Money) Scale(num int64, den int64) (Money, error) {
+	if den == 0 {
+		return m, ErrInvalidAmount
+	}
+
+	if m.Amount == math.MinInt64 || num == math.MinInt64 || den == math.MinInt64 {
+		return m, ErrOverflow
+	}
+
+	negative := (m.Amount < 0) != (num < 0) != (den < 0)
+
+	hi, lo := bits.Mul64(uint64(abs64(m.Amount)), uint64(abs64(num)))
+	if hi >= uint64(abs64(den)) {
+		return m, ErrOverflow
+	}
+
+	quo, _ := bits.Div64(hi, lo, uint64(abs64(den)))
+	if quo > math.MaxInt64 {
+		return m, ErrOverflow
+	}
+
+	m.Amount = int64(quo)
+	if negative {
+		m.Amount = -m.Amount
+	}
+
+	return m, nil
+}
+
+// In returns the amount in the giving currency, returning ErrCurrencyMismatch
+// if the amount is held in another currency.
+func (m Money) In(cu Currency) (Money, error) {
+	if !m.Currency.Same(cu) {
+		return m, ErrCurrencyMismatch
+	}
+
+	return Money{Amount: m.Amount, Currency: cu}, nil
+}
+
+// Decimal returns the amount as a decimal string with all the minor units of
+// its currency, as in "-12.50".
+func (m Money) Decimal() string {
+	exp := m.Currency.Exponent
+
+	var sign string
+	abs := uint64(m.Amount)
+
+	if m.Amount < 0 {
+		sign = "-"
+		abs = uint64(-(m.Amount + 1)) + 1
+	}
+
+	digits := strconv.FormatUint(abs, 10)
+	if exp == 0 {
+		return sign + digits
+	}
+
+	if len(digits) <= exp {
+		digits = strings.Repeat("0", exp-len(digits)+1) + digits
+	}
+
+	point := len(digits) - exp
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+// String returns the amount formatted for the DefaultLocale, as in "-$12.50".
+func (m Money) String() string {
+	return m.Format(DefaultLocale)
+}
+
+// MarshalJSON implements the json.Marshaler interface, encoding the amount as
+// a decimal string followed by the code of its currency. A Money without a
+// currency, as left unset, is encoded as null.
+func (m Money) MarshalJSON() ([]byte, error) {
+	if m.Currency.Code == "" {
+		if m.Amount != 0 {
+			return nil, ErrNoCurrency
+		}
+
+		return []byte("null"), nil
+	}
+
+	return json.Marshal(m.Decimal() + " " + m.Currency.Code)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface, decoding a decimal
+// string in the currency it names, else in the currency already held by the
+// Money, returning ErrNoCurrency when there is neither. Plain JSON numbers are
+// also accepted and parsed from their literal text so they suffer no rounding.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	text := string(data)
+
+	if text == "null" {
+		return nil
+	}
+
+	if strings.HasPrefix(text, `"`) {
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+	}
+
+	money, err := ParseMoney(text, m.Currency)
+	if err != nil {
+		return err
+	}
+
+	*m = money
+	return nil
+}
+
+// Exchange returns the amount converted into the giving currency at the rate,
+// which is the count of units of the currency paid for a single unit of the
+// amount's currency. The result is rounded half away from zero to the minor
+// units of the currency.
+func (m Money) Exchange(rate *big.Rat, cu Currency) (Money, error) {
+	if rate == nil || rate.Sign() <= 0 {
+		return m, ErrInvalidRate
+	}
+
+	value := new(big.Rat).SetInt64(m.Amount)
+	value.Mul(value, rate)
+
+	// Rescale from the minor units of the amount to those of the currency.
+	from, to := m.Currency.Exponent, cu.Exponent
+	scale := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(to-from))), nil))
+
+	if to > from {
+		value.Mul(value, scale)
+	} else {
+		value.Quo(value, scale)
+	}
+
+	// Round half away from zero: (2*num + sign*den) / (2*den), truncated.
+	num, den := new(big.Int).Set(value.Num()), value.Denom()
+	num.Mul(num, big.NewInt(2))
+	num.Add(num, new(big.Int).Mul(den, big.NewInt(int64(value.Sign()))))
+	num.Quo(num, new(big.Int).Mul(den, big.NewInt(2)))
+
+	if !num.IsInt64() {
+		return m, ErrOverflow
+	}
+
+	return Money{Amount: num.Int64(), Currency: cu}, nil
+}
+
+// abs returns the absolute value of the giving integer.
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+
+	return n
+}
+
+// abs64 returns the absolute value of the giving integer, which must not be
+// math.MinInt64.
+func abs64(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+
+	return n
+}
+
+//==============================================================================
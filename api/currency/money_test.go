@@ -0,0 +1,361 @@
+package currency
+
+import (
+	"encoding/json"
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+//==============================================================================
+
+// find returns the currency with the giving code, failing the test if it is
+// not listed.
+func find(t *testing.T, code string) Currency {
+	t.Helper()
+
+	cu, err := ISO4217.Find(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return cu
+}
+
+//==============================================================================
+
+// TestMoneyJSON checks amounts of currencies of every precision are decoded
+// from JSON as they were encoded, alone and within the commands holding them.
+func TestMoneyJSON(t *testing.T) {
+	tests := []struct {
+		code   string
+		amount string
+		minor  int64
+		text   string
+	}{
+		{"JPY", "1500", 1500, `"1500 JPY"`},
+		{"USD", "12.50", 1250, `"12.50 USD"`},
+		{"USD", "-0.05", -5, `"-0.05 USD"`},
+		{"BHD", "1.234", 1234, `"1.234 BHD"`},
+	}
+
+	type command struct {
+		Title string
+		Price Money
+		Limit *Money
+	}
+
+	for _, test := range tests {
+		money, err := ParseMoney(test.amount, find(t, test.code))
+		if err != nil {
+			t.Fatalf("%s: %s", test.code, err)
+		}
+
+		data, err := json.Marshal(money)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(data) != test.text {
+			t.Errorf("Expected %s %s to be encoded as %s, got %s", test.amount, test.code, test.text, data)
+		}
+
+		var decoded Money
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("%s: %s", test.code, err)
+		}
+
+		if decoded != money || decoded.Amount != test.minor {
+			t.Errorf("Expected %s to be decoded as %+v, got %+v", data, money, decoded)
+		}
+
+		data, err = json.Marshal(command{Title: "Rent", Price: money, Limit: &money})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var cmd command
+		if err := json.Unmarshal(data, &cmd); err != nil {
+			t.Fatalf("%s: %s", test.code, err)
+		}
+
+		if cmd.Price != money || cmd.Limit == nil || *cmd.Limit != money {
+			t.Errorf("Expected the command amounts to be decoded as %+v, got %+v and %v", money, cmd.Price, cmd.Limit)
+		}
+	}
+}
+
+// TestMoneyJSONCurrency checks amounts naming no currency are decoded in the
+// currency already held, and refused when none is held or another is named.
+func TestMoneyJSONCurrency(t *testing.T) {
+	usd := find(t, "USD")
+
+	held := NewMoney(0, usd)
+	if err := json.Unmarshal([]byte(`"12.5"`), &held); err != nil || held != NewMoney(1250, usd) {
+		t.Fatalf("Expected an amount decoded in the held currency, got %+v: %v", held, err)
+	}
+
+	held = NewMoney(0, usd)
+	if err := json.Unmarshal([]byte(`12.50`), &held); err != nil || held != NewMoney(1250, usd) {
+		t.Fatalf("Expected a plain number decoded in the held currency, got %+v: %v", held, err)
+	}
+
+	held = NewMoney(0, usd)
+	if err := json.Unmarshal([]byte(`"12.50 EUR"`), &held); err != ErrCurrencyMismatch {
+		t.Fatalf("Expected ErrCurrencyMismatch for an amount of another currency, got %v", err)
+	}
+
+	var unset Money
+	if err := json.Unmarshal([]byte(`"12.50"`), &unset); err != ErrNoCurrency {
+		t.Fatalf("Expected ErrNoCurrency for an amount naming no currency, got %v", err)
+	}
+
+	if err := json.Unmarshal([]byte(`null`), &unset); err != nil || unset != (Money{}) {
+		t.Fatalf("Expected null to leave the amount unset, got %+v: %v", unset, err)
+	}
+
+	if data, err := json.Marshal(Money{}); err != nil || string(data) != "null" {
+		t.Fatalf("Expected an unset amount to be encoded as null, got %s: %v", data, err)
+	}
+
+	if _, err := json.Marshal(Money{Amount: 1250}); err == nil {
+		t.Fatal("Expected an amount without a currency to be refused")
+	}
+}
+
+// TestParseMoneyErrors checks malformed amounts, amounts past the precision of
+// their currency and amounts past the range of Money are refused.
+func TestParseMoneyErrors(t *testing.T) {
+	usd := find(t, "USD")
+
+	tests := []struct {
+		amount string
+		cu     Currency
+		err    error
+	}{
+		{"", usd, ErrInvalidAmount},
+		{".", usd, ErrInvalidAmount},
+		{"-", usd, ErrInvalidAmount},
+		{"1.2.3", usd, ErrInvalidAmount},
+		{"12,50", usd, ErrInvalidAmount},
+		{"1e3", usd, ErrInvalidAmount},
+		{"--1", usd, ErrInvalidAmount},
+		{"twelve", usd, ErrInvalidAmount},
+		{"12.505", usd, ErrPrecision},
+		{"1.5", find(t, "JPY"), ErrPrecision},
+		{"92233720368547758.08", usd, ErrOverflow},
+		{"12.50 EUR", usd, ErrCurrencyMismatch},
+		{"12.50", Currency{}, ErrNoCurrency},
+	}
+
+	for _, test := range tests {
+		money, err := ParseMoney(test.amount, test.cu)
+		if err == nil {
+			t.Errorf("Expected %q to be refused with %v, got %+v", test.amount, test.err, money)
+			continue
+		}
+
+		// Malformed amounts are refused with ErrInvalidAmount naming them.
+		if err != test.err && !(test.err == ErrInvalidAmount && strings.HasPrefix(err.Error(), ErrInvalidAmount.Error())) {
+			t.Errorf("Expected %q to be refused with %v, got %v", test.amount, test.err, err)
+		}
+	}
+
+	if _, err := ParseMoney("12.50 XYZ", usd); err == nil {
+		t.Error("Expected an amount of an unknown currency to be refused")
+	}
+}
+
+// TestParseMoney checks signs, trailing zeros and missing digits on either
+// side of the decimal point are accepted.
+func TestParseMoney(t *testing.T) {
+	usd := find(t, "USD")
+
+	tests := []struct {
+		amount string
+		minor  int64
+	}{
+		{"12", 1200},
+		{"12.5", 1250},
+		{"12.500", 1250},
+		{".5", 50},
+		{"5.", 500},
+		{"+5", 500},
+		{"-5.05", -505},
+		{" 7.25 USD ", 725},
+		{"-0", 0},
+		{"92233720368547758.07", math.MaxInt64},
+		{"-92233720368547758.07", -math.MaxInt64},
+	}
+
+	for _, test := range tests {
+		money, err := ParseMoney(test.amount, usd)
+		if err != nil || money != NewMoney(test.minor, usd) {
+			t.Errorf("Expected %q to be %d cents, got %+v: %v", test.amount, test.minor, money, err)
+		}
+	}
+}
+
+// TestMoneyArithmetic checks amounts of different currencies are never added,
+// subtracted or compared, and that overflowing sums are refused.
+func TestMoneyArithmetic(t *testing.T) {
+	usd, eur := find(t, "USD"), find(t, "EUR")
+
+	a, b := NewMoney(1250, usd), NewMoney(-2000, usd)
+
+	if sum, err := a.Add(b); err != nil || sum != NewMoney(-750, usd) {
+		t.Fatalf("Expected a sum of -750, got %+v: %v", sum, err)
+	}
+
+	if diff, err := a.Sub(b); err != nil || diff != NewMoney(3250, usd) {
+		t.Fatalf("Expected a difference of 3250, got %+v: %v", diff, err)
+	}
+
+	if cmp, err := a.Cmp(b); err != nil || cmp != 1 {
+		t.Fatalf("Expected 1250 to compare above -2000, got %d: %v", cmp, err)
+	}
+
+	other := NewMoney(1250, eur)
+
+	if _, err := a.Add(other); err != ErrCurrencyMismatch {
+		t.Errorf("Expected ErrCurrencyMismatch adding EUR to USD, got %v", err)
+	}
+
+	if _, err := a.Sub(other); err != ErrCurrencyMismatch {
+		t.Errorf("Expected ErrCurrencyMismatch subtracting EUR from USD, got %v", err)
+	}
+
+	if _, err := a.Cmp(other); err != ErrCurrencyMismatch {
+		t.Errorf("Expected ErrCurrencyMismatch comparing EUR to USD, got %v", err)
+	}
+
+	if _, err := a.In(eur); err != ErrCurrencyMismatch {
+		t.Errorf("Expected ErrCurrencyMismatch holding USD in EUR, got %v", err)
+	}
+
+	max, min := NewMoney(math.MaxInt64, usd), NewMoney(math.MinInt64, usd)
+
+	if _, err := max.Add(NewMoney(1, usd)); err != ErrOverflow {
+		t.Errorf("Expected ErrOverflow past the largest amount, got %v", err)
+	}
+
+	if _, err := min.Add(NewMoney(-1, usd)); err != ErrOverflow {
+		t.Errorf("Expected ErrOverflow past the smallest amount, got %v", err)
+	}
+
+	if _, err := a.Sub(min); err != ErrOverflow {
+		t.Errorf("Expected ErrOverflow subtracting the smallest amount, got %v", err)
+	}
+}
+
+// TestMoneyNegative checks negative amounts are negated and written with their
+// sign, including the smallest amount Money holds.
+func TestMoneyNegative(t *testing.T) {
+	usd, jpy := find(t, "USD"), find(t, "JPY")
+
+	tests := []struct {
+		money   Money
+		decimal string
+	}{
+		{NewMoney(-1250, usd), "-12.50"},
+		{NewMoney(-5, usd), "-0.05"},
+		{NewMoney(-1500, jpy), "-1500"},
+		{NewMoney(1250, usd).Neg(), "-12.50"},
+		{NewMoney(-1250, usd).Neg(), "12.50"},
+		{NewMoney(math.MinInt64, usd), "-92233720368547758.08"},
+	}
+
+	for _, test := range tests {
+		if decimal := test.money.Decimal(); decimal != test.decimal {
+			t.Errorf("Expected %d to be written %s, got %s", test.money.Amount, test.decimal, decimal)
+		}
+	}
+
+	if text := NewMoney(-1250, usd).String(); text != "-$12.50" {
+		t.Errorf("Expected -$12.50, got %s", text)
+	}
+}
+
+// TestMoneyScale checks scaled amounts are truncated towards zero on either
+// side of it, and that results past the range of Money are refused.
+func TestMoneyScale(t *testing.T) {
+	usd := find(t, "USD")
+
+	tests := []struct {
+		minor int64
+		num   int64
+		den   int64
+		want  int64
+	}{
+		{1000, 1, 3, 333},
+		{2000, 1, 3, 666},
+		{-2000, 1, 3, -666},
+		{2000, -1, 3, -666},
+		{2000, 1, -3, -666},
+		{-2000, -1, -3, -666},
+		{-2000, -1, 3, 666},
+		{999, 1, 1000, 0},
+		{-999, 1, 1000, 0},
+		{1, 3, 2, 1},
+		{math.MaxInt64, 100, 100, math.MaxInt64},
+		{math.MaxInt64 / 10 * 8, 100, 80, math.MaxInt64 / 10 * 10},
+	}
+
+	for _, test := range tests {
+		scaled, err := NewMoney(test.minor, usd).Scale(test.num, test.den)
+		if err != nil || scaled != NewMoney(test.want, usd) {
+			t.Errorf("Expected %d*%d/%d to be %d, got %d: %v", test.minor, test.num, test.den, test.want, scaled.Amount, err)
+		}
+	}
+
+	if _, err := NewMoney(1, usd).Scale(1, 0); err != ErrInvalidAmount {
+		t.Errorf("Expected ErrInvalidAmount scaling by a zero denominator, got %v", err)
+	}
+
+	if _, err := NewMoney(math.MaxInt64, usd).Scale(2, 1); err != ErrOverflow {
+		t.Errorf("Expected ErrOverflow scaling past the largest amount, got %v", err)
+	}
+
+	if _, err := NewMoney(math.MinInt64, usd).Scale(1, 1); err != ErrOverflow {
+		t.Errorf("Expected ErrOverflow scaling the smallest amount, got %v", err)
+	}
+}
+
+// TestMoneyExchange checks exchanged amounts are rescaled to the minor units
+// of their currency and rounded half away from zero.
+func TestMoneyExchange(t *testing.T) {
+	usd, jpy, bhd := find(t, "USD"), find(t, "JPY"), find(t, "BHD")
+
+	tests := []struct {
+		money Money
+		rate  string
+		to    Currency
+		want  int64
+	}{
+		{NewMoney(1000, usd), "1.5", jpy, 15},
+		{NewMoney(1, usd), "0.5", usd, 1},
+		{NewMoney(-1, usd), "0.5", usd, -1},
+		{NewMoney(1, usd), "0.49", usd, 0},
+		{NewMoney(1000, usd), "0.377", bhd, 3770},
+		{NewMoney(15, jpy), "0.0067", usd, 10},
+	}
+
+	for _, test := range tests {
+		rate, ok := new(big.Rat).SetString(test.rate)
+		if !ok {
+			t.Fatalf("Invalid rate %s", test.rate)
+		}
+
+		exchanged, err := test.money.Exchange(rate, test.to)
+		if err != nil || exchanged != NewMoney(test.want, test.to) {
+			t.Errorf("Expected %s at %s to be %d %s, got %+v: %v", test.money.Decimal(), test.rate, test.want, test.to.Code, exchanged, err)
+		}
+	}
+
+	for _, rate := range []*big.Rat{nil, big.NewRat(0, 1), big.NewRat(-1, 1)} {
+		if _, err := NewMoney(1, usd).Exchange(rate, jpy); err != ErrInvalidRate {
+			t.Errorf("Expected ErrInvalidRate for a rate of %v, got %v", rate, err)
+		}
+	}
+}
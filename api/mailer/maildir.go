@@ -0,0 +1,79 @@
+package mailer
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/satori/go.uuid"
+)
+
+//==============================================================================
+
+// Maildir provides a Mailer which delivers messages as files into a maildir,
+// as read by most mail clients, which suits development and tests. Messages
+// are written into its `tmp` directory and then moved into `new`, so readers
+// never see a partial message.
+type Maildir struct {
+	path string
+	from string
+}
+
+// NewMaildir returns a new Maildir instance which delivers messages sent from
+// the giving address into the maildir at path, creating it if needed.
+func NewMaildir(path string, from string) (*Maildir, error) {
+	if _, err := mail.ParseAddress(from); err != nil {
+		return nil, ErrInvalidAddress
+	}
+
+	for _, dir := range []string{"tmp", "new", "cur"} {
+		if err := os.MkdirAll(filepath.Join(path, dir), 0700); err != nil {
+			return nil, err
+		}
+	}
+
+	md := Maildir{
+		path: path,
+		from: from,
+	}
+
+	return &md, nil
+}
+
+// Send delivers the message into the `new` directory of the maildir.
+func (m *Maildir) Send(msg Message) error {
+	now := time.Now()
+
+	content, err := compose(m.from, msg, now)
+	if err != nil {
+		return err
+	}
+
+	host, err := os.Hostname()
+	if err != nil {
+		host = "localhost"
+	}
+
+	// Maildir names escape the characters of the host which they reserve.
+	host = strings.NewReplacer("/", `\057`, ":", `\072`).Replace(host)
+
+	name := fmt.Sprintf("%d.%s.%s", now.UnixNano(), uuid.NewV4(), host)
+	tmp := filepath.Join(m.path, "tmp", name)
+
+	if err := ioutil.WriteFile(tmp, content, 0600); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp, filepath.Join(m.path, "new", name)); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	return nil
+}
+
+//==============================================================================
@@ -0,0 +1,111 @@
+// Package mailer provides the delivery of mail to users outside the app, with
+// an SMTP Mailer for production and a Maildir Mailer for development, messages
+// rendered from templates and a Queue which retries failed deliveries.
+package mailer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/satori/go.uuid"
+)
+
+//==============================================================================
+
+// ErrInvalidAddress is returned when a message is addressed to, or sent from,
+// an invalid address.
+var ErrInvalidAddress = errors.New("Invalid Mail Address")
+
+//==============================================================================
+
+// Message defines a mail message sent to a single address, carrying its body
+// as plain Text and, if given, as HTML.
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Mailer defines the delivery of mail messages.
+type Mailer interface {
+	Send(Message) error
+}
+
+//==============================================================================
+
+// compose returns the message sent from the giving address as a MIME message,
+// holding its text and html bodies as alternatives.
+func compose(from string, msg Message, now time.Time) ([]byte, error) {
+	sender, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, ErrInvalidAddress
+	}
+
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return nil, ErrInvalidAddress
+	}
+
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+
+	if err := writePart(parts, "text/plain", msg.Text); err != nil {
+		return nil, err
+	}
+
+	if msg.HTML != "" {
+		if err := writePart(parts, "text/html", msg.HTML); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+
+	// Line breaks within the subject would start new headers.
+	subject := strings.Join(strings.Fields(msg.Subject), " ")
+
+	domain := sender.Address[strings.LastIndex(sender.Address, "@")+1:]
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "From: %s\r\n", sender.String())
+	fmt.Fprintf(&out, "To: %s\r\n", to.String())
+	fmt.Fprintf(&out, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&out, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	fmt.Fprintf(&out, "Message-ID: <%s@%s>\r\n", uuid.NewV4(), domain)
+	fmt.Fprintf(&out, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&out, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", parts.Boundary())
+	body.WriteTo(&out)
+
+	return out.Bytes(), nil
+}
+
+// writePart writes the content as a quoted-printable part of the giving type.
+func writePart(parts *multipart.Writer, kind string, content string) error {
+	part, err := parts.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {kind + "; charset=utf-8"},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return err
+	}
+
+	qp := quotedprintable.NewWriter(part)
+	if _, err := qp.Write([]byte(content)); err != nil {
+		return err
+	}
+
+	return qp.Close()
+}
+
+//==============================================================================
@@ -0,0 +1,269 @@
+package mailer
+
+import (
+	"container/heap"
+	"errors"
+	"sync"
+	"time"
+)
+
+//==============================================================================
+
+// ErrQueueFull is returned when a message is queued while the queue holds as
+// many messages as it can.
+var ErrQueueFull = errors.New("Mail Queue Full")
+
+// ErrQueueClosed is returned when a message is queued after the queue was
+// closed.
+var ErrQueueClosed = errors.New("Mail Queue Closed")
+
+//==============================================================================
+
+// Queue provides the delivery of messages through a Mailer in the background,
+// retrying each failed delivery after a backoff which doubles on every
+// attempt. Retries wait within a schedule of their own rather than holding up
+// the delivery of the messages queued after them. Messages which fail every
+// attempt are handed to the failed function, if any.
+type Queue struct {
+	mailer  Mailer
+	retries int
+	backoff time.Duration
+	failed  func(Message, error)
+	rl      sync.Mutex
+	closed  bool
+	waiting retryQueue
+	wake    chan struct{}
+	pending chan Message
+	due     chan send
+	stop    chan struct{}
+	workers sync.WaitGroup
+}
+
+// NewQueue returns a new Queue instance holding up to size messages, which are
+// delivered through the mailer and retried up to retries times, first after
+// the giving backoff.
+func NewQueue(mailer Mailer, size int, retries int, backoff time.Duration, failed func(Message, error)) *Queue {
+	q := Queue{
+		mailer:  mailer,
+		retries: retries,
+		backoff: backoff,
+		failed:  failed,
+		wake:    make(chan struct{}, 1),
+		pending: make(chan Message, size),
+		due:     make(chan send),
+		stop:    make(chan struct{}),
+	}
+
+	q.workers.Add(2)
+	go q.run()
+	go q.schedule()
+
+	return &q
+}
+
+// Enqueue queues the message for delivery, returning ErrQueueFull if the
+// queue holds as many messages as it can.
+func (q *Queue) Enqueue(msg Message) error {
+	q.rl.Lock()
+	defer q.rl.Unlock()
+
+	if q.closed {
+		return ErrQueueClosed
+	}
+
+	select {
+	case q.pending <- msg:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Close stops the queue from accepting messages and waits for the messages
+// already queued to be delivered. Deliveries are no longer retried once the
+// queue is closed, those still waiting on a retry being handed to the failed
+// function with the error of their last attempt.
+func (q *Queue) Close() {
+	q.rl.Lock()
+
+	if !q.closed {
+		q.closed = true
+		close(q.stop)
+		close(q.pending)
+	}
+
+	q.rl.Unlock()
+
+	q.workers.Wait()
+}
+
+// run delivers the queued messages and the retries falling due until the
+// queue is closed and drained.
+func (q *Queue) run() {
+	defer q.workers.Done()
+
+	for {
+		select {
+		case msg, ok := <-q.pending:
+			if !ok {
+				return
+			}
+
+			q.deliver(send{Message: msg, Number: 1, Wait: q.backoff})
+		case next := <-q.due:
+			q.deliver(next)
+		}
+	}
+}
+
+// deliver makes the attempt at sending the message, scheduling the next one
+// unless it was sent, its retries are spent or the queue is closed, in which
+// case the message is handed to the failed function.
+func (q *Queue) deliver(next send) {
+	next.Err = q.mailer.Send(next.Message)
+	if next.Err == nil {
+		return
+	}
+
+	q.rl.Lock()
+
+	if q.closed || next.Number > q.retries {
+		q.rl.Unlock()
+		q.fail(next)
+		return
+	}
+
+	heap.Push(&q.waiting, send{
+		Message: next.Message,
+		Number:  next.Number + 1,
+		Wait:    next.Wait * 2,
+		Due:     time.Now().Add(next.Wait),
+		Err:     next.Err,
+	})
+
+	q.rl.Unlock()
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// fail hands the message of the send to the failed function, if any.
+func (q *Queue) fail(next send) {
+	if q.failed != nil {
+		q.failed(next.Message, next.Err)
+	}
+}
+
+// schedule hands the retries to the worker as they fall due, until the queue
+// is closed, failing those still waiting.
+func (q *Queue) schedule() {
+	defer q.workers.Done()
+
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+
+	for {
+		q.rl.Lock()
+		var ready []send
+
+		now := time.Now()
+		for q.waiting.Len() > 0 && !q.waiting[0].Due.After(now) {
+			ready = append(ready, heap.Pop(&q.waiting).(send))
+		}
+
+		wait := time.Hour
+		if q.waiting.Len() > 0 {
+			wait = q.waiting[0].Due.Sub(now)
+		}
+		q.rl.Unlock()
+
+		for index, next := range ready {
+			select {
+			case q.due <- next:
+			case <-q.stop:
+				q.drop(ready[index:])
+				return
+			}
+		}
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+
+		timer.Reset(wait)
+
+		select {
+		case <-q.stop:
+			q.drop(nil)
+			return
+		case <-q.wake:
+		case <-timer.C:
+		}
+	}
+}
+
+// drop fails the giving retries along with those still waiting once the queue
+// is closed.
+func (q *Queue) drop(ready []send) {
+	q.rl.Lock()
+	for q.waiting.Len() > 0 {
+		ready = append(ready, heap.Pop(&q.waiting).(send))
+	}
+	q.rl.Unlock()
+
+	for _, next := range ready {
+		q.fail(next)
+	}
+}
+
+//==============================================================================
+
+// send defines the attempt Number at sending a message, due at the giving time
+// when retried, the backoff before the one after it and the error of the
+// attempt before it.
+type send struct {
+	Message Message
+	Number  int
+	Wait    time.Duration
+	Due     time.Time
+	Err     error
+}
+
+// retryQueue implements heap.Interface over the retries waiting to be made,
+// the earliest due first.
+type retryQueue []send
+
+// Len returns the count of waiting retries.
+func (r retryQueue) Len() int {
+	return len(r)
+}
+
+// Less returns true/false if the retry at i falls due before the one at j.
+func (r retryQueue) Less(i, j int) bool {
+	return r[i].Due.Before(r[j].Due)
+}
+
+// Swap swaps the retries at i and j.
+func (r retryQueue) Swap(i, j int) {
+	r[i], r[j] = r[j], r[i]
+}
+
+// Push adds the retry at the end of the queue.
+func (r *retryQueue) Push(item interface{}) {
+	*r = append(*r, item.(send))
+}
+
+// Pop removes and returns the retry at the end of the queue.
+func (r *retryQueue) Pop() interface{} {
+	old := *r
+	last := old[len(old)-1]
+	*r = old[:len(old)-1]
+	return last
+}
+
+//==============================================================================
@@ -0,0 +1,157 @@
+package mailer
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+//==============================================================================
+
+// errUnreachable is returned by the stub mailer for the addresses it fails.
+var errUnreachable = errors.New("Unreachable")
+
+// stubMailer records the messages sent through it, failing those addressed to
+// one of its failing addresses the giving number of times, or always if -1.
+type stubMailer struct {
+	ml      sync.Mutex
+	failing map[string]int
+	tries   map[string]int
+	notify  chan Message
+}
+
+// newStubMailer returns a stubMailer failing the giving addresses.
+func newStubMailer(failing map[string]int) *stubMailer {
+	sm := stubMailer{
+		failing: failing,
+		tries:   make(map[string]int),
+		notify:  make(chan Message, 16),
+	}
+
+	return &sm
+}
+
+// Send implements the Mailer interface.
+func (s *stubMailer) Send(msg Message) error {
+	s.ml.Lock()
+	defer s.ml.Unlock()
+
+	s.tries[msg.To]++
+
+	if fails, ok := s.failing[msg.To]; ok && (fails < 0 || s.tries[msg.To] <= fails) {
+		return errUnreachable
+	}
+
+	s.notify <- msg
+	return nil
+}
+
+// wait waits for the message addressed to the giving address to be sent.
+func (s *stubMailer) wait(t *testing.T, to string) {
+	t.Helper()
+
+	timeout := time.After(5 * time.Second)
+
+	for {
+		select {
+		case msg := <-s.notify:
+			if msg.To == to {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("Expected the message to %s to be sent", to)
+		}
+	}
+}
+
+//==============================================================================
+
+// TestQueueRetries checks failed deliveries are retried until sent, and are
+// handed to the failed function once their retries are spent.
+func TestQueueRetries(t *testing.T) {
+	sm := newStubMailer(map[string]int{"flaky@pocket.io": 2, "gone@pocket.io": -1})
+
+	failed := make(chan error, 1)
+
+	q := NewQueue(sm, 8, 3, time.Millisecond, func(msg Message, err error) {
+		if msg.To == "gone@pocket.io" {
+			failed <- err
+		}
+	})
+	defer q.Close()
+
+	for _, to := range []string{"flaky@pocket.io", "gone@pocket.io"} {
+		if err := q.Enqueue(Message{To: to, Subject: "Alert"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sm.wait(t, "flaky@pocket.io")
+
+	select {
+	case err := <-failed:
+		if err != errUnreachable {
+			t.Fatalf("Expected the failed message to carry its last error, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the message failing every attempt to be handed to the failed function")
+	}
+
+	sm.ml.Lock()
+	defer sm.ml.Unlock()
+
+	if sm.tries["flaky@pocket.io"] != 3 || sm.tries["gone@pocket.io"] != 4 {
+		t.Fatalf("Expected 3 and 4 attempts, got %v", sm.tries)
+	}
+}
+
+// TestQueueRetriesWait checks a message waiting on its retry does not delay
+// the messages queued after it, and is handed to the failed function once the
+// queue is closed.
+func TestQueueRetriesWait(t *testing.T) {
+	sm := newStubMailer(map[string]int{"gone@pocket.io": -1})
+
+	var fl sync.Mutex
+	var failed []Message
+
+	q := NewQueue(sm, 8, 5, time.Hour, func(msg Message, err error) {
+		fl.Lock()
+		defer fl.Unlock()
+
+		failed = append(failed, msg)
+	})
+
+	for _, to := range []string{"gone@pocket.io", "owner@pocket.io"} {
+		if err := q.Enqueue(Message{To: to, Subject: "Alert"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	start := time.Now()
+	sm.wait(t, "owner@pocket.io")
+
+	if waited := time.Since(start); waited > time.Second {
+		t.Fatalf("Expected the next message to be sent without waiting on the retry, waited %s", waited)
+	}
+
+	q.Close()
+
+	if err := q.Enqueue(Message{To: "owner@pocket.io"}); err != ErrQueueClosed {
+		t.Fatalf("Expected ErrQueueClosed once closed, got %v", err)
+	}
+
+	fl.Lock()
+	defer fl.Unlock()
+
+	if len(failed) != 1 || failed[0].To != "gone@pocket.io" {
+		t.Fatalf("Expected the waiting message to fail on close, got %+v", failed)
+	}
+
+	sm.ml.Lock()
+	defer sm.ml.Unlock()
+
+	if sm.tries["gone@pocket.io"] != 1 {
+		t.Fatalf("Expected a single attempt before the hour long backoff, got %d", sm.tries["gone@pocket.io"])
+	}
+}
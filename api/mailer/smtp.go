@@ -0,0 +1,128 @@
+package mailer
+
+import (
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"time"
+)
+
+//==============================================================================
+
+// ErrNoAuth is returned when a username is given for an SMTP server which
+// offers no authentication.
+var ErrNoAuth = errors.New("SMTP server does not support AUTH")
+
+// SMTP provides a Mailer which delivers messages through an SMTP server,
+// upgrading the connection with STARTTLS when the server offers it. Dialing
+// the server and the whole exchange of each message are bound by the timeout,
+// so a stalled server fails the delivery rather than holding up the queue.
+type SMTP struct {
+	addr    string
+	host    string
+	from    string
+	auth    smtp.Auth
+	timeout time.Duration
+}
+
+// NewSMTP returns a new SMTP instance which sends messages from the giving
+// address through the server at addr, as in "smtp.example.com:587", within
+// the giving timeout. The server is authenticated with when a username is
+// given.
+func NewSMTP(addr string, from string, username string, password string, timeout time.Duration) (*SMTP, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := mail.ParseAddress(from); err != nil {
+		return nil, ErrInvalidAddress
+	}
+
+	sm := SMTP{
+		addr:    addr,
+		host:    host,
+		from:    from,
+		timeout: timeout,
+	}
+
+	if username != "" {
+		sm.auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return &sm, nil
+}
+
+// Send delivers the message through the SMTP server.
+func (s *SMTP) Send(msg Message) error {
+	content, err := compose(s.from, msg, time.Now())
+	if err != nil {
+		return err
+	}
+
+	from, _ := mail.ParseAddress(s.from)
+	to, _ := mail.ParseAddress(msg.To)
+
+	dialer := net.Dialer{Timeout: s.timeout}
+
+	conn, err := dialer.Dial("tcp", s.addr)
+	if err != nil {
+		return err
+	}
+
+	defer conn.Close()
+
+	if err := conn.SetDeadline(time.Now().Add(s.timeout)); err != nil {
+		return err
+	}
+
+	client, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		return err
+	}
+
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: s.host}); err != nil {
+			return err
+		}
+	}
+
+	if s.auth != nil {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return ErrNoAuth
+		}
+
+		if err := client.Auth(s.auth); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(from.Address); err != nil {
+		return err
+	}
+
+	if err := client.Rcpt(to.Address); err != nil {
+		return err
+	}
+
+	body, err := client.Data()
+	if err != nil {
+		return err
+	}
+
+	if _, err := body.Write(content); err != nil {
+		return err
+	}
+
+	if err := body.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+//==============================================================================
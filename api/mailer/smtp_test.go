@@ -0,0 +1,50 @@
+package mailer
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+// TestSMTPTimeout checks a delivery to an SMTP server which never answers is
+// given up on once the timeout passes.
+func TestSMTPTimeout(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer listener.Close()
+
+	// Accept connections but never greet them, as a stalled server would.
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			defer conn.Close()
+		}
+	}()
+
+	sm, err := NewSMTP(listener.Addr().String(), "pocket@localhost", "", "", 100*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+
+	go func() {
+		done <- sm.Send(Message{To: "owner@pocket.io", Subject: "Alert", Text: "Over budget"})
+	}()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("Expected the delivery to a stalled server to fail")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the delivery to a stalled server to time out")
+	}
+}
@@ -0,0 +1,77 @@
+package mailer
+
+import (
+	"bytes"
+	htmltemplate "html/template"
+	"path/filepath"
+	"strings"
+	texttemplate "text/template"
+)
+
+//==============================================================================
+
+// Templates provides the rendering of messages from the `*.tml` files of a
+// directory. Each message is defined by three templates named after it, as in
+// `{{ define "reset.subject" }}`, `{{ define "reset.text" }}` and
+// `{{ define "reset.html" }}`, the last being escaped as HTML.
+type Templates struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+// LoadTemplates returns the Templates defined by the `*.tml` files of the
+// giving directory.
+func LoadTemplates(dir string) (*Templates, error) {
+	pattern := filepath.Join(dir, "*.tml")
+
+	text, err := texttemplate.ParseGlob(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	html, err := htmltemplate.ParseGlob(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	tm := Templates{
+		text: text,
+		html: html,
+	}
+
+	return &tm, nil
+}
+
+// Render returns the message with the giving name addressed to the giving
+// address, executing its templates with the data. The html body is left empty
+// if the message defines none.
+func (t *Templates) Render(name string, to string, data interface{}) (Message, error) {
+	msg := Message{To: to}
+
+	var subject, text bytes.Buffer
+
+	if err := t.text.ExecuteTemplate(&subject, name+".subject", data); err != nil {
+		return msg, err
+	}
+
+	if err := t.text.ExecuteTemplate(&text, name+".text", data); err != nil {
+		return msg, err
+	}
+
+	msg.Subject = strings.TrimSpace(subject.String())
+	msg.Text = strings.TrimSpace(text.String()) + "\n"
+
+	if t.html.Lookup(name+".html") == nil {
+		return msg, nil
+	}
+
+	var html bytes.Buffer
+	if err := t.html.ExecuteTemplate(&html, name+".html", data); err != nil {
+		return msg, err
+	}
+
+	msg.HTML = html.String()
+	return msg, nil
+}
+
+//==============================================================================
@@ -0,0 +1,280 @@
+package queries
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+//==============================================================================
+
+// MaxChangeKeys defines the number of record keys a ChangeLog remembers the
+// last change of. Past it, the keys changed longest ago are forgotten and
+// clients behind them are handed a full refresh.
+var MaxChangeKeys = 10000
+
+// ChangeLog provides a monotonically increasing record of changes made to
+// records, keyed by the record key. Each change advances the log and the
+// position of the log is handed to clients as the DeltaID of their response,
+// which they return as the DiffTag of their next request.
+//
+// The log lives in memory, so each ChangeLog numbers its changes within an
+// epoch of its own, which prefixes its DeltaIDs as `<epoch>:<seq>`. A tag from
+// another epoch, as one handed out before a restart, or from before the
+// changes the log still remembers, is answered with a full refresh.
+type ChangeLog struct {
+	cl    sync.RWMutex
+	epoch string
+	seq   int64
+	floor int64
+	keys  map[string]int64
+	subs  map[*Subscription]bool
+}
+
+// NewChangeLog returns a new ChangeLog instance.
+func NewChangeLog() *ChangeLog {
+	cl := ChangeLog{
+		epoch: strconv.FormatInt(time.Now().UnixNano(), 36),
+		keys:  make(map[string]int64),
+		subs:  make(map[*Subscription]bool),
+	}
+
+	return &cl
+}
+
+// Subscribe returns a new Subscription to the changes which touch any of the
+// watched keys.
+func (c *ChangeLog) Subscribe(watch []string) *Subscription {
+	sub := Subscription{
+		watch:   make(map[string]bool, len(watch)),
+		pending: make(map[string]bool),
+		ready:   make(chan struct{}, 1),
+	}
+
+	for _, key := range watch {
+		sub.watch[key] = true
+	}
+
+	c.cl.Lock()
+	c.subs[&sub] = true
+	c.cl.Unlock()
+
+	return &sub
+}
+
+// Unsubscribe stops the delivery of changes to the giving subscription.
+func (c *ChangeLog) Unsubscribe(sub *Subscription) {
+	c.cl.Lock()
+	delete(c.subs, sub)
+	c.cl.Unlock()
+}
+
+// Touch records a change for all the giving record keys, returning the new
+// DeltaID of the log.
+func (c *ChangeLog) Touch(keys ...string) string {
+	c.cl.Lock()
+	defer c.cl.Unlock()
+
+	if len(keys) == 0 {
+		return c.deltaID()
+	}
+
+	c.seq++
+
+	for _, key := range keys {
+		c.keys[key] = c.seq
+	}
+
+	if len(c.keys) > MaxChangeKeys {
+		c.prune()
+	}
+
+	deltaID := c.deltaID()
+
+	for sub := range c.subs {
+		sub.offer(keys, deltaID)
+	}
+
+	return deltaID
+}
+
+// DeltaID returns the current position of the log.
+func (c *ChangeLog) DeltaID() string {
+	c.cl.RLock()
+	defer c.cl.RUnlock()
+
+	return c.deltaID()
+}
+
+// Since returns the keys changed after the giving DeltaID alongside the
+// current DeltaID of the log. When watch is not empty, only the keys contained
+// within it are returned. An empty tag returns no keys, as the caller has no
+// prior state to compare against. A tag the log can not answer, being of
+// another epoch or older than the changes it remembers, returns every watched
+// key, or every key it remembers when watch is empty, so the caller refreshes
+// all it holds.
+func (c *ChangeLog) Since(tag string, watch []string) ([]string, string) {
+	c.cl.RLock()
+	defer c.cl.RUnlock()
+
+	deltaID := c.deltaID()
+
+	if tag == "" {
+		return nil, deltaID
+	}
+
+	last, ok := c.position(tag)
+	if !ok {
+		return c.refresh(watch), deltaID
+	}
+
+	var watched map[string]bool
+	if len(watch) > 0 {
+		watched = make(map[string]bool, len(watch))
+		for _, key := range watch {
+			watched[key] = true
+		}
+	}
+
+	var deltas []string
+
+	for key, seq := range c.keys {
+		if seq <= last {
+			continue
+		}
+
+		if watched != nil && !watched[key] {
+			continue
+		}
+
+		deltas = append(deltas, key)
+	}
+
+	return deltas, deltaID
+}
+
+// deltaID returns the DeltaID of the current position of the log. It expects
+// the lock to be held.
+func (c *ChangeLog) deltaID() string {
+	return c.epoch + ":" + strconv.FormatInt(c.seq, 10)
+}
+
+// position returns the position of the log the giving DeltaID was handed out
+// at and true if the log remembers every change made after it, else false. It
+// expects the lock to be held.
+func (c *ChangeLog) position(tag string) (int64, bool) {
+	sep := strings.LastIndex(tag, ":")
+	if sep == -1 || tag[:sep] != c.epoch {
+		return 0, false
+	}
+
+	seq, err := strconv.ParseInt(tag[sep+1:], 10, 64)
+	if err != nil || seq < c.floor || seq > c.seq {
+		return 0, false
+	}
+
+	return seq, true
+}
+
+// refresh returns the keys reported to a caller whose tag the log can not
+// answer. It expects the lock to be held.
+func (c *ChangeLog) refresh(watch []string) []string {
+	if len(watch) > 0 {
+		return append([]string(nil), watch...)
+	}
+
+	keys := make([]string, 0, len(c.keys))
+	for key := range c.keys {
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+// prune forgets the keys changed longest ago, keeping the half of
+// MaxChangeKeys changed last, and raises the floor of the log past them. It
+// expects the lock to be held.
+func (c *ChangeLog) prune() {
+	seqs := make([]int64, 0, len(c.keys))
+	for _, seq := range c.keys {
+		seqs = append(seqs, seq)
+	}
+
+	sort.Slice(seqs, func(i, j int) bool { return seqs[i] < seqs[j] })
+
+	floor := seqs[len(seqs)-MaxChangeKeys/2-1]
+
+	for key, seq := range c.keys {
+		if seq <= floor {
+			delete(c.keys, key)
+		}
+	}
+
+	c.floor = floor
+}
+
+//==============================================================================
+
+// Subscription defines a feed of the changes made to the records watched by
+// its subscriber. Changes are collected until the subscriber takes them, so a
+// slow subscriber receives them merged rather than missing any.
+type Subscription struct {
+	watch   map[string]bool
+	sl      sync.Mutex
+	pending map[string]bool
+	deltaID string
+	ready   chan struct{}
+}
+
+// Ready returns a channel which receives a value when changes are pending.
+func (s *Subscription) Ready() <-chan struct{} {
+	return s.ready
+}
+
+// Changes returns and clears the keys of the pending changes alongside the
+// DeltaID of the last of them.
+func (s *Subscription) Changes() ([]string, string) {
+	s.sl.Lock()
+	defer s.sl.Unlock()
+
+	keys := make([]string, 0, len(s.pending))
+	for key := range s.pending {
+		keys = append(keys, key)
+	}
+
+	s.pending = make(map[string]bool)
+	return keys, s.deltaID
+}
+
+// offer adds the keys of a change into the pending changes if the change
+// touches any of the watched keys.
+func (s *Subscription) offer(keys []string, deltaID string) {
+	var watched bool
+
+	for _, key := range keys {
+		if s.watch[key] {
+			watched = true
+			break
+		}
+	}
+
+	if !watched {
+		return
+	}
+
+	s.sl.Lock()
+	for _, key := range keys {
+		s.pending[key] = true
+	}
+	s.deltaID = deltaID
+	s.sl.Unlock()
+
+	select {
+	case s.ready <- struct{}{}:
+	default:
+	}
+}
+
+//==============================================================================
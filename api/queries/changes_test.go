@@ -0,0 +1,95 @@
+package queries
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+)
+
+//==============================================================================
+
+// TestChangeLogSince checks the keys changed after a tag are reported, and
+// that an empty tag reports none.
+func TestChangeLogSince(t *testing.T) {
+	changes := NewChangeLog()
+
+	first := changes.Touch("pocket")
+	changes.Touch("budget", "pocket")
+	changes.Touch("item")
+
+	if deltas, _ := changes.Since("", nil); len(deltas) != 0 {
+		t.Fatalf("Expected no deltas for an empty tag, got %v", deltas)
+	}
+
+	deltas, deltaID := changes.Since(first, nil)
+	sort.Strings(deltas)
+
+	if fmt.Sprint(deltas) != "[budget item pocket]" || deltaID != changes.DeltaID() {
+		t.Fatalf("Expected the keys changed after %s up to %s, got %v at %s", first, changes.DeltaID(), deltas, deltaID)
+	}
+
+	if deltas, _ := changes.Since(first, []string{"item"}); fmt.Sprint(deltas) != "[item]" {
+		t.Fatalf("Expected only the watched keys, got %v", deltas)
+	}
+
+	if deltas, _ := changes.Since(changes.DeltaID(), nil); len(deltas) != 0 {
+		t.Fatalf("Expected no deltas for the current tag, got %v", deltas)
+	}
+}
+
+// TestChangeLogForeignEpoch checks a tag handed out by another log, as one
+// before a restart, is answered with a full refresh rather than being read as
+// a position of this log.
+func TestChangeLogForeignEpoch(t *testing.T) {
+	before := NewChangeLog()
+	before.Touch("pocket")
+	before.Touch("budget")
+	tag := before.DeltaID()
+
+	restarted := NewChangeLog()
+	restarted.epoch = before.epoch + "0"
+	restarted.Touch("item")
+
+	if deltas, _ := restarted.Since(tag, []string{"pocket", "budget"}); len(deltas) != 2 {
+		t.Fatalf("Expected every watched key for a tag of another epoch, got %v", deltas)
+	}
+
+	if deltas, _ := restarted.Since(tag, nil); fmt.Sprint(deltas) != "[item]" {
+		t.Fatalf("Expected every remembered key for a tag of another epoch, got %v", deltas)
+	}
+
+	for _, tag := range []string{"2", "bogus", restarted.epoch + ":9", restarted.epoch + ":x"} {
+		if deltas, _ := restarted.Since(tag, nil); fmt.Sprint(deltas) != "[item]" {
+			t.Errorf("Expected a full refresh for tag %q, got %v", tag, deltas)
+		}
+	}
+}
+
+// TestChangeLogPrune checks the log remembers at most MaxChangeKeys keys, and
+// that tags older than the changes it still remembers get a full refresh.
+func TestChangeLogPrune(t *testing.T) {
+	defer func(max int) { MaxChangeKeys = max }(MaxChangeKeys)
+	MaxChangeKeys = 10
+
+	changes := NewChangeLog()
+	first := changes.Touch("key-0")
+
+	for index := 1; index <= MaxChangeKeys; index++ {
+		changes.Touch(fmt.Sprintf("key-%d", index))
+	}
+
+	if len(changes.keys) > MaxChangeKeys {
+		t.Fatalf("Expected at most %d keys, got %d", MaxChangeKeys, len(changes.keys))
+	}
+
+	if deltas, _ := changes.Since(first, []string{"key-0"}); fmt.Sprint(deltas) != "[key-0]" {
+		t.Fatalf("Expected a full refresh for a tag older than the log, got %v", deltas)
+	}
+
+	recent := changes.DeltaID()
+	changes.Touch("key-new")
+
+	if deltas, _ := changes.Since(recent, nil); fmt.Sprint(deltas) != "[key-new]" {
+		t.Fatalf("Expected only the key changed after a recent tag, got %v", deltas)
+	}
+}
@@ -0,0 +1,200 @@
+// Package queries provides the server side of the coquery protocol, where the
+// batched queries sent by a client.Servo are resolved against registered
+// handlers and replied to with a single data.ResponsePack.
+package queries
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/influx6/coquery/data"
+	"github.com/influx6/faux/context"
+	"github.com/influx6/faux/web/app"
+)
+
+//==============================================================================
+
+// Events defines event logger that allows us to record events for a specific
+// action that occured.
+type Events interface {
+	Log(context interface{}, name string, message string, data ...interface{})
+	Error(context interface{}, name string, err error, message string, data ...interface{})
+}
+
+//==============================================================================
+
+// ErrEmptyQuery is returned when a query with no resource name is provided.
+var ErrEmptyQuery = errors.New("Empty Query")
+
+// Query defines a parsed coquery query, made up of the name of the resource
+// requested and the parameters provided alongside it, as in
+// `budgets?pocket=4a3b`.
+type Query struct {
+	Raw    string
+	Name   string
+	Params url.Values
+}
+
+// Parse returns a Query from the giving query string else returns an error if
+// the query is invalid.
+func Parse(qry string) (Query, error) {
+	q := Query{Raw: qry}
+
+	name := strings.TrimSpace(qry)
+	params := ""
+
+	if index := strings.Index(name, "?"); index != -1 {
+		params = name[index+1:]
+		name = name[:index]
+	}
+
+	q.Name = strings.Trim(name, "/")
+	if q.Name == "" {
+		return q, ErrEmptyQuery
+	}
+
+	var err error
+	if q.Params, err = url.ParseQuery(params); err != nil {
+		return q, err
+	}
+
+	return q, nil
+}
+
+// Get returns the first value of the giving parameter.
+func (q Query) Get(key string) string {
+	return q.Params.Get(key)
+}
+
+// Require returns the first value of the giving parameter else returns an
+// error if it was not provided.
+func (q Query) Require(key string) (string, error) {
+	val := q.Params.Get(key)
+	if val == "" {
+		return "", fmt.Errorf("Query[%s] requires parameter[%s]", q.Name, key)
+	}
+
+	return val, nil
+}
+
+//==============================================================================
+
+// Handler defines the function type which resolves a query into its records.
+// The records returned must be a slice of values which encode into JSON
+// objects.
+type Handler func(ctx context.Context, q Query) (interface{}, error)
+
+// Resolver provides the central registry of query handlers for a server and
+// handles the processing of coquery requests against those handlers.
+type Resolver struct {
+	Events
+	recordKey string
+	changes   *ChangeLog
+	rl        sync.RWMutex
+	handlers  map[string]Handler
+}
+
+// New returns a new Resolver instance. The recordKey is the field within every
+// record which uniquely identifies it, and the ChangeLog provides the deltas
+// reported back to clients, if nil no deltas are ever reported.
+func New(events Events, recordKey string, changes *ChangeLog) *Resolver {
+	rs := Resolver{
+		Events:    events,
+		recordKey: recordKey,
+		changes:   changes,
+		handlers:  make(map[string]Handler),
+	}
+
+	return &rs
+}
+
+// Register adds the handler for the giving query name, replacing any previous
+// handler registered with that name.
+func (r *Resolver) Register(name string, h Handler) {
+	r.rl.Lock()
+	defer r.rl.Unlock()
+
+	r.handlers[strings.Trim(name, "/")] = h
+}
+
+// Resolve executes all queries within the request, returning a batched
+// ResponsePack where each result matches the query at the same index.
+func (r *Resolver) Resolve(ctx context.Context, rc data.RequestContext) data.ResponsePack {
+	r.Events.Log("Resolver", "Resolve", "Started : Request[%s] : Queries[%d]", rc.RequestID, len(rc.Queries))
+
+	pack := data.ResponsePack{
+		RecordKey: r.recordKey,
+		RequestID: rc.RequestID,
+		Batched:   true,
+		Results:   make(data.Parameters, 0, len(rc.Queries)),
+	}
+
+	// Collect the deltas before resolving, so changes made while the queries
+	// run get reported on the next request.
+	if r.changes != nil {
+		if rc.Diffs {
+			pack.Deltas, pack.DeltaID = r.changes.Since(rc.DiffTag, rc.DiffWatch)
+		} else {
+			pack.DeltaID = r.changes.DeltaID()
+		}
+	}
+
+	for _, qry := range rc.Queries {
+		records, err := r.resolve(ctx, qry)
+		if err != nil {
+			r.Events.Error("Resolver", "Resolve", err, "Query[%s]", qry)
+			pack.Results = append(pack.Results, data.Parameter{
+				"QueryFailed": true,
+				"Message":     fmt.Sprintf("Query[%s] failed", qry),
+				"Error":       err.Error(),
+			})
+			continue
+		}
+
+		pack.Results = append(pack.Results, data.Parameter{"data": records})
+	}
+
+	r.Events.Log("Resolver", "Resolve", "Completed")
+	return pack
+}
+
+// resolve parses and executes a single query against its handler.
+func (r *Resolver) resolve(ctx context.Context, qry string) (interface{}, error) {
+	q, err := Parse(qry)
+	if err != nil {
+		return nil, err
+	}
+
+	r.rl.RLock()
+	handler, ok := r.handlers[q.Name]
+	r.rl.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("Unknown Query[%s]", q.Name)
+	}
+
+	return handler(ctx, q)
+}
+
+// Serve implements the app.Handler signature, decoding the RequestContext
+// delivered in the body of the request and responding with the resolved
+// ResponsePack.
+func (r *Resolver) Serve(ctx context.Context, rw *app.ResponseRequest, params app.Param) error {
+	var rc data.RequestContext
+
+	if err := json.NewDecoder(rw.R.Body).Decode(&rc); err != nil {
+		r.Events.Error("Resolver", "Serve", err, "Completed")
+		rw.RespondError(http.StatusBadRequest, err)
+		return nil
+	}
+
+	rw.Respond(http.StatusOK, r.Resolve(ctx, rc))
+	return nil
+}
+
+//==============================================================================
@@ -0,0 +1,91 @@
+package rates
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"time"
+)
+
+//==============================================================================
+
+// ECBBase defines the base currency of the reference rates published by the
+// European Central Bank.
+const ECBBase = "EUR"
+
+// ecbEnvelope defines the layout of the euro foreign exchange reference rates
+// files published by the European Central Bank, as in eurofxref-daily.xml and
+// eurofxref-hist.xml, which both hold a Cube per day of the rates against the
+// euro.
+type ecbEnvelope struct {
+	XMLName xml.Name `xml:"Envelope"`
+	Days    []struct {
+		Time  string `xml:"time,attr"`
+		Rates []struct {
+			Currency string `xml:"currency,attr"`
+			Rate     string `xml:"rate,attr"`
+		} `xml:"Cube"`
+	} `xml:"Cube>Cube"`
+}
+
+// ParseECB returns a Table of the rates held by the ECB reference rates XML
+// read from the reader.
+func ParseECB(r io.Reader) (*Table, error) {
+	var env ecbEnvelope
+
+	if err := xml.NewDecoder(r).Decode(&env); err != nil {
+		return nil, fmt.Errorf("Invalid ECB rates: %s", err)
+	}
+
+	table := NewTable(ECBBase)
+
+	for _, d := range env.Days {
+		date, err := time.Parse(dateLayout, d.Time)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid ECB rates date[%s]: %s", d.Time, err)
+		}
+
+		for _, rate := range d.Rates {
+			value, ok := new(big.Rat).SetString(rate.Rate)
+			if !ok {
+				return nil, fmt.Errorf("Invalid ECB rate[%s] for %s on %s", rate.Rate, rate.Currency, d.Time)
+			}
+
+			if err := table.Add(date, rate.Currency, value); err != nil {
+				return nil, fmt.Errorf("Invalid ECB rate[%s] for %s on %s: %s", rate.Rate, rate.Currency, d.Time, err)
+			}
+		}
+	}
+
+	return table, nil
+}
+
+// LoadECB returns a Table of the rates held by the ECB reference rates files at
+// the giving paths, such as a historical file alongside the latest daily one.
+func LoadECB(paths ...string) (*Table, error) {
+	table := NewTable(ECBBase)
+
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+
+		loaded, err := ParseECB(file)
+		file.Close()
+
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", path, err)
+		}
+
+		if err := table.Merge(loaded); err != nil {
+			return nil, err
+		}
+	}
+
+	return table, nil
+}
+
+//==============================================================================
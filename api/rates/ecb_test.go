@@ -0,0 +1,190 @@
+package rates
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+//==============================================================================
+
+// ecbFixture holds two days of the ECB reference rates in the layout of
+// eurofxref-hist.xml, the latest first. ISK is only published on the first day.
+const ecbFixture = `<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+	<gesmes:subject>Reference rates</gesmes:subject>
+	<gesmes:Sender>
+		<gesmes:name>European Central Bank</gesmes:name>
+	</gesmes:Sender>
+	<Cube>
+		<Cube time="2024-01-03">
+			<Cube currency="USD" rate="1.0919"/>
+			<Cube currency="JPY" rate="155.52"/>
+			<Cube currency="GBP" rate="0.86350"/>
+		</Cube>
+		<Cube time="2024-01-02">
+			<Cube currency="USD" rate="1.0956"/>
+			<Cube currency="JPY" rate="155.66"/>
+			<Cube currency="GBP" rate="0.86760"/>
+			<Cube currency="ISK" rate="150.10"/>
+		</Cube>
+	</Cube>
+</gesmes:Envelope>`
+
+// date returns the start of the UTC day of the giving date.
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// rat returns the rational number held by the giving decimal.
+func rat(t *testing.T, value string) *big.Rat {
+	t.Helper()
+
+	r, ok := new(big.Rat).SetString(value)
+	if !ok {
+		t.Fatalf("Invalid rational %q", value)
+	}
+
+	return r
+}
+
+// parseFixture returns the Table of the rates held by the fixture.
+func parseFixture(t *testing.T) *Table {
+	t.Helper()
+
+	table, err := ParseECB(strings.NewReader(ecbFixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return table
+}
+
+//==============================================================================
+
+// TestParseECB checks every day and rate of the reference rates is held by the
+// table against the euro.
+func TestParseECB(t *testing.T) {
+	table := parseFixture(t)
+
+	if table.Base() != ECBBase {
+		t.Fatalf("Expected a table of base %s, got %s", ECBBase, table.Base())
+	}
+
+	if first, last := table.Dates(); !first.Equal(date(2024, 1, 2)) || !last.Equal(date(2024, 1, 3)) {
+		t.Fatalf("Expected rates from 2024-01-02 to 2024-01-03, got %v to %v", first, last)
+	}
+
+	tests := []struct {
+		code  string
+		on    time.Time
+		value string
+	}{
+		{"USD", date(2024, 1, 2), "1.0956"},
+		{"USD", date(2024, 1, 3), "1.0919"},
+		{"JPY", date(2024, 1, 3), "155.52"},
+		{"gbp", date(2024, 1, 3), "0.8635"},
+		{"ISK", date(2024, 1, 2), "150.1"},
+		{"EUR", date(2024, 1, 3), "1"},
+	}
+
+	for _, test := range tests {
+		rate, err := table.Rate(ECBBase, test.code, test.on)
+		if err != nil {
+			t.Errorf("Expected a rate for %s on %v, got %s", test.code, test.on, err)
+			continue
+		}
+
+		if rate.Value.Cmp(rat(t, test.value)) != 0 {
+			t.Errorf("Expected 1 EUR = %s %s on %v, got %s", test.value, test.code, test.on, rate)
+		}
+	}
+}
+
+// TestParseECBInvalid checks files which are not reference rates, or hold an
+// invalid date or rate, are refused.
+func TestParseECBInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		xml  string
+	}{
+		{"not xml", "USD 1.0919"},
+		{"invalid date", strings.Replace(ecbFixture, "2024-01-03", "03/01/2024", 1)},
+		{"invalid rate", strings.Replace(ecbFixture, "1.0919", "1,0919", 1)},
+		{"zero rate", strings.Replace(ecbFixture, "1.0919", "0", 1)},
+		{"negative rate", strings.Replace(ecbFixture, "1.0919", "-1.0919", 1)},
+	}
+
+	for _, test := range tests {
+		if _, err := ParseECB(strings.NewReader(test.xml)); err == nil {
+			t.Errorf("Expected %s to be refused", test.name)
+		}
+	}
+}
+
+// TestLoadECB checks the rates of several files are merged into one table, the
+// later files replacing the rates of days already held.
+func TestLoadECB(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pocket-rates")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	daily := `<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+	<Cube>
+		<Cube time="2024-01-04">
+			<Cube currency="USD" rate="1.0953"/>
+		</Cube>
+		<Cube time="2024-01-03">
+			<Cube currency="USD" rate="1.0920"/>
+		</Cube>
+	</Cube>
+</gesmes:Envelope>`
+
+	hist, latest := filepath.Join(dir, "eurofxref-hist.xml"), filepath.Join(dir, "eurofxref-daily.xml")
+
+	if err := ioutil.WriteFile(hist, []byte(ecbFixture), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(latest, []byte(daily), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	table, err := LoadECB(hist, latest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if first, last := table.Dates(); !first.Equal(date(2024, 1, 2)) || !last.Equal(date(2024, 1, 4)) {
+		t.Fatalf("Expected rates from 2024-01-02 to 2024-01-04, got %v to %v", first, last)
+	}
+
+	tests := []struct {
+		code  string
+		on    time.Time
+		value string
+	}{
+		{"USD", date(2024, 1, 3), "1.092"},
+		{"USD", date(2024, 1, 4), "1.0953"},
+		{"GBP", date(2024, 1, 3), "0.8635"},
+	}
+
+	for _, test := range tests {
+		rate, err := table.Rate(ECBBase, test.code, test.on)
+		if err != nil || rate.Value.Cmp(rat(t, test.value)) != 0 {
+			t.Errorf("Expected 1 EUR = %s %s on %v, got %v: %v", test.value, test.code, test.on, rate.Value, err)
+		}
+	}
+
+	if _, err := LoadECB(hist, filepath.Join(dir, "missing.xml")); err == nil {
+		t.Fatal("Expected a missing file to be refused")
+	}
+}
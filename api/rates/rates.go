@@ -0,0 +1,218 @@
+// Package rates provides dated currency exchange rates, allowing amounts held
+// in different currencies to be converted into a single currency at the rate
+// which was valid on the date the amount was spent.
+package rates
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influx6/pocket/api/currency"
+)
+
+//==============================================================================
+
+// ErrNoRate is returned when no rate is known between two currencies on a date.
+var ErrNoRate = errors.New("No Exchange Rate")
+
+// Rate defines the number of units of the To currency paid for a single unit of
+// the From currency, as published on the Date.
+type Rate struct {
+	From  string
+	To    string
+	Date  time.Time
+	Value *big.Rat
+}
+
+// String returns the rate as in "1 EUR = 1.0956 USD (2024-01-02)".
+func (r Rate) String() string {
+	return fmt.Sprintf("1 %s = %s %s (%s)", r.From, r.Value.FloatString(6), r.To, r.Date.Format(dateLayout))
+}
+
+// Provider defines a source of exchange rates.
+type Provider interface {
+
+	// Rate returns the rate between the currencies, given by their ISO 4217
+	// codes, which was valid on the giving date, else returns ErrNoRate.
+	Rate(from, to string, on time.Time) (Rate, error)
+}
+
+// Convert returns the amount converted into the giving currency at the rate of
+// the provider valid on the giving date. Amounts already in the currency are
+// returned as they are, while amounts or currencies without a code can not be
+// converted and return currency.ErrCurrencyMismatch.
+func Convert(p Provider, m currency.Money, to currency.Currency, on time.Time) (currency.Money, error) {
+	if m.Currency.Same(to) {
+		return m, nil
+	}
+
+	if m.Currency.Code == "" || to.Code == "" {
+		return m, currency.ErrCurrencyMismatch
+	}
+
+	rate, err := p.Rate(m.Currency.Code, to.Code, on)
+	if err != nil {
+		return m, err
+	}
+
+	return m.Exchange(rate.Value, to)
+}
+
+//==============================================================================
+
+// dateLayout defines the layout of the dates rates are published for.
+const dateLayout = "2006-01-02"
+
+// day defines the rates against the base currency published on a date.
+type day struct {
+	date  time.Time
+	rates map[string]*big.Rat
+}
+
+// Table provides a Provider holding the rates of every currency against a
+// single base currency for a series of dates, as published by central banks.
+// Rates between two other currencies are crossed through the base. The rate
+// valid on a date is the last one published on or before it, so weekends and
+// holidays use the rates of the previous working day.
+type Table struct {
+	base string
+	tl   sync.RWMutex
+	days []day
+}
+
+// NewTable returns a new Table of rates against the giving base currency.
+func NewTable(base string) *Table {
+	tb := Table{base: strings.ToUpper(base)}
+	return &tb
+}
+
+// Base returns the code of the base currency of the table.
+func (t *Table) Base() string {
+	return t.base
+}
+
+// Add records the number of units of the currency paid for a single unit of
+// the base currency on the giving date, replacing any rate already recorded.
+func (t *Table) Add(date time.Time, code string, value *big.Rat) error {
+	if value == nil || value.Sign() <= 0 {
+		return currency.ErrInvalidRate
+	}
+
+	date = truncate(date)
+	code = strings.ToUpper(code)
+
+	t.tl.Lock()
+	defer t.tl.Unlock()
+
+	index := sort.Search(len(t.days), func(i int) bool {
+		return !t.days[i].date.Before(date)
+	})
+
+	if index == len(t.days) || !t.days[index].date.Equal(date) {
+		t.days = append(t.days, day{})
+		copy(t.days[index+1:], t.days[index:])
+		t.days[index] = day{date: date, rates: make(map[string]*big.Rat)}
+	}
+
+	t.days[index].rates[code] = new(big.Rat).Set(value)
+	return nil
+}
+
+// Merge adds all the rates of the giving table, which must share the same base
+// currency, into the table.
+func (t *Table) Merge(other *Table) error {
+	if other.base != t.base {
+		return fmt.Errorf("Rates of base[%s] can not be merged into base[%s]", other.base, t.base)
+	}
+
+	other.tl.RLock()
+	defer other.tl.RUnlock()
+
+	for _, d := range other.days {
+		for code, value := range d.rates {
+			if err := t.Add(d.date, code, value); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Dates returns the first and last dates rates are held for.
+func (t *Table) Dates() (time.Time, time.Time) {
+	t.tl.RLock()
+	defer t.tl.RUnlock()
+
+	if len(t.days) == 0 {
+		return time.Time{}, time.Time{}
+	}
+
+	return t.days[0].date, t.days[len(t.days)-1].date
+}
+
+// Rate returns the rate between the currencies which was valid on the giving
+// date, else returns ErrNoRate.
+func (t *Table) Rate(from, to string, on time.Time) (Rate, error) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+
+	rate := Rate{From: from, To: to, Date: truncate(on), Value: big.NewRat(1, 1)}
+	if from == to {
+		return rate, nil
+	}
+
+	t.tl.RLock()
+	defer t.tl.RUnlock()
+
+	on = truncate(on)
+
+	// Find the last day published on or before the date.
+	index := sort.Search(len(t.days), func(i int) bool {
+		return t.days[i].date.After(on)
+	}) - 1
+
+	for ; index >= 0; index-- {
+		d := t.days[index]
+
+		fromBase, ok := d.base(t.base, from)
+		if !ok {
+			continue
+		}
+
+		toBase, ok := d.base(t.base, to)
+		if !ok {
+			continue
+		}
+
+		rate.Date = d.date
+		rate.Value = new(big.Rat).Quo(toBase, fromBase)
+		return rate, nil
+	}
+
+	return rate, fmt.Errorf("%s: %s to %s on %s", ErrNoRate, from, to, on.Format(dateLayout))
+}
+
+// base returns the units of the currency paid for a single unit of the base
+// currency on the day.
+func (d day) base(base, code string) (*big.Rat, bool) {
+	if code == base {
+		return big.NewRat(1, 1), true
+	}
+
+	value, ok := d.rates[code]
+	return value, ok
+}
+
+// truncate returns the date at the start of its day in UTC, which rates are
+// published for.
+func truncate(date time.Time) time.Time {
+	y, m, dd := date.UTC().Date()
+	return time.Date(y, m, dd, 0, 0, 0, 0, time.UTC)
+}
+
+//==============================================================================
@@ -0,0 +1,183 @@
+package rates
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influx6/pocket/api/currency"
+)
+
+//==============================================================================
+
+// money returns the amount of the giving currency parsed from the decimal,
+// failing the test if either is invalid.
+func money(t *testing.T, amount string, code string) currency.Money {
+	t.Helper()
+
+	cu, err := currency.ISO4217.Find(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := currency.ParseMoney(amount, cu)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return m
+}
+
+// isNoRate returns true/false if the error reports no rate was known.
+func isNoRate(err error) bool {
+	return err != nil && strings.HasPrefix(err.Error(), ErrNoRate.Error())
+}
+
+//==============================================================================
+
+// TestCrossRates checks rates between two currencies other than the euro are
+// crossed through it, and the rate back is the inverse of the rate there.
+func TestCrossRates(t *testing.T) {
+	table := parseFixture(t)
+
+	tests := []struct {
+		from  string
+		to    string
+		on    time.Time
+		value string
+	}{
+		{"USD", "EUR", date(2024, 1, 3), "1/1.0919"},
+		{"USD", "GBP", date(2024, 1, 3), "0.8635/1.0919"},
+		{"GBP", "USD", date(2024, 1, 3), "1.0919/0.8635"},
+		{"GBP", "JPY", date(2024, 1, 2), "155.66/0.8676"},
+		{"USD", "USD", date(2024, 1, 3), "1"},
+	}
+
+	for _, test := range tests {
+		parts := strings.Split(test.value, "/")
+
+		want := rat(t, parts[0])
+		if len(parts) == 2 {
+			want.Quo(want, rat(t, parts[1]))
+		}
+
+		rate, err := table.Rate(test.from, test.to, test.on)
+		if err != nil {
+			t.Errorf("Expected a rate from %s to %s, got %s", test.from, test.to, err)
+			continue
+		}
+
+		if rate.Value.Cmp(want) != 0 {
+			t.Errorf("Expected 1 %s = %s %s, got %s", test.from, want.FloatString(6), test.to, rate)
+		}
+	}
+}
+
+// TestConvert checks amounts are converted through the euro at the rate of
+// the day, rounded to the minor units of the currency converted into.
+func TestConvert(t *testing.T) {
+	table := parseFixture(t)
+
+	tests := []struct {
+		amount   currency.Money
+		to       string
+		on       time.Time
+		expected currency.Money
+	}{
+		{money(t, "100.00", "USD"), "GBP", date(2024, 1, 3), money(t, "79.08", "GBP")},
+		{money(t, "100.00", "USD"), "JPY", date(2024, 1, 3), money(t, "14243", "JPY")},
+		{money(t, "100.00", "USD"), "EUR", date(2024, 1, 2), money(t, "91.27", "EUR")},
+		{money(t, "5000", "JPY"), "USD", date(2024, 1, 3), money(t, "35.10", "USD")},
+		{money(t, "100.00", "GBP"), "GBP", date(2016, 1, 1), money(t, "100.00", "GBP")},
+	}
+
+	for _, test := range tests {
+		to := test.expected.Currency
+
+		converted, err := Convert(table, test.amount, to, test.on)
+		if err != nil {
+			t.Errorf("Expected %s to convert into %s, got %s", test.amount, test.to, err)
+			continue
+		}
+
+		if converted != test.expected {
+			t.Errorf("Expected %s to convert into %s, got %s", test.amount, test.expected, converted)
+		}
+	}
+
+	if _, err := Convert(table, currency.Money{Amount: 100}, money(t, "0", "USD").Currency, date(2024, 1, 3)); err != currency.ErrCurrencyMismatch {
+		t.Fatalf("Expected ErrCurrencyMismatch converting an amount without a currency, got %v", err)
+	}
+}
+
+// TestMissingCurrency checks rates to or from a currency which was never
+// published are refused with ErrNoRate, and leave the amount unconverted.
+func TestMissingCurrency(t *testing.T) {
+	table := parseFixture(t)
+
+	tests := []struct {
+		from string
+		to   string
+	}{
+		{"USD", "CHF"},
+		{"CHF", "USD"},
+		{"EUR", "CHF"},
+	}
+
+	for _, test := range tests {
+		if _, err := table.Rate(test.from, test.to, date(2024, 1, 3)); !isNoRate(err) {
+			t.Errorf("Expected ErrNoRate from %s to %s, got %v", test.from, test.to, err)
+		}
+	}
+
+	amount := money(t, "100.00", "USD")
+
+	converted, err := Convert(table, amount, money(t, "0", "CHF").Currency, date(2024, 1, 3))
+	if !isNoRate(err) {
+		t.Fatalf("Expected ErrNoRate converting into CHF, got %v", err)
+	}
+
+	if converted != amount {
+		t.Fatalf("Expected the amount to be left as it was, got %s", converted)
+	}
+}
+
+// TestStaleRates checks dates without rates of their own use the last rates
+// published before them, reporting the date of those rates, while dates before
+// any rate was published have none.
+func TestStaleRates(t *testing.T) {
+	table := parseFixture(t)
+
+	tests := []struct {
+		from      string
+		to        string
+		on        time.Time
+		published time.Time
+	}{
+		{"EUR", "USD", date(2024, 1, 3).Add(18 * time.Hour), date(2024, 1, 3)},
+		{"EUR", "USD", date(2024, 1, 6), date(2024, 1, 3)},
+		{"USD", "GBP", date(2024, 3, 1), date(2024, 1, 3)},
+		{"USD", "ISK", date(2024, 1, 3), date(2024, 1, 2)},
+		{"ISK", "JPY", date(2024, 1, 8), date(2024, 1, 2)},
+	}
+
+	for _, test := range tests {
+		rate, err := table.Rate(test.from, test.to, test.on)
+		if err != nil {
+			t.Errorf("Expected a rate from %s to %s on %v, got %s", test.from, test.to, test.on, err)
+			continue
+		}
+
+		if !rate.Date.Equal(test.published) {
+			t.Errorf("Expected the rate from %s to %s on %v to be the one of %v, got %s", test.from, test.to, test.on, test.published, rate)
+		}
+	}
+
+	if _, err := table.Rate("EUR", "USD", date(2024, 1, 1)); !isNoRate(err) {
+		t.Fatalf("Expected ErrNoRate before the first rates, got %v", err)
+	}
+
+	if _, err := NewTable(ECBBase).Rate("EUR", "USD", date(2024, 1, 3)); !isNoRate(err) {
+		t.Fatalf("Expected ErrNoRate from an empty table, got %v", err)
+	}
+}
@@ -0,0 +1,598 @@
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/influx6/pocket/api/accounts"
+	"github.com/influx6/pocket/api/budgets"
+)
+
+//==============================================================================
+
+// JournalLimit defines how many entries the journal of a File store holds
+// before it is folded into the snapshot.
+const JournalLimit = 1000
+
+// Operations recorded by the entries of the journal of a File store.
+const (
+	opPocket          = "pocket"
+	opBudget          = "budget"
+	opItem            = "item"
+	opCategory        = "category"
+	opEvent           = "event"
+	opCommit          = "commit"
+	opRule            = "rule"
+	opDeleteRule      = "delete_rule"
+	opAlerts          = "alerts"
+	opNotification    = "notification"
+	opWebhook         = "webhook"
+	opDeleteWebhook   = "delete_webhook"
+	opRecurring       = "recurring"
+	opDeleteRecurring = "delete_recurring"
+	opUser            = "user"
+)
+
+// journalEntry defines a single change appended to the journal of a File
+// store, holding the record the operation was given.
+type journalEntry struct {
+	Seq    int64           `json:"seq"`
+	Op     string          `json:"op"`
+	Record json.RawMessage `json:"record"`
+}
+
+// commitEntry defines the record of a journal entry committing an event along
+// with the budget or item record it changed.
+type commitEntry struct {
+	Event  budgets.EventRecord   `json:"event"`
+	Budget *budgets.BudgetRecord `json:"budget,omitempty"`
+	Item   *budgets.ItemRecord   `json:"item,omitempty"`
+}
+
+//==============================================================================
+
+// File provides a Store which serves all records from memory, appending every
+// change to a journal file beside the store file. Once the journal holds
+// JournalLimit entries it is folded into a snapshot of the records written to
+// the store file. Delivery logs are appended to a file of their own and kept
+// out of the snapshot. Records are loaded back from all three when opened.
+type File struct {
+	*Memory
+	path string
+	fl   sync.Mutex
+
+	seq     int64
+	entries int
+	journal *os.File
+
+	logged     int
+	kept       int
+	deliveries *os.File
+}
+
+// NewFile returns a new File store which persists into the giving path,
+// loading any records already stored there.
+func NewFile(path string) (*File, error) {
+	fs := File{
+		Memory: NewMemory(),
+		path:   path,
+	}
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	if len(content) > 0 {
+		var snap snapshot
+
+		if err := json.Unmarshal(content, &snap); err != nil {
+			return nil, err
+		}
+
+		fs.seq = snap.Journal
+		fs.Memory.restore(snap)
+	}
+
+	fs.journal, err = openLog(path+".journal", fs.replay)
+	if err != nil {
+		return nil, err
+	}
+
+	fs.deliveries, err = openLog(path+".deliveries", fs.reload)
+	if err != nil {
+		fs.journal.Close()
+		return nil, err
+	}
+
+	return &fs, nil
+}
+
+// Close folds the journal into the snapshot and closes the files of the store.
+func (f *File) Close() error {
+	f.fl.Lock()
+	defer f.fl.Unlock()
+
+	err := f.compact()
+
+	f.journal.Close()
+	f.deliveries.Close()
+
+	return err
+}
+
+// SavePocket stores the giving pocket record.
+func (f *File) SavePocket(pocket budgets.PocketRecord) error {
+	return f.record(opPocket, pocket, func() error {
+		return f.Memory.SavePocket(pocket)
+	})
+}
+
+// SaveBudget stores the giving budget record.
+func (f *File) SaveBudget(budget budgets.BudgetRecord) error {
+	return f.record(opBudget, budget, func() error {
+		return f.Memory.SaveBudget(budget)
+	})
+}
+
+// SaveItem stores the giving budget item record.
+func (f *File) SaveItem(item budgets.ItemRecord) error {
+	return f.record(opItem, item, func() error {
+		return f.Memory.SaveItem(item)
+	})
+}
+
+// SaveCategory stores the giving category record.
+func (f *File) SaveCategory(category budgets.CategoryRecord) error {
+	return f.record(opCategory, category, func() error {
+		return f.Memory.SaveCategory(category)
+	})
+}
+
+// AppendEvent appends the giving event into the log.
+func (f *File) AppendEvent(event budgets.EventRecord) error {
+	return f.record(opEvent, event, func() error {
+		return f.Memory.AppendEvent(event)
+	})
+}
+
+// CommitEvent appends the giving event into the log and stores the budget or
+// item record it changed, if given, within a single entry of the journal.
+func (f *File) CommitEvent(event budgets.EventRecord, budget *budgets.BudgetRecord, item *budgets.ItemRecord) error {
+	return f.record(opCommit, commitEntry{Event: event, Budget: budget, Item: item}, func() error {
+		return f.Memory.CommitEvent(event, budget, item)
+	})
+}
+
+// SaveRule stores the giving alert rule record.
+func (f *File) SaveRule(rule budgets.RuleRecord) error {
+	return f.record(opRule, rule, func() error {
+		return f.Memory.SaveRule(rule)
+	})
+}
+
+// DeleteRule removes the alert rule record with the giving id.
+func (f *File) DeleteRule(id string) error {
+	return f.record(opDeleteRule, id, func() error {
+		return f.Memory.DeleteRule(id)
+	})
+}
+
+// SaveAlerts stores the giving alert record, removing it when it holds no
+// notices.
+func (f *File) SaveAlerts(alerts budgets.AlertRecord) error {
+	return f.record(opAlerts, alerts, func() error {
+		return f.Memory.SaveAlerts(alerts)
+	})
+}
+
+// SaveNotification stores the giving notification record.
+func (f *File) SaveNotification(notice budgets.NotificationRecord) error {
+	return f.record(opNotification, notice, func() error {
+		return f.Memory.SaveNotification(notice)
+	})
+}
+
+// SaveWebhook stores the giving webhook record.
+func (f *File) SaveWebhook(hook budgets.WebhookRecord) error {
+	return f.record(opWebhook, hook, func() error {
+		return f.Memory.SaveWebhook(hook)
+	})
+}
+
+// DeleteWebhook removes the webhook record with the giving id and its delivery
+// log.
+func (f *File) DeleteWebhook(id string) error {
+	return f.record(opDeleteWebhook, id, func() error {
+		return f.Memory.DeleteWebhook(id)
+	})
+}
+
+// SaveDelivery appends the giving delivery record to the delivery log of its
+// webhook, which is written into the deliveries file rather than the journal.
+func (f *File) SaveDelivery(delivery budgets.DeliveryRecord) error {
+	f.fl.Lock()
+	defer f.fl.Unlock()
+
+	if err := f.Memory.SaveDelivery(delivery); err != nil {
+		return err
+	}
+
+	if err := appendLine(f.deliveries, delivery); err != nil {
+		return err
+	}
+
+	// Deliveries beyond MaxDeliveries are dropped from memory but remain in
+	// the file until it is rewritten along with the snapshot.
+	if f.logged++; f.logged >= 2*f.kept+JournalLimit {
+		return f.compact()
+	}
+
+	return nil
+}
+
+// SaveRecurring stores the giving recurring item record.
+func (f *File) SaveRecurring(recurring budgets.RecurringRecord) error {
+	return f.record(opRecurring, recurring, func() error {
+		return f.Memory.SaveRecurring(recurring)
+	})
+}
+
+// DeleteRecurring removes the recurring item record with the giving id.
+func (f *File) DeleteRecurring(id string) error {
+	return f.record(opDeleteRecurring, id, func() error {
+		return f.Memory.DeleteRecurring(id)
+	})
+}
+
+// SaveUser stores the giving user record.
+func (f *File) SaveUser(user accounts.UserRecord) error {
+	return f.record(opUser, user, func() error {
+		return f.Memory.SaveUser(user)
+	})
+}
+
+//==============================================================================
+
+// record appends the change as an entry of the operation to the journal and
+// only then applies it to the records held in memory, cutting the entry from
+// the journal again if it can not be applied, so the journal never holds a
+// change the store refused. The journal is folded into the snapshot once it
+// holds JournalLimit entries. Changes are appended and applied under the same
+// lock so the journal holds them in the order they were applied.
+func (f *File) record(op string, record interface{}, apply func() error) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	f.fl.Lock()
+	defer f.fl.Unlock()
+
+	info, err := f.journal.Stat()
+	if err != nil {
+		return err
+	}
+
+	if err := appendLine(f.journal, journalEntry{Seq: f.seq + 1, Op: op, Record: data}); err != nil {
+		f.rollback(info.Size())
+		return err
+	}
+
+	if err := apply(); err != nil {
+		if rerr := f.rollback(info.Size()); rerr != nil {
+			return fmt.Errorf("%s: the refused %s entry could not be cut from the journal: %s", err, op, rerr)
+		}
+
+		return err
+	}
+
+	f.seq++
+
+	if f.entries++; f.entries >= JournalLimit {
+		return f.compact()
+	}
+
+	return nil
+}
+
+// rollback cuts the journal back to the giving size, dropping the entry last
+// appended to it.
+func (f *File) rollback(size int64) error {
+	if err := f.journal.Truncate(size); err != nil {
+		return err
+	}
+
+	return f.journal.Sync()
+}
+
+// replay applies the journal entry held by the line to the records in memory,
+// skipping entries already folded into the snapshot.
+func (f *File) replay(line []byte) error {
+	var entry journalEntry
+
+	if err := json.Unmarshal(line, &entry); err != nil {
+		return err
+	}
+
+	f.entries++
+
+	if entry.Seq <= f.seq {
+		return nil
+	}
+
+	f.seq = entry.Seq
+
+	switch entry.Op {
+	case opPocket:
+		var pocket budgets.PocketRecord
+		if err := json.Unmarshal(entry.Record, &pocket); err != nil {
+			return err
+		}
+
+		return f.Memory.SavePocket(pocket)
+	case opBudget:
+		var budget budgets.BudgetRecord
+		if err := json.Unmarshal(entry.Record, &budget); err != nil {
+			return err
+		}
+
+		return f.Memory.SaveBudget(budget)
+	case opItem:
+		var item budgets.ItemRecord
+		if err := json.Unmarshal(entry.Record, &item); err != nil {
+			return err
+		}
+
+		return f.Memory.SaveItem(item)
+	case opCategory:
+		var category budgets.CategoryRecord
+		if err := json.Unmarshal(entry.Record, &category); err != nil {
+			return err
+		}
+
+		return f.Memory.SaveCategory(category)
+	case opEvent:
+		var event budgets.EventRecord
+		if err := json.Unmarshal(entry.Record, &event); err != nil {
+			return err
+		}
+
+		return f.Memory.AppendEvent(event)
+	case opCommit:
+		var commit commitEntry
+		if err := json.Unmarshal(entry.Record, &commit); err != nil {
+			return err
+		}
+
+		return f.Memory.CommitEvent(commit.Event, commit.Budget, commit.Item)
+	case opRule:
+		var rule budgets.RuleRecord
+		if err := json.Unmarshal(entry.Record, &rule); err != nil {
+			return err
+		}
+
+		return f.Memory.SaveRule(rule)
+	case opAlerts:
+		var alerts budgets.AlertRecord
+		if err := json.Unmarshal(entry.Record, &alerts); err != nil {
+			return err
+		}
+
+		return f.Memory.SaveAlerts(alerts)
+	case opNotification:
+		var notice budgets.NotificationRecord
+		if err := json.Unmarshal(entry.Record, &notice); err != nil {
+			return err
+		}
+
+		return f.Memory.SaveNotification(notice)
+	case opWebhook:
+		var hook budgets.WebhookRecord
+		if err := json.Unmarshal(entry.Record, &hook); err != nil {
+			return err
+		}
+
+		return f.Memory.SaveWebhook(hook)
+	case opRecurring:
+		var recurring budgets.RecurringRecord
+		if err := json.Unmarshal(entry.Record, &recurring); err != nil {
+			return err
+		}
+
+		return f.Memory.SaveRecurring(recurring)
+	case opUser:
+		var user accounts.UserRecord
+		if err := json.Unmarshal(entry.Record, &user); err != nil {
+			return err
+		}
+
+		return f.Memory.SaveUser(user)
+	case opDeleteRule, opDeleteWebhook, opDeleteRecurring:
+		var id string
+		if err := json.Unmarshal(entry.Record, &id); err != nil {
+			return err
+		}
+
+		var err error
+
+		switch entry.Op {
+		case opDeleteRule:
+			err = f.Memory.DeleteRule(id)
+		case opDeleteWebhook:
+			err = f.Memory.DeleteWebhook(id)
+		default:
+			err = f.Memory.DeleteRecurring(id)
+		}
+
+		if err == ErrNotFound {
+			return nil
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// reload adds the delivery record held by the line to the delivery log of its
+// webhook, skipping deliveries of webhooks since deleted.
+func (f *File) reload(line []byte) error {
+	var delivery budgets.DeliveryRecord
+
+	if err := json.Unmarshal(line, &delivery); err != nil {
+		return err
+	}
+
+	f.logged++
+
+	if _, err := f.Memory.Webhook(delivery.Webhook); err == ErrNotFound {
+		return nil
+	}
+
+	return f.Memory.SaveDelivery(delivery)
+}
+
+// compact rewrites the deliveries file with the delivery logs held in memory
+// and the store file with a snapshot of every other record, which then
+// replaces the entries of the journal.
+func (f *File) compact() error {
+	snap := f.Memory.snapshot()
+	snap.Journal = f.seq
+
+	var logs bytes.Buffer
+
+	encoder := json.NewEncoder(&logs)
+	for _, delivery := range snap.Deliveries {
+		if err := encoder.Encode(delivery); err != nil {
+			return err
+		}
+	}
+
+	if err := writeFile(f.path+".deliveries", logs.Bytes()); err != nil {
+		return err
+	}
+
+	deliveries, err := os.OpenFile(f.path+".deliveries", os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+
+	f.deliveries.Close()
+	f.deliveries = deliveries
+	f.logged, f.kept = len(snap.Deliveries), len(snap.Deliveries)
+
+	snap.Deliveries = nil
+
+	content, err := json.MarshalIndent(snap, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	if err := writeFile(f.path, content); err != nil {
+		return err
+	}
+
+	// Entries left behind by a failure to truncate are skipped when replayed,
+	// being no later than the sequence held by the snapshot.
+	if err := f.journal.Truncate(0); err != nil {
+		return err
+	}
+
+	f.entries = 0
+	return nil
+}
+
+//==============================================================================
+
+// openLog calls fn with every line of the file at the giving path, creating it
+// if needed, and returns the file opened for appending. A trailing line without
+// its newline, as left by a crash within a write, is cut from the file.
+func openLog(path string, fn func(line []byte) error) (*os.File, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	var offset int64
+
+	reader := bufio.NewReader(file)
+
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+
+		if err := fn(line); err != nil {
+			file.Close()
+			return nil, err
+		}
+
+		offset += int64(len(line))
+	}
+
+	if err := file.Truncate(offset); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return file, nil
+}
+
+// appendLine writes the giving value as a line of JSON to the end of the file,
+// syncing it to disk.
+func appendLine(file *os.File, value interface{}) error {
+	content, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(append(content, '\n')); err != nil {
+		return err
+	}
+
+	return file.Sync()
+}
+
+// writeFile writes the content into a temporary file which then replaces the
+// file at the giving path, ensuring a crash never leaves a partial file behind.
+func writeFile(path string, content []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path))
+	if err != nil {
+		return err
+	}
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+//==============================================================================
@@ -0,0 +1,168 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/influx6/pocket/api/budgets"
+)
+
+// TestFileJournal checks changes are appended to the journal and delivery logs
+// to a file of their own, both being read back when reopened and the journal
+// being folded into a snapshot without the delivery logs when closed.
+func TestFileJournal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pocket-store")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "pocket.json")
+
+	fs, err := NewFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := fs.SaveWebhook(budgets.WebhookRecord{ID: "hook", Pocket: "pocket"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := fs.SaveRule(budgets.RuleRecord{ID: "rule", Budget: "budget"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := fs.DeleteRule("rule"); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := fs.SaveDelivery(budgets.DeliveryRecord{ID: fmt.Sprintf("delivery-%d", i), Webhook: "hook"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("Expected no snapshot to be written before the journal is full: %v", err)
+	}
+
+	// A crash within a write leaves a partial line at the end of the journal.
+	journal, err := os.OpenFile(path+".journal", os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	journal.WriteString(`{"seq":99,"op":"pocket","rec`)
+	journal.Close()
+
+	check := func(fs *File) {
+		if _, err := fs.Webhook("hook"); err != nil {
+			t.Fatalf("Expected the webhook to be read back: %s", err)
+		}
+
+		if _, err := fs.Rule("rule"); err != ErrNotFound {
+			t.Fatalf("Expected the deleted rule to stay deleted, got %v", err)
+		}
+
+		if log, err := fs.Deliveries("hook"); err != nil || len(log) != 3 {
+			t.Fatalf("Expected 3 deliveries to be read back, got %d: %v", len(log), err)
+		}
+	}
+
+	reopened, err := NewFile(path)
+	if err != nil {
+		t.Fatalf("Failed to reopen the store: %s", err)
+	}
+
+	check(reopened)
+
+	if err := reopened.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if info, err := os.Stat(path + ".journal"); err != nil || info.Size() != 0 {
+		t.Fatalf("Expected the journal to be folded into the snapshot: %v", err)
+	}
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var snap snapshot
+	if err := json.Unmarshal(content, &snap); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(snap.Webhooks) != 1 || len(snap.Deliveries) != 0 {
+		t.Fatalf("Expected a snapshot with the webhook but no deliveries, got %d and %d", len(snap.Webhooks), len(snap.Deliveries))
+	}
+
+	compacted, err := NewFile(path)
+	if err != nil {
+		t.Fatalf("Failed to reopen the compacted store: %s", err)
+	}
+
+	defer compacted.Close()
+
+	check(compacted)
+}
+
+// TestFileRefused checks a change the store refuses is cut from the journal
+// again, leaving the store to reopen with only the changes it applied.
+func TestFileRefused(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pocket-store")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "pocket.json")
+
+	fs, err := NewFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := fs.SaveRule(budgets.RuleRecord{ID: "rule", Budget: "budget"}); err != nil {
+		t.Fatal(err)
+	}
+
+	before, err := os.Stat(path + ".journal")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := fs.DeleteRule("missing"); err != ErrNotFound {
+		t.Fatalf("Expected ErrNotFound deleting a missing rule, got %v", err)
+	}
+
+	if after, err := os.Stat(path + ".journal"); err != nil || after.Size() != before.Size() {
+		t.Fatalf("Expected the refused change to be cut from the journal: %v", err)
+	}
+
+	if err := fs.SaveRule(budgets.RuleRecord{ID: "other", Budget: "budget"}); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := NewFile(path)
+	if err != nil {
+		t.Fatalf("Failed to reopen the store: %s", err)
+	}
+
+	defer reopened.Close()
+
+	if rules, err := reopened.Rules("budget"); err != nil || len(rules) != 2 {
+		t.Fatalf("Expected both rules to be read back, got %d: %v", len(rules), err)
+	}
+
+	if reopened.seq != 2 {
+		t.Fatalf("Expected the journal to hold 2 entries, got %d", reopened.seq)
+	}
+}
@@ -0,0 +1,810 @@
+package store
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influx6/pocket/api/accounts"
+	"github.com/influx6/pocket/api/budgets"
+)
+
+//==============================================================================
+
+// snapshot defines the complete set of records held by a Memory store.
+type snapshot struct {
+	Pockets    []budgets.PocketRecord       `json:"pockets"`
+	Budgets    []budgets.BudgetRecord       `json:"budgets"`
+	Items      []budgets.ItemRecord         `json:"items"`
+	Categories []budgets.CategoryRecord     `json:"categories"`
+	Events     []budgets.EventRecord        `json:"events"`
+	Rules      []budgets.RuleRecord         `json:"rules"`
+	Alerts     []budgets.AlertRecord        `json:"alerts"`
+	Notices    []budgets.NotificationRecord `json:"notifications"`
+	Webhooks   []budgets.WebhookRecord      `json:"webhooks"`
+	Deliveries []budgets.DeliveryRecord     `json:"deliveries"`
+	Recurring  []budgets.RecurringRecord    `json:"recurring"`
+	Users      []accounts.UserRecord        `json:"users"`
+
+	// Journal holds the sequence of the last journal entry of a File store
+	// folded into the snapshot.
+	Journal int64 `json:"journal,omitempty"`
+}
+
+//==============================================================================
+
+// Memory provides a Store which keeps all records within maps in memory.
+type Memory struct {
+	rl         sync.RWMutex
+	pockets    map[string]budgets.PocketRecord
+	budgets    map[string]budgets.BudgetRecord
+	items      map[string]budgets.ItemRecord
+	categories map[string]budgets.CategoryRecord
+	events     []budgets.EventRecord
+	rules      map[string]budgets.RuleRecord
+	alerts     map[string]budgets.AlertRecord
+	notices    map[string]budgets.NotificationRecord
+	webhooks   map[string]budgets.WebhookRecord
+	deliveries map[string][]budgets.DeliveryRecord
+	recurring  map[string]budgets.RecurringRecord
+	users      map[string]accounts.UserRecord
+}
+
+// NewMemory returns a new Memory instance.
+func NewMemory() *Memory {
+	mem := Memory{
+		pockets:    make(map[string]budgets.PocketRecord),
+		budgets:    make(map[string]budgets.BudgetRecord),
+		items:      make(map[string]budgets.ItemRecord),
+		categories: make(map[string]budgets.CategoryRecord),
+		rules:      make(map[string]budgets.RuleRecord),
+		alerts:     make(map[string]budgets.AlertRecord),
+		notices:    make(map[string]budgets.NotificationRecord),
+		webhooks:   make(map[string]budgets.WebhookRecord),
+		deliveries: make(map[string][]budgets.DeliveryRecord),
+		recurring:  make(map[string]budgets.RecurringRecord),
+		users:      make(map[string]accounts.UserRecord),
+	}
+
+	return &mem
+}
+
+// SavePocket stores the giving pocket record.
+func (m *Memory) SavePocket(pocket budgets.PocketRecord) error {
+	m.rl.Lock()
+	defer m.rl.Unlock()
+
+	m.pockets[pocket.ID] = pocket
+	return nil
+}
+
+// Pocket returns the pocket record with the giving id.
+func (m *Memory) Pocket(id string) (budgets.PocketRecord, error) {
+	m.rl.RLock()
+	defer m.rl.RUnlock()
+
+	pocket, ok := m.pockets[id]
+	if !ok {
+		return pocket, ErrNotFound
+	}
+
+	return pocket, nil
+}
+
+// Pockets returns all pocket records of the giving owner ordered by their
+// title.
+func (m *Memory) Pockets(owner string) ([]budgets.PocketRecord, error) {
+	m.rl.RLock()
+	defer m.rl.RUnlock()
+
+	records := make([]budgets.PocketRecord, 0)
+	for _, pocket := range m.pockets {
+		if pocket.Owner == owner {
+			records = append(records, pocket)
+		}
+	}
+
+	sort.Sort(pocketsByTitle(records))
+	return records, nil
+}
+
+// SaveBudget stores the giving budget record.
+func (m *Memory) SaveBudget(budget budgets.BudgetRecord) error {
+	m.rl.Lock()
+	defer m.rl.Unlock()
+
+	m.budgets[budget.ID] = budget
+	return nil
+}
+
+// Budget returns the budget record with the giving id.
+func (m *Memory) Budget(id string) (budgets.BudgetRecord, error) {
+	m.rl.RLock()
+	defer m.rl.RUnlock()
+
+	budget, ok := m.budgets[id]
+	if !ok {
+		return budget, ErrNotFound
+	}
+
+	return budget, nil
+}
+
+// Budgets returns all budget records of the giving pocket ordered by their
+// title.
+func (m *Memory) Budgets(pocket string) ([]budgets.BudgetRecord, error) {
+	m.rl.RLock()
+	defer m.rl.RUnlock()
+
+	records := make([]budgets.BudgetRecord, 0)
+	for _, budget := range m.budgets {
+		if budget.Pocket == pocket {
+			records = append(records, budget)
+		}
+	}
+
+	sort.Sort(budgetsByTitle(records))
+	return records, nil
+}
+
+// SaveItem stores the giving budget item record.
+func (m *Memory) SaveItem(item budgets.ItemRecord) error {
+	m.rl.Lock()
+	defer m.rl.Unlock()
+
+	m.items[item.ID] = item
+	return nil
+}
+
+// Item returns the budget item record with the giving id.
+func (m *Memory) Item(id string) (budgets.ItemRecord, error) {
+	m.rl.RLock()
+	defer m.rl.RUnlock()
+
+	item, ok := m.items[id]
+	if !ok {
+		return item, ErrNotFound
+	}
+
+	return item, nil
+}
+
+// Items returns all item records of the giving budget ordered by their time.
+func (m *Memory) Items(budget string) ([]budgets.ItemRecord, error) {
+	m.rl.RLock()
+	defer m.rl.RUnlock()
+
+	records := make([]budgets.ItemRecord, 0)
+	for _, item := range m.items {
+		if item.Budget == budget {
+			records = append(records, item)
+		}
+	}
+
+	sort.Sort(itemsByTime(records))
+	return records, nil
+}
+
+// SaveCategory stores the giving category record.
+func (m *Memory) SaveCategory(category budgets.CategoryRecord) error {
+	m.rl.Lock()
+	defer m.rl.Unlock()
+
+	m.categories[category.ID] = category
+	return nil
+}
+
+// Category returns the category record with the giving id.
+func (m *Memory) Category(id string) (budgets.CategoryRecord, error) {
+	m.rl.RLock()
+	defer m.rl.RUnlock()
+
+	category, ok := m.categories[id]
+	if !ok {
+		return category, ErrNotFound
+	}
+
+	return category, nil
+}
+
+// Categories returns all category records of the giving owner ordered by
+// their name.
+func (m *Memory) Categories(owner string) ([]budgets.CategoryRecord, error) {
+	m.rl.RLock()
+	defer m.rl.RUnlock()
+
+	records := make([]budgets.CategoryRecord, 0)
+	for _, category := range m.categories {
+		if category.Owner == owner {
+			records = append(records, category)
+		}
+	}
+
+	sort.Sort(categoriesByName(records))
+	return records, nil
+}
+
+// AppendEvent appends the giving event into the log, numbering it after the
+// events already appended.
+func (m *Memory) AppendEvent(event budgets.EventRecord) error {
+	m.rl.Lock()
+	defer m.rl.Unlock()
+
+	event.Seq = int64(len(m.events) + 1)
+	m.events = append(m.events, event)
+	return nil
+}
+
+// CommitEvent appends the giving event into the log and stores the budget or
+// item record it changed, if given, at once.
+func (m *Memory) CommitEvent(event budgets.EventRecord, budget *budgets.BudgetRecord, item *budgets.ItemRecord) error {
+	m.rl.Lock()
+	defer m.rl.Unlock()
+
+	event.Seq = int64(len(m.events) + 1)
+	m.events = append(m.events, event)
+
+	if budget != nil {
+		m.budgets[budget.ID] = *budget
+	}
+
+	if item != nil {
+		m.items[item.ID] = *item
+	}
+
+	return nil
+}
+
+// Events returns all events of the giving pocket in the order they were
+// appended.
+func (m *Memory) Events(pocket string) ([]budgets.EventRecord, error) {
+	m.rl.RLock()
+	defer m.rl.RUnlock()
+
+	records := make([]budgets.EventRecord, 0)
+	for _, event := range m.events {
+		if event.Pocket == pocket {
+			records = append(records, event)
+		}
+	}
+
+	return records, nil
+}
+
+// SaveRule stores the giving alert rule record.
+func (m *Memory) SaveRule(rule budgets.RuleRecord) error {
+	m.rl.Lock()
+	defer m.rl.Unlock()
+
+	m.rules[rule.ID] = rule
+	return nil
+}
+
+// Rule returns the alert rule record with the giving id.
+func (m *Memory) Rule(id string) (budgets.RuleRecord, error) {
+	m.rl.RLock()
+	defer m.rl.RUnlock()
+
+	rule, ok := m.rules[id]
+	if !ok {
+		return rule, ErrNotFound
+	}
+
+	return rule, nil
+}
+
+// Rules returns all alert rule records watching the giving budget ordered by
+// their kind.
+func (m *Memory) Rules(budget string) ([]budgets.RuleRecord, error) {
+	m.rl.RLock()
+	defer m.rl.RUnlock()
+
+	records := make([]budgets.RuleRecord, 0)
+	for _, rule := range m.rules {
+		if rule.Budget == budget {
+			records = append(records, rule)
+		}
+	}
+
+	sort.Sort(rulesByKind(records))
+	return records, nil
+}
+
+// DeleteRule removes the alert rule record with the giving id.
+func (m *Memory) DeleteRule(id string) error {
+	m.rl.Lock()
+	defer m.rl.Unlock()
+
+	if _, ok := m.rules[id]; !ok {
+		return ErrNotFound
+	}
+
+	delete(m.rules, id)
+	return nil
+}
+
+// WatchedBudgets returns the ids of all budgets watched by alert rules.
+func (m *Memory) WatchedBudgets() ([]string, error) {
+	m.rl.RLock()
+	defer m.rl.RUnlock()
+
+	seen := make(map[string]bool)
+	ids := make([]string, 0)
+
+	for _, rule := range m.rules {
+		if !seen[rule.Budget] {
+			seen[rule.Budget] = true
+			ids = append(ids, rule.Budget)
+		}
+	}
+
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// SaveAlerts stores the giving alert record, removing it when it holds no
+// notices.
+func (m *Memory) SaveAlerts(alerts budgets.AlertRecord) error {
+	m.rl.Lock()
+	defer m.rl.Unlock()
+
+	if len(alerts.Notices) == 0 {
+		delete(m.alerts, alerts.Budget)
+		return nil
+	}
+
+	m.alerts[alerts.Budget] = alerts
+	return nil
+}
+
+// Alerts returns the alert record of the budget with the giving id.
+func (m *Memory) Alerts(budget string) (budgets.AlertRecord, error) {
+	m.rl.RLock()
+	defer m.rl.RUnlock()
+
+	alerts, ok := m.alerts[budget]
+	if !ok {
+		return alerts, ErrNotFound
+	}
+
+	return alerts, nil
+}
+
+// SaveNotification stores the giving notification record.
+func (m *Memory) SaveNotification(notice budgets.NotificationRecord) error {
+	m.rl.Lock()
+	defer m.rl.Unlock()
+
+	m.notices[notice.ID] = notice
+	return nil
+}
+
+// Notification returns the notification record with the giving id.
+func (m *Memory) Notification(id string) (budgets.NotificationRecord, error) {
+	m.rl.RLock()
+	defer m.rl.RUnlock()
+
+	notice, ok := m.notices[id]
+	if !ok {
+		return notice, ErrNotFound
+	}
+
+	return notice, nil
+}
+
+// Notifications returns all notification records of the giving owner, the
+// newest first.
+func (m *Memory) Notifications(owner string) ([]budgets.NotificationRecord, error) {
+	m.rl.RLock()
+	defer m.rl.RUnlock()
+
+	records := make([]budgets.NotificationRecord, 0)
+	for _, notice := range m.notices {
+		if notice.Owner == owner {
+			records = append(records, notice)
+		}
+	}
+
+	sort.Sort(noticesByTime(records))
+	return records, nil
+}
+
+// SaveWebhook stores the giving webhook record.
+func (m *Memory) SaveWebhook(hook budgets.WebhookRecord) error {
+	m.rl.Lock()
+	defer m.rl.Unlock()
+
+	m.webhooks[hook.ID] = hook
+	return nil
+}
+
+// Webhook returns the webhook record with the giving id.
+func (m *Memory) Webhook(id string) (budgets.WebhookRecord, error) {
+	m.rl.RLock()
+	defer m.rl.RUnlock()
+
+	hook, ok := m.webhooks[id]
+	if !ok {
+		return hook, ErrNotFound
+	}
+
+	return hook, nil
+}
+
+// Webhooks returns all webhook records of the giving pocket, the oldest
+// first.
+func (m *Memory) Webhooks(pocket string) ([]budgets.WebhookRecord, error) {
+	m.rl.RLock()
+	defer m.rl.RUnlock()
+
+	records := make([]budgets.WebhookRecord, 0)
+	for _, hook := range m.webhooks {
+		if hook.Pocket == pocket {
+			records = append(records, hook)
+		}
+	}
+
+	sort.Sort(webhooksByCreated(records))
+	return records, nil
+}
+
+// DeleteWebhook removes the webhook record with the giving id and its delivery
+// log.
+func (m *Memory) DeleteWebhook(id string) error {
+	m.rl.Lock()
+	defer m.rl.Unlock()
+
+	if _, ok := m.webhooks[id]; !ok {
+		return ErrNotFound
+	}
+
+	delete(m.webhooks, id)
+	delete(m.deliveries, id)
+	return nil
+}
+
+// SaveDelivery appends the giving delivery record to the delivery log of its
+// webhook, dropping the oldest beyond MaxDeliveries.
+func (m *Memory) SaveDelivery(delivery budgets.DeliveryRecord) error {
+	m.rl.Lock()
+	defer m.rl.Unlock()
+
+	log := append(m.deliveries[delivery.Webhook], delivery)
+	if len(log) > MaxDeliveries {
+		log = append([]budgets.DeliveryRecord(nil), log[len(log)-MaxDeliveries:]...)
+	}
+
+	m.deliveries[delivery.Webhook] = log
+	return nil
+}
+
+// Deliveries returns the delivery log of the webhook with the giving id, the
+// newest first.
+func (m *Memory) Deliveries(webhook string) ([]budgets.DeliveryRecord, error) {
+	m.rl.RLock()
+	defer m.rl.RUnlock()
+
+	records := make([]budgets.DeliveryRecord, 0, len(m.deliveries[webhook]))
+	records = append(records, m.deliveries[webhook]...)
+
+	sort.Sort(deliveriesByTime(records))
+	return records, nil
+}
+
+// SaveRecurring stores the giving recurring item record.
+func (m *Memory) SaveRecurring(recurring budgets.RecurringRecord) error {
+	m.rl.Lock()
+	defer m.rl.Unlock()
+
+	m.recurring[recurring.ID] = recurring
+	return nil
+}
+
+// Recurring returns the recurring item record with the giving id.
+func (m *Memory) Recurring(id string) (budgets.RecurringRecord, error) {
+	m.rl.RLock()
+	defer m.rl.RUnlock()
+
+	recurring, ok := m.recurring[id]
+	if !ok {
+		return recurring, ErrNotFound
+	}
+
+	return recurring, nil
+}
+
+// Recurrings returns all recurring item records of the giving budget ordered
+// by title.
+func (m *Memory) Recurrings(budget string) ([]budgets.RecurringRecord, error) {
+	m.rl.RLock()
+	defer m.rl.RUnlock()
+
+	records := make([]budgets.RecurringRecord, 0)
+	for _, recurring := range m.recurring {
+		if recurring.Budget == budget {
+			records = append(records, recurring)
+		}
+	}
+
+	sort.Sort(recurringByTitle(records))
+	return records, nil
+}
+
+// DueRecurrings returns all recurring item records with an item due on or
+// before the giving time.
+func (m *Memory) DueRecurrings(through time.Time) ([]budgets.RecurringRecord, error) {
+	m.rl.RLock()
+	defer m.rl.RUnlock()
+
+	records := make([]budgets.RecurringRecord, 0)
+	for _, recurring := range m.recurring {
+		if !recurring.Due.IsZero() && !recurring.Due.After(through) {
+			records = append(records, recurring)
+		}
+	}
+
+	sort.Sort(recurringByTitle(records))
+	return records, nil
+}
+
+// DeleteRecurring removes the recurring item record with the giving id.
+func (m *Memory) DeleteRecurring(id string) error {
+	m.rl.Lock()
+	defer m.rl.Unlock()
+
+	if _, ok := m.recurring[id]; !ok {
+		return ErrNotFound
+	}
+
+	delete(m.recurring, id)
+	return nil
+}
+
+// SaveUser stores the giving user record.
+func (m *Memory) SaveUser(user accounts.UserRecord) error {
+	m.rl.Lock()
+	defer m.rl.Unlock()
+
+	m.users[strings.ToLower(user.Email)] = user
+	return nil
+}
+
+// User returns the user record with the giving email.
+func (m *Memory) User(email string) (accounts.UserRecord, error) {
+	m.rl.RLock()
+	defer m.rl.RUnlock()
+
+	user, ok := m.users[strings.ToLower(email)]
+	if !ok {
+		return user, ErrNotFound
+	}
+
+	return user, nil
+}
+
+// UserByToken returns the user record which holds the giving session token.
+func (m *Memory) UserByToken(token string) (accounts.UserRecord, error) {
+	m.rl.RLock()
+	defer m.rl.RUnlock()
+
+	if token == "" {
+		return accounts.UserRecord{}, ErrNotFound
+	}
+
+	for _, user := range m.users {
+		for _, session := range user.Sessions {
+			if session.Token == token {
+				return user, nil
+			}
+		}
+	}
+
+	return accounts.UserRecord{}, ErrNotFound
+}
+
+// UserByID returns the user record with the giving id.
+func (m *Memory) UserByID(id string) (accounts.UserRecord, error) {
+	m.rl.RLock()
+	defer m.rl.RUnlock()
+
+	for _, user := range m.users {
+		if user.ID == id {
+			return user, nil
+		}
+	}
+
+	return accounts.UserRecord{}, ErrNotFound
+}
+
+// snapshot returns a copy of all records held by the store.
+func (m *Memory) snapshot() snapshot {
+	m.rl.RLock()
+	defer m.rl.RUnlock()
+
+	var snap snapshot
+
+	for _, pocket := range m.pockets {
+		snap.Pockets = append(snap.Pockets, pocket)
+	}
+
+	for _, budget := range m.budgets {
+		snap.Budgets = append(snap.Budgets, budget)
+	}
+
+	for _, item := range m.items {
+		snap.Items = append(snap.Items, item)
+	}
+
+	for _, category := range m.categories {
+		snap.Categories = append(snap.Categories, category)
+	}
+
+	snap.Events = append(snap.Events, m.events...)
+
+	for _, rule := range m.rules {
+		snap.Rules = append(snap.Rules, rule)
+	}
+
+	for _, alerts := range m.alerts {
+		snap.Alerts = append(snap.Alerts, alerts)
+	}
+
+	for _, notice := range m.notices {
+		snap.Notices = append(snap.Notices, notice)
+	}
+
+	for _, hook := range m.webhooks {
+		snap.Webhooks = append(snap.Webhooks, hook)
+	}
+
+	for _, log := range m.deliveries {
+		snap.Deliveries = append(snap.Deliveries, log...)
+	}
+
+	for _, recurring := range m.recurring {
+		snap.Recurring = append(snap.Recurring, recurring)
+	}
+
+	for _, user := range m.users {
+		snap.Users = append(snap.Users, user)
+	}
+
+	return snap
+}
+
+// restore loads all records within the snapshot into the store.
+func (m *Memory) restore(snap snapshot) {
+	m.rl.Lock()
+	defer m.rl.Unlock()
+
+	for _, pocket := range snap.Pockets {
+		m.pockets[pocket.ID] = pocket
+	}
+
+	for _, budget := range snap.Budgets {
+		m.budgets[budget.ID] = budget
+	}
+
+	for _, item := range snap.Items {
+		m.items[item.ID] = item
+	}
+
+	for _, category := range snap.Categories {
+		m.categories[category.ID] = category
+	}
+
+	m.events = append(m.events, snap.Events...)
+
+	for _, rule := range snap.Rules {
+		m.rules[rule.ID] = rule
+	}
+
+	for _, alerts := range snap.Alerts {
+		m.alerts[alerts.Budget] = alerts
+	}
+
+	for _, notice := range snap.Notices {
+		m.notices[notice.ID] = notice
+	}
+
+	for _, hook := range snap.Webhooks {
+		m.webhooks[hook.ID] = hook
+	}
+
+	for _, delivery := range snap.Deliveries {
+		m.deliveries[delivery.Webhook] = append(m.deliveries[delivery.Webhook], delivery)
+	}
+
+	for _, recurring := range snap.Recurring {
+		m.recurring[recurring.ID] = recurring
+	}
+
+	for _, user := range snap.Users {
+		m.users[strings.ToLower(user.Email)] = user
+	}
+}
+
+//==============================================================================
+
+// pocketsByTitle implements sort.Interface to order pockets by title.
+type pocketsByTitle []budgets.PocketRecord
+
+func (p pocketsByTitle) Len() int           { return len(p) }
+func (p pocketsByTitle) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+func (p pocketsByTitle) Less(i, j int) bool { return p[i].Title < p[j].Title }
+
+// budgetsByTitle implements sort.Interface to order budgets by title.
+type budgetsByTitle []budgets.BudgetRecord
+
+func (b budgetsByTitle) Len() int           { return len(b) }
+func (b budgetsByTitle) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b budgetsByTitle) Less(i, j int) bool { return b[i].Title < b[j].Title }
+
+// categoriesByName implements sort.Interface to order categories by name.
+type categoriesByName []budgets.CategoryRecord
+
+func (c categoriesByName) Len() int           { return len(c) }
+func (c categoriesByName) Swap(i, j int)      { c[i], c[j] = c[j], c[i] }
+func (c categoriesByName) Less(i, j int) bool { return c[i].Name < c[j].Name }
+
+// rulesByKind implements sort.Interface to order rules by kind, keeping rules
+// of the same kind in the order of their id.
+type rulesByKind []budgets.RuleRecord
+
+func (r rulesByKind) Len() int      { return len(r) }
+func (r rulesByKind) Swap(i, j int) { r[i], r[j] = r[j], r[i] }
+func (r rulesByKind) Less(i, j int) bool {
+	if r[i].Kind != r[j].Kind {
+		return r[i].Kind < r[j].Kind
+	}
+
+	return r[i].ID < r[j].ID
+}
+
+// noticesByTime implements sort.Interface to order notifications by their
+// time, the newest first.
+type noticesByTime []budgets.NotificationRecord
+
+func (n noticesByTime) Len() int           { return len(n) }
+func (n noticesByTime) Swap(i, j int)      { n[i], n[j] = n[j], n[i] }
+func (n noticesByTime) Less(i, j int) bool { return n[i].Time.After(n[j].Time) }
+
+// webhooksByCreated implements sort.Interface to order webhooks by the time
+// they were created.
+type webhooksByCreated []budgets.WebhookRecord
+
+func (w webhooksByCreated) Len() int           { return len(w) }
+func (w webhooksByCreated) Swap(i, j int)      { w[i], w[j] = w[j], w[i] }
+func (w webhooksByCreated) Less(i, j int) bool { return w[i].Created.Before(w[j].Created) }
+
+// deliveriesByTime implements sort.Interface to order delivery attempts by
+// their time, the newest first.
+type deliveriesByTime []budgets.DeliveryRecord
+
+func (d deliveriesByTime) Len() int           { return len(d) }
+func (d deliveriesByTime) Swap(i, j int)      { d[i], d[j] = d[j], d[i] }
+func (d deliveriesByTime) Less(i, j int) bool { return d[i].Time.After(d[j].Time) }
+
+// recurringByTitle implements sort.Interface to order recurring items by
+// title, keeping those of the same title in the order of their id.
+type recurringByTitle []budgets.RecurringRecord
+
+func (r recurringByTitle) Len() int      { return len(r) }
+func (r recurringByTitle) Swap(i, j int) { r[i], r[j] = r[j], r[i] }
+func (r recurringByTitle) Less(i, j int) bool {
+	if r[i].Title != r[j].Title {
+		return r[i].Title < r[j].Title
+	}
+
+	return r[i].ID < r[j].ID
+}
+
+// itemsByTime implements sort.Interface to order items by their time.
+type itemsByTime []budgets.ItemRecord
+
+func (t itemsByTime) Len() int           { return len(t) }
+func (t itemsByTime) Swap(i, j int)      { t[i], t[j] = t[j], t[i] }
+func (t itemsByTime) Less(i, j int) bool { return t[i].Time.Before(t[j].Time) }
+
+//==============================================================================
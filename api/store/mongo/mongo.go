@@ -0,0 +1,656 @@
+// Package mongo provides a store.Store backed by MongoDB through the mgo
+// driver, keeping users, pockets, budgets, items, categories, events, alert
+// rules, notifications, webhooks, deliveries and recurring items in their own
+// collections.
+package mongo
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/influx6/pocket/api/accounts"
+	"github.com/influx6/pocket/api/budgets"
+	"github.com/influx6/pocket/api/store"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+//==============================================================================
+
+// Collection names used by the store.
+const (
+	UsersCollection      = "users"
+	PocketsCollection    = "pockets"
+	BudgetsCollection    = "budgets"
+	ItemsCollection      = "items"
+	CategoriesCollection = "categories"
+	EventsCollection     = "events"
+	CountersCollection   = "counters"
+	RulesCollection      = "rules"
+	AlertsCollection     = "alerts"
+	NoticesCollection    = "notifications"
+	WebhooksCollection   = "webhooks"
+	DeliveriesCollection = "deliveries"
+	RecurringCollection  = "recurring"
+)
+
+// eventsCounter names the counter which numbers the appended events.
+const eventsCounter = "events"
+
+// indexes defines the indexes ensured on each collection when a Store is
+// created.
+var indexes = map[string][]mgo.Index{
+	UsersCollection: {
+		{Key: []string{"email"}, Unique: true, Background: true},
+		{Key: []string{"sessions.token"}, Sparse: true, Background: true},
+		{Key: []string{"id"}, Unique: true, Background: true},
+	},
+	PocketsCollection: {
+		{Key: []string{"owner", "title"}, Background: true},
+	},
+	BudgetsCollection: {
+		{Key: []string{"pocket", "title"}, Background: true},
+	},
+	ItemsCollection: {
+		{Key: []string{"budget", "time"}, Background: true},
+	},
+	CategoriesCollection: {
+		{Key: []string{"owner", "name"}, Background: true},
+	},
+	EventsCollection: {
+		{Key: []string{"pocket", "seq"}, Background: true},
+	},
+	RulesCollection: {
+		{Key: []string{"budget", "kind"}, Background: true},
+	},
+	NoticesCollection: {
+		{Key: []string{"owner", "-time"}, Background: true},
+	},
+	WebhooksCollection: {
+		{Key: []string{"pocket", "created"}, Background: true},
+	},
+	DeliveriesCollection: {
+		{Key: []string{"webhook", "-time"}, Background: true},
+	},
+	RecurringCollection: {
+		{Key: []string{"budget", "title"}, Background: true},
+		{Key: []string{"due"}, Background: true},
+	},
+}
+
+//==============================================================================
+
+// Store provides a store.Store which keeps its records within a MongoDB
+// database.
+type Store struct {
+	session *mgo.Session
+	db      string
+}
+
+// Dial connects to the MongoDB server at the giving address and returns a
+// Store using the provided database.
+func Dial(addr string, db string, timeout time.Duration) (*Store, error) {
+	session, err := mgo.DialWithTimeout(addr, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	session.SetMode(mgo.Monotonic, true)
+
+	st, err := New(session, db)
+	if err != nil {
+		session.Close()
+		return nil, err
+	}
+
+	return st, nil
+}
+
+// New returns a Store using the provided session and database, ensuring the
+// indexes of all collections exists. The Store works with copies of the
+// session and the caller remains responsible for closing it.
+func New(session *mgo.Session, db string) (*Store, error) {
+	st := Store{
+		session: session,
+		db:      db,
+	}
+
+	if err := st.ensureIndexes(); err != nil {
+		return nil, err
+	}
+
+	return &st, nil
+}
+
+// Close closes the underline session of the store.
+func (s *Store) Close() error {
+	s.session.Close()
+	return nil
+}
+
+// SavePocket stores the giving pocket record.
+func (s *Store) SavePocket(pocket budgets.PocketRecord) error {
+	return s.execute(PocketsCollection, func(col *mgo.Collection) error {
+		_, err := col.UpsertId(pocket.ID, pocket)
+		return err
+	})
+}
+
+// Pocket returns the pocket record with the giving id.
+func (s *Store) Pocket(id string) (budgets.PocketRecord, error) {
+	var pocket budgets.PocketRecord
+
+	err := s.execute(PocketsCollection, func(col *mgo.Collection) error {
+		return col.FindId(id).One(&pocket)
+	})
+
+	return pocket, err
+}
+
+// Pockets returns all pocket records of the giving owner ordered by their
+// title.
+func (s *Store) Pockets(owner string) ([]budgets.PocketRecord, error) {
+	records := make([]budgets.PocketRecord, 0)
+
+	err := s.execute(PocketsCollection, func(col *mgo.Collection) error {
+		return col.Find(bson.M{"owner": owner}).Sort("title").All(&records)
+	})
+
+	return records, err
+}
+
+// SaveBudget stores the giving budget record.
+func (s *Store) SaveBudget(budget budgets.BudgetRecord) error {
+	return s.execute(BudgetsCollection, func(col *mgo.Collection) error {
+		_, err := col.UpsertId(budget.ID, budget)
+		return err
+	})
+}
+
+// Budget returns the budget record with the giving id.
+func (s *Store) Budget(id string) (budgets.BudgetRecord, error) {
+	var budget budgets.BudgetRecord
+
+	err := s.execute(BudgetsCollection, func(col *mgo.Collection) error {
+		return col.FindId(id).One(&budget)
+	})
+
+	return budget, err
+}
+
+// Budgets returns all budget records of the giving pocket ordered by their
+// title.
+func (s *Store) Budgets(pocket string) ([]budgets.BudgetRecord, error) {
+	records := make([]budgets.BudgetRecord, 0)
+
+	err := s.execute(BudgetsCollection, func(col *mgo.Collection) error {
+		return col.Find(bson.M{"pocket": pocket}).Sort("title").All(&records)
+	})
+
+	return records, err
+}
+
+// SaveItem stores the giving budget item record.
+func (s *Store) SaveItem(item budgets.ItemRecord) error {
+	return s.execute(ItemsCollection, func(col *mgo.Collection) error {
+		_, err := col.UpsertId(item.ID, item)
+		return err
+	})
+}
+
+// Item returns the budget item record with the giving id.
+func (s *Store) Item(id string) (budgets.ItemRecord, error) {
+	var item budgets.ItemRecord
+
+	err := s.execute(ItemsCollection, func(col *mgo.Collection) error {
+		return col.FindId(id).One(&item)
+	})
+
+	return item, err
+}
+
+// Items returns all item records of the giving budget ordered by their time.
+func (s *Store) Items(budget string) ([]budgets.ItemRecord, error) {
+	records := make([]budgets.ItemRecord, 0)
+
+	err := s.execute(ItemsCollection, func(col *mgo.Collection) error {
+		return col.Find(bson.M{"budget": budget}).Sort("time").All(&records)
+	})
+
+	return records, err
+}
+
+// SaveCategory stores the giving category record.
+func (s *Store) SaveCategory(category budgets.CategoryRecord) error {
+	return s.execute(CategoriesCollection, func(col *mgo.Collection) error {
+		_, err := col.UpsertId(category.ID, category)
+		return err
+	})
+}
+
+// Category returns the category record with the giving id.
+func (s *Store) Category(id string) (budgets.CategoryRecord, error) {
+	var category budgets.CategoryRecord
+
+	err := s.execute(CategoriesCollection, func(col *mgo.Collection) error {
+		return col.FindId(id).One(&category)
+	})
+
+	return category, err
+}
+
+// Categories returns all category records of the giving owner ordered by
+// their name.
+func (s *Store) Categories(owner string) ([]budgets.CategoryRecord, error) {
+	records := make([]budgets.CategoryRecord, 0)
+
+	err := s.execute(CategoriesCollection, func(col *mgo.Collection) error {
+		return col.Find(bson.M{"owner": owner}).Sort("name").All(&records)
+	})
+
+	return records, err
+}
+
+// AppendEvent appends the giving event into the log, numbering it with the
+// next value of the events counter.
+func (s *Store) AppendEvent(event budgets.EventRecord) error {
+	var counter struct {
+		Seq int64 `bson:"seq"`
+	}
+
+	err := s.execute(CountersCollection, func(col *mgo.Collection) error {
+		change := mgo.Change{
+			Update:    bson.M{"$inc": bson.M{"seq": 1}},
+			Upsert:    true,
+			ReturnNew: true,
+		}
+
+		_, err := col.FindId(eventsCounter).Apply(change, &counter)
+		return err
+	})
+
+	if err != nil {
+		return err
+	}
+
+	event.Seq = counter.Seq
+
+	return s.execute(EventsCollection, func(col *mgo.Collection) error {
+		return col.Insert(event)
+	})
+}
+
+// CommitEvent appends the giving event into the log and stores the budget or
+// item record it changed, if given. MongoDB offers no writes across documents,
+// so the event is removed again when the record can not be stored. This only
+// approaches the guarantee of store.Events: should the removal fail too, or the
+// store go down in between, the event stays in the log without its record, and
+// the error returned reports the event left behind along with the failure to
+// store the record.
+func (s *Store) CommitEvent(event budgets.EventRecord, budget *budgets.BudgetRecord, item *budgets.ItemRecord) error {
+	if err := s.AppendEvent(event); err != nil {
+		return err
+	}
+
+	var err error
+
+	if budget != nil {
+		err = s.SaveBudget(*budget)
+	}
+
+	if err == nil && item != nil {
+		err = s.SaveItem(*item)
+	}
+
+	if err == nil {
+		return nil
+	}
+
+	rerr := s.execute(EventsCollection, func(col *mgo.Collection) error {
+		return col.RemoveId(event.ID)
+	})
+
+	if rerr != nil {
+		return fmt.Errorf("%s: event[%s] was left in the log as it could not be removed: %s", err, event.ID, rerr)
+	}
+
+	return err
+}
+
+// Events returns all events of the giving pocket in the order they were
+// appended.
+func (s *Store) Events(pocket string) ([]budgets.EventRecord, error) {
+	records := make([]budgets.EventRecord, 0)
+
+	err := s.execute(EventsCollection, func(col *mgo.Collection) error {
+		return col.Find(bson.M{"pocket": pocket}).Sort("seq").All(&records)
+	})
+
+	return records, err
+}
+
+// SaveRule stores the giving alert rule record.
+func (s *Store) SaveRule(rule budgets.RuleRecord) error {
+	return s.execute(RulesCollection, func(col *mgo.Collection) error {
+		_, err := col.UpsertId(rule.ID, rule)
+		return err
+	})
+}
+
+// Rule returns the alert rule record with the giving id.
+func (s *Store) Rule(id string) (budgets.RuleRecord, error) {
+	var rule budgets.RuleRecord
+
+	err := s.execute(RulesCollection, func(col *mgo.Collection) error {
+		return col.FindId(id).One(&rule)
+	})
+
+	return rule, err
+}
+
+// Rules returns all alert rule records watching the giving budget ordered by
+// their kind.
+func (s *Store) Rules(budget string) ([]budgets.RuleRecord, error) {
+	records := make([]budgets.RuleRecord, 0)
+
+	err := s.execute(RulesCollection, func(col *mgo.Collection) error {
+		return col.Find(bson.M{"budget": budget}).Sort("kind", "_id").All(&records)
+	})
+
+	return records, err
+}
+
+// DeleteRule removes the alert rule record with the giving id.
+func (s *Store) DeleteRule(id string) error {
+	return s.execute(RulesCollection, func(col *mgo.Collection) error {
+		return col.RemoveId(id)
+	})
+}
+
+// WatchedBudgets returns the ids of all budgets watched by alert rules.
+func (s *Store) WatchedBudgets() ([]string, error) {
+	ids := make([]string, 0)
+
+	err := s.execute(RulesCollection, func(col *mgo.Collection) error {
+		return col.Find(nil).Distinct("budget", &ids)
+	})
+
+	return ids, err
+}
+
+// SaveAlerts stores the giving alert record, removing it when it holds no
+// notices.
+func (s *Store) SaveAlerts(alerts budgets.AlertRecord) error {
+	return s.execute(AlertsCollection, func(col *mgo.Collection) error {
+		if len(alerts.Notices) == 0 {
+			_, err := col.RemoveAll(bson.M{"_id": alerts.Budget})
+			return err
+		}
+
+		_, err := col.UpsertId(alerts.Budget, alerts)
+		return err
+	})
+}
+
+// Alerts returns the alert record of the budget with the giving id.
+func (s *Store) Alerts(budget string) (budgets.AlertRecord, error) {
+	var alerts budgets.AlertRecord
+
+	err := s.execute(AlertsCollection, func(col *mgo.Collection) error {
+		return col.FindId(budget).One(&alerts)
+	})
+
+	return alerts, err
+}
+
+// SaveNotification stores the giving notification record.
+func (s *Store) SaveNotification(notice budgets.NotificationRecord) error {
+	return s.execute(NoticesCollection, func(col *mgo.Collection) error {
+		_, err := col.UpsertId(notice.ID, notice)
+		return err
+	})
+}
+
+// Notification returns the notification record with the giving id.
+func (s *Store) Notification(id string) (budgets.NotificationRecord, error) {
+	var notice budgets.NotificationRecord
+
+	err := s.execute(NoticesCollection, func(col *mgo.Collection) error {
+		return col.FindId(id).One(&notice)
+	})
+
+	return notice, err
+}
+
+// Notifications returns all notification records of the giving owner, the
+// newest first.
+func (s *Store) Notifications(owner string) ([]budgets.NotificationRecord, error) {
+	records := make([]budgets.NotificationRecord, 0)
+
+	err := s.execute(NoticesCollection, func(col *mgo.Collection) error {
+		return col.Find(bson.M{"owner": owner}).Sort("-time").All(&records)
+	})
+
+	return records, err
+}
+
+// SaveWebhook stores the giving webhook record.
+func (s *Store) SaveWebhook(hook budgets.WebhookRecord) error {
+	return s.execute(WebhooksCollection, func(col *mgo.Collection) error {
+		_, err := col.UpsertId(hook.ID, hook)
+		return err
+	})
+}
+
+// Webhook returns the webhook record with the giving id.
+func (s *Store) Webhook(id string) (budgets.WebhookRecord, error) {
+	var hook budgets.WebhookRecord
+
+	err := s.execute(WebhooksCollection, func(col *mgo.Collection) error {
+		return col.FindId(id).One(&hook)
+	})
+
+	return hook, err
+}
+
+// Webhooks returns all webhook records of the giving pocket, the oldest
+// first.
+func (s *Store) Webhooks(pocket string) ([]budgets.WebhookRecord, error) {
+	records := make([]budgets.WebhookRecord, 0)
+
+	err := s.execute(WebhooksCollection, func(col *mgo.Collection) error {
+		return col.Find(bson.M{"pocket": pocket}).Sort("created").All(&records)
+	})
+
+	return records, err
+}
+
+// DeleteWebhook removes the webhook record with the giving id and its delivery
+// log.
+func (s *Store) DeleteWebhook(id string) error {
+	err := s.execute(WebhooksCollection, func(col *mgo.Collection) error {
+		return col.RemoveId(id)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return s.execute(DeliveriesCollection, func(col *mgo.Collection) error {
+		_, err := col.RemoveAll(bson.M{"webhook": id})
+		return err
+	})
+}
+
+// SaveDelivery appends the giving delivery record to the delivery log of its
+// webhook, removing the oldest beyond store.MaxDeliveries.
+func (s *Store) SaveDelivery(delivery budgets.DeliveryRecord) error {
+	return s.execute(DeliveriesCollection, func(col *mgo.Collection) error {
+		if err := col.Insert(delivery); err != nil {
+			return err
+		}
+
+		var stale []struct {
+			ID string `bson:"_id"`
+		}
+
+		query := col.Find(bson.M{"webhook": delivery.Webhook}).Sort("-time").Skip(store.MaxDeliveries)
+		if err := query.Select(bson.M{"_id": 1}).All(&stale); err != nil || len(stale) == 0 {
+			return err
+		}
+
+		ids := make([]string, 0, len(stale))
+		for _, record := range stale {
+			ids = append(ids, record.ID)
+		}
+
+		_, err := col.RemoveAll(bson.M{"_id": bson.M{"$in": ids}})
+		return err
+	})
+}
+
+// Deliveries returns the delivery log of the webhook with the giving id, the
+// newest first.
+func (s *Store) Deliveries(webhook string) ([]budgets.DeliveryRecord, error) {
+	records := make([]budgets.DeliveryRecord, 0)
+
+	err := s.execute(DeliveriesCollection, func(col *mgo.Collection) error {
+		return col.Find(bson.M{"webhook": webhook}).Sort("-time").All(&records)
+	})
+
+	return records, err
+}
+
+// SaveRecurring stores the giving recurring item record.
+func (s *Store) SaveRecurring(recurring budgets.RecurringRecord) error {
+	return s.execute(RecurringCollection, func(col *mgo.Collection) error {
+		_, err := col.UpsertId(recurring.ID, recurring)
+		return err
+	})
+}
+
+// Recurring returns the recurring item record with the giving id.
+func (s *Store) Recurring(id string) (budgets.RecurringRecord, error) {
+	var recurring budgets.RecurringRecord
+
+	err := s.execute(RecurringCollection, func(col *mgo.Collection) error {
+		return col.FindId(id).One(&recurring)
+	})
+
+	return recurring, err
+}
+
+// Recurrings returns all recurring item records of the giving budget ordered
+// by title.
+func (s *Store) Recurrings(budget string) ([]budgets.RecurringRecord, error) {
+	records := make([]budgets.RecurringRecord, 0)
+
+	err := s.execute(RecurringCollection, func(col *mgo.Collection) error {
+		return col.Find(bson.M{"budget": budget}).Sort("title", "_id").All(&records)
+	})
+
+	return records, err
+}
+
+// DueRecurrings returns all recurring item records with an item due on or
+// before the giving time.
+func (s *Store) DueRecurrings(through time.Time) ([]budgets.RecurringRecord, error) {
+	records := make([]budgets.RecurringRecord, 0)
+
+	err := s.execute(RecurringCollection, func(col *mgo.Collection) error {
+		return col.Find(bson.M{"due": bson.M{"$gt": time.Time{}, "$lte": through}}).Sort("title", "_id").All(&records)
+	})
+
+	return records, err
+}
+
+// DeleteRecurring removes the recurring item record with the giving id.
+func (s *Store) DeleteRecurring(id string) error {
+	return s.execute(RecurringCollection, func(col *mgo.Collection) error {
+		return col.RemoveId(id)
+	})
+}
+
+// SaveUser stores the giving user record, under its email in lowercase.
+func (s *Store) SaveUser(user accounts.UserRecord) error {
+	user.Email = strings.ToLower(user.Email)
+
+	return s.execute(UsersCollection, func(col *mgo.Collection) error {
+		_, err := col.Upsert(bson.M{"email": user.Email}, user)
+		return err
+	})
+}
+
+// User returns the user record with the giving email, regardless of its case.
+func (s *Store) User(email string) (accounts.UserRecord, error) {
+	var user accounts.UserRecord
+
+	err := s.execute(UsersCollection, func(col *mgo.Collection) error {
+		return col.Find(bson.M{"email": strings.ToLower(email)}).One(&user)
+	})
+
+	return user, err
+}
+
+// UserByToken returns the user record which holds the giving session token.
+func (s *Store) UserByToken(token string) (accounts.UserRecord, error) {
+	var user accounts.UserRecord
+
+	if token == "" {
+		return user, store.ErrNotFound
+	}
+
+	err := s.execute(UsersCollection, func(col *mgo.Collection) error {
+		return col.Find(bson.M{"sessions.token": token}).One(&user)
+	})
+
+	return user, err
+}
+
+// UserByID returns the user record with the giving id.
+func (s *Store) UserByID(id string) (accounts.UserRecord, error) {
+	var user accounts.UserRecord
+
+	err := s.execute(UsersCollection, func(col *mgo.Collection) error {
+		return col.Find(bson.M{"id": id}).One(&user)
+	})
+
+	return user, err
+}
+
+// ensureIndexes creates the indexes defined for every collection.
+func (s *Store) ensureIndexes() error {
+	for name, list := range indexes {
+		for _, index := range list {
+			err := s.execute(name, func(col *mgo.Collection) error {
+				return col.EnsureIndex(index)
+			})
+
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// execute runs the giving function against the named collection using a copy
+// of the store session, translating mgo.ErrNotFound into store.ErrNotFound.
+func (s *Store) execute(name string, fx func(*mgo.Collection) error) error {
+	ses := s.session.Copy()
+	defer ses.Close()
+
+	if err := fx(ses.DB(s.db).C(name)); err != nil {
+		if err == mgo.ErrNotFound {
+			return store.ErrNotFound
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+//==============================================================================
@@ -0,0 +1,299 @@
+package mongo
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/influx6/pocket/api/accounts"
+	"github.com/influx6/pocket/api/budgets"
+	"github.com/influx6/pocket/api/currency"
+	"github.com/influx6/pocket/api/store"
+	"gopkg.in/mgo.v2/dbtest"
+)
+
+//==============================================================================
+
+// server runs the mongod instance the tests run against, started only when a
+// mongod binary is found on the PATH.
+var server dbtest.DBServer
+
+// available is true when mongod was found and the server was set up.
+var available bool
+
+// Store is closed through io.Closer by the server on shutdown.
+var _ io.Closer = (*Store)(nil)
+
+func TestMain(m *testing.M) {
+	os.Exit(run(m))
+}
+
+// run sets up the database server, if mongod is installed, around the tests.
+func run(m *testing.M) int {
+	if _, err := exec.LookPath("mongod"); err != nil {
+		return m.Run()
+	}
+
+	dir, err := ioutil.TempDir("", "pocket-mongo")
+	if err != nil {
+		return m.Run()
+	}
+
+	defer os.RemoveAll(dir)
+
+	server.SetPath(dir)
+	defer server.Stop()
+
+	available = true
+	return m.Run()
+}
+
+// newStore returns a Store over a fresh database and the function which wipes
+// it once the test is done, skipping the test when mongod is not installed.
+func newStore(t *testing.T) (*Store, func()) {
+	if !available {
+		t.Skip("mongod is not installed")
+	}
+
+	session := server.Session()
+
+	st, err := New(session, "pocket_test")
+	if err != nil {
+		session.Close()
+		t.Fatalf("Failed to create store: %s", err)
+	}
+
+	return st, func() {
+		session.Close()
+		server.Wipe()
+	}
+}
+
+//==============================================================================
+
+// TestPockets checks pockets, budgets and items are stored and listed in their
+// orders, with their amounts.
+func TestPockets(t *testing.T) {
+	st, done := newStore(t)
+	defer done()
+
+	usd, err := currency.ISO4217.Find("USD")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, title := range []string{"Travel", "Home"} {
+		pocket := budgets.PocketRecord{ID: title, Owner: "owner", Title: title, Currency: "USD"}
+		if err := st.SavePocket(pocket); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pockets, err := st.Pockets("owner")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(pockets) != 2 || pockets[0].Title != "Home" {
+		t.Fatalf("Expected two pockets ordered by title, got %+v", pockets)
+	}
+
+	if _, err := st.Pocket("missing"); err != store.ErrNotFound {
+		t.Fatalf("Expected store.ErrNotFound for a missing pocket, got %v", err)
+	}
+
+	price := currency.NewMoney(1250, usd)
+
+	if err := st.SaveBudget(budgets.BudgetRecord{ID: "rent", Pocket: "Home", Title: "Rent", Price: price}); err != nil {
+		t.Fatal(err)
+	}
+
+	budget, err := st.Budget("rent")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if budget.Price.Amount != 1250 || budget.Price.Currency.Code != "USD" {
+		t.Fatalf("Expected budget price of 1250 USD, got %+v", budget.Price)
+	}
+
+	now := time.Now()
+
+	for index, id := range []string{"late", "early"} {
+		item := budgets.ItemRecord{ID: id, Budget: "rent", Price: price, Time: now.Add(-time.Duration(index) * time.Hour)}
+		if err := st.SaveItem(item); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	items, err := st.Items("rent")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(items) != 2 || items[0].ID != "early" {
+		t.Fatalf("Expected two items ordered by time, got %+v", items)
+	}
+}
+
+// TestEvents checks events are numbered in the order they are appended.
+func TestEvents(t *testing.T) {
+	st, done := newStore(t)
+	defer done()
+
+	for _, id := range []string{"first", "second", "third"} {
+		event := budgets.EventRecord{ID: id, Pocket: "pocket", Kind: "budget.new", Time: time.Now()}
+		if err := st.AppendEvent(event); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	events, err := st.Events("pocket")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 3 {
+		t.Fatalf("Expected 3 events, got %d", len(events))
+	}
+
+	for index, id := range []string{"first", "second", "third"} {
+		if events[index].ID != id || events[index].Seq != int64(index+1) {
+			t.Fatalf("Expected event %s numbered %d, got %+v", id, index+1, events[index])
+		}
+	}
+}
+
+// TestDeliveries checks the delivery log keeps the latest store.MaxDeliveries
+// attempts and is removed along with its webhook.
+func TestDeliveries(t *testing.T) {
+	st, done := newStore(t)
+	defer done()
+
+	if err := st.SaveWebhook(budgets.WebhookRecord{ID: "hook", Pocket: "pocket"}); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+
+	for index := 0; index < store.MaxDeliveries+5; index++ {
+		delivery := budgets.DeliveryRecord{
+			ID:      fmt.Sprintf("delivery-%d", index),
+			Webhook: "hook",
+			Attempt: index,
+			Time:    start.Add(time.Duration(index) * time.Second),
+		}
+
+		if err := st.SaveDelivery(delivery); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	log, err := st.Deliveries("hook")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(log) != store.MaxDeliveries || log[0].Attempt != store.MaxDeliveries+4 {
+		t.Fatalf("Expected the latest %d deliveries, newest first, got %d", store.MaxDeliveries, len(log))
+	}
+
+	if err := st.DeleteWebhook("hook"); err != nil {
+		t.Fatal(err)
+	}
+
+	if log, err = st.Deliveries("hook"); err != nil || len(log) != 0 {
+		t.Fatalf("Expected the delivery log to be removed, got %d: %v", len(log), err)
+	}
+}
+
+// TestDueRecurrings checks only recurring items due through the giving time
+// are returned.
+func TestDueRecurrings(t *testing.T) {
+	st, done := newStore(t)
+	defer done()
+
+	now := time.Now()
+
+	records := []budgets.RecurringRecord{
+		{ID: "due", Budget: "rent", Title: "Due", Due: now.Add(-time.Hour)},
+		{ID: "later", Budget: "rent", Title: "Later", Due: now.Add(time.Hour)},
+		{ID: "ended", Budget: "rent", Title: "Ended"},
+	}
+
+	for _, record := range records {
+		if err := st.SaveRecurring(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	due, err := st.DueRecurrings(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(due) != 1 || due[0].ID != "due" {
+		t.Fatalf("Expected only the due recurring item, got %+v", due)
+	}
+
+	if err := st.DeleteRecurring("due"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := st.Recurring("due"); err != store.ErrNotFound {
+		t.Fatalf("Expected store.ErrNotFound for a deleted recurring item, got %v", err)
+	}
+}
+
+// TestUsers checks users are found by their email, id and session token.
+func TestUsers(t *testing.T) {
+	st, done := newStore(t)
+	defer done()
+
+	user := accounts.UserRecord{
+		ID:    "user",
+		Email: "user@pocket.io",
+		Sessions: []accounts.SessionRecord{
+			{Token: "laptop", Expires: time.Now().Add(time.Hour)},
+			{Token: "phone", Expires: time.Now().Add(time.Hour)},
+		},
+	}
+	if err := st.SaveUser(user); err != nil {
+		t.Fatal(err)
+	}
+
+	if found, err := st.User(user.Email); err != nil || found.ID != user.ID {
+		t.Fatalf("Expected user by email, got %+v: %v", found, err)
+	}
+
+	if found, err := st.User("User@Pocket.IO"); err != nil || found.ID != user.ID {
+		t.Fatalf("Expected user by email in another case, got %+v: %v", found, err)
+	}
+
+	user.Email = "USER@pocket.io"
+	if err := st.SaveUser(user); err != nil {
+		t.Fatal(err)
+	}
+
+	if found, err := st.User("user@pocket.io"); err != nil || found.ID != user.ID || found.Email != "user@pocket.io" {
+		t.Fatalf("Expected the user saved under another case to replace it, got %+v: %v", found, err)
+	}
+
+	if found, err := st.UserByID(user.ID); err != nil || found.Email != "user@pocket.io" {
+		t.Fatalf("Expected user by id, got %+v: %v", found, err)
+	}
+
+	for _, session := range user.Sessions {
+		if found, err := st.UserByToken(session.Token); err != nil || found.ID != user.ID {
+			t.Fatalf("Expected user by token %q, got %+v: %v", session.Token, found, err)
+		}
+	}
+
+	if _, err := st.UserByToken(""); err != store.ErrNotFound {
+		t.Fatalf("Expected store.ErrNotFound for an empty token, got %v", err)
+	}
+}
@@ -0,0 +1,130 @@
+// Package store provides the persistence layer for pockets, budgets, items,
+// categories, alert rules, notifications, webhooks, recurring items, users and
+// the event log of every pocket, with an in-memory implementation for tests and
+// a file-backed implementation for durable storage.
+package store
+
+import (
+	"errors"
+	"time"
+
+	"github.com/influx6/pocket/api/accounts"
+	"github.com/influx6/pocket/api/budgets"
+)
+
+//==============================================================================
+
+// ErrNotFound is returned when a record requested does not exists.
+var ErrNotFound = errors.New("Record Not Found")
+
+//==============================================================================
+
+// Pockets defines the storage of pocket records.
+type Pockets interface {
+	SavePocket(budgets.PocketRecord) error
+	Pocket(id string) (budgets.PocketRecord, error)
+	Pockets(owner string) ([]budgets.PocketRecord, error)
+}
+
+// Budgets defines the storage of budget records.
+type Budgets interface {
+	SaveBudget(budgets.BudgetRecord) error
+	Budget(id string) (budgets.BudgetRecord, error)
+	Budgets(pocket string) ([]budgets.BudgetRecord, error)
+}
+
+// Items defines the storage of budget item records.
+type Items interface {
+	SaveItem(budgets.ItemRecord) error
+	Item(id string) (budgets.ItemRecord, error)
+	Items(budget string) ([]budgets.ItemRecord, error)
+}
+
+// Categories defines the storage of item category records.
+type Categories interface {
+	SaveCategory(budgets.CategoryRecord) error
+	Category(id string) (budgets.CategoryRecord, error)
+	Categories(owner string) ([]budgets.CategoryRecord, error)
+}
+
+// Events defines the append-only storage of the events applied to the budgets
+// and items of pockets. Events are never changed once appended. CommitEvent
+// appends an event along with the budget or item record it left behind, so
+// neither is stored without the other. Stores without writes across records,
+// such as the mongo store, may only approach this and document how far.
+type Events interface {
+	AppendEvent(budgets.EventRecord) error
+	CommitEvent(event budgets.EventRecord, budget *budgets.BudgetRecord, item *budgets.ItemRecord) error
+	Events(pocket string) ([]budgets.EventRecord, error)
+}
+
+// Rules defines the storage of the alert rules watching budgets and of the
+// notifications they last raised for each budget. Saving an alert record
+// without notices removes it.
+type Rules interface {
+	SaveRule(budgets.RuleRecord) error
+	Rule(id string) (budgets.RuleRecord, error)
+	Rules(budget string) ([]budgets.RuleRecord, error)
+	DeleteRule(id string) error
+	WatchedBudgets() ([]string, error)
+	SaveAlerts(budgets.AlertRecord) error
+	Alerts(budget string) (budgets.AlertRecord, error)
+}
+
+// Notifications defines the storage of the notifications raised for users.
+type Notifications interface {
+	SaveNotification(budgets.NotificationRecord) error
+	Notification(id string) (budgets.NotificationRecord, error)
+	Notifications(owner string) ([]budgets.NotificationRecord, error)
+}
+
+// MaxDeliveries defines how many of the latest delivery attempts are kept
+// within the delivery log of each webhook.
+const MaxDeliveries = 100
+
+// Webhooks defines the storage of the webhooks of pockets and of their
+// delivery logs, which keep the latest MaxDeliveries attempts of each webhook.
+// Deleting a webhook removes its delivery log.
+type Webhooks interface {
+	SaveWebhook(budgets.WebhookRecord) error
+	Webhook(id string) (budgets.WebhookRecord, error)
+	Webhooks(pocket string) ([]budgets.WebhookRecord, error)
+	DeleteWebhook(id string) error
+	SaveDelivery(budgets.DeliveryRecord) error
+	Deliveries(webhook string) ([]budgets.DeliveryRecord, error)
+}
+
+// RecurringItems defines the storage of the recurring items written against
+// budgets.
+type RecurringItems interface {
+	SaveRecurring(budgets.RecurringRecord) error
+	Recurring(id string) (budgets.RecurringRecord, error)
+	Recurrings(budget string) ([]budgets.RecurringRecord, error)
+	DueRecurrings(through time.Time) ([]budgets.RecurringRecord, error)
+	DeleteRecurring(id string) error
+}
+
+// Users defines the storage of user records.
+type Users interface {
+	SaveUser(accounts.UserRecord) error
+	User(email string) (accounts.UserRecord, error)
+	UserByToken(token string) (accounts.UserRecord, error)
+	UserByID(id string) (accounts.UserRecord, error)
+}
+
+// Store defines the complete storage interface used by the pocket server. All
+// Save methods create the record if it does not exists else replace it.
+type Store interface {
+	Pockets
+	Budgets
+	Items
+	Categories
+	Events
+	Rules
+	Notifications
+	Webhooks
+	RecurringItems
+	Users
+}
+
+//==============================================================================
@@ -0,0 +1,311 @@
+// Package transport provides a client.ServeTransport built on the net/http
+// package, allowing client.Servo instances to query a pocket-server and follow
+// its update stream from Go programs such as tests, command line tools and
+// other services.
+package transport
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/influx6/coquery/data"
+)
+
+//==============================================================================
+
+// StatusError is returned when a request is replied to with a status outside
+// of the 2xx range.
+type StatusError struct {
+	Status  int
+	Message string `json:"error"`
+}
+
+// Error returns the message of the error.
+func (s *StatusError) Error() string {
+	return fmt.Sprintf("Request Failed[%d]: %s", s.Status, s.Message)
+}
+
+//==============================================================================
+
+// HTTP defines a client.ServeTransport which issues requests through a
+// http.Client. Every attempt is bound by the Timeout and by the Context, whose
+// cancellation ends all requests in flight. Requests replied to with a 5xx
+// status or which fail to reach the server are retried up to Retries times,
+// waiting Backoff before the first retry and doubling it on each one after.
+type HTTP struct {
+	Client   *http.Client
+	Context  context.Context
+	Header   http.Header
+	Timeout  time.Duration
+	Retries  int
+	Backoff  time.Duration
+	Compress bool
+}
+
+// New returns a new instance of HTTP where each attempt lasts at most the
+// giving timeout.
+func New(timeout time.Duration) *HTTP {
+	ht := HTTP{
+		Client:  &http.Client{},
+		Context: context.Background(),
+		Header:  make(http.Header),
+		Timeout: timeout,
+		Retries: 3,
+		Backoff: 200 * time.Millisecond,
+	}
+
+	return &ht
+}
+
+// WithToken returns the transport after setting the session token sent with
+// each request.
+func (h *HTTP) WithToken(token string) *HTTP {
+	h.Header.Set("Authorization", "Bearer "+token)
+	return h
+}
+
+// Do issues the requests and collects the response into a pack.
+func (h *HTTP) Do(addr string, body io.Reader) (data.ResponsePack, error) {
+	ctx := h.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	return h.DoContext(ctx, addr, body)
+}
+
+// DoContext issues the requests, bound by the provided context, and collects the
+// response into a pack.
+func (h *HTTP) DoContext(ctx context.Context, addr string, body io.Reader) (data.ResponsePack, error) {
+	var pack data.ResponsePack
+
+	content, err := ioutil.ReadAll(body)
+	if err != nil {
+		return pack, err
+	}
+
+	if h.Compress {
+		if content, err = compress(content); err != nil {
+			return pack, err
+		}
+	}
+
+	backoff := h.Backoff
+
+	for attempt := 0; ; attempt++ {
+		var retry bool
+
+		pack, retry, err = h.attempt(ctx, addr, content)
+		if err == nil || !retry || attempt >= h.Retries {
+			return pack, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return pack, ctx.Err()
+		case <-time.After(backoff):
+			backoff *= 2
+		}
+	}
+}
+
+// attempt issues a single request, returning true alongside its error if the
+// request may succeed when retried.
+func (h *HTTP) attempt(ctx context.Context, addr string, content []byte) (data.ResponsePack, bool, error) {
+	var pack data.ResponsePack
+
+	if h.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.Timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequest("POST", addr, bytes.NewReader(content))
+	if err != nil {
+		return pack, false, err
+	}
+
+	req = req.WithContext(ctx)
+
+	for key, values := range h.Header {
+		req.Header[key] = values
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	if h.Compress {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+
+	client := h.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+
+		// Only failures to reach the server are worth retrying, not the end
+		// of the context the request was bound by.
+		return pack, ctx.Err() == nil, err
+	}
+
+	defer res.Body.Close()
+
+	reader := io.Reader(res.Body)
+
+	if res.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(res.Body)
+		if err != nil {
+			return pack, false, err
+		}
+
+		defer gz.Close()
+		reader = gz
+	}
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		serr := StatusError{Status: res.StatusCode}
+		json.NewDecoder(reader).Decode(&serr)
+		return pack, res.StatusCode >= 500, &serr
+	}
+
+	if err := json.NewDecoder(reader).Decode(&pack); err != nil {
+		return pack, false, err
+	}
+
+	return pack, false, nil
+}
+
+// compress returns the gzip compressed form of the content.
+func compress(content []byte) ([]byte, error) {
+	var buf bytes.Buffer
+
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(content); err != nil {
+		return nil, err
+	}
+
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+//==============================================================================
+
+// Subscribe streams the changes pushed by the update stream at the giving
+// address, which are handed to the handler as packs carrying the DeltaID and
+// Deltas of each change, such as for client.Servo.Push. Dropped streams are
+// resumed after the backoff from the last change received. It blocks until the
+// Context of the transport ends.
+func (h *HTTP) Subscribe(addr string, hl func(data.ResponsePack)) error {
+	ctx := h.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var lastID string
+
+	for {
+		err := h.stream(ctx, addr, &lastID, hl)
+
+		// Requests which are refused will be refused again, so give up.
+		if serr, ok := err.(*StatusError); ok && serr.Status < 500 {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(h.Backoff):
+		}
+	}
+}
+
+// stream reads the events of a single connection to the update stream,
+// recording the id of the last event received.
+func (h *HTTP) stream(ctx context.Context, addr string, lastID *string, hl func(data.ResponsePack)) error {
+	req, err := http.NewRequest("GET", addr, nil)
+	if err != nil {
+		return err
+	}
+
+	req = req.WithContext(ctx)
+
+	for key, values := range h.Header {
+		req.Header[key] = values
+	}
+
+	req.Header.Set("Accept", "text/event-stream")
+
+	if *lastID != "" {
+		req.Header.Set("Last-Event-ID", *lastID)
+	}
+
+	client := h.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		serr := StatusError{Status: res.StatusCode}
+		json.NewDecoder(res.Body).Decode(&serr)
+		return &serr
+	}
+
+	var event, id, payload string
+
+	scanner := bufio.NewScanner(res.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		switch {
+		case line == "":
+			if event == "delta" && payload != "" {
+				var pack data.ResponsePack
+				if err := json.Unmarshal([]byte(payload), &pack); err == nil {
+					*lastID = id
+					hl(pack)
+				}
+			}
+
+			event, payload = "", ""
+
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(line[len("event:"):])
+
+		case strings.HasPrefix(line, "id:"):
+			id = strings.TrimSpace(line[len("id:"):])
+
+		case strings.HasPrefix(line, "data:"):
+			payload += strings.TrimSpace(line[len("data:"):])
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	return io.EOF
+}
+
+//==============================================================================
@@ -0,0 +1,114 @@
+package webhooks
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+//==============================================================================
+
+// ErrForbiddenAddress is returned when a delivery would connect to an address
+// which is not publicly routable, such as a loopback, private or link-local
+// one, guarding the services next to the server from user supplied URLs.
+var ErrForbiddenAddress = errors.New("Forbidden Webhook Address")
+
+// reservedNets defines the ranges not covered by the checks of net.IP which
+// are never publicly routable: the "this network" range of 0.0.0.0/8 and the
+// shared address space of carrier-grade NAT, 100.64.0.0/10.
+var reservedNets = []*net.IPNet{
+	mustCIDR("0.0.0.0/8"),
+	mustCIDR("100.64.0.0/10"),
+}
+
+// mustCIDR returns the network of the giving CIDR notation, panicking if it is
+// invalid.
+func mustCIDR(cidr string) *net.IPNet {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		panic(err)
+	}
+
+	return network
+}
+
+//==============================================================================
+
+// NewClient returns the http.Client deliveries are made through, waiting up to
+// the giving timeout for each. It only connects to public addresses, checked
+// once the host is resolved so no name can point it elsewhere, and hands back
+// redirects as the response instead of following them.
+func NewClient(timeout time.Duration) *http.Client {
+	return newClient(timeout, guardAddress)
+}
+
+// newClient returns the http.Client of NewClient, with the giving Control hook
+// vetting the addresses it connects to.
+func newClient(timeout time.Duration, control func(string, string, syscall.RawConn) error) *http.Client {
+	dialer := net.Dialer{
+		Timeout: timeout,
+		Control: control,
+	}
+
+	transport := http.Transport{
+		DialContext:         dialer.DialContext,
+		TLSHandshakeTimeout: timeout,
+		MaxIdleConns:        16,
+		IdleConnTimeout:     90 * time.Second,
+	}
+
+	client := http.Client{
+		Transport: &transport,
+		Timeout:   timeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	return &client
+}
+
+// guardAddress implements the net.Dialer Control hook, refusing connections to
+// addresses which are not public.
+func guardAddress(network string, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	if !Public(net.ParseIP(host)) {
+		return ErrForbiddenAddress
+	}
+
+	return nil
+}
+
+// Public returns true/false if the ip is publicly routable, being none of the
+// unspecified, loopback, private, link-local, such as the 169.254.169.254 of
+// cloud metadata services, multicast or reserved addresses.
+func Public(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+
+	switch {
+	case ip.IsUnspecified(), ip.IsLoopback(), ip.IsPrivate():
+		return false
+	case ip.IsLinkLocalUnicast(), ip.IsLinkLocalMulticast():
+		return false
+	case ip.IsMulticast(), ip.IsInterfaceLocalMulticast():
+		return false
+	}
+
+	for _, network := range reservedNets {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+
+	return true
+}
+
+//==============================================================================
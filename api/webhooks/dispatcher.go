@@ -0,0 +1,330 @@
+package webhooks
+
+import (
+	"bytes"
+	"container/heap"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+)
+
+//==============================================================================
+
+// ErrQueueFull is returned when a request is queued while the dispatcher holds
+// as many requests as it can.
+var ErrQueueFull = errors.New("Webhook Queue Full")
+
+// ErrQueueClosed is returned when a request is queued after the dispatcher was
+// closed.
+var ErrQueueClosed = errors.New("Webhook Queue Closed")
+
+// maxResponse defines how much of a response body is read before the
+// connection is given up for reuse.
+const maxResponse = 64 << 10
+
+//==============================================================================
+
+// Request defines a payload to deliver to the URL of the webhook identified by
+// Hook, signed with Secret and identified by ID across its attempts.
+type Request struct {
+	ID     string
+	Hook   string
+	URL    string
+	Secret string
+	Event  string
+	Body   []byte
+}
+
+// Attempt defines the outcome of a single delivery of a request, holding the
+// status of the response or the error which prevented one.
+type Attempt struct {
+	Request  Request
+	Number   int
+	Time     time.Time
+	Duration time.Duration
+	Status   int
+	Err      error
+}
+
+// OK returns true/false if the attempt was answered with a 2xx status.
+func (a Attempt) OK() bool {
+	return a.Err == nil && a.Status >= 200 && a.Status < 300
+}
+
+// Retry returns true/false if the attempt failed in a way a later attempt may
+// not. Receivers rejecting a delivery with any other 4xx status are not asked
+// again.
+func (a Attempt) Retry() bool {
+	if a.Err != nil {
+		return true
+	}
+
+	return a.Status >= 500 || a.Status == http.StatusRequestTimeout || a.Status == http.StatusTooManyRequests
+}
+
+//==============================================================================
+
+// Dispatcher provides the delivery of requests by a pool of workers in the
+// background, retrying each failed delivery after a backoff which doubles on
+// every attempt. Retries wait within a schedule of their own rather than
+// holding up a worker. Every attempt is handed to the report function, if any.
+type Dispatcher struct {
+	client  *http.Client
+	retries int
+	backoff time.Duration
+	report  func(Attempt)
+	rl      sync.Mutex
+	closed  bool
+	waiting retryQueue
+	wake    chan struct{}
+	pending chan Request
+	due     chan delivery
+	stop    chan struct{}
+	workers sync.WaitGroup
+}
+
+// NewDispatcher returns a new Dispatcher instance holding up to size requests,
+// which are delivered through the client by the giving number of workers and
+// retried up to retries times, first after the giving backoff.
+func NewDispatcher(client *http.Client, workers int, size int, retries int, backoff time.Duration, report func(Attempt)) *Dispatcher {
+	d := Dispatcher{
+		client:  client,
+		retries: retries,
+		backoff: backoff,
+		report:  report,
+		wake:    make(chan struct{}, 1),
+		pending: make(chan Request, size),
+		due:     make(chan delivery),
+		stop:    make(chan struct{}),
+	}
+
+	for i := 0; i < workers; i++ {
+		d.workers.Add(1)
+		go d.run()
+	}
+
+	d.workers.Add(1)
+	go d.schedule()
+
+	return &d
+}
+
+// Enqueue queues the request for delivery, returning ErrQueueFull if the
+// dispatcher holds as many requests as it can.
+func (d *Dispatcher) Enqueue(req Request) error {
+	d.rl.Lock()
+	defer d.rl.Unlock()
+
+	if d.closed {
+		return ErrQueueClosed
+	}
+
+	select {
+	case d.pending <- req:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Close stops the dispatcher from accepting requests and waits for the
+// requests already queued to be delivered. Deliveries are no longer retried
+// once the dispatcher is closed.
+func (d *Dispatcher) Close() {
+	d.rl.Lock()
+
+	if !d.closed {
+		d.closed = true
+		close(d.stop)
+		close(d.pending)
+	}
+
+	d.rl.Unlock()
+
+	d.workers.Wait()
+}
+
+// run delivers the queued requests and the retries falling due until the
+// dispatcher is closed and drained.
+func (d *Dispatcher) run() {
+	defer d.workers.Done()
+
+	for {
+		select {
+		case req, ok := <-d.pending:
+			if !ok {
+				return
+			}
+
+			d.deliver(delivery{Request: req, Number: 1, Wait: d.backoff})
+		case next := <-d.due:
+			d.deliver(next)
+		}
+	}
+}
+
+// deliver makes the attempt at the delivery, scheduling the next one unless
+// it was accepted, its retries are spent, the receiver rejected it or the
+// dispatcher is closed.
+func (d *Dispatcher) deliver(next delivery) {
+	attempt := d.post(next.Request, next.Number)
+
+	if d.report != nil {
+		d.report(attempt)
+	}
+
+	if attempt.OK() || !attempt.Retry() || next.Number > d.retries {
+		return
+	}
+
+	d.rl.Lock()
+	defer d.rl.Unlock()
+
+	if d.closed {
+		return
+	}
+
+	heap.Push(&d.waiting, delivery{
+		Request: next.Request,
+		Number:  next.Number + 1,
+		Wait:    next.Wait * 2,
+		Due:     time.Now().Add(next.Wait),
+	})
+
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// schedule hands the retries to the workers as they fall due, until the
+// dispatcher is closed, dropping those still waiting.
+func (d *Dispatcher) schedule() {
+	defer d.workers.Done()
+
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+
+	for {
+		d.rl.Lock()
+		var ready []delivery
+
+		now := time.Now()
+		for d.waiting.Len() > 0 && !d.waiting[0].Due.After(now) {
+			ready = append(ready, heap.Pop(&d.waiting).(delivery))
+		}
+
+		wait := time.Hour
+		if d.waiting.Len() > 0 {
+			wait = d.waiting[0].Due.Sub(now)
+		}
+		d.rl.Unlock()
+
+		for _, next := range ready {
+			select {
+			case d.due <- next:
+			case <-d.stop:
+				return
+			}
+		}
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+
+		timer.Reset(wait)
+
+		select {
+		case <-d.stop:
+			return
+		case <-d.wake:
+		case <-timer.C:
+		}
+	}
+}
+
+// post makes a single attempt at delivering the request.
+func (d *Dispatcher) post(req Request, number int) Attempt {
+	attempt := Attempt{
+		Request: req,
+		Number:  number,
+		Time:    time.Now(),
+	}
+
+	hr, err := http.NewRequest("POST", req.URL, bytes.NewReader(req.Body))
+	if err != nil {
+		attempt.Err = err
+		return attempt
+	}
+
+	hr.Header.Set("Content-Type", "application/json")
+	hr.Header.Set("User-Agent", "pocket-webhooks")
+	hr.Header.Set(EventHeader, req.Event)
+	hr.Header.Set(DeliveryHeader, req.ID)
+	hr.Header.Set(SignatureHeader, Sign(req.Secret, req.Body))
+
+	res, err := d.client.Do(hr)
+	attempt.Duration = time.Since(attempt.Time)
+
+	if err != nil {
+		attempt.Err = err
+		return attempt
+	}
+
+	io.Copy(ioutil.Discard, io.LimitReader(res.Body, maxResponse))
+	res.Body.Close()
+
+	attempt.Status = res.StatusCode
+	return attempt
+}
+
+//==============================================================================
+
+// delivery defines the attempt Number at delivering a request, due at the
+// giving time when retried, and the backoff before the one after it.
+type delivery struct {
+	Request Request
+	Number  int
+	Wait    time.Duration
+	Due     time.Time
+}
+
+// retryQueue implements heap.Interface over the retries waiting to be made,
+// the earliest due first.
+type retryQueue []delivery
+
+// Len returns the count of waiting retries.
+func (r retryQueue) Len() int {
+	return len(r)
+}
+
+// Less returns true/false if the retry at i falls due before the one at j.
+func (r retryQueue) Less(i, j int) bool {
+	return r[i].Due.Before(r[j].Due)
+}
+
+// Swap swaps the retries at i and j.
+func (r retryQueue) Swap(i, j int) {
+	r[i], r[j] = r[j], r[i]
+}
+
+// Push adds the retry at the end of the queue.
+func (r *retryQueue) Push(item interface{}) {
+	*r = append(*r, item.(delivery))
+}
+
+// Pop removes and returns the retry at the end of the queue.
+func (r *retryQueue) Pop() interface{} {
+	old := *r
+	last := old[len(old)-1]
+	*r = old[:len(old)-1]
+	return last
+}
+
+//==============================================================================
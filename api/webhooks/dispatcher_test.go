@@ -0,0 +1,287 @@
+package webhooks
+
+import (
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+//==============================================================================
+
+// receiver records the deliveries made to it, answering each with the next of
+// its statuses, the last repeated once they run out.
+type receiver struct {
+	rl       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := ioutil.ReadAll(req.Body)
+
+	r.rl.Lock()
+	r.requests = append(r.requests, req)
+	r.bodies = append(r.bodies, body)
+
+	status := r.statuses[0]
+	if len(r.statuses) > 1 {
+		r.statuses = r.statuses[1:]
+	}
+	r.rl.Unlock()
+
+	w.WriteHeader(status)
+}
+
+// reports collects the attempts reported by a dispatcher.
+type reports struct {
+	rl       sync.Mutex
+	attempts []Attempt
+	done     chan struct{}
+	want     int
+}
+
+// newReports returns a reports which signals once the giving count of attempts
+// were reported.
+func newReports(want int) *reports {
+	r := reports{
+		done: make(chan struct{}),
+		want: want,
+	}
+
+	return &r
+}
+
+// report implements the report function of the Dispatcher.
+func (r *reports) report(attempt Attempt) {
+	r.rl.Lock()
+	defer r.rl.Unlock()
+
+	r.attempts = append(r.attempts, attempt)
+	if len(r.attempts) == r.want {
+		close(r.done)
+	}
+}
+
+// wait waits for the attempts to be reported, returning them.
+func (r *reports) wait(t *testing.T) []Attempt {
+	select {
+	case <-r.done:
+	case <-time.After(5 * time.Second):
+		r.rl.Lock()
+		defer r.rl.Unlock()
+
+		t.Fatalf("Expected %d attempts, got %d", r.want, len(r.attempts))
+	}
+
+	r.rl.Lock()
+	defer r.rl.Unlock()
+
+	return append([]Attempt(nil), r.attempts...)
+}
+
+//==============================================================================
+
+// TestDispatcherSignature checks deliveries carry their event, id and the
+// signature of their body.
+func TestDispatcherSignature(t *testing.T) {
+	rc := receiver{statuses: []int{http.StatusOK}}
+
+	server := httptest.NewServer(&rc)
+	defer server.Close()
+
+	rp := newReports(1)
+
+	d := NewDispatcher(server.Client(), 2, 8, 0, time.Millisecond, rp.report)
+	defer d.Close()
+
+	req := Request{ID: "delivery", Hook: "hook", URL: server.URL, Secret: "secret", Event: "item.new", Body: []byte(`{"event":"item.new"}`)}
+	if err := d.Enqueue(req); err != nil {
+		t.Fatal(err)
+	}
+
+	attempts := rp.wait(t)
+	if !attempts[0].OK() || attempts[0].Number != 1 || attempts[0].Request.Hook != "hook" {
+		t.Fatalf("Expected a first attempt accepted, got %+v", attempts[0])
+	}
+
+	rc.rl.Lock()
+	defer rc.rl.Unlock()
+
+	got := rc.requests[0]
+
+	if got.Header.Get(EventHeader) != "item.new" || got.Header.Get(DeliveryHeader) != "delivery" {
+		t.Fatalf("Expected event and delivery headers, got %v", got.Header)
+	}
+
+	if !Verify("secret", rc.bodies[0], got.Header.Get(SignatureHeader)) {
+		t.Fatalf("Expected signature %q to verify the body", got.Header.Get(SignatureHeader))
+	}
+
+	if Verify("other", rc.bodies[0], got.Header.Get(SignatureHeader)) {
+		t.Fatal("Expected signature not to verify under another secret")
+	}
+}
+
+// TestDispatcherRetries checks failed deliveries are retried until accepted,
+// and rejected deliveries are not.
+func TestDispatcherRetries(t *testing.T) {
+	rc := receiver{statuses: []int{http.StatusInternalServerError, http.StatusTooManyRequests, http.StatusOK}}
+
+	server := httptest.NewServer(&rc)
+	defer server.Close()
+
+	rp := newReports(3)
+
+	d := NewDispatcher(server.Client(), 2, 8, 5, time.Millisecond, rp.report)
+	defer d.Close()
+
+	if err := d.Enqueue(Request{ID: "delivery", URL: server.URL}); err != nil {
+		t.Fatal(err)
+	}
+
+	attempts := rp.wait(t)
+
+	for index, status := range []int{http.StatusInternalServerError, http.StatusTooManyRequests, http.StatusOK} {
+		if attempts[index].Number != index+1 || attempts[index].Status != status {
+			t.Fatalf("Expected attempt %d answered with %d, got %+v", index+1, status, attempts[index])
+		}
+	}
+
+	if attempts[2].Time.Sub(attempts[1].Time) < 2*time.Millisecond {
+		t.Fatalf("Expected the backoff to double between retries")
+	}
+
+	rejected := receiver{statuses: []int{http.StatusBadRequest, http.StatusOK}}
+
+	other := httptest.NewServer(&rejected)
+	defer other.Close()
+
+	rp = newReports(1)
+
+	rd := NewDispatcher(other.Client(), 1, 8, 5, time.Millisecond, rp.report)
+
+	if err := rd.Enqueue(Request{ID: "rejected", URL: other.URL}); err != nil {
+		t.Fatal(err)
+	}
+
+	rp.wait(t)
+	time.Sleep(20 * time.Millisecond)
+	rd.Close()
+
+	rejected.rl.Lock()
+	defer rejected.rl.Unlock()
+
+	if len(rejected.requests) != 1 {
+		t.Fatalf("Expected a rejected delivery not to be retried, got %d attempts", len(rejected.requests))
+	}
+}
+
+// TestDispatcherRetriesWait checks retries waiting on their backoff leave the
+// workers free for other deliveries, and are dropped once closed.
+func TestDispatcherRetriesWait(t *testing.T) {
+	rc := receiver{statuses: []int{http.StatusServiceUnavailable, http.StatusOK}}
+
+	server := httptest.NewServer(&rc)
+	defer server.Close()
+
+	rp := newReports(2)
+
+	d := NewDispatcher(server.Client(), 1, 8, 5, time.Hour, rp.report)
+
+	if err := d.Enqueue(Request{ID: "failing", URL: server.URL}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := d.Enqueue(Request{ID: "next", URL: server.URL}); err != nil {
+		t.Fatal(err)
+	}
+
+	attempts := rp.wait(t)
+	if attempts[1].Request.ID != "next" || !attempts[1].OK() {
+		t.Fatalf("Expected the next delivery while the first waits to retry, got %+v", attempts[1])
+	}
+
+	closed := make(chan struct{})
+
+	go func() {
+		d.Close()
+		close(closed)
+	}()
+
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected close not to wait on the retries")
+	}
+
+	if err := d.Enqueue(Request{ID: "late", URL: server.URL}); err != ErrQueueClosed {
+		t.Fatalf("Expected ErrQueueClosed once closed, got %v", err)
+	}
+}
+
+//==============================================================================
+
+// TestClientPublic checks the delivery client refuses to connect to addresses
+// which are not public.
+func TestClientPublic(t *testing.T) {
+	for _, addr := range []string{"127.0.0.1", "::1", "10.1.2.3", "192.168.1.1", "172.16.0.1", "169.254.169.254", "fe80::1", "0.0.0.0", "224.0.0.1", "0.1.2.3", "0.255.255.255", "100.64.0.1", "100.127.255.254", "::ffff:100.100.100.200", "::ffff:10.0.0.1"} {
+		if Public(net.ParseIP(addr)) {
+			t.Errorf("Expected %s not to be public", addr)
+		}
+	}
+
+	for _, addr := range []string{"8.8.8.8", "93.184.216.34", "2606:4700::1111", "1.0.0.1", "100.63.255.255", "100.128.0.1"} {
+		if !Public(net.ParseIP(addr)) {
+			t.Errorf("Expected %s to be public", addr)
+		}
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Expected no request to reach a loopback receiver")
+	}))
+	defer server.Close()
+
+	res, err := NewClient(time.Second).Post(server.URL, "application/json", nil)
+	if err == nil {
+		res.Body.Close()
+		t.Fatal("Expected the delivery to a loopback address to fail")
+	}
+
+	if !errors.Is(err, ErrForbiddenAddress) {
+		t.Fatalf("Expected ErrForbiddenAddress, got %v", err)
+	}
+}
+
+// TestClientRedirects checks the delivery client hands back redirects instead
+// of following them.
+func TestClientRedirects(t *testing.T) {
+	var followed bool
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/internal" {
+			followed = true
+			return
+		}
+
+		http.Redirect(w, r, "/internal", http.StatusTemporaryRedirect)
+	}))
+	defer server.Close()
+
+	res, err := newClient(time.Second, nil).Post(server.URL, "application/json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res.Body.Close()
+
+	if followed || res.StatusCode != http.StatusTemporaryRedirect {
+		t.Fatalf("Expected the redirect to be handed back, got %d", res.StatusCode)
+	}
+}
@@ -0,0 +1,53 @@
+// Package webhooks provides the delivery of JSON payloads to the URLs users
+// register, signed with HMAC-SHA256 so receivers can verify their origin, and
+// retried in the background when they fail.
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+//==============================================================================
+
+// Headers set on every delivery.
+const (
+	EventHeader     = "X-Pocket-Event"
+	DeliveryHeader  = "X-Pocket-Delivery"
+	SignatureHeader = "X-Pocket-Signature"
+)
+
+// signaturePrefix names the hash of the signatures carried by deliveries.
+const signaturePrefix = "sha256="
+
+//==============================================================================
+
+// NewSecret returns a new random secret for signing deliveries.
+func NewSecret() (string, error) {
+	secret := make([]byte, 32)
+
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(secret), nil
+}
+
+// Sign returns the signature of the body under the giving secret, as carried
+// by the SignatureHeader of deliveries, in the form `sha256=<hex digest>`.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify returns true/false if the signature matches the body under the
+// giving secret, comparing them in constant time.
+func Verify(secret string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
+
+//==============================================================================
@@ -0,0 +1,34 @@
+package layers
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/gopherjs/gopherjs/js"
+	"github.com/influx6/pocket/api/bootstrap"
+)
+
+//==============================================================================
+
+// ErrNoBootstrap is returned when the page holds no bootstrap data.
+var ErrNoBootstrap = errors.New("No bootstrap data within page")
+
+// LoadBootstrap returns the bootstrap data rendered into the index page by the
+// pocket-server.
+func LoadBootstrap() (bootstrap.Data, error) {
+	var data bootstrap.Data
+
+	raw := js.Global.Get(bootstrap.Global)
+	if raw == js.Undefined || raw == nil {
+		return data, ErrNoBootstrap
+	}
+
+	content := js.Global.Get("JSON").Call("stringify", raw).String()
+	if err := json.Unmarshal([]byte(content), &data); err != nil {
+		return data, err
+	}
+
+	return data, nil
+}
+
+//==============================================================================
@@ -0,0 +1,112 @@
+package layers
+
+import (
+	"github.com/influx6/gu/gudispatch"
+	"github.com/influx6/pocket/api/budgets"
+)
+
+//==============================================================================
+
+// CommandLayer sets up the posting of the commands dispatched by the pocket
+// views to the pocket-server at the giving address, dispatching a Notify for
+// every command which fails.
+func CommandLayer(addr string) {
+	gudispatch.Subscribe(func(ni *budgets.NewBudgetItem) {
+		go postCommand(addr+"/items", ni)
+	})
+
+	gudispatch.Subscribe(func(nc *budgets.NewCategory) {
+		go postCommand(addr+"/categories", nc)
+	})
+
+	gudispatch.Subscribe(func(ab *budgets.AmendBudget) {
+		go postCommand(addr+"/budgets/amend", ab)
+	})
+
+	gudispatch.Subscribe(func(db *budgets.DeleteBudget) {
+		go postCommand(addr+"/budgets/delete", db)
+	})
+
+	gudispatch.Subscribe(func(rb *budgets.RestoreBudget) {
+		go postCommand(addr+"/budgets/restore", rb)
+	})
+
+	gudispatch.Subscribe(func(ab *budgets.ArchiveBudget) {
+		go postCommand(addr+"/budgets/archive", ab)
+	})
+
+	gudispatch.Subscribe(func(ai *budgets.AmendBudgetItem) {
+		go postCommand(addr+"/items/amend", ai)
+	})
+
+	gudispatch.Subscribe(func(di *budgets.DeleteBudgetItem) {
+		go postCommand(addr+"/items/delete", di)
+	})
+
+	gudispatch.Subscribe(func(ri *budgets.RestoreBudgetItem) {
+		go postCommand(addr+"/items/restore", ri)
+	})
+
+	gudispatch.Subscribe(func(ai *budgets.ArchiveBudgetItem) {
+		go postCommand(addr+"/items/archive", ai)
+	})
+
+	gudispatch.Subscribe(func(nr *budgets.NewRule) {
+		go postCommand(addr+"/rules", nr)
+	})
+
+	gudispatch.Subscribe(func(dr *budgets.DeleteRule) {
+		go postCommand(addr+"/rules/delete", dr)
+	})
+
+	gudispatch.Subscribe(func(nw *budgets.NewWebhook) {
+		go postCommand(addr+"/webhooks", nw)
+	})
+
+	gudispatch.Subscribe(func(dw *budgets.DeleteWebhook) {
+		go postCommand(addr+"/webhooks/delete", dw)
+	})
+
+	gudispatch.Subscribe(func(nr *budgets.NewRecurring) {
+		go postCommand(addr+"/recurring", nr)
+	})
+
+	gudispatch.Subscribe(func(dr *budgets.DeleteRecurring) {
+		go postCommand(addr+"/recurring/delete", dr)
+	})
+
+	gudispatch.Subscribe(func(so *budgets.SkipOccurrence) {
+		go postCommand(addr+"/recurring/skip", so)
+	})
+
+	gudispatch.Subscribe(func(po *budgets.PostponeOccurrence) {
+		go postCommand(addr+"/recurring/postpone", po)
+	})
+
+	gudispatch.Subscribe(func(rn *budgets.ReadNotification) {
+		go postCommand(addr+"/notifications/read", rn)
+	})
+
+	gudispatch.Subscribe(func(ra *budgets.ReadAllNotifications) {
+		go postCommand(addr+"/notifications/read-all", ra)
+	})
+}
+
+// postCommand posts the command to the endpoint, dispatching a Notify if the
+// request fails.
+func postCommand(endpoint string, command interface{}) {
+	if err := postJSON(endpoint, command, nil); err != nil {
+		msg := err.Error()
+
+		if rerr, ok := err.(*requestError); ok {
+			msg = rerr.Message
+		}
+
+		gudispatch.Dispatch(&budgets.Notify{
+			Message: msg,
+			Type:    budgets.FailedSync,
+		})
+	}
+}
+
+//==============================================================================
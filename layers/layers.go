@@ -1,25 +1,53 @@
 package layers
 
 import (
+	"strconv"
+	"sync/atomic"
+
 	"github.com/gopherjs/gopherjs/js"
 	"github.com/influx6/coquery/client"
-	"github.com/influx6/faux/utils"
 	"github.com/influx6/gu/gudispatch"
 	"github.com/influx6/gu/guviews"
-	"github.com/influx6/pocket/budgets"
+	"github.com/influx6/pocket/api/accounts"
+	"github.com/influx6/pocket/api/bootstrap"
+	"github.com/influx6/pocket/api/budgets"
+	"github.com/influx6/pocket/api/currency"
 )
 
 //==============================================================================
 
 // uuis defines a uuid-generator which allows us generate increasing uuid values.
-var uuis = utils.NewIncr(0)
+var uuis incr
+
+// incr provides a concurrently safe generator of increasing id values.
+type incr struct {
+	last int64
+}
+
+// New returns the next id value of the generator.
+func (i *incr) New() string {
+	return strconv.FormatInt(atomic.AddInt64(&i.last, 1), 10)
+}
 
 //==============================================================================
 
-// PocketLayer returns a view instanced with a Pocket rendering provider.
-func PocketLayer(currency string, qs client.Server, mount *js.Object) guviews.Views {
+// PocketLayer returns a view instanced with a Pocket rendering provider for the
+// giving pocket, taking over the markup rendered into the mount by the server.
+// The default currency is used when the pocket's currency is unknown, the
+// amounts are written for the giving locale and items can be filed under the
+// giving categories.
+func PocketLayer(pocket bootstrap.Pocket, categories budgets.Categories, defaultCurrency string, localeTag string, qs client.Server, mount *js.Object) guviews.Views {
+
+	cu, err := currency.ISO4217.Find(pocket.Pocket.Currency)
+	if err != nil {
+		cu, err = currency.ISO4217.Find(defaultCurrency)
+	}
+
+	locale, lerr := currency.FindLocale(localeTag)
+	if lerr != nil {
+		locale = currency.DefaultLocale
+	}
 
-	cu, err := budgets.BudgetCurrency.Find(currency)
 	if err != nil {
 		gudispatch.Dispatch(&budgets.Notify{
 			Message: err.Error(),
@@ -28,16 +56,81 @@ func PocketLayer(currency string, qs client.Server, mount *js.Object) guviews.Vi
 		return nil
 	}
 
-	uuid := uuis.New()
+	uuid := pocket.Pocket.ID
 
 	guviews.MustCreate(guviews.ViewConfig{
 		Name:  "pocket/pocket-budget",
 		ID:    uuid,
 		Paths: []string{"/", "/pockets"},
 		Param: budgets.BudgetOptions{
-			UUID:     uuid,
+			UUID:       uuid,
+			Server:     qs,
+			Currency:   cu,
+			Locale:     locale,
+			Records:    pocket.Budgets,
+			Categories: categories,
+		},
+	})
+
+	view := guviews.MustGet(uuid)
+	view.Hydrate(mount)
+
+	return view
+}
+
+//==============================================================================
+
+// NotificationLayer instantiates the notification centre of the user with the
+// giving id, which shows every notification dispatched along with those stored
+// for the user by the server.
+func NotificationLayer(user string, qs client.Server, mount *js.Object) guviews.Views {
+	guviews.MustCreate(guviews.ViewConfig{
+		Name:  "pocket/notifications",
+		ID:    user,
+		Paths: []string{"/", "/pockets"},
+		Param: budgets.NotificationOptions{
+			UUID:   user,
+			Server: qs,
+		},
+	})
+
+	view := guviews.MustGet(user)
+	view.Mount(mount)
+
+	return view
+}
+
+//==============================================================================
+
+// UpcomingLayer instantiates the view listing the items the recurring items of
+// the giving pocket are due to write, its amounts held in the currency of the
+// pocket, else the default currency, and written for the giving locale.
+func UpcomingLayer(pocket bootstrap.Pocket, defaultCurrency string, localeTag string, qs client.Server, mount *js.Object) guviews.Views {
+	cu, err := currency.ISO4217.Find(pocket.Pocket.Currency)
+	if err != nil {
+		cu, err = currency.ISO4217.Find(defaultCurrency)
+	}
+
+	if err != nil {
+		return nil
+	}
+
+	locale, err := currency.FindLocale(localeTag)
+	if err != nil {
+		locale = currency.DefaultLocale
+	}
+
+	uuid := budgets.UpcomingID(pocket.Pocket.ID)
+
+	guviews.MustCreate(guviews.ViewConfig{
+		Name:  "pocket/upcoming",
+		ID:    uuid,
+		Paths: []string{"/", "/pockets"},
+		Param: budgets.UpcomingOptions{
+			UUID:     pocket.Pocket.ID,
 			Server:   qs,
 			Currency: cu,
+			Locale:   locale,
 		},
 	})
 
@@ -51,16 +144,117 @@ func PocketLayer(currency string, qs client.Server, mount *js.Object) guviews.Vi
 
 // LoginLayer instantiates the login layer for the application, setting up
 // and returning the view concerned with login.
-func LoginLayer(qs client.Server, mount *js.Object) guviews.Views {
+func LoginLayer(addr string, qs client.Server, mount *js.Object) guviews.Views {
+	uuid := uuis.New()
+
+	guviews.MustCreate(guviews.ViewConfig{
+		Name:  "pocket/user-login",
+		ID:    uuid,
+		Paths: []string{"/login"},
+		Param: accounts.AccountOptions{UUID: uuid},
+	})
+
+	gudispatch.Subscribe(func(lu *accounts.LoginUser) {
+		if lu.UUID != uuid {
+			return
+		}
+
+		if fields := lu.Validate(); len(fields) > 0 {
+			gudispatch.Dispatch(&accounts.AccountErrors{UUID: uuid, Fields: fields})
+			return
+		}
+
+		go startSession(uuid, addr+"/accounts/login", lu)
+	})
+
+	view := guviews.MustGet(uuid)
+	view.Mount(mount)
 
-	return nil
+	return view
 }
 
 //==============================================================================
 
 // AccountLayer instantiates the account layer for the application, setting up
 // and returning the view concerned with login.
-func AccountLayer(qs client.Server, mount *js.Object) guviews.Views {
+func AccountLayer(addr string, qs client.Server, mount *js.Object) guviews.Views {
+	uuid := uuis.New()
+
+	guviews.MustCreate(guviews.ViewConfig{
+		Name:  "pocket/new-user",
+		ID:    uuid,
+		Paths: []string{"/register"},
+		Param: accounts.AccountOptions{UUID: uuid},
+	})
+
+	gudispatch.Subscribe(func(ru *accounts.RegisterUser) {
+		if ru.UUID != uuid {
+			return
+		}
+
+		if fields := ru.Validate(); len(fields) > 0 {
+			gudispatch.Dispatch(&accounts.AccountErrors{UUID: uuid, Fields: fields})
+			return
+		}
+
+		go startSession(uuid, addr+"/accounts/register", ru)
+	})
+
+	gudispatch.Subscribe(func(lu *accounts.LogoutUser) {
+		go func() {
+			if lu.Token == "" {
+				lu.Token = SessionToken()
+			}
 
-	return nil
+			postJSON(addr+"/accounts/logout", lu, nil)
+			js.Global.Get("localStorage").Call("removeItem", sessionKey)
+			gudispatch.Navigate("/login")
+		}()
+	})
+
+	view := guviews.MustGet(uuid)
+	view.Mount(mount)
+
+	return view
 }
+
+//==============================================================================
+
+// sessionKey defines the localStorage key which holds the session token.
+const sessionKey = "pocket.session"
+
+// SessionToken returns the session token stored by the last successful login.
+func SessionToken() string {
+	token := js.Global.Get("localStorage").Call("getItem", sessionKey)
+	if token == nil || token == js.Undefined {
+		return ""
+	}
+
+	return token.String()
+}
+
+// startSession posts the giving account request to the endpoint, storing the
+// session received or dispatching the errors returned to the view.
+func startSession(uuid string, endpoint string, body interface{}) {
+	var session accounts.Session
+
+	if err := postJSON(endpoint, body, &session); err != nil {
+		ae := accounts.AccountErrors{UUID: uuid, Message: err.Error()}
+
+		if rerr, ok := err.(*requestError); ok {
+			ae.Message = rerr.Message
+			ae.Fields = rerr.Fields
+		}
+
+		gudispatch.Dispatch(&ae)
+		return
+	}
+
+	js.Global.Get("localStorage").Call("setItem", sessionKey, session.Token)
+	gudispatch.Dispatch(&session)
+
+	// Reload the index page, which the server renders with the user's pockets.
+	js.Global.Get("location").Call("assign", "/")
+}
+
+//==============================================================================
@@ -0,0 +1,111 @@
+package layers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"time"
+
+	"github.com/influx6/coquery/data"
+	"github.com/influx6/pocket/api/accounts"
+
+	"honnef.co/go/js/xhr"
+)
+
+//==============================================================================
+
+// requestError defines the JSON error returned by the pocket-server when a
+// request fails.
+type requestError struct {
+	Status  int                  `json:"-"`
+	Message string               `json:"error"`
+	Fields  accounts.FieldErrors `json:"fields"`
+}
+
+// Error returns the message of the error.
+func (r *requestError) Error() string {
+	return fmt.Sprintf("Request Failed[%d]: %s", r.Status, r.Message)
+}
+
+// RequestTimeout defines the time a request made to the pocket-server may take
+// before it fails.
+var RequestTimeout = 60 * time.Second
+
+// postJSON sends the body as JSON to the giving endpoint, decoding the response
+// into the response value if not nil. It blocks and must be called within
+// a goroutine.
+func postJSON(endpoint string, body interface{}, response interface{}) error {
+	content, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	reply, err := send(endpoint, content)
+	if err != nil {
+		return err
+	}
+
+	if response == nil || reply == "" {
+		return nil
+	}
+
+	return json.Unmarshal([]byte(reply), response)
+}
+
+// send posts the JSON content to the endpoint with the session token of the
+// user, returning the text of the response else a requestError if the request
+// failed.
+func send(endpoint string, content []byte) (string, error) {
+	req := xhr.NewRequest("POST", endpoint)
+	req.Timeout = int(RequestTimeout / time.Millisecond)
+	req.ResponseType = xhr.Text
+	req.SetRequestHeader("Content-Type", "application/json")
+
+	if token := SessionToken(); token != "" {
+		req.SetRequestHeader("Authorization", "Bearer "+token)
+	}
+
+	if err := req.Send(string(content)); err != nil {
+		return "", err
+	}
+
+	if req.Status < 200 || req.Status >= 300 {
+		rerr := requestError{Status: req.Status}
+		json.Unmarshal([]byte(req.ResponseText), &rerr)
+		return "", &rerr
+	}
+
+	return req.ResponseText, nil
+}
+
+//==============================================================================
+
+// HTTP provides a client.ServeTransport built on the XMLRequestHTTP provided by
+// the browser which authenticates every request with the session token.
+var HTTP sessionHTTP
+
+type sessionHTTP struct{}
+
+// Do issues the requests and collects the response into a pack.
+func (sessionHTTP) Do(addr string, body io.Reader) (data.ResponsePack, error) {
+	var pack data.ResponsePack
+
+	content, err := ioutil.ReadAll(body)
+	if err != nil {
+		return pack, err
+	}
+
+	reply, err := send(addr, content)
+	if err != nil {
+		return pack, err
+	}
+
+	if err := json.Unmarshal([]byte(reply), &pack); err != nil {
+		return pack, err
+	}
+
+	return pack, nil
+}
+
+//==============================================================================
@@ -0,0 +1,64 @@
+package layers
+
+import (
+	"encoding/json"
+	"net/url"
+
+	"github.com/gopherjs/gopherjs/js"
+	"github.com/influx6/coquery/client"
+	"github.com/influx6/coquery/data"
+	"github.com/influx6/gu/gudispatch"
+	"github.com/influx6/pocket/api/budgets"
+)
+
+//==============================================================================
+
+// LiveUpdates follows the update stream of the pocket-server for the giving
+// pockets of the user through an EventSource, which the browser resumes when
+// dropped. Each change is pushed into the servo, firing the update triggers
+// watching the changed records, and the pockets it touched are synced so
+// budgets added to empty pockets show up as well, as are the notifications of
+// the user when they change. It returns the EventSource, which is closed to
+// stop the updates.
+func LiveUpdates(addr string, servo *client.Servo, user string, pockets []string) *js.Object {
+	params := url.Values{"pocket": pockets}
+
+	source := js.Global.Get("EventSource").New(addr+"/updates?"+params.Encode(), map[string]interface{}{
+		"withCredentials": true,
+	})
+
+	watched := make(map[string]bool, len(pockets))
+	for _, pocket := range pockets {
+		watched[pocket] = true
+	}
+
+	source.Call("addEventListener", "delta", func(event *js.Object) {
+		var pack data.ResponsePack
+
+		if err := json.Unmarshal([]byte(event.Get("data").String()), &pack); err != nil {
+			gudispatch.Dispatch(&budgets.Notify{
+				Message: err.Error(),
+				Type:    budgets.FailedSync,
+			})
+			return
+		}
+
+		go func() {
+			servo.Push(pack)
+
+			for _, key := range pack.Deltas {
+				switch {
+				case watched[key]:
+					gudispatch.Dispatch(&budgets.SyncBudget{UUID: key})
+					gudispatch.Dispatch(&budgets.SyncUpcoming{UUID: key})
+				case key == budgets.NotificationsKey(user):
+					gudispatch.Dispatch(&budgets.SyncNotifications{UUID: user})
+				}
+			}
+		}()
+	})
+
+	return source
+}
+
+//==============================================================================
@@ -5,7 +5,6 @@ import (
 	"time"
 
 	"github.com/influx6/coquery/client"
-	"github.com/influx6/coquery/client/js"
 	"github.com/influx6/pocket/layers"
 
 	"honnef.co/go/js/dom"
@@ -34,8 +33,43 @@ func main() {
 	window := dom.GetWindow()
 	doc := window.Document()
 
-	client := client.NewServo(events, "http://127.0.0.1:3000", 300*time.Millisecond, js.HTTP)
+	boot, err := layers.LoadBootstrap()
+	if err != nil {
+		events.Error("pocket-client", "main", err, "Failed to load bootstrap data")
+		return
+	}
 
-	layers.AccountLayer(client, doc.QuerySelector("body").Underlying())
+	client := client.NewServo(events, boot.API, 300*time.Millisecond, layers.HTTP)
+
+	layers.AccountLayer(boot.API, client, doc.QuerySelector("body").Underlying())
+
+	if boot.User == nil {
+		return
+	}
+
+	layers.CommandLayer(boot.API)
+
+	if mount := doc.QuerySelector("[data-notifications]"); mount != nil {
+		layers.NotificationLayer(boot.User.ID, client, mount.Underlying())
+	}
+
+	var pockets []string
+
+	// Take over the pockets already rendered into the page by the server.
+	for _, pocket := range boot.Pockets {
+		mount := doc.QuerySelector(fmt.Sprintf("[data-pocket=%q]", pocket.Pocket.ID))
+		if mount == nil {
+			continue
+		}
+
+		layers.PocketLayer(pocket, boot.Categories, boot.Currency, boot.Locale, client, mount.Underlying())
+		pockets = append(pockets, pocket.Pocket.ID)
+
+		if upcoming := doc.QuerySelector(fmt.Sprintf("[data-upcoming=%q]", pocket.Pocket.ID)); upcoming != nil {
+			layers.UpcomingLayer(pocket, boot.Currency, boot.Locale, client, upcoming.Underlying())
+		}
+	}
+
+	layers.LiveUpdates(boot.API, client, boot.User.ID, pockets)
 
 }
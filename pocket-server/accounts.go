@@ -0,0 +1,469 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/influx6/faux/context"
+	"github.com/influx6/faux/web/app"
+	"github.com/influx6/pocket/api/accounts"
+	"github.com/influx6/pocket/api/budgets"
+	"github.com/influx6/pocket/api/currency"
+	"github.com/influx6/pocket/api/queries"
+	"github.com/influx6/pocket/api/store"
+	"github.com/satori/go.uuid"
+)
+
+//==============================================================================
+
+// ErrInvalidLogin is returned when the email or password of a login request
+// does not match an account.
+var ErrInvalidLogin = errors.New("Invalid email or password")
+
+// ErrInvalidSession is returned when a request carries no valid session token.
+var ErrInvalidSession = errors.New("Invalid Session")
+
+// ErrInvalidReset is returned when a password reset carries a token which
+// does not match the account or has expired.
+var ErrInvalidReset = errors.New("Invalid or expired reset token")
+
+// resetLifetime defines how long a mailed password reset token can be used.
+const resetLifetime = time.Hour
+
+// accountData provides the registration and session handling of the
+// pocket-server user accounts.
+type accountData struct {
+	store    store.Users
+	mail     *postOffice
+	lifetime time.Duration
+}
+
+// newAccountData returns a new instance of accountData issuing sessions which
+// last for the giving lifetime, mailing password reset tokens through the
+// giving postOffice, if any.
+func newAccountData(st store.Users, lifetime time.Duration, office *postOffice) *accountData {
+	ad := accountData{
+		store:    st,
+		mail:     office,
+		lifetime: lifetime,
+	}
+
+	return &ad
+}
+
+// Register adds the account queries into the provided resolver.
+func (a *accountData) Register(rs *queries.Resolver) {
+	rs.Register("preferences", a.queryPreferences)
+}
+
+// Routes registers the account routes.
+func (a *accountData) Routes(pa *app.App) {
+	app.PageRoute(pa, "POST", "/accounts/register", a.register)
+	app.PageRoute(pa, "POST", "/accounts/login", a.login)
+	app.PageRoute(pa, "POST", "/accounts/logout", a.logout)
+	app.PageRoute(pa, "POST", "/accounts/currency", a.setCurrency)
+	app.PageRoute(pa, "POST", "/accounts/preferences", a.setPreferences)
+	app.PageRoute(pa, "POST", "/accounts/reset/request", a.requestReset)
+	app.PageRoute(pa, "POST", "/accounts/reset", a.resetPassword)
+}
+
+// queryPreferences resolves the `preferences` query, returning the
+// preferences of the caller.
+func (a *accountData) queryPreferences(ctx context.Context, q queries.Query) (interface{}, error) {
+	user, ok := contextUser(ctx)
+	if !ok {
+		return nil, ErrInvalidSession
+	}
+
+	return []accounts.Preferences{user.Preferences}, nil
+}
+
+// register handles the accounts.RegisterUser command, creating the account
+// and responding with a new session.
+func (a *accountData) register(ctx context.Context, rw *app.ResponseRequest, params app.Param) error {
+	var ru accounts.RegisterUser
+
+	if err := json.NewDecoder(rw.R.Body).Decode(&ru); err != nil {
+		return err
+	}
+
+	if fields := ru.Validate(); len(fields) > 0 {
+		respondFields(rw, http.StatusBadRequest, "Invalid Registration", fields)
+		return nil
+	}
+
+	email := accounts.NormalizeEmail(ru.Email)
+
+	if _, err := a.store.User(email); err != store.ErrNotFound {
+		if err != nil {
+			return err
+		}
+
+		respondFields(rw, http.StatusConflict, "Invalid Registration", accounts.FieldErrors{
+			{Name: "email", Error: "Email is already registered"},
+		})
+		return nil
+	}
+
+	hash, err := accounts.HashPassword(ru.Password)
+	if err != nil {
+		return err
+	}
+
+	user := accounts.UserRecord{
+		ID:      uuid.NewV4().String(),
+		Email:   email,
+		Hash:    hash,
+		Created: time.Now(),
+	}
+
+	session, err := a.newSession(&user)
+	if err != nil {
+		return err
+	}
+
+	setSessionCookie(rw, session)
+	rw.Respond(http.StatusCreated, session)
+	return nil
+}
+
+// login handles the accounts.LoginUser command, responding with a new session
+// when the password matches.
+func (a *accountData) login(ctx context.Context, rw *app.ResponseRequest, params app.Param) error {
+	var lu accounts.LoginUser
+
+	if err := json.NewDecoder(rw.R.Body).Decode(&lu); err != nil {
+		return err
+	}
+
+	if fields := lu.Validate(); len(fields) > 0 {
+		respondFields(rw, http.StatusBadRequest, "Invalid Login", fields)
+		return nil
+	}
+
+	user, err := a.store.User(accounts.NormalizeEmail(lu.Email))
+	if err != nil {
+		if err == store.ErrNotFound {
+			rw.RespondError(http.StatusUnauthorized, ErrInvalidLogin)
+			return nil
+		}
+
+		return err
+	}
+
+	ok, err := accounts.CheckPassword(user.Hash, lu.Password)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		rw.RespondError(http.StatusUnauthorized, ErrInvalidLogin)
+		return nil
+	}
+
+	session, err := a.newSession(&user)
+	if err != nil {
+		return err
+	}
+
+	setSessionCookie(rw, session)
+	rw.Respond(http.StatusOK, session)
+	return nil
+}
+
+// logout handles the accounts.LogoutUser command, ending the session of the
+// token provided either in the body or the Authorization header.
+func (a *accountData) logout(ctx context.Context, rw *app.ResponseRequest, params app.Param) error {
+	var lu accounts.LogoutUser
+
+	if rw.R.ContentLength != 0 {
+		if err := json.NewDecoder(rw.R.Body).Decode(&lu); err != nil {
+			return err
+		}
+	}
+
+	if lu.Token == "" {
+		lu.Token = requestToken(rw.R)
+	}
+
+	clearSessionCookie(rw)
+
+	user, err := a.store.UserByToken(lu.Token)
+	if err != nil {
+		if err == store.ErrNotFound {
+			rw.RespondError(http.StatusUnauthorized, ErrInvalidSession)
+			return nil
+		}
+
+		return err
+	}
+
+	user.EndSession(lu.Token)
+
+	if err := a.store.SaveUser(user); err != nil {
+		return err
+	}
+
+	rw.Respond(http.StatusNoContent, nil)
+	return nil
+}
+
+// setCurrency handles the accounts.SetCurrency command, changing the home
+// currency of the caller.
+func (a *accountData) setCurrency(ctx context.Context, rw *app.ResponseRequest, params app.Param) error {
+	user, ok := contextUser(ctx)
+	if !ok {
+		rw.RespondError(http.StatusUnauthorized, ErrInvalidSession)
+		return nil
+	}
+
+	var sc accounts.SetCurrency
+
+	if err := json.NewDecoder(rw.R.Body).Decode(&sc); err != nil {
+		return err
+	}
+
+	cu, err := currency.ISO4217.Find(sc.Currency)
+	if err != nil {
+		respondFields(rw, http.StatusBadRequest, "Invalid Currency", accounts.FieldErrors{
+			{Name: "currency", Error: "Provide a valid ISO 4217 currency"},
+		})
+		return nil
+	}
+
+	user.Currency = cu.Code
+
+	if err := a.store.SaveUser(user); err != nil {
+		return err
+	}
+
+	rw.Respond(http.StatusNoContent, nil)
+	return nil
+}
+
+// setPreferences handles the accounts.SetPreferences command, changing how
+// the caller is reached outside the app.
+func (a *accountData) setPreferences(ctx context.Context, rw *app.ResponseRequest, params app.Param) error {
+	user, ok := contextUser(ctx)
+	if !ok {
+		rw.RespondError(http.StatusUnauthorized, ErrInvalidSession)
+		return nil
+	}
+
+	var sp accounts.SetPreferences
+
+	if err := json.NewDecoder(rw.R.Body).Decode(&sp); err != nil {
+		return err
+	}
+
+	switch budgets.Severity(sp.MailAlerts) {
+	case "", budgets.SeverityInfo, budgets.SeverityWarning, budgets.SeverityCritical:
+	default:
+		respondFields(rw, http.StatusBadRequest, "Invalid Preferences", accounts.FieldErrors{
+			{Name: "mail_alerts", Error: "Provide info, warning, critical or nothing"},
+		})
+		return nil
+	}
+
+	user.Preferences = sp.Preferences
+
+	if err := a.store.SaveUser(user); err != nil {
+		return err
+	}
+
+	rw.Respond(http.StatusNoContent, nil)
+	return nil
+}
+
+// requestReset handles the accounts.RequestReset command, mailing a password
+// reset token to the account with the giving email. It responds the same
+// whether or not the account exists, so accounts can not be discovered.
+func (a *accountData) requestReset(ctx context.Context, rw *app.ResponseRequest, params app.Param) error {
+	if a.mail == nil {
+		rw.RespondError(http.StatusServiceUnavailable, ErrMailDisabled)
+		return nil
+	}
+
+	var rr accounts.RequestReset
+
+	if err := json.NewDecoder(rw.R.Body).Decode(&rr); err != nil {
+		return err
+	}
+
+	user, err := a.store.User(accounts.NormalizeEmail(rr.Email))
+	if err != nil {
+		if err == store.ErrNotFound {
+			rw.Respond(http.StatusNoContent, nil)
+			return nil
+		}
+
+		return err
+	}
+
+	token, err := accounts.NewToken()
+	if err != nil {
+		return err
+	}
+
+	user.ResetHash = accounts.HashToken(token)
+	user.ResetExpires = time.Now().Add(resetLifetime)
+
+	if err := a.store.SaveUser(user); err != nil {
+		return err
+	}
+
+	link := a.mail.Link("/?" + url.Values{"reset": {token}, "email": {user.Email}}.Encode())
+
+	if err := a.mail.Send("reset", user.Email, resetMail{Email: user.Email, Link: link, Expires: user.ResetExpires}); err != nil {
+		return err
+	}
+
+	rw.Respond(http.StatusNoContent, nil)
+	return nil
+}
+
+// resetPassword handles the accounts.ResetPassword command, replacing the
+// password of the account when the mailed token matches. The token can only
+// be used once and every session of the account is ended.
+func (a *accountData) resetPassword(ctx context.Context, rw *app.ResponseRequest, params app.Param) error {
+	var rp accounts.ResetPassword
+
+	if err := json.NewDecoder(rw.R.Body).Decode(&rp); err != nil {
+		return err
+	}
+
+	if fields := rp.Validate(); len(fields) > 0 {
+		respondFields(rw, http.StatusBadRequest, "Invalid Reset", fields)
+		return nil
+	}
+
+	user, err := a.store.User(accounts.NormalizeEmail(rp.Email))
+	if err != nil {
+		if err == store.ErrNotFound {
+			rw.RespondError(http.StatusUnauthorized, ErrInvalidReset)
+			return nil
+		}
+
+		return err
+	}
+
+	if !accounts.CheckToken(user.ResetHash, rp.Token) || !time.Now().Before(user.ResetExpires) {
+		rw.RespondError(http.StatusUnauthorized, ErrInvalidReset)
+		return nil
+	}
+
+	hash, err := accounts.HashPassword(rp.Password)
+	if err != nil {
+		return err
+	}
+
+	user.Hash = hash
+	user.ResetHash = ""
+	user.ResetExpires = time.Time{}
+	user.Sessions = nil
+
+	if err := a.store.SaveUser(user); err != nil {
+		return err
+	}
+
+	rw.Respond(http.StatusNoContent, nil)
+	return nil
+}
+
+// newSession issues a new session token for the user and stores it beside the
+// sessions the user already holds, so logging in from another device does not
+// end the sessions of the user's other devices.
+func (a *accountData) newSession(user *accounts.UserRecord) (accounts.Session, error) {
+	token, err := accounts.NewToken()
+	if err != nil {
+		return accounts.Session{}, err
+	}
+
+	now := time.Now()
+	record := accounts.SessionRecord{Token: token, Expires: now.Add(a.lifetime)}
+
+	user.AddSession(record, now)
+
+	if err := a.store.SaveUser(*user); err != nil {
+		return accounts.Session{}, err
+	}
+
+	session := accounts.Session{
+		Email:   user.Email,
+		Token:   record.Token,
+		Expires: record.Expires,
+	}
+
+	return session, nil
+}
+
+//==============================================================================
+
+// respondFields renders a JSON error carrying the giving field errors.
+func respondFields(rw *app.ResponseRequest, code int, message string, fields accounts.FieldErrors) {
+	jerr := app.JSONError{Error: message}
+
+	for _, field := range fields {
+		jerr.Fields = append(jerr.Fields, app.Field{
+			Name:  field.Name,
+			Error: field.Error,
+		})
+	}
+
+	rw.Respond(code, jerr)
+}
+
+// sessionCookie defines the cookie which carries the session token for page
+// loads, which can not set the Authorization header.
+const sessionCookie = "pocket_session"
+
+// setSessionCookie stores the token of the session in the session cookie.
+func setSessionCookie(rw *app.ResponseRequest, session accounts.Session) {
+	http.SetCookie(rw, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    session.Token,
+		Path:     "/",
+		Expires:  session.Expires,
+		HttpOnly: true,
+		Secure:   rw.R.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// clearSessionCookie removes the session cookie.
+func clearSessionCookie(rw *app.ResponseRequest) {
+	http.SetCookie(rw, &http.Cookie{
+		Name:     sessionCookie,
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+	})
+}
+
+// requestToken returns the session token carried by the request's
+// Authorization header. The session cookie is only honoured for GET and HEAD
+// requests, which never change records, so it can not be used to forge
+// commands from other sites.
+func requestToken(r *http.Request) string {
+	auth := strings.TrimSpace(r.Header.Get("Authorization"))
+
+	if len(auth) > 7 && strings.EqualFold(auth[:7], "Bearer ") {
+		return strings.TrimSpace(auth[7:])
+	}
+
+	if r.Method != "GET" && r.Method != "HEAD" {
+		return ""
+	}
+
+	if cookie, err := r.Cookie(sessionCookie); err == nil {
+		return cookie.Value
+	}
+
+	return ""
+}
+
+//==============================================================================
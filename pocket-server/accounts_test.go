@@ -0,0 +1,52 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/influx6/pocket/api/accounts"
+)
+
+//==============================================================================
+
+// TestSessionsPerDevice checks logging in from another device keeps the
+// sessions of the user's other devices, and logging out ends only the session
+// it was sent with.
+func TestSessionsPerDevice(t *testing.T) {
+	ts := newTestServer(t)
+	defer ts.Close()
+
+	laptop := ts.register(t, "owner@pocket.io")
+
+	var session accounts.Session
+
+	lu := accounts.LoginUser{Email: "owner@pocket.io", Password: "pocket-password"}
+	if status := ts.post(t, "/accounts/login", "", lu, &session); status != http.StatusOK {
+		t.Fatalf("Expected login to succeed, got status %d", status)
+	}
+
+	phone := session.Token
+	if phone == "" || phone == laptop {
+		t.Fatalf("Expected the login to issue a new session token, got %q", phone)
+	}
+
+	prefs := accounts.SetPreferences{Preferences: accounts.Preferences{MailAlerts: "warning"}}
+
+	for _, token := range []string{laptop, phone} {
+		if status := ts.post(t, "/accounts/preferences", token, prefs, nil); status != http.StatusNoContent {
+			t.Fatalf("Expected session %q to stay valid, got status %d", token, status)
+		}
+	}
+
+	if status := ts.post(t, "/accounts/logout", laptop, nil, nil); status != http.StatusNoContent {
+		t.Fatalf("Expected logout to succeed, got status %d", status)
+	}
+
+	if status := ts.post(t, "/accounts/preferences", laptop, prefs, nil); status != http.StatusUnauthorized {
+		t.Fatalf("Expected the logged out session to be refused, got status %d", status)
+	}
+
+	if status := ts.post(t, "/accounts/preferences", phone, prefs, nil); status != http.StatusNoContent {
+		t.Fatalf("Expected the other session to stay valid after logout, got status %d", status)
+	}
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influx6/faux/context"
+	"github.com/influx6/faux/web/app"
+)
+
+//==============================================================================
+
+// ErrUnknownAsset is returned when a static asset which does not exists is
+// requested.
+var ErrUnknownAsset = errors.New("Unknown Asset")
+
+// staticPrefix defines the path prefix which static assets are served under.
+const staticPrefix = "/static/"
+
+// templatesDir defines the directory within the static directory holding the
+// page templates, which are never served as assets.
+const templatesDir = "templates"
+
+// assetHash defines the content hash of an asset file, along with the
+// modification time and size the hash was computed for.
+type assetHash struct {
+	mod  time.Time
+	size int64
+	sum  string
+}
+
+// assets serves the files of the static directory, fingerprinting each by the
+// hash of its content. Requests carrying the current hash of the file as their
+// `v` parameter are cached forever, while all others are revalidated on use.
+type assets struct {
+	root   http.Dir
+	fl     sync.Mutex
+	hashes map[string]assetHash
+}
+
+// newAssets returns a new instance of assets serving the giving directory.
+func newAssets(root string) *assets {
+	as := assets{
+		root:   http.Dir(root),
+		hashes: make(map[string]assetHash),
+	}
+
+	return &as
+}
+
+// Routes registers the route serving the static assets.
+func (a *assets) Routes(pa *app.App) {
+	app.PageRoute(pa, "GET", staticPrefix+"*file", a.serve)
+}
+
+// Path returns the public path of the giving asset, fingerprinted with the
+// hash of its content when available.
+func (a *assets) Path(name string) string {
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+
+	sum, err := a.hash(name)
+	if err != nil {
+		events.Error(contexts, "assets.Path", err, "Failed to hash asset[%s]", name)
+		return staticPrefix + name
+	}
+
+	return staticPrefix + name + "?v=" + sum
+}
+
+// open returns the file of the giving asset, rejecting directories, dotfiles
+// and the page templates.
+func (a *assets) open(name string) (http.File, os.FileInfo, error) {
+	for _, part := range strings.Split(name, "/") {
+		if strings.HasPrefix(part, ".") {
+			return nil, nil, ErrUnknownAsset
+		}
+	}
+
+	if name == templatesDir || strings.HasPrefix(name, templatesDir+"/") {
+		return nil, nil, ErrUnknownAsset
+	}
+
+	file, err := a.root.Open("/" + name)
+	if err != nil {
+		return nil, nil, ErrUnknownAsset
+	}
+
+	info, err := file.Stat()
+	if err != nil || info.IsDir() {
+		file.Close()
+		return nil, nil, ErrUnknownAsset
+	}
+
+	return file, info, nil
+}
+
+// hash returns the content hash of the giving asset, reusing the last hash
+// computed for it until the file changes.
+func (a *assets) hash(name string) (string, error) {
+	file, info, err := a.open(name)
+	if err != nil {
+		return "", err
+	}
+
+	defer file.Close()
+
+	a.fl.Lock()
+	last, ok := a.hashes[name]
+	a.fl.Unlock()
+
+	if ok && last.mod.Equal(info.ModTime()) && last.size == info.Size() {
+		return last.sum, nil
+	}
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, file); err != nil {
+		return "", err
+	}
+
+	last = assetHash{
+		mod:  info.ModTime(),
+		size: info.Size(),
+		sum:  hex.EncodeToString(hasher.Sum(nil))[:16],
+	}
+
+	a.fl.Lock()
+	a.hashes[name] = last
+	a.fl.Unlock()
+
+	return last.sum, nil
+}
+
+// serve writes out the requested asset with the caching headers for its
+// content hash.
+func (a *assets) serve(ctx context.Context, rw *app.ResponseRequest, params app.Param) error {
+	name := strings.TrimPrefix(path.Clean("/"+params["file"]), "/")
+
+	sum, err := a.hash(name)
+	if err != nil {
+		if err == ErrUnknownAsset {
+			rw.RespondError(http.StatusNotFound, err)
+			return nil
+		}
+
+		return err
+	}
+
+	file, info, err := a.open(name)
+	if err != nil {
+		rw.RespondError(http.StatusNotFound, err)
+		return nil
+	}
+
+	defer file.Close()
+
+	rw.Header().Set("ETag", `"`+sum+`"`)
+
+	if rw.R.URL.Query().Get("v") == sum {
+		rw.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	} else {
+		rw.Header().Set("Cache-Control", "no-cache")
+	}
+
+	http.ServeContent(rw, rw.R, info.Name(), info.ModTime(), file)
+	return nil
+}
+
+//==============================================================================
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/influx6/faux/web/app"
+)
+
+//==============================================================================
+
+// writeAsset writes the content into the asset of the static directory at
+// the giving name, dated at the giving time.
+func writeAsset(t *testing.T, dir string, name string, content string, mod time.Time) {
+	file := filepath.Join(dir, filepath.FromSlash(name))
+
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(file, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Chtimes(file, mod, mod); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// sum returns the fingerprint expected for an asset of the giving content.
+func sum(content string) string {
+	hash := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(hash[:])[:16]
+}
+
+// get requests the path from the server with the giving headers, returning
+// the response with its body read.
+func get(t *testing.T, server *httptest.Server, path string, headers map[string]string) (*http.Response, string) {
+	req, err := http.NewRequest("GET", server.URL+path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return res, string(body)
+}
+
+//==============================================================================
+
+// TestAssetPath checks assets are fingerprinted with the hash of their content,
+// which changes along with the content, while unknown assets are left without
+// one.
+func TestAssetPath(t *testing.T) {
+	events.level = SilentLevel
+
+	dir, err := ioutil.TempDir("", "pocket-static")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	mod := time.Date(2016, 3, 1, 9, 0, 0, 0, time.UTC)
+	writeAsset(t, dir, "js/app.js", "console.log(1)", mod)
+
+	as := newAssets(dir)
+
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"js/app.js", "/static/js/app.js?v=" + sum("console.log(1)")},
+		{"/js/../js/app.js", "/static/js/app.js?v=" + sum("console.log(1)")},
+		{"js/missing.js", "/static/js/missing.js"},
+		{"templates/index.tml", "/static/templates/index.tml"},
+	}
+
+	for _, test := range tests {
+		if found := as.Path(test.name); found != test.expected {
+			t.Errorf("Expected the path of %s to be %s, got %s", test.name, test.expected, found)
+		}
+	}
+
+	// Content of the same size written later is hashed again.
+	writeAsset(t, dir, "js/app.js", "console.log(2)", mod.Add(time.Second))
+
+	if found, expected := as.Path("js/app.js"), "/static/js/app.js?v="+sum("console.log(2)"); found != expected {
+		t.Fatalf("Expected the path of the changed asset to be %s, got %s", expected, found)
+	}
+}
+
+// TestAssetServe checks assets requested with their current fingerprint are
+// cached for good, all others are revalidated against their hash, and
+// templates, dotfiles and directories are never served.
+func TestAssetServe(t *testing.T) {
+	events.level = SilentLevel
+
+	dir, err := ioutil.TempDir("", "pocket-static")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	mod := time.Date(2016, 3, 1, 9, 0, 0, 0, time.UTC)
+	writeAsset(t, dir, "js/app.js", "console.log(1)", mod)
+	writeAsset(t, dir, "templates/index.tml", "{{ define \"index\" }}{{ end }}", mod)
+	writeAsset(t, dir, ".env", "SECRET=1", mod)
+
+	pa := app.New(events, false, nil)
+	newAssets(dir).Routes(pa)
+
+	server := httptest.NewServer(pa)
+	defer server.Close()
+
+	fingerprint := sum("console.log(1)")
+
+	tests := []struct {
+		path    string
+		headers map[string]string
+		status  int
+		cache   string
+	}{
+		{"/static/js/app.js?v=" + fingerprint, nil, http.StatusOK, "public, max-age=31536000, immutable"},
+		{"/static/js/app.js", nil, http.StatusOK, "no-cache"},
+		{"/static/js/app.js?v=stale", nil, http.StatusOK, "no-cache"},
+		{"/static/js/app.js", map[string]string{"If-None-Match": `"` + fingerprint + `"`}, http.StatusNotModified, "no-cache"},
+		{"/static/js/missing.js", nil, http.StatusNotFound, ""},
+		{"/static/js", nil, http.StatusNotFound, ""},
+		{"/static/templates/index.tml", nil, http.StatusNotFound, ""},
+		{"/static/.env", nil, http.StatusNotFound, ""},
+		{"/static/js/../.env", nil, http.StatusNotFound, ""},
+	}
+
+	for _, test := range tests {
+		res, body := get(t, server, test.path, test.headers)
+
+		if res.StatusCode != test.status {
+			t.Errorf("Expected status %d for %s, got %d", test.status, test.path, res.StatusCode)
+			continue
+		}
+
+		if test.status == http.StatusNotFound {
+			continue
+		}
+
+		if cache := res.Header.Get("Cache-Control"); cache != test.cache {
+			t.Errorf("Expected Cache-Control %q for %s, got %q", test.cache, test.path, cache)
+		}
+
+		if etag := res.Header.Get("ETag"); etag != `"`+fingerprint+`"` {
+			t.Errorf("Expected the ETag of %s to be its fingerprint, got %s", test.path, etag)
+		}
+
+		if test.status == http.StatusOK && body != "console.log(1)" {
+			t.Errorf("Expected the content of the asset for %s, got %q", test.path, body)
+		}
+	}
+}
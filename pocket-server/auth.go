@@ -0,0 +1,97 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/influx6/faux/context"
+	"github.com/influx6/faux/web/app"
+	"github.com/influx6/pocket/api/accounts"
+	"github.com/influx6/pocket/api/store"
+)
+
+//==============================================================================
+
+// contextKey defines the type of keys stored by the pocket-server within a
+// request context.
+type contextKey string
+
+// userKey defines the context key which holds the authenticated user record.
+const userKey contextKey = "pocket.user"
+
+// contextUser returns the authenticated user stored in the context.
+func contextUser(ctx context.Context) (accounts.UserRecord, bool) {
+	item, found := ctx.Get(userKey)
+	if !found {
+		return accounts.UserRecord{}, false
+	}
+
+	user, ok := item.(accounts.UserRecord)
+	return user, ok
+}
+
+//==============================================================================
+
+// publicRoutes defines the routes, as `METHOD path`, which are served without
+// a session.
+var publicRoutes = map[string]bool{
+	"GET /":                        true,
+	"POST /accounts/register":      true,
+	"POST /accounts/login":         true,
+	"POST /accounts/reset/request": true,
+	"POST /accounts/reset":         true,
+	"POST /accounts/logout":        true,
+}
+
+// publicPrefixes defines the path prefixes which are served without a session.
+var publicPrefixes = []string{staticPrefix}
+
+// isPublic returns true/false if the request targets a public route.
+func isPublic(r *http.Request) bool {
+	if publicRoutes[r.Method+" "+r.URL.Path] {
+		return true
+	}
+
+	for _, prefix := range publicPrefixes {
+		if strings.HasPrefix(r.URL.Path, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// authenticate returns an app.Middleware which resolves the session token of
+// each request into its user, storing the user record in the context handed to
+// the next handler. Requests to non-public routes without a valid session are
+// rejected.
+func authenticate(users store.Users) app.Middleware {
+	return func(next app.Handler) app.Handler {
+		return func(ctx context.Context, rw *app.ResponseRequest, params app.Param) error {
+			token := requestToken(rw.R)
+
+			if token != "" {
+				user, err := users.UserByToken(token)
+				if err != nil && err != store.ErrNotFound {
+					events.Error(contexts, "authenticate", err, "Failed to resolve session")
+					rw.RespondError(http.StatusInternalServerError, err)
+					return nil
+				}
+
+				if _, ok := user.Session(token, time.Now()); err == nil && ok {
+					return next(ctx.WithValue(userKey, user), rw, params)
+				}
+			}
+
+			if isPublic(rw.R) {
+				return next(ctx, rw, params)
+			}
+
+			rw.RespondError(http.StatusUnauthorized, ErrInvalidSession)
+			return nil
+		}
+	}
+}
+
+//==============================================================================
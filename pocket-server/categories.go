@@ -0,0 +1,359 @@
+package main
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/influx6/faux/context"
+	"github.com/influx6/pocket/api/budgets"
+	"github.com/influx6/pocket/api/currency"
+	"github.com/influx6/pocket/api/queries"
+	"github.com/influx6/pocket/api/store"
+	"github.com/satori/go.uuid"
+)
+
+//==============================================================================
+
+// ErrUnknownCategory is returned when a category which does not exists is
+// referenced.
+var ErrUnknownCategory = errors.New("Unknown Category")
+
+// ErrInvalidCategory is returned when a category is created without a name or
+// with an invalid color.
+var ErrInvalidCategory = errors.New("Invalid Category")
+
+// Spending groupings supported by the `spending` query.
+const (
+	byCategory = "category"
+	byTag      = "tag"
+)
+
+//==============================================================================
+
+// queryCategories resolves the `categories` query, returning all categories of
+// the caller.
+func (p *pocketData) queryCategories(ctx context.Context, q queries.Query) (interface{}, error) {
+	user, ok := contextUser(ctx)
+	if !ok {
+		return nil, ErrInvalidSession
+	}
+
+	return p.store.Categories(user.ID)
+}
+
+// querySpending resolves the `spending?pocket=<id>&by=category|tag` query,
+// returning the totals of the items of the pocket, or of a single budget given
+// by `budget=<id>`, grouped by category or by tag. The total of a category
+// includes the items of all categories nested under it, and items without a
+// category or tag are totalled under an empty id.
+func (p *pocketData) querySpending(ctx context.Context, q queries.Query) (interface{}, error) {
+	id, err := q.Require("pocket")
+	if err != nil {
+		return nil, err
+	}
+
+	pocket, err := p.ownedPocket(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	by := q.Get("by")
+	if by == "" {
+		by = byCategory
+	}
+
+	if by != byCategory && by != byTag {
+		return nil, errors.New("Query[spending] parameter[by] must be category or tag")
+	}
+
+	records, err := p.store.Budgets(pocket.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if budget := q.Get("budget"); budget != "" {
+		var found []budgets.BudgetRecord
+
+		for _, record := range records {
+			if record.ID == budget {
+				found = append(found, record)
+			}
+		}
+
+		if len(found) == 0 {
+			return nil, ErrUnknownBudget
+		}
+
+		records = found
+	}
+
+	categories, err := p.store.Categories(pocket.Owner)
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := p.itemFilter(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	cu := pocketCurrency(pocket)
+	spending := newSpending(budgets.Categories(categories), cu)
+
+	for _, record := range records {
+		if record.Deleted != nil {
+			continue
+		}
+
+		items, err := p.store.Items(record.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range items {
+			if item.Deleted != nil || !filter(item) {
+				continue
+			}
+
+			if by == byTag {
+				err = spending.addTags(item.Tags, item.Price)
+			} else {
+				err = spending.addCategory(item.Category, item.Price)
+			}
+
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return spending.list(), nil
+}
+
+// itemFilter returns a function which matches the items filed under the
+// `category` of the query, including the categories nested under it, and
+// carrying every `tag` of the query.
+func (p *pocketData) itemFilter(ctx context.Context, q queries.Query) (func(budgets.ItemRecord) bool, error) {
+	var categories budgets.Categories
+
+	category := q.Get("category")
+	if category != "" {
+		if _, err := p.ownedCategory(ctx, category); err != nil {
+			return nil, err
+		}
+
+		user, _ := contextUser(ctx)
+
+		records, err := p.store.Categories(user.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		categories = budgets.Categories(records)
+	}
+
+	tags := budgets.NormalizeTags(q.Params["tag"])
+
+	return func(item budgets.ItemRecord) bool {
+		if category != "" && !categories.Within(item.Category, category) {
+			return false
+		}
+
+		for _, tag := range tags {
+			if !hasTag(item.Tags, tag) {
+				return false
+			}
+		}
+
+		return true
+	}, nil
+}
+
+// hasTag returns true/false if the tag is within the list.
+func hasTag(tags []string, tag string) bool {
+	for _, item := range tags {
+		if item == tag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ownedCategory returns the category with the giving id if it belongs to the
+// caller, else returns ErrUnknownCategory.
+func (p *pocketData) ownedCategory(ctx context.Context, id string) (budgets.CategoryRecord, error) {
+	user, ok := contextUser(ctx)
+	if !ok {
+		return budgets.CategoryRecord{}, ErrInvalidSession
+	}
+
+	category, err := p.store.Category(id)
+	if err != nil {
+		if err == store.ErrNotFound {
+			return category, ErrUnknownCategory
+		}
+
+		return category, err
+	}
+
+	if category.Owner != user.ID {
+		return budgets.CategoryRecord{}, ErrUnknownCategory
+	}
+
+	return category, nil
+}
+
+// AddCategory adds a new category owned by the caller from the provided
+// command.
+func (p *pocketData) AddCategory(ctx context.Context, nc budgets.NewCategory) (budgets.CategoryRecord, error) {
+	user, ok := contextUser(ctx)
+	if !ok {
+		return budgets.CategoryRecord{}, ErrInvalidSession
+	}
+
+	name := strings.TrimSpace(nc.Name)
+	if name == "" {
+		return budgets.CategoryRecord{}, ErrInvalidCategory
+	}
+
+	if nc.Parent != "" {
+		if _, err := p.ownedCategory(ctx, nc.Parent); err != nil {
+			return budgets.CategoryRecord{}, err
+		}
+	}
+
+	color := strings.TrimSpace(nc.Color)
+	if color == "" {
+		color = budgets.CategoryColor(name)
+	}
+
+	if !budgets.ValidColor(color) {
+		return budgets.CategoryRecord{}, ErrInvalidCategory
+	}
+
+	category := budgets.CategoryRecord{
+		ID:     uuid.NewV4().String(),
+		Owner:  user.ID,
+		Name:   name,
+		Parent: nc.Parent,
+		Color:  color,
+	}
+
+	if err := p.store.SaveCategory(category); err != nil {
+		return category, err
+	}
+
+	p.changes.Touch(category.ID, user.ID)
+	return category, nil
+}
+
+//==============================================================================
+
+// spending collects the totals of items grouped by category or tag.
+type spending struct {
+	categories budgets.Categories
+	currency   currency.Currency
+	totals     map[string]*budgets.Spending
+}
+
+// newSpending returns a new instance of spending for amounts of the giving
+// currency.
+func newSpending(categories budgets.Categories, cu currency.Currency) *spending {
+	sp := spending{
+		categories: categories,
+		currency:   cu,
+		totals:     make(map[string]*budgets.Spending),
+	}
+
+	return &sp
+}
+
+// addCategory adds the price into the total of the category and of every
+// category it is nested under.
+func (s *spending) addCategory(id string, price currency.Money) error {
+	path := s.categories.Path(id)
+	if len(path) == 0 {
+		return s.add(budgets.Spending{Name: "Uncategorized"}, price)
+	}
+
+	for _, category := range path {
+		err := s.add(budgets.Spending{
+			ID:     category.ID,
+			Name:   category.Name,
+			Parent: category.Parent,
+			Color:  category.Color,
+		}, price)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// addTags adds the price into the total of every tag.
+func (s *spending) addTags(tags []string, price currency.Money) error {
+	if len(tags) == 0 {
+		return s.add(budgets.Spending{Name: "Untagged"}, price)
+	}
+
+	for _, tag := range tags {
+		if err := s.add(budgets.Spending{ID: tag, Name: tag}, price); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// add adds the price into the total of the grouping, creating it from the
+// details provided if new.
+func (s *spending) add(group budgets.Spending, price currency.Money) error {
+	total, ok := s.totals[group.ID]
+	if !ok {
+		group.Total = currency.NewMoney(0, s.currency)
+		total = &group
+
+		s.totals[group.ID] = total
+	}
+
+	sum, err := total.Total.Add(price)
+	if err != nil {
+		return err
+	}
+
+	total.Total = sum
+	total.Count++
+	return nil
+}
+
+// list returns the totals ordered by the name of their groupings, with the
+// items without a grouping last.
+func (s *spending) list() []budgets.Spending {
+	list := make([]budgets.Spending, 0, len(s.totals))
+
+	for _, total := range s.totals {
+		list = append(list, *total)
+	}
+
+	sort.Sort(spendingByName(list))
+	return list
+}
+
+// spendingByName implements sort.Interface to order totals by name.
+type spendingByName []budgets.Spending
+
+func (s spendingByName) Len() int      { return len(s) }
+func (s spendingByName) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s spendingByName) Less(i, j int) bool {
+	if (s[i].ID == "") != (s[j].ID == "") {
+		return s[j].ID == ""
+	}
+
+	return s[i].Name < s[j].Name
+}
+
+//==============================================================================
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/influx6/pocket/api/budgets"
+)
+
+//==============================================================================
+
+// addCategory creates a category of the giving name under the parent, if any,
+// returning it.
+func (ts *testServer) addCategory(t *testing.T, token string, name string, parent string) budgets.CategoryRecord {
+	var category budgets.CategoryRecord
+
+	nc := budgets.NewCategory{Name: name, Parent: parent}
+	if status := ts.post(t, "/categories", token, nc, &category); status != http.StatusCreated {
+		t.Fatalf("Expected category %s to be created, got status %d", name, status)
+	}
+
+	return category
+}
+
+// addItem creates an item of the budget at the giving price, filed under the
+// category and tags, returning it.
+func (ts *testServer) addItem(t *testing.T, token string, budget string, price string, category string, tags ...string) budgets.ItemRecord {
+	var item budgets.ItemRecord
+
+	ni := map[string]interface{}{"UUID": budget, "Title": "Item", "Price": price, "Category": category, "Tags": tags}
+	if status := ts.post(t, "/items", token, ni, &item); status != http.StatusCreated {
+		t.Fatalf("Expected item to be created, got status %d", status)
+	}
+
+	return item
+}
+
+// totals returns the count and total of every grouping of the spending query
+// result, keyed by name.
+func totals(list []interface{}) map[string][2]interface{} {
+	found := make(map[string][2]interface{}, len(list))
+
+	for _, record := range list {
+		group, _ := record.(map[string]interface{})
+		found[group["name"].(string)] = [2]interface{}{group["count"], group["total"]}
+	}
+
+	return found
+}
+
+//==============================================================================
+
+// TestSpendingByCategory checks spending is totalled by category, each total
+// including the categories nested under it, and by tag, with the items
+// without either totalled last.
+func TestSpendingByCategory(t *testing.T) {
+	ts := newTestServer(t)
+	defer ts.Close()
+
+	token := ts.register(t, "owner@pocket.io")
+	budget := ts.addBudget(t, token, "1200 USD")
+
+	food := ts.addCategory(t, token, "Food", "")
+	groceries := ts.addCategory(t, token, "Groceries", food.ID)
+	transport := ts.addCategory(t, token, "Transport", "")
+
+	ts.addItem(t, token, budget.ID, "30 USD", groceries.ID, "Weekly")
+	ts.addItem(t, token, budget.ID, "20 USD", food.ID, "#weekly", "treat")
+	ts.addItem(t, token, budget.ID, "15 USD", transport.ID)
+	ts.addItem(t, token, budget.ID, "10 USD", "")
+
+	deleted := ts.addItem(t, token, budget.ID, "500 USD", groceries.ID, "weekly")
+	if status := ts.post(t, "/items/delete", token, map[string]interface{}{"UUID": deleted.ID}, nil); status != http.StatusOK {
+		t.Fatalf("Expected item to be deleted, got status %d", status)
+	}
+
+	pack := ts.query(t, token,
+		"spending?pocket="+budget.Pocket,
+		"spending?pocket="+budget.Pocket+"&by=tag",
+		"spending?pocket="+budget.Pocket+"&category="+food.ID,
+		"spending?pocket="+budget.Pocket+"&by=month",
+	)
+
+	tests := []struct {
+		name     string
+		result   int
+		expected map[string][2]interface{}
+		last     string
+	}{
+		{
+			name:   "by category",
+			result: 0,
+			expected: map[string][2]interface{}{
+				"Food":          {float64(2), "50.00 USD"},
+				"Groceries":     {float64(1), "30.00 USD"},
+				"Transport":     {float64(1), "15.00 USD"},
+				"Uncategorized": {float64(1), "10.00 USD"},
+			},
+			last: "Uncategorized",
+		},
+		{
+			name:   "by tag",
+			result: 1,
+			expected: map[string][2]interface{}{
+				"treat":    {float64(1), "20.00 USD"},
+				"weekly":   {float64(2), "50.00 USD"},
+				"Untagged": {float64(2), "25.00 USD"},
+			},
+			last: "Untagged",
+		},
+		{
+			name:   "within a category",
+			result: 2,
+			expected: map[string][2]interface{}{
+				"Food":      {float64(2), "50.00 USD"},
+				"Groceries": {float64(1), "30.00 USD"},
+			},
+			last: "Groceries",
+		},
+	}
+
+	for _, test := range tests {
+		list := records(t, pack.Results[test.result])
+
+		found := totals(list)
+		if len(found) != len(test.expected) {
+			t.Errorf("Expected spending %s of %v, got %v", test.name, test.expected, found)
+			continue
+		}
+
+		for name, total := range test.expected {
+			if found[name] != total {
+				t.Errorf("Expected spending %s under %s of %v, got %v", test.name, name, total, found[name])
+			}
+		}
+
+		if last, _ := list[len(list)-1].(map[string]interface{}); last["name"] != test.last {
+			t.Errorf("Expected spending %s to end with %s, got %v", test.name, test.last, last["name"])
+		}
+	}
+
+	if failed, _ := pack.Results[3]["QueryFailed"].(bool); !failed {
+		t.Fatalf("Expected spending by an unknown grouping to fail, got %v", pack.Results[3])
+	}
+}
+
+// TestItemFilters checks items are listed by the category they are filed under,
+// including nested categories, and by carrying every tag asked for, while the
+// categories of other users are refused.
+func TestItemFilters(t *testing.T) {
+	ts := newTestServer(t)
+	defer ts.Close()
+
+	token := ts.register(t, "owner@pocket.io")
+	other := ts.register(t, "other@pocket.io")
+
+	budget := ts.addBudget(t, token, "1200 USD")
+
+	food := ts.addCategory(t, token, "Food", "")
+	groceries := ts.addCategory(t, token, "Groceries", food.ID)
+	transport := ts.addCategory(t, token, "Transport", "")
+	foreign := ts.addCategory(t, other, "Foreign", "")
+
+	ts.addItem(t, token, budget.ID, "30 USD", groceries.ID, "weekly")
+	ts.addItem(t, token, budget.ID, "20 USD", food.ID, "weekly", "treat")
+	ts.addItem(t, token, budget.ID, "15 USD", transport.ID, "treat")
+
+	pack := ts.query(t, token,
+		"items?budget="+budget.ID+"&category="+food.ID,
+		"items?budget="+budget.ID+"&category="+groceries.ID,
+		"items?budget="+budget.ID+"&tag=treat",
+		"items?budget="+budget.ID+"&tag=weekly&tag=Treat",
+		"items?budget="+budget.ID+"&category="+food.ID+"&tag=treat",
+		"items?budget="+budget.ID+"&category="+foreign.ID,
+	)
+
+	for index, count := range []int{2, 1, 2, 1, 1} {
+		if list := records(t, pack.Results[index]); len(list) != count {
+			t.Errorf("Expected query %d to list %d items, got %d", index, count, len(list))
+		}
+	}
+
+	if failed, _ := pack.Results[5]["QueryFailed"].(bool); !failed {
+		t.Fatalf("Expected the category of another user to be refused, got %v", pack.Results[5])
+	}
+
+	ni := map[string]interface{}{"UUID": budget.ID, "Title": "Item", "Price": "5 USD", "Category": foreign.ID}
+	if status := ts.post(t, "/items", token, ni, nil); status != http.StatusBadRequest {
+		t.Fatalf("Expected an item filed under another user's category to be refused, got status %d", status)
+	}
+
+	nc := budgets.NewCategory{Name: "Nested", Parent: foreign.ID}
+	if status := ts.post(t, "/categories", token, nc, nil); status != http.StatusBadRequest {
+		t.Fatalf("Expected a category nested under another user's category to be refused, got status %d", status)
+	}
+
+	for _, nc := range []budgets.NewCategory{{Name: " "}, {Name: "Bills", Color: "red"}} {
+		if status := ts.post(t, "/categories", token, nc, nil); status != http.StatusBadRequest {
+			t.Errorf("Expected category %+v to be refused, got status %d", nc, status)
+		}
+	}
+}
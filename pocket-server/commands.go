@@ -0,0 +1,438 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/influx6/faux/context"
+	"github.com/influx6/faux/web/app"
+	"github.com/influx6/pocket/api/budgets"
+)
+
+//==============================================================================
+
+// Routes registers the command routes which change the pocket records.
+func (p *pocketData) Routes(pa *app.App) {
+	app.PageRoute(pa, "POST", "/pockets", p.newPocket)
+	app.PageRoute(pa, "POST", "/budgets", p.newBudget)
+	app.PageRoute(pa, "POST", "/items", p.newItem)
+	app.PageRoute(pa, "POST", "/categories", p.newCategory)
+	app.PageRoute(pa, "POST", "/pockets/replay", p.replayPocket)
+	app.PageRoute(pa, "POST", "/rules", p.newRule)
+	app.PageRoute(pa, "POST", "/rules/delete", p.deleteRule)
+	app.PageRoute(pa, "POST", "/webhooks", p.newWebhook)
+	app.PageRoute(pa, "POST", "/webhooks/delete", p.deleteWebhook)
+	app.PageRoute(pa, "POST", "/recurring", p.newRecurring)
+	app.PageRoute(pa, "POST", "/recurring/delete", p.deleteRecurring)
+	app.PageRoute(pa, "POST", "/recurring/skip", p.skipOccurrence)
+	app.PageRoute(pa, "POST", "/recurring/postpone", p.postponeOccurrence)
+	app.PageRoute(pa, "POST", "/notifications/read", p.readNotification)
+	app.PageRoute(pa, "POST", "/notifications/read-all", p.readAllNotifications)
+
+	app.PageRoute(pa, "POST", "/budgets/amend", p.amendBudget)
+	app.PageRoute(pa, "POST", "/budgets/delete", p.deleteBudget)
+	app.PageRoute(pa, "POST", "/budgets/restore", p.restoreBudget)
+	app.PageRoute(pa, "POST", "/budgets/archive", p.archiveBudget)
+
+	app.PageRoute(pa, "POST", "/items/amend", p.amendItem)
+	app.PageRoute(pa, "POST", "/items/delete", p.deleteItem)
+	app.PageRoute(pa, "POST", "/items/restore", p.restoreItem)
+	app.PageRoute(pa, "POST", "/items/archive", p.archiveItem)
+}
+
+// newPocket handles the budgets.NewPocket command.
+func (p *pocketData) newPocket(ctx context.Context, rw *app.ResponseRequest, params app.Param) error {
+	var np budgets.NewPocket
+
+	if err := json.NewDecoder(rw.R.Body).Decode(&np); err != nil {
+		return err
+	}
+
+	pocket, err := p.AddPocket(ctx, np)
+	if err != nil {
+		return err
+	}
+
+	rw.Respond(http.StatusCreated, pocket)
+	return nil
+}
+
+// newBudget handles the budgets.NewBudget command.
+func (p *pocketData) newBudget(ctx context.Context, rw *app.ResponseRequest, params app.Param) error {
+	var nb budgets.NewBudget
+
+	if err := json.NewDecoder(rw.R.Body).Decode(&nb); err != nil {
+		return err
+	}
+
+	budget, err := p.AddBudget(ctx, nb)
+	if err != nil {
+		return err
+	}
+
+	rw.Respond(http.StatusCreated, budget)
+	return nil
+}
+
+// newItem handles the budgets.NewBudgetItem command.
+func (p *pocketData) newItem(ctx context.Context, rw *app.ResponseRequest, params app.Param) error {
+	var ni budgets.NewBudgetItem
+
+	if err := json.NewDecoder(rw.R.Body).Decode(&ni); err != nil {
+		return err
+	}
+
+	item, err := p.AddItem(ctx, ni)
+	if err != nil {
+		return err
+	}
+
+	rw.Respond(http.StatusCreated, item)
+	return nil
+}
+
+// newCategory handles the budgets.NewCategory command.
+func (p *pocketData) newCategory(ctx context.Context, rw *app.ResponseRequest, params app.Param) error {
+	var nc budgets.NewCategory
+
+	if err := json.NewDecoder(rw.R.Body).Decode(&nc); err != nil {
+		return err
+	}
+
+	category, err := p.AddCategory(ctx, nc)
+	if err != nil {
+		return err
+	}
+
+	rw.Respond(http.StatusCreated, category)
+	return nil
+}
+
+// replayPocket handles the budgets.ReplayPocket command.
+func (p *pocketData) replayPocket(ctx context.Context, rw *app.ResponseRequest, params app.Param) error {
+	var rp budgets.ReplayPocket
+
+	if err := json.NewDecoder(rw.R.Body).Decode(&rp); err != nil {
+		return err
+	}
+
+	pocket, err := p.ReplayPocket(ctx, rp)
+	if err != nil {
+		return err
+	}
+
+	rw.Respond(http.StatusOK, pocket)
+	return nil
+}
+
+// newRule handles the budgets.NewRule command.
+func (p *pocketData) newRule(ctx context.Context, rw *app.ResponseRequest, params app.Param) error {
+	var nr budgets.NewRule
+
+	if err := json.NewDecoder(rw.R.Body).Decode(&nr); err != nil {
+		return err
+	}
+
+	rule, err := p.AddRule(ctx, nr)
+	if err != nil {
+		return err
+	}
+
+	rw.Respond(http.StatusCreated, rule)
+	return nil
+}
+
+// deleteRule handles the budgets.DeleteRule command.
+func (p *pocketData) deleteRule(ctx context.Context, rw *app.ResponseRequest, params app.Param) error {
+	var dr budgets.DeleteRule
+
+	if err := json.NewDecoder(rw.R.Body).Decode(&dr); err != nil {
+		return err
+	}
+
+	rule, err := p.RemoveRule(ctx, dr)
+	if err != nil {
+		return err
+	}
+
+	rw.Respond(http.StatusOK, rule)
+	return nil
+}
+
+// newWebhook handles the budgets.NewWebhook command.
+func (p *pocketData) newWebhook(ctx context.Context, rw *app.ResponseRequest, params app.Param) error {
+	var nw budgets.NewWebhook
+
+	if err := json.NewDecoder(rw.R.Body).Decode(&nw); err != nil {
+		return err
+	}
+
+	hook, err := p.AddWebhook(ctx, nw)
+	if err != nil {
+		return err
+	}
+
+	rw.Respond(http.StatusCreated, hook)
+	return nil
+}
+
+// deleteWebhook handles the budgets.DeleteWebhook command.
+func (p *pocketData) deleteWebhook(ctx context.Context, rw *app.ResponseRequest, params app.Param) error {
+	var dw budgets.DeleteWebhook
+
+	if err := json.NewDecoder(rw.R.Body).Decode(&dw); err != nil {
+		return err
+	}
+
+	hook, err := p.RemoveWebhook(ctx, dw)
+	if err != nil {
+		return err
+	}
+
+	rw.Respond(http.StatusOK, hook)
+	return nil
+}
+
+// newRecurring handles the budgets.NewRecurring command.
+func (p *pocketData) newRecurring(ctx context.Context, rw *app.ResponseRequest, params app.Param) error {
+	var nr budgets.NewRecurring
+
+	if err := json.NewDecoder(rw.R.Body).Decode(&nr); err != nil {
+		return err
+	}
+
+	recurring, err := p.AddRecurring(ctx, nr)
+	if err != nil {
+		return err
+	}
+
+	rw.Respond(http.StatusCreated, recurring)
+	return nil
+}
+
+// deleteRecurring handles the budgets.DeleteRecurring command.
+func (p *pocketData) deleteRecurring(ctx context.Context, rw *app.ResponseRequest, params app.Param) error {
+	var dr budgets.DeleteRecurring
+
+	if err := json.NewDecoder(rw.R.Body).Decode(&dr); err != nil {
+		return err
+	}
+
+	recurring, err := p.RemoveRecurring(ctx, dr)
+	if err != nil {
+		return err
+	}
+
+	rw.Respond(http.StatusOK, recurring)
+	return nil
+}
+
+// skipOccurrence handles the budgets.SkipOccurrence command.
+func (p *pocketData) skipOccurrence(ctx context.Context, rw *app.ResponseRequest, params app.Param) error {
+	var so budgets.SkipOccurrence
+
+	if err := json.NewDecoder(rw.R.Body).Decode(&so); err != nil {
+		return err
+	}
+
+	recurring, err := p.SkipOccurrence(ctx, so)
+	if err != nil {
+		return err
+	}
+
+	rw.Respond(http.StatusOK, recurring)
+	return nil
+}
+
+// postponeOccurrence handles the budgets.PostponeOccurrence command.
+func (p *pocketData) postponeOccurrence(ctx context.Context, rw *app.ResponseRequest, params app.Param) error {
+	var po budgets.PostponeOccurrence
+
+	if err := json.NewDecoder(rw.R.Body).Decode(&po); err != nil {
+		return err
+	}
+
+	recurring, err := p.PostponeOccurrence(ctx, po)
+	if err != nil {
+		return err
+	}
+
+	rw.Respond(http.StatusOK, recurring)
+	return nil
+}
+
+// readNotification handles the budgets.ReadNotification command.
+func (p *pocketData) readNotification(ctx context.Context, rw *app.ResponseRequest, params app.Param) error {
+	var rn budgets.ReadNotification
+
+	if err := json.NewDecoder(rw.R.Body).Decode(&rn); err != nil {
+		return err
+	}
+
+	notice, err := p.ReadNotification(ctx, rn)
+	if err != nil {
+		return err
+	}
+
+	rw.Respond(http.StatusOK, notice)
+	return nil
+}
+
+// readAllNotifications handles the budgets.ReadAllNotifications command.
+func (p *pocketData) readAllNotifications(ctx context.Context, rw *app.ResponseRequest, params app.Param) error {
+	var ra budgets.ReadAllNotifications
+
+	if err := json.NewDecoder(rw.R.Body).Decode(&ra); err != nil {
+		return err
+	}
+
+	notices, err := p.ReadAllNotifications(ctx, ra)
+	if err != nil {
+		return err
+	}
+
+	rw.Respond(http.StatusOK, notices)
+	return nil
+}
+
+//==============================================================================
+
+// amendBudget handles the budgets.AmendBudget command.
+func (p *pocketData) amendBudget(ctx context.Context, rw *app.ResponseRequest, params app.Param) error {
+	var ab budgets.AmendBudget
+
+	if err := json.NewDecoder(rw.R.Body).Decode(&ab); err != nil {
+		return err
+	}
+
+	budget, err := p.AmendBudget(ctx, ab)
+	if err != nil {
+		return err
+	}
+
+	rw.Respond(http.StatusOK, budget)
+	return nil
+}
+
+// deleteBudget handles the budgets.DeleteBudget command.
+func (p *pocketData) deleteBudget(ctx context.Context, rw *app.ResponseRequest, params app.Param) error {
+	var db budgets.DeleteBudget
+
+	if err := json.NewDecoder(rw.R.Body).Decode(&db); err != nil {
+		return err
+	}
+
+	budget, err := p.DeleteBudget(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	rw.Respond(http.StatusOK, budget)
+	return nil
+}
+
+// restoreBudget handles the budgets.RestoreBudget command.
+func (p *pocketData) restoreBudget(ctx context.Context, rw *app.ResponseRequest, params app.Param) error {
+	var rb budgets.RestoreBudget
+
+	if err := json.NewDecoder(rw.R.Body).Decode(&rb); err != nil {
+		return err
+	}
+
+	budget, err := p.RestoreBudget(ctx, rb)
+	if err != nil {
+		return err
+	}
+
+	rw.Respond(http.StatusOK, budget)
+	return nil
+}
+
+// archiveBudget handles the budgets.ArchiveBudget command.
+func (p *pocketData) archiveBudget(ctx context.Context, rw *app.ResponseRequest, params app.Param) error {
+	var ab budgets.ArchiveBudget
+
+	if err := json.NewDecoder(rw.R.Body).Decode(&ab); err != nil {
+		return err
+	}
+
+	budget, err := p.ArchiveBudget(ctx, ab)
+	if err != nil {
+		return err
+	}
+
+	rw.Respond(http.StatusOK, budget)
+	return nil
+}
+
+//==============================================================================
+
+// amendItem handles the budgets.AmendBudgetItem command.
+func (p *pocketData) amendItem(ctx context.Context, rw *app.ResponseRequest, params app.Param) error {
+	var ai budgets.AmendBudgetItem
+
+	if err := json.NewDecoder(rw.R.Body).Decode(&ai); err != nil {
+		return err
+	}
+
+	item, err := p.AmendItem(ctx, ai)
+	if err != nil {
+		return err
+	}
+
+	rw.Respond(http.StatusOK, item)
+	return nil
+}
+
+// deleteItem handles the budgets.DeleteBudgetItem command.
+func (p *pocketData) deleteItem(ctx context.Context, rw *app.ResponseRequest, params app.Param) error {
+	var di budgets.DeleteBudgetItem
+
+	if err := json.NewDecoder(rw.R.Body).Decode(&di); err != nil {
+		return err
+	}
+
+	item, err := p.DeleteItem(ctx, di)
+	if err != nil {
+		return err
+	}
+
+	rw.Respond(http.StatusOK, item)
+	return nil
+}
+
+// restoreItem handles the budgets.RestoreBudgetItem command.
+func (p *pocketData) restoreItem(ctx context.Context, rw *app.ResponseRequest, params app.Param) error {
+	var ri budgets.RestoreBudgetItem
+
+	if err := json.NewDecoder(rw.R.Body).Decode(&ri); err != nil {
+		return err
+	}
+
+	item, err := p.RestoreItem(ctx, ri)
+	if err != nil {
+		return err
+	}
+
+	rw.Respond(http.StatusOK, item)
+	return nil
+}
+
+// archiveItem handles the budgets.ArchiveBudgetItem command.
+func (p *pocketData) archiveItem(ctx context.Context, rw *app.ResponseRequest, params app.Param) error {
+	var ai budgets.ArchiveBudgetItem
+
+	if err := json.NewDecoder(rw.R.Body).Decode(&ai); err != nil {
+		return err
+	}
+
+	item, err := p.ArchiveItem(ctx, ai)
+	if err != nil {
+		return err
+	}
+
+	rw.Respond(http.StatusOK, item)
+	return nil
+}
+
+//==============================================================================
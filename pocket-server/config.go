@@ -0,0 +1,452 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/mail"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ardanlabs/kit/cfg"
+	"github.com/influx6/pocket/api/currency"
+	"gopkg.in/yaml.v2"
+)
+
+//==============================================================================
+
+// envNamespace defines the prefix of environment variables which override the
+// configuration, as in POCKET_HTTP or POCKET_STORE_BACKEND.
+const envNamespace = "POCKET"
+
+// Storage backends supported by the pocket-server.
+const (
+	FileBackend   = "file"
+	MemoryBackend = "memory"
+	MongoBackend  = "mongo"
+)
+
+// Log levels supported by the pocket-server.
+const (
+	InfoLevel   = "info"
+	ErrorLevel  = "error"
+	SilentLevel = "silent"
+)
+
+// Mail backends supported by the pocket-server.
+const (
+	SMTPMailer    = "smtp"
+	MaildirMailer = "maildir"
+)
+
+// MailConfig defines the configuration of outbound mail, which is disabled
+// unless a backend is given. Links within mails are made absolute with the
+// URL, defaulting to the API address. Each delivery through SMTP is given up
+// on after Timeout.
+type MailConfig struct {
+	Backend  string        `yaml:"backend"`
+	From     string        `yaml:"from"`
+	SMTP     string        `yaml:"smtp"`
+	Username string        `yaml:"username"`
+	Password string        `yaml:"password"`
+	Maildir  string        `yaml:"maildir"`
+	URL      string        `yaml:"url"`
+	Retries  int           `yaml:"retries"`
+	Timeout  time.Duration `yaml:"timeout"`
+}
+
+// WebhooksConfig defines the delivery of webhooks, each delivery waiting up to
+// Timeout for a response and being retried up to Retries times, first after
+// Backoff, which doubles on every attempt.
+type WebhooksConfig struct {
+	Retries int           `yaml:"retries"`
+	Backoff time.Duration `yaml:"backoff"`
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// StoreConfig defines the configuration of the storage backend.
+type StoreConfig struct {
+	Backend string `yaml:"backend"`
+	Path    string `yaml:"path"`
+	Mongo   string `yaml:"mongo"`
+	DB      string `yaml:"db"`
+}
+
+// Config defines the configuration of the pocket-server.
+type Config struct {
+	HTTP            string         `yaml:"http"`
+	API             string         `yaml:"api"`
+	Store           StoreConfig    `yaml:"store"`
+	Mail            MailConfig     `yaml:"mail"`
+	Webhooks        WebhooksConfig `yaml:"webhooks"`
+	Static          string         `yaml:"static"`
+	Currency        string         `yaml:"currency"`
+	Locale          string         `yaml:"locale"`
+	Rates           []string       `yaml:"rates"`
+	Origins         []string       `yaml:"origins"`
+	LogLevel        string         `yaml:"log_level"`
+	SessionLifetime time.Duration  `yaml:"session_lifetime"`
+}
+
+// defaultConfig returns the configuration used when no value is provided.
+func defaultConfig() Config {
+	return Config{
+		HTTP: ":3000",
+		Store: StoreConfig{
+			Backend: FileBackend,
+			Path:    "pocket.json",
+			Mongo:   "127.0.0.1:27017",
+			DB:      "pocket",
+		},
+		Mail: MailConfig{
+			From:    "Pocket <pocket@localhost>",
+			Maildir: "mail",
+			Retries: 5,
+			Timeout: 30 * time.Second,
+		},
+		Webhooks: WebhooksConfig{
+			Retries: 5,
+			Backoff: 10 * time.Second,
+			Timeout: 10 * time.Second,
+		},
+		Static:          "static",
+		Currency:        "USD",
+		Locale:          currency.DefaultLocale.Tag,
+		Origins:         []string{"*"},
+		LogLevel:        InfoLevel,
+		SessionLifetime: 30 * 24 * time.Hour,
+	}
+}
+
+// loadConfig returns the configuration built from the defaults, the YAML file
+// provided by the -config flag, the POCKET_ environment variables and the
+// command line flags, with each overriding the previous.
+func loadConfig(args []string) (Config, error) {
+	conf := defaultConfig()
+
+	flags := flag.NewFlagSet("pocket-server", flag.ContinueOnError)
+	configFile := flags.String("config", "", "Path to a YAML configuration file")
+	httpAddr := flags.String("http", conf.HTTP, "Address to serve HTTP requests on")
+	api := flags.String("api", conf.API, "Public address of the API, defaults to the origin of the index page")
+	backend := flags.String("store", conf.Store.Backend, "Storage backend: file, memory or mongo")
+	storePath := flags.String("store-path", conf.Store.Path, "File used by the file storage backend")
+	static := flags.String("static", conf.Static, "Directory of the static assets")
+	ratesFiles := flags.String("rates", strings.Join(conf.Rates, ","), "Comma separated ECB reference rates XML files")
+	logLevel := flags.String("log", conf.LogLevel, "Log level: info, error or silent")
+	mailBackend := flags.String("mail", conf.Mail.Backend, "Mail backend: smtp or maildir, mail is disabled when empty")
+	mailDir := flags.String("mail-dir", conf.Mail.Maildir, "Directory used by the maildir mail backend")
+
+	if err := flags.Parse(args); err != nil {
+		return conf, err
+	}
+
+	if *configFile != "" {
+		content, err := ioutil.ReadFile(*configFile)
+		if err != nil {
+			return conf, err
+		}
+
+		if err := yaml.Unmarshal(content, &conf); err != nil {
+			return conf, fmt.Errorf("Invalid config file[%s]: %s", *configFile, err)
+		}
+	}
+
+	if err := conf.fromEnv(); err != nil {
+		return conf, err
+	}
+
+	flags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "http":
+			conf.HTTP = *httpAddr
+		case "api":
+			conf.API = *api
+		case "store":
+			conf.Store.Backend = *backend
+		case "store-path":
+			conf.Store.Path = *storePath
+		case "static":
+			conf.Static = *static
+		case "rates":
+			conf.Rates = splitList(*ratesFiles)
+		case "log":
+			conf.LogLevel = *logLevel
+		case "mail":
+			conf.Mail.Backend = *mailBackend
+		case "mail-dir":
+			conf.Mail.Maildir = *mailDir
+		}
+	})
+
+	return conf, conf.Validate()
+}
+
+// fromEnv overrides the configuration with the POCKET_ environment variables.
+func (c *Config) fromEnv() error {
+	// The provider errors when no variable of the namespace exists, which
+	// just leaves nothing to override.
+	if !hasEnv(envNamespace) {
+		return nil
+	}
+
+	env, err := cfg.EnvProvider{Namespace: envNamespace}.Provide()
+	if err != nil {
+		return fmt.Errorf("Invalid %s_ environment: %s", envNamespace, err)
+	}
+
+	for key, val := range env {
+		switch key {
+		case "HTTP":
+			c.HTTP = val
+		case "API":
+			c.API = val
+		case "STORE_BACKEND":
+			c.Store.Backend = val
+		case "STORE_PATH":
+			c.Store.Path = val
+		case "STORE_MONGO":
+			c.Store.Mongo = val
+		case "STORE_DB":
+			c.Store.DB = val
+		case "STATIC":
+			c.Static = val
+		case "CURRENCY":
+			c.Currency = val
+		case "LOCALE":
+			c.Locale = val
+		case "RATES":
+			c.Rates = splitList(val)
+		case "ORIGINS":
+			c.Origins = nil
+			for _, origin := range strings.Split(val, ",") {
+				c.Origins = append(c.Origins, strings.TrimSpace(origin))
+			}
+		case "MAIL_BACKEND":
+			c.Mail.Backend = val
+		case "MAIL_FROM":
+			c.Mail.From = val
+		case "MAIL_SMTP":
+			c.Mail.SMTP = val
+		case "MAIL_USERNAME":
+			c.Mail.Username = val
+		case "MAIL_PASSWORD":
+			c.Mail.Password = val
+		case "MAIL_MAILDIR":
+			c.Mail.Maildir = val
+		case "MAIL_URL":
+			c.Mail.URL = val
+		case "MAIL_RETRIES":
+			retries, err := strconv.Atoi(val)
+			if err != nil {
+				return fmt.Errorf("Invalid %s_MAIL_RETRIES: %s", envNamespace, err)
+			}
+
+			c.Mail.Retries = retries
+		case "MAIL_TIMEOUT":
+			timeout, err := time.ParseDuration(val)
+			if err != nil {
+				return fmt.Errorf("Invalid %s_MAIL_TIMEOUT: %s", envNamespace, err)
+			}
+
+			c.Mail.Timeout = timeout
+		case "WEBHOOKS_RETRIES":
+			retries, err := strconv.Atoi(val)
+			if err != nil {
+				return fmt.Errorf("Invalid %s_WEBHOOKS_RETRIES: %s", envNamespace, err)
+			}
+
+			c.Webhooks.Retries = retries
+		case "WEBHOOKS_BACKOFF":
+			backoff, err := time.ParseDuration(val)
+			if err != nil {
+				return fmt.Errorf("Invalid %s_WEBHOOKS_BACKOFF: %s", envNamespace, err)
+			}
+
+			c.Webhooks.Backoff = backoff
+		case "WEBHOOKS_TIMEOUT":
+			timeout, err := time.ParseDuration(val)
+			if err != nil {
+				return fmt.Errorf("Invalid %s_WEBHOOKS_TIMEOUT: %s", envNamespace, err)
+			}
+
+			c.Webhooks.Timeout = timeout
+		case "LOG_LEVEL":
+			c.LogLevel = val
+		case "SESSION_LIFETIME":
+			lifetime, err := time.ParseDuration(val)
+			if err != nil {
+				return fmt.Errorf("Invalid %s_SESSION_LIFETIME: %s", envNamespace, err)
+			}
+
+			c.SessionLifetime = lifetime
+		default:
+			return fmt.Errorf("Unknown environment variable %s_%s", envNamespace, key)
+		}
+	}
+
+	return nil
+}
+
+// hasEnv returns true/false if a variable of the giving namespace is within the
+// environment.
+func hasEnv(namespace string) bool {
+	for _, env := range os.Environ() {
+		if strings.HasPrefix(env, namespace+"_") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Validate returns an error describing every invalid value of the
+// configuration.
+func (c Config) Validate() error {
+	var problems []string
+
+	if _, _, err := net.SplitHostPort(c.HTTP); err != nil {
+		problems = append(problems, fmt.Sprintf("http: invalid address %q", c.HTTP))
+	}
+
+	switch c.Store.Backend {
+	case FileBackend:
+		if c.Store.Path == "" {
+			problems = append(problems, "store.path: required by the file backend")
+		}
+	case MongoBackend:
+		if c.Store.Mongo == "" {
+			problems = append(problems, "store.mongo: required by the mongo backend")
+		}
+
+		if c.Store.DB == "" {
+			problems = append(problems, "store.db: required by the mongo backend")
+		}
+	case MemoryBackend:
+	default:
+		problems = append(problems, fmt.Sprintf("store.backend: unknown backend %q", c.Store.Backend))
+	}
+
+	if c.API != "" {
+		if api, err := url.Parse(c.API); err != nil || api.Scheme == "" || api.Host == "" {
+			problems = append(problems, fmt.Sprintf("api: invalid address %q", c.API))
+		}
+	}
+
+	problems = append(problems, c.Mail.problems(c.API)...)
+
+	if c.Webhooks.Retries < 0 {
+		problems = append(problems, "webhooks.retries: must not be below zero")
+	}
+
+	if c.Webhooks.Backoff <= 0 {
+		problems = append(problems, "webhooks.backoff: must be above zero")
+	}
+
+	if c.Webhooks.Timeout <= 0 {
+		problems = append(problems, "webhooks.timeout: must be above zero")
+	}
+
+	if info, err := os.Stat(c.Static); err != nil || !info.IsDir() {
+		problems = append(problems, fmt.Sprintf("static: %q is not a directory", c.Static))
+	}
+
+	if _, err := currency.ISO4217.Find(c.Currency); err != nil {
+		problems = append(problems, fmt.Sprintf("currency: unknown currency %q", c.Currency))
+	}
+
+	if _, err := currency.FindLocale(c.Locale); err != nil {
+		problems = append(problems, fmt.Sprintf("locale: unknown locale %q", c.Locale))
+	}
+
+	for _, path := range c.Rates {
+		if info, err := os.Stat(path); err != nil || info.IsDir() {
+			problems = append(problems, fmt.Sprintf("rates: %q is not a file", path))
+		}
+	}
+
+	for _, origin := range c.Origins {
+		if strings.TrimSpace(origin) == "" {
+			problems = append(problems, "origins: empty origin")
+		}
+	}
+
+	switch c.LogLevel {
+	case InfoLevel, ErrorLevel, SilentLevel:
+	default:
+		problems = append(problems, fmt.Sprintf("log_level: unknown level %q", c.LogLevel))
+	}
+
+	if c.SessionLifetime <= 0 {
+		problems = append(problems, "session_lifetime: must be above zero")
+	}
+
+	if len(problems) > 0 {
+		return errors.New("Invalid configuration: " + strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+// problems returns the problems found within the mail configuration, whose
+// links default to the giving API address.
+func (m MailConfig) problems(api string) []string {
+	var problems []string
+
+	switch m.Backend {
+	case "":
+		return nil
+	case SMTPMailer:
+		if _, _, err := net.SplitHostPort(m.SMTP); err != nil {
+			problems = append(problems, fmt.Sprintf("mail.smtp: invalid address %q", m.SMTP))
+		}
+	case MaildirMailer:
+		if m.Maildir == "" {
+			problems = append(problems, "mail.maildir: required by the maildir backend")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("mail.backend: unknown backend %q", m.Backend))
+	}
+
+	if _, err := mail.ParseAddress(m.From); err != nil {
+		problems = append(problems, fmt.Sprintf("mail.from: invalid address %q", m.From))
+	}
+
+	switch {
+	case m.URL != "":
+		if link, err := url.Parse(m.URL); err != nil || link.Scheme == "" || link.Host == "" {
+			problems = append(problems, fmt.Sprintf("mail.url: invalid address %q", m.URL))
+		}
+	case api == "":
+		problems = append(problems, "mail.url: required for the links of mails unless api is given")
+	}
+
+	if m.Retries < 0 {
+		problems = append(problems, "mail.retries: must not be below zero")
+	}
+
+	if m.Timeout <= 0 {
+		problems = append(problems, "mail.timeout: must be above zero")
+	}
+
+	return problems
+}
+
+// splitList returns the non-empty values of the comma separated list.
+func splitList(list string) []string {
+	var values []string
+
+	for _, value := range strings.Split(list, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
+
+//==============================================================================
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+//==============================================================================
+
+// TestConfigFromEnv checks the POCKET_ environment variables override the
+// configuration, while unknown or malformed variables are reported.
+func TestConfigFromEnv(t *testing.T) {
+	conf := defaultConfig()
+	if err := conf.fromEnv(); err != nil {
+		t.Fatalf("Expected an environment without POCKET_ variables to be accepted: %s", err)
+	}
+
+	t.Setenv("POCKET_HTTP", ":6060")
+	t.Setenv("POCKET_SESSION_LIFETIME", "1h")
+
+	if err := conf.fromEnv(); err != nil {
+		t.Fatal(err)
+	}
+
+	if conf.HTTP != ":6060" || conf.SessionLifetime != time.Hour {
+		t.Fatalf("Expected the environment to override the configuration, got %+v", conf)
+	}
+
+	t.Setenv("POCKET_SESSION_LIFETIME", "forever")
+
+	if err := conf.fromEnv(); err == nil {
+		t.Fatal("Expected a malformed POCKET_SESSION_LIFETIME to be reported")
+	}
+
+	t.Setenv("POCKET_SESSION_LIFETIME", "1h")
+	t.Setenv("POCKET_HTTTP", ":6060")
+
+	if err := conf.fromEnv(); err == nil {
+		t.Fatal("Expected an unknown POCKET_HTTTP to be reported")
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import "net/http"
+
+//==============================================================================
+
+// corsHandler provides an http.Handler which answers CORS preflight requests
+// and sets the Access-Control headers for the origins it allows before handing
+// requests to the next handler. Credentials are only allowed for the origins
+// listed explicitly, never for those allowed by "*".
+type corsHandler struct {
+	all     bool
+	origins map[string]bool
+	next    http.Handler
+}
+
+// newCORS returns a new corsHandler allowing the giving origins, where "*"
+// allows every origin without credentials.
+func newCORS(origins []string, next http.Handler) *corsHandler {
+	ch := corsHandler{
+		origins: make(map[string]bool),
+		next:    next,
+	}
+
+	for _, origin := range origins {
+		if origin == "*" {
+			ch.all = true
+			continue
+		}
+
+		ch.origins[origin] = true
+	}
+
+	return &ch
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (c *corsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	origin := r.Header.Get("Origin")
+
+	if origin != "" && (c.all || c.origins[origin]) {
+		if c.origins[origin] {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+		} else {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		}
+
+		w.Header().Add("Vary", "Origin")
+
+		if r.Method == "OPTIONS" && r.Header.Get("Access-Control-Request-Method") != "" {
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Encoding, Authorization")
+			w.Header().Set("Access-Control-Max-Age", "86400")
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+	}
+
+	c.next.ServeHTTP(w, r)
+}
+
+//==============================================================================
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+//==============================================================================
+
+// TestCORSCredentials checks credentials are only allowed for origins listed
+// explicitly, the wildcard allowing every other origin without them.
+func TestCORSCredentials(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		origins     []string
+		origin      string
+		allow       string
+		credentials string
+	}{
+		{[]string{"*"}, "https://evil.example.com", "*", ""},
+		{[]string{"https://pocket.example.com"}, "https://pocket.example.com", "https://pocket.example.com", "true"},
+		{[]string{"https://pocket.example.com"}, "https://evil.example.com", "", ""},
+		{[]string{"*", "https://pocket.example.com"}, "https://pocket.example.com", "https://pocket.example.com", "true"},
+		{[]string{"*", "https://pocket.example.com"}, "https://evil.example.com", "*", ""},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest("POST", "/", nil)
+		req.Header.Set("Origin", test.origin)
+
+		rec := httptest.NewRecorder()
+		newCORS(test.origins, next).ServeHTTP(rec, req)
+
+		if allow := rec.Header().Get("Access-Control-Allow-Origin"); allow != test.allow {
+			t.Errorf("Expected origin %s allowed as %q by %v, got %q", test.origin, test.allow, test.origins, allow)
+		}
+
+		if credentials := rec.Header().Get("Access-Control-Allow-Credentials"); credentials != test.credentials {
+			t.Errorf("Expected credentials %q for origin %s by %v, got %q", test.credentials, test.origin, test.origins, credentials)
+		}
+	}
+}
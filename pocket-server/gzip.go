@@ -0,0 +1,205 @@
+package main
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/influx6/faux/context"
+	"github.com/influx6/faux/web/app"
+)
+
+//==============================================================================
+
+// maxBodySize defines the number of bytes a request body may hold, both as
+// sent and once inflated when gzip encoded.
+const maxBodySize = 1 << 20
+
+// ErrBodyTooLarge is returned when reading a request body past maxBodySize.
+var ErrBodyTooLarge = errors.New("Request Body Too Large")
+
+// rejectLarge provides an app.Middleware which responds with a 413 to requests
+// whose handler failed on a body larger than maxBodySize, rather than with the
+// 400 given to other failures.
+func rejectLarge(next app.Handler) app.Handler {
+	return func(ctx context.Context, rw *app.ResponseRequest, params app.Param) error {
+		err := next(ctx, rw, params)
+
+		var maxErr *http.MaxBytesError
+		if err == ErrBodyTooLarge || errors.As(err, &maxErr) {
+			rw.RespondError(http.StatusRequestEntityTooLarge, ErrBodyTooLarge)
+			return nil
+		}
+
+		return err
+	}
+}
+
+//==============================================================================
+
+// gzipHandler provides an http.Handler which limits request bodies to
+// maxBodySize, decompresses gzip encoded request bodies and compresses the
+// responses of clients which accept gzip before handing requests to the next
+// handler.
+type gzipHandler struct {
+	next http.Handler
+}
+
+// newGzip returns a new gzipHandler for the next handler.
+func newGzip(next http.Handler) *gzipHandler {
+	gh := gzipHandler{
+		next: next,
+	}
+
+	return &gh
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (g *gzipHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Body != nil {
+		r.Body = http.MaxBytesReader(w, r.Body, maxBodySize)
+	}
+
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		body, err := gzip.NewReader(r.Body)
+		if err != nil {
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				http.Error(w, ErrBodyTooLarge.Error(), http.StatusRequestEntityTooLarge)
+				return
+			}
+
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		defer body.Close()
+
+		r.Body = &limitedBody{ReadCloser: body, left: maxBodySize}
+		r.Header.Del("Content-Encoding")
+		r.ContentLength = -1
+	}
+
+	// Ranged responses refer to the uncompressed content, so leave them as is.
+	if r.Header.Get("Range") != "" || !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+		g.next.ServeHTTP(w, r)
+		return
+	}
+
+	gw := gzipWriter{ResponseWriter: w}
+	defer gw.Close()
+
+	g.next.ServeHTTP(&gw, r)
+}
+
+//==============================================================================
+
+// limitedBody provides an io.ReadCloser which fails with ErrBodyTooLarge once
+// more than left bytes are read from it.
+type limitedBody struct {
+	io.ReadCloser
+	left int64
+}
+
+// Read implements the io.Reader interface.
+func (l *limitedBody) Read(b []byte) (int, error) {
+	if l.left < 0 {
+		return 0, ErrBodyTooLarge
+	}
+
+	// Read a byte past the limit, to tell a body ending at it from one
+	// going beyond.
+	if int64(len(b)) > l.left+1 {
+		b = b[:l.left+1]
+	}
+
+	n, err := l.ReadCloser.Read(b)
+	l.left -= int64(n)
+
+	if l.left < 0 {
+		return n + int(l.left), ErrBodyTooLarge
+	}
+
+	return n, err
+}
+
+//==============================================================================
+
+// gzipWriter provides a http.ResponseWriter which compresses the body written
+// to it, starting the compression only once a body is written so responses
+// without one are left untouched.
+type gzipWriter struct {
+	http.ResponseWriter
+	gz      *gzip.Writer
+	started bool
+	skip    bool
+}
+
+// WriteHeader implements the http.ResponseWriter interface.
+func (g *gzipWriter) WriteHeader(code int) {
+	if !g.started {
+		g.start(code)
+	}
+
+	g.ResponseWriter.WriteHeader(code)
+}
+
+// Write implements the http.ResponseWriter interface.
+func (g *gzipWriter) Write(b []byte) (int, error) {
+	if !g.started {
+		g.start(http.StatusOK)
+	}
+
+	if g.skip {
+		return g.ResponseWriter.Write(b)
+	}
+
+	return g.gz.Write(b)
+}
+
+// start decides whether the response is compressed, setting the headers
+// before they are written.
+func (g *gzipWriter) start(code int) {
+	g.started = true
+
+	header := g.ResponseWriter.Header()
+	header.Add("Vary", "Accept-Encoding")
+
+	if code == http.StatusNoContent || code == http.StatusNotModified || header.Get("Content-Encoding") != "" {
+		g.skip = true
+		return
+	}
+
+	header.Set("Content-Encoding", "gzip")
+	header.Del("Content-Length")
+	g.gz = gzip.NewWriter(g.ResponseWriter)
+}
+
+// Flush implements the http.Flusher interface, writing out the body compressed
+// so far.
+func (g *gzipWriter) Flush() {
+	if !g.started {
+		g.start(http.StatusOK)
+	}
+
+	if g.gz != nil {
+		g.gz.Flush()
+	}
+
+	if flusher, ok := g.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Close flushes the compressed body.
+func (g *gzipWriter) Close() error {
+	if g.gz == nil {
+		return nil
+	}
+
+	return g.gz.Close()
+}
+
+//==============================================================================
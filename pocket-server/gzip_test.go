@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/influx6/pocket/api/accounts"
+)
+
+//==============================================================================
+
+// TestGzipBodyLimit checks bodies past maxBodySize are refused with a 413,
+// whether sent as is or inflated from a small gzip encoded body.
+func TestGzipBodyLimit(t *testing.T) {
+	ts := newTestServer(t)
+	defer ts.Close()
+
+	gz := httptest.NewServer(newGzip(ts.Config.Handler))
+	defer gz.Close()
+
+	register := func(email string) []byte {
+		payload, err := json.Marshal(accounts.RegisterUser{Email: email, Password: "pocket-password"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return payload
+	}
+
+	compress := func(payload []byte) []byte {
+		var buf bytes.Buffer
+
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(payload); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		return buf.Bytes()
+	}
+
+	large := register(strings.Repeat("a", maxBodySize) + "@pocket.io")
+
+	tests := []struct {
+		name   string
+		body   []byte
+		gzip   bool
+		status int
+	}{
+		{"plain", register("plain@pocket.io"), false, http.StatusCreated},
+		{"gzip", compress(register("gzip@pocket.io")), true, http.StatusCreated},
+		{"large plain", large, false, http.StatusRequestEntityTooLarge},
+		{"large inflated", compress(large), true, http.StatusRequestEntityTooLarge},
+	}
+
+	for _, test := range tests {
+		req, err := http.NewRequest("POST", gz.URL+"/accounts/register", bytes.NewReader(test.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if test.gzip {
+			req.Header.Set("Content-Encoding", "gzip")
+		}
+
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		res.Body.Close()
+
+		if res.StatusCode != test.status {
+			t.Errorf("Expected status %d for the %s body of %d bytes, got %d", test.status, test.name, len(test.body), res.StatusCode)
+		}
+	}
+}
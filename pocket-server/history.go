@@ -0,0 +1,216 @@
+package main
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/influx6/faux/context"
+	"github.com/influx6/pocket/api/budgets"
+	"github.com/influx6/pocket/api/queries"
+)
+
+//==============================================================================
+
+// ErrInvalidDate is returned when a query is given a date it can not parse.
+var ErrInvalidDate = errors.New("Invalid Date, expected YYYY-MM-DD or RFC3339")
+
+// dateLayout defines the layout of the plain dates accepted by queries.
+const dateLayout = "2006-01-02"
+
+//==============================================================================
+
+// ReplayPocket rebuilds the budgets and items of the caller's pocket referenced
+// by the command by replaying its event log, storing every record the log
+// holds. Records created before the log was kept are left as they are.
+func (p *pocketData) ReplayPocket(ctx context.Context, rp budgets.ReplayPocket) (budgets.PocketRecord, error) {
+	pocket, err := p.ownedPocket(ctx, rp.UUID)
+	if err != nil {
+		return pocket, err
+	}
+
+	defer p.locks.lock(pocket.ID)()
+
+	events, err := p.store.Events(pocket.ID)
+	if err != nil {
+		return pocket, err
+	}
+
+	// Events changing records created before the log was kept have no target
+	// within the ledger, and are skipped.
+	ledger := budgets.NewLedger(pocketCurrency(pocket))
+	for _, event := range events {
+		if err := ledger.Apply(event); err != nil && err != budgets.ErrUnknownTarget {
+			return pocket, err
+		}
+	}
+
+	for _, budget := range ledger.Budgets {
+		if err := p.store.SaveBudget(budget); err != nil {
+			return pocket, err
+		}
+	}
+
+	for _, item := range ledger.Items {
+		if err := p.store.SaveItem(item); err != nil {
+			return pocket, err
+		}
+	}
+
+	return pocket, p.touchPocket(pocket.ID)
+}
+
+//==============================================================================
+
+// asOf returns the time given by the `at` parameter of the query and true, or
+// false if none was given. Plain dates stand for the end of the day in UTC.
+func asOf(q queries.Query) (time.Time, bool, error) {
+	value := q.Get("at")
+	if value == "" {
+		return time.Time{}, false, nil
+	}
+
+	if day, err := time.Parse(dateLayout, value); err == nil {
+		return day.AddDate(0, 0, 1).Add(-time.Nanosecond), true, nil
+	}
+
+	at, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return at, false, ErrInvalidDate
+	}
+
+	return at, true, nil
+}
+
+// ledgerAt returns the ledger of the pocket built by replaying the events of
+// its log applied on or before the giving time. Records created before the
+// log was kept are not within the ledger.
+func (p *pocketData) ledgerAt(pocket budgets.PocketRecord, at time.Time) (*budgets.Ledger, error) {
+	events, err := p.store.Events(pocket.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	ledger := budgets.NewLedger(pocketCurrency(pocket))
+	for _, event := range budgets.Until(events, at) {
+		if err := ledger.Apply(event); err != nil && err != budgets.ErrUnknownTarget {
+			return nil, err
+		}
+	}
+
+	return ledger, nil
+}
+
+// budgetsAt returns the budgets of the pocket as they stood at the giving
+// time, ordered by their title.
+func (p *pocketData) budgetsAt(pocket budgets.PocketRecord, at time.Time) ([]budgets.BudgetRecord, error) {
+	ledger, err := p.ledgerAt(pocket, at)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]budgets.BudgetRecord, 0, len(ledger.Budgets))
+	for _, budget := range ledger.Budgets {
+		records = append(records, budget)
+	}
+
+	sort.Sort(budgetsByTitle(records))
+	return records, nil
+}
+
+// itemsAt returns the items of the budget as they stood at the giving time,
+// ordered by their time.
+func (p *pocketData) itemsAt(budget budgets.BudgetRecord, at time.Time) ([]budgets.ItemRecord, error) {
+	pocket, err := p.store.Pocket(budget.Pocket)
+	if err != nil {
+		return nil, err
+	}
+
+	ledger, err := p.ledgerAt(pocket, at)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]budgets.ItemRecord, 0)
+	for _, item := range ledger.Items {
+		if item.Budget == budget.ID {
+			records = append(records, item)
+		}
+	}
+
+	sort.Sort(itemsByTime(records))
+	return records, nil
+}
+
+//==============================================================================
+
+// budgetsByTitle implements sort.Interface to order budgets by title.
+type budgetsByTitle []budgets.BudgetRecord
+
+func (b budgetsByTitle) Len() int           { return len(b) }
+func (b budgetsByTitle) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b budgetsByTitle) Less(i, j int) bool { return b[i].Title < b[j].Title }
+
+// itemsByTime implements sort.Interface to order items by their time.
+type itemsByTime []budgets.ItemRecord
+
+func (t itemsByTime) Len() int           { return len(t) }
+func (t itemsByTime) Swap(i, j int)      { t[i], t[j] = t[j], t[i] }
+func (t itemsByTime) Less(i, j int) bool { return t[i].Time.Before(t[j].Time) }
+
+//==============================================================================
+
+// queryHistory resolves the `history?budget=<id>` and `history?item=<id>`
+// queries, returning the events applied to the budget, including those applied
+// to its items, or to the single item, in the order they were applied.
+func (p *pocketData) queryHistory(ctx context.Context, q queries.Query) (interface{}, error) {
+	if id := q.Get("item"); id != "" {
+		item, err := p.ownedItem(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		budget, err := p.store.Budget(item.Budget)
+		if err != nil {
+			return nil, err
+		}
+
+		return p.history(budget.Pocket, func(event budgets.EventRecord) bool {
+			return event.Item == item.ID
+		})
+	}
+
+	id, err := q.Require("budget")
+	if err != nil {
+		return nil, err
+	}
+
+	budget, err := p.ownedBudget(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.history(budget.Pocket, func(event budgets.EventRecord) bool {
+		return event.Budget == budget.ID
+	})
+}
+
+// history returns the events within the log of the pocket which match the
+// filter.
+func (p *pocketData) history(pocket string, filter func(budgets.EventRecord) bool) ([]budgets.EventRecord, error) {
+	events, err := p.store.Events(pocket)
+	if err != nil {
+		return nil, err
+	}
+
+	matched := make([]budgets.EventRecord, 0, len(events))
+	for _, event := range events {
+		if filter(event) {
+			matched = append(matched, event)
+		}
+	}
+
+	return matched, nil
+}
+
+//==============================================================================
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/influx6/pocket/api/budgets"
+	"github.com/influx6/pocket/api/queries"
+)
+
+//==============================================================================
+
+// kinds returns the kinds of the events listed by a history query.
+func kinds(t *testing.T, result map[string]interface{}) []string {
+	var found []string
+
+	for _, record := range records(t, result) {
+		event, _ := record.(map[string]interface{})
+		found = append(found, event["kind"].(string))
+	}
+
+	return found
+}
+
+//==============================================================================
+
+// TestHistory checks the history of a budget lists the events applied to it
+// and its items in order, the history of an item only its own, and neither is
+// served to other users.
+func TestHistory(t *testing.T) {
+	ts := newTestServer(t)
+	defer ts.Close()
+
+	token := ts.register(t, "owner@pocket.io")
+	other := ts.register(t, "other@pocket.io")
+
+	budget := ts.addBudget(t, token, "1200 USD")
+	item := ts.addItem(t, token, budget.ID, "350 USD", "")
+
+	commands := []struct {
+		path string
+		body map[string]interface{}
+	}{
+		{"/budgets/amend", map[string]interface{}{"UUID": budget.ID, "Title": "Housing"}},
+		{"/items/amend", map[string]interface{}{"UUID": item.ID, "Price": "360 USD"}},
+		{"/items/delete", map[string]interface{}{"UUID": item.ID}},
+		{"/budgets/archive", map[string]interface{}{"UUID": budget.ID, "Archived": true}},
+	}
+
+	for _, command := range commands {
+		if status := ts.post(t, command.path, token, command.body, nil); status != http.StatusOK {
+			t.Fatalf("Expected %s to succeed, got status %d", command.path, status)
+		}
+	}
+
+	pack := ts.query(t, token, "history?budget="+budget.ID, "history?item="+item.ID)
+
+	tests := []struct {
+		name     string
+		found    []string
+		expected []string
+	}{
+		{
+			name:  "budget",
+			found: kinds(t, pack.Results[0]),
+			expected: []string{
+				budgets.EventNewBudget,
+				budgets.EventNewBudgetItem,
+				budgets.EventAmendBudget,
+				budgets.EventAmendBudgetItem,
+				budgets.EventDeleteBudgetItem,
+				budgets.EventArchiveBudget,
+			},
+		},
+		{
+			name:  "item",
+			found: kinds(t, pack.Results[1]),
+			expected: []string{
+				budgets.EventNewBudgetItem,
+				budgets.EventAmendBudgetItem,
+				budgets.EventDeleteBudgetItem,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		if len(test.found) != len(test.expected) {
+			t.Errorf("Expected the %s history %v, got %v", test.name, test.expected, test.found)
+			continue
+		}
+
+		for index, kind := range test.expected {
+			if test.found[index] != kind {
+				t.Errorf("Expected the %s history %v, got %v", test.name, test.expected, test.found)
+				break
+			}
+		}
+	}
+
+	pack = ts.query(t, other, "history?budget="+budget.ID, "history?item="+item.ID, "history")
+
+	for index, result := range pack.Results {
+		if failed, _ := result["QueryFailed"].(bool); !failed {
+			t.Errorf("Expected history query %d of another user to fail, got %v", index, result)
+		}
+	}
+}
+
+// TestReplayPocket checks replaying a pocket rebuilds its budgets and items
+// from the event log, undoing changes made to the stored records outside it.
+func TestReplayPocket(t *testing.T) {
+	ts := newTestServer(t)
+	defer ts.Close()
+
+	token := ts.register(t, "owner@pocket.io")
+	other := ts.register(t, "other@pocket.io")
+
+	budget := ts.addBudget(t, token, "1200 USD")
+	item := ts.addItem(t, token, budget.ID, "350 USD", "")
+
+	if status := ts.post(t, "/items/amend", token, map[string]interface{}{"UUID": item.ID, "Title": "March"}, nil); status != http.StatusOK {
+		t.Fatalf("Expected item to be amended, got status %d", status)
+	}
+
+	if status := ts.post(t, "/items/delete", token, map[string]interface{}{"UUID": item.ID}, nil); status != http.StatusOK {
+		t.Fatalf("Expected item to be deleted, got status %d", status)
+	}
+
+	expected, err := ts.pockets.store.Item(item.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Change the stored records behind the back of the log.
+	stray, err := ts.pockets.store.Budget(budget.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stray.Title = "Stray"
+	if err := ts.pockets.store.SaveBudget(stray); err != nil {
+		t.Fatal(err)
+	}
+
+	changed := expected
+	changed.Title, changed.Deleted = "Stray", nil
+	if err := ts.pockets.store.SaveItem(changed); err != nil {
+		t.Fatal(err)
+	}
+
+	rp := budgets.ReplayPocket{UUID: budget.Pocket}
+
+	if status := ts.post(t, "/pockets/replay", other, rp, nil); status != http.StatusBadRequest {
+		t.Fatalf("Expected the replay of another user's pocket to be refused, got status %d", status)
+	}
+
+	if status := ts.post(t, "/pockets/replay", token, rp, nil); status != http.StatusOK {
+		t.Fatalf("Expected the pocket to be replayed, got status %d", status)
+	}
+
+	replayed, err := ts.pockets.store.Budget(budget.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if replayed.Title != "Rent" || replayed.Price != budget.Price {
+		t.Fatalf("Expected the budget rebuilt as created, got %+v", replayed)
+	}
+
+	restored, err := ts.pockets.store.Item(item.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if restored.Title != "March" || restored.Price != expected.Price || restored.Deleted == nil || !restored.Deleted.Equal(*expected.Deleted) {
+		t.Fatalf("Expected the item rebuilt as amended and trashed, got %+v", restored)
+	}
+}
+
+// TestAsOf checks plain dates stand for the end of their day in UTC, times
+// are taken as given and anything else is refused.
+func TestAsOf(t *testing.T) {
+	tests := []struct {
+		value    string
+		past     bool
+		expected time.Time
+		err      error
+	}{
+		{"", false, time.Time{}, nil},
+		{"2016-03-01", true, time.Date(2016, 3, 1, 23, 59, 59, 999999999, time.UTC), nil},
+		{"2016-03-01T10:30:00Z", true, time.Date(2016, 3, 1, 10, 30, 0, 0, time.UTC), nil},
+		{"2016-03-01T10:30:00.25+02:00", true, time.Date(2016, 3, 1, 8, 30, 0, 250000000, time.UTC), nil},
+		{"yesterday", false, time.Time{}, ErrInvalidDate},
+		{"2016-02-30", false, time.Time{}, ErrInvalidDate},
+	}
+
+	for _, test := range tests {
+		q := queries.Query{Params: url.Values{"at": {test.value}}}
+
+		at, past, err := asOf(q)
+		if err != test.err || past != test.past || !at.Equal(test.expected) {
+			t.Errorf("Expected %q to stand for %v (%t, %v), got %v (%t, %v)", test.value, test.expected, test.past, test.err, at, past, err)
+		}
+	}
+}
+
+// TestQueryAsOf checks budgets and items are listed as they stood at a past
+// time, before the changes made after it, and nothing is listed before the
+// pocket held any.
+func TestQueryAsOf(t *testing.T) {
+	ts := newTestServer(t)
+	defer ts.Close()
+
+	token := ts.register(t, "owner@pocket.io")
+
+	budget := ts.addBudget(t, token, "1200 USD")
+	item := ts.addItem(t, token, budget.ID, "350 USD", "")
+
+	// stamp returns the current time as a query parameter, apart from the
+	// times of the changes before and after it.
+	stamp := func() string {
+		time.Sleep(5 * time.Millisecond)
+		at := time.Now().UTC().Format(time.RFC3339Nano)
+		time.Sleep(5 * time.Millisecond)
+		return at
+	}
+
+	created := stamp()
+
+	commands := []struct {
+		path string
+		body map[string]interface{}
+	}{
+		{"/budgets/amend", map[string]interface{}{"UUID": budget.ID, "Title": "Housing", "Price": "1300 USD"}},
+		{"/items/amend", map[string]interface{}{"UUID": item.ID, "Price": "360 USD"}},
+		{"/items/delete", map[string]interface{}{"UUID": item.ID}},
+	}
+
+	for _, command := range commands {
+		if status := ts.post(t, command.path, token, command.body, nil); status != http.StatusOK {
+			t.Fatalf("Expected %s to succeed, got status %d", command.path, status)
+		}
+	}
+
+	changed := stamp()
+
+	if status := ts.post(t, "/budgets/archive", token, map[string]interface{}{"UUID": budget.ID, "Archived": true}, nil); status != http.StatusOK {
+		t.Fatalf("Expected budget to be archived, got status %d", status)
+	}
+
+	pack := ts.query(t, token,
+		"budgets?pocket="+budget.Pocket+"&at="+created,
+		"items?budget="+budget.ID+"&at="+created,
+		"budgets?pocket="+budget.Pocket+"&at="+changed,
+		"items?budget="+budget.ID+"&at="+changed,
+		"items?budget="+budget.ID+"&at="+changed+"&trash=true",
+		"budgets?pocket="+budget.Pocket,
+		"budgets?pocket="+budget.Pocket+"&at=2000-01-01",
+		"budgets?pocket="+budget.Pocket+"&at=yesterday",
+	)
+
+	tests := []struct {
+		name     string
+		field    string
+		expected []string
+	}{
+		{"budgets once created", "price", []string{"1200.00 USD"}},
+		{"items once created", "price", []string{"350.00 USD"}},
+		{"budgets once changed", "title", []string{"Housing"}},
+		{"items once changed", "price", []string{}},
+		{"items in the trash once changed", "price", []string{"360.00 USD"}},
+		{"budgets now", "title", []string{}},
+		{"budgets before any", "title", []string{}},
+	}
+
+	for index, test := range tests {
+		list := records(t, pack.Results[index])
+		if len(list) != len(test.expected) {
+			t.Errorf("Expected %d %s, got %v", len(test.expected), test.name, list)
+			continue
+		}
+
+		for at, value := range test.expected {
+			if record, _ := list[at].(map[string]interface{}); record[test.field] != value {
+				t.Errorf("Expected %s with %s %s, got %v", test.name, test.field, value, record[test.field])
+			}
+		}
+	}
+
+	if failed, _ := pack.Results[7]["QueryFailed"].(bool); !failed {
+		t.Fatalf("Expected an invalid date to be refused, got %v", pack.Results[7])
+	}
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+	"path/filepath"
+
+	"github.com/influx6/faux/context"
+	"github.com/influx6/faux/web/app"
+	"github.com/influx6/pocket/api/bootstrap"
+	"github.com/influx6/pocket/api/budgets"
+	"github.com/influx6/pocket/api/currency"
+	"github.com/influx6/pocket/api/store"
+)
+
+//==============================================================================
+
+// indexPage renders the `index` template of the static directory, handing the
+// pocket-client its bootstrap data.
+type indexPage struct {
+	api      string
+	currency string
+	locale   currency.Locale
+	store    store.Store
+	tmpl     *template.Template
+}
+
+// renderedPocket defines the markup of a pocket rendered into the index page.
+type renderedPocket struct {
+	ID   string
+	HTML template.HTML
+}
+
+// indexData defines the data the `index` template is executed with.
+type indexData struct {
+	Bootstrap bootstrap.Data
+	Global    string
+	Pockets   []renderedPocket
+}
+
+// newIndexPage returns a new instance of indexPage with the templates of the
+// static directory, which can reference assets through the `asset` function.
+// The pockets of the current user are loaded from the giving store.
+func newIndexPage(conf Config, as *assets, st store.Store) (*indexPage, error) {
+	locale, err := currency.FindLocale(conf.Locale)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl, err := template.New("pocket").Funcs(template.FuncMap{
+		"asset": as.Path,
+	}).ParseGlob(filepath.Join(conf.Static, templatesDir, "*.tml"))
+	if err != nil {
+		return nil, err
+	}
+
+	ip := indexPage{
+		api:      conf.API,
+		currency: conf.Currency,
+		locale:   locale,
+		store:    st,
+		tmpl:     tmpl,
+	}
+
+	return &ip, nil
+}
+
+// Routes registers the route serving the index page.
+func (i *indexPage) Routes(pa *app.App) {
+	app.PageRoute(pa, "GET", "/", i.serve)
+}
+
+// serve renders the index page with the bootstrap data for the request, along
+// with the pockets of the current user.
+func (i *indexPage) serve(ctx context.Context, rw *app.ResponseRequest, params app.Param) error {
+	data := indexData{
+		Global: bootstrap.Global,
+		Bootstrap: bootstrap.Data{
+			API:      i.api,
+			Currency: i.currency,
+			Locale:   i.locale.Tag,
+		},
+	}
+
+	if data.Bootstrap.API == "" {
+		data.Bootstrap.API = requestOrigin(rw.R)
+	}
+
+	if user, ok := contextUser(ctx); ok {
+		data.Bootstrap.User = &bootstrap.User{
+			ID:    user.ID,
+			Email: user.Email,
+		}
+
+		if err := i.renderPockets(user.ID, &data); err != nil {
+			events.Error(contexts, "indexPage.serve", err, "Failed to render pockets")
+			rw.RespondError(http.StatusInternalServerError, err)
+			return nil
+		}
+	}
+
+	var page bytes.Buffer
+	if err := i.tmpl.ExecuteTemplate(&page, "index", data); err != nil {
+		events.Error(contexts, "indexPage.serve", err, "Failed to render index")
+		rw.RespondError(http.StatusInternalServerError, err)
+		return nil
+	}
+
+	rw.Header().Set("Content-Type", "text/html; charset=utf-8")
+	rw.Header().Set("Cache-Control", "no-cache")
+	rw.WriteHeader(http.StatusOK)
+	page.WriteTo(rw)
+
+	return nil
+}
+
+// renderPockets renders the pockets of the giving user into the page data.
+func (i *indexPage) renderPockets(owner string, data *indexData) error {
+	pockets, err := i.store.Pockets(owner)
+	if err != nil {
+		return err
+	}
+
+	categories, err := i.store.Categories(owner)
+	if err != nil {
+		return err
+	}
+
+	data.Bootstrap.Categories = budgets.Categories(categories)
+
+	for _, pocket := range pockets {
+		stored, err := i.store.Budgets(pocket.ID)
+		if err != nil {
+			return err
+		}
+
+		// Only the active budgets are shown, as the `budgets` query lists.
+		records := make([]budgets.BudgetRecord, 0, len(stored))
+		for _, record := range stored {
+			if record.Active() {
+				records = append(records, record)
+			}
+		}
+
+		cu, err := currency.ISO4217.Find(pocket.Currency)
+		if err != nil {
+			cu, _ = currency.ISO4217.Find(i.currency)
+		}
+
+		html, err := budgets.RenderPocket(budgets.BudgetOptions{
+			UUID:       pocket.ID,
+			Currency:   cu,
+			Locale:     i.locale,
+			Records:    records,
+			Categories: data.Bootstrap.Categories,
+		})
+		if err != nil {
+			return err
+		}
+
+		data.Pockets = append(data.Pockets, renderedPocket{ID: pocket.ID, HTML: html})
+		data.Bootstrap.Pockets = append(data.Bootstrap.Pockets, bootstrap.Pocket{
+			Pocket:  pocket,
+			Budgets: records,
+		})
+	}
+
+	return nil
+}
+
+// requestOrigin returns the origin the request was sent to.
+func requestOrigin(r *http.Request) string {
+	if r.TLS != nil {
+		return "https://" + r.Host
+	}
+
+	return "http://" + r.Host
+}
+
+//==============================================================================
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/influx6/faux/web/app"
+	"github.com/influx6/pocket/api/budgets"
+)
+
+//==============================================================================
+
+// newIndexServer returns a server of the index page and assets of the static
+// directory, over the store of the test server.
+func newIndexServer(t *testing.T, ts *testServer) *httptest.Server {
+	conf := defaultConfig()
+	conf.Static = filepath.Join("..", "static")
+
+	pa := app.New(events, false, nil, authenticate(ts.pockets.store))
+
+	as := newAssets(conf.Static)
+	as.Routes(pa)
+
+	index, err := newIndexPage(conf, as, ts.pockets.store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	index.Routes(pa)
+
+	return httptest.NewServer(pa)
+}
+
+//==============================================================================
+
+// TestIndexRender checks the index page renders the active budgets of the
+// pockets of the signed in user into the markup the client hydrates, along
+// with the bootstrap data, and renders no pockets without a session.
+func TestIndexRender(t *testing.T) {
+	ts := newTestServer(t)
+	defer ts.Close()
+
+	index := newIndexServer(t, ts)
+	defer index.Close()
+
+	token := ts.register(t, "owner@pocket.io")
+	budget := ts.addBudget(t, token, "1200 USD")
+
+	var archived budgets.BudgetRecord
+	nb := map[string]interface{}{"UUID": budget.Pocket, "Title": "Old", "Price": "50 USD"}
+	if status := ts.post(t, "/budgets", token, nb, &archived); status != http.StatusCreated {
+		t.Fatalf("Expected budget to be created, got status %d", status)
+	}
+
+	if status := ts.post(t, "/budgets/archive", token, map[string]interface{}{"UUID": archived.ID, "Archived": true}, nil); status != http.StatusOK {
+		t.Fatalf("Expected budget to be archived, got status %d", status)
+	}
+
+	res, page := get(t, index, "/", map[string]string{"Authorization": "Bearer " + token})
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("Expected the index page, got status %d", res.StatusCode)
+	}
+
+	if cache := res.Header.Get("Cache-Control"); cache != "no-cache" {
+		t.Fatalf("Expected the index page to be revalidated, got %q", cache)
+	}
+
+	expected := []string{
+		`data-pocket="` + budget.Pocket + `"`,
+		`uid="` + budget.Pocket + `"`,
+		`id="budget-` + budget.ID + `"`,
+		"$1,200.00",
+		"owner@pocket.io",
+		`"pockets":[{`,
+		"data-notifications",
+		`src="/static/js/app.js`,
+	}
+
+	for _, text := range expected {
+		if !strings.Contains(page, text) {
+			t.Errorf("Expected the index page to hold %q, got %s", text, page)
+		}
+	}
+
+	if strings.Contains(page, `id="budget-`+archived.ID+`"`) {
+		t.Errorf("Expected the archived budget not to be rendered, got %s", page)
+	}
+
+	res, page = get(t, index, "/", nil)
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("Expected the index page without a session, got status %d", res.StatusCode)
+	}
+
+	for _, text := range []string{"data-pocket", "data-notifications", "owner@pocket.io"} {
+		if strings.Contains(page, text) {
+			t.Errorf("Expected the index page without a session not to hold %q, got %s", text, page)
+		}
+	}
+}
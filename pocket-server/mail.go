@@ -0,0 +1,122 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/influx6/pocket/api/budgets"
+	"github.com/influx6/pocket/api/mailer"
+)
+
+//==============================================================================
+
+// ErrMailDisabled is returned when a mail is sent while no mail backend is
+// configured.
+var ErrMailDisabled = errors.New("Mail is not configured")
+
+// mailTemplatesDir defines the directory within the templates directory
+// holding the templates of mails.
+const mailTemplatesDir = "mail"
+
+// Sizing of the mail queue and the backoff before its first retry.
+const (
+	mailQueueSize = 256
+	mailBackoff   = 5 * time.Second
+)
+
+//==============================================================================
+
+// alertMail defines the data the `alert` mail templates are executed with.
+type alertMail struct {
+	Notice budgets.NotificationRecord
+	Link   string
+}
+
+// resetMail defines the data the `reset` mail templates are executed with.
+type resetMail struct {
+	Email   string
+	Link    string
+	Expires time.Time
+}
+
+//==============================================================================
+
+// postOffice provides the mailing of templated messages through the retrying
+// queue of the configured mailer. A nil postOffice mails nothing, returning
+// ErrMailDisabled.
+type postOffice struct {
+	queue     *mailer.Queue
+	templates *mailer.Templates
+	url       string
+}
+
+// newPostOffice returns a new postOffice instance for the mail configuration,
+// or nil if mail is disabled.
+func newPostOffice(conf Config) (*postOffice, error) {
+	var mm mailer.Mailer
+	var err error
+
+	switch conf.Mail.Backend {
+	case "":
+		return nil, nil
+	case SMTPMailer:
+		mm, err = mailer.NewSMTP(conf.Mail.SMTP, conf.Mail.From, conf.Mail.Username, conf.Mail.Password, conf.Mail.Timeout)
+	default:
+		mm, err = mailer.NewMaildir(conf.Mail.Maildir, conf.Mail.From)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	templates, err := mailer.LoadTemplates(filepath.Join(conf.Static, templatesDir, mailTemplatesDir))
+	if err != nil {
+		return nil, err
+	}
+
+	link := conf.Mail.URL
+	if link == "" {
+		link = conf.API
+	}
+
+	po := postOffice{
+		queue: mailer.NewQueue(mm, mailQueueSize, conf.Mail.Retries, mailBackoff, func(msg mailer.Message, err error) {
+			events.Error(contexts, "postOffice", err, "Failed to mail %q to %s", msg.Subject, msg.To)
+		}),
+		templates: templates,
+		url:       strings.TrimSuffix(link, "/"),
+	}
+
+	return &po, nil
+}
+
+// Link returns the absolute form of the giving path within the app.
+func (p *postOffice) Link(path string) string {
+	return p.url + path
+}
+
+// Send queues the mail rendered from the templates with the giving name for
+// delivery to the giving address.
+func (p *postOffice) Send(name string, to string, data interface{}) error {
+	if p == nil {
+		return ErrMailDisabled
+	}
+
+	msg, err := p.templates.Render(name, to, data)
+	if err != nil {
+		return err
+	}
+
+	return p.queue.Enqueue(msg)
+}
+
+// Close waits for the mails already queued to be delivered.
+func (p *postOffice) Close() {
+	if p != nil {
+		p.queue.Close()
+	}
+}
+
+//==============================================================================
@@ -1,13 +1,20 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"time"
 
-	"github.com/influx6/faux/context"
 	"github.com/influx6/faux/web/app"
+	"github.com/influx6/pocket/api/queries"
+	"github.com/influx6/pocket/api/rates"
+	"github.com/influx6/pocket/api/store"
+	"github.com/influx6/pocket/api/store/mongo"
 )
 
 //==============================================================================
@@ -15,15 +22,25 @@ import (
 var events eventlog
 
 // logg provides a concrete implementation of a logger.
-type eventlog struct{}
+type eventlog struct {
+	level string
+}
 
 // Log logs all standard log reports.
 func (l eventlog) Log(context interface{}, name string, message string, data ...interface{}) {
+	if l.level == ErrorLevel || l.level == SilentLevel {
+		return
+	}
+
 	fmt.Printf("Log: %s : %s : %s : %s\n", context, "DEV", name, fmt.Sprintf(message, data...))
 }
 
 // Error logs all error reports.
 func (l eventlog) Error(context interface{}, name string, err error, message string, data ...interface{}) {
+	if l.level == SilentLevel {
+		return
+	}
+
 	fmt.Printf("Error: %s : %s : %s : %s : Error %s\n", context, "DEV", name, fmt.Sprintf(message, data...), err)
 }
 
@@ -31,21 +48,132 @@ func (l eventlog) Error(context interface{}, name string, err error, message str
 
 var contexts = "pocket-app"
 
+// shutdownTimeout defines how long requests in flight are waited on when the
+// server shuts down.
+const shutdownTimeout = 15 * time.Second
+
 //==============================================================================
 
 func main() {
+	conf, err := loadConfig(os.Args[1:])
+	if err != nil {
+		events.Error(contexts, "main", err, "Failed to load configuration")
+		os.Exit(1)
+	}
+
+	events.level = conf.LogLevel
+
+	db, err := openStore(conf.Store)
+	if err != nil {
+		events.Error(contexts, "main", err, "Failed to open store[%s]", conf.Store.Backend)
+		os.Exit(1)
+	}
+
+	table, err := rates.LoadECB(conf.Rates...)
+	if err != nil {
+		events.Error(contexts, "main", err, "Failed to load exchange rates")
+		os.Exit(1)
+	}
+
+	if first, last := table.Dates(); !last.IsZero() {
+		events.Log(contexts, "main", "Loaded exchange rates from %s to %s", first.Format("2006-01-02"), last.Format("2006-01-02"))
+	}
+
+	office, err := newPostOffice(conf)
+	if err != nil {
+		events.Error(contexts, "main", err, "Failed to set up mail[%s]", conf.Mail.Backend)
+		os.Exit(1)
+	}
+
+	// Scope every query and command to the user of the request's session.
+	pocketapp := app.New(events, false, nil, rejectLarge, authenticate(db))
+
+	changes := queries.NewChangeLog()
+	pockets := newPocketData(db, changes, table, conf.Currency, office, conf.Webhooks)
+	pockets.StartScheduler(scheduleInterval)
 
-	pocketapp := app.New(events, true, nil, nil)
+	resolver := queries.New(events, "id", changes)
+	pockets.Register(resolver)
+	pockets.Routes(pocketapp)
+	pockets.UpdateRoutes(pocketapp)
 
-	app.PageRoute(pocketapp, "GET", "/", func(ctx context.Context, w *app.ResponseRequest, params app.Param) error {
+	users := newAccountData(db, conf.SessionLifetime, office)
+	users.Register(resolver)
+	users.Routes(pocketapp)
 
-		return nil
-	})
+	assets := newAssets(conf.Static)
+	assets.Routes(pocketapp)
 
-	go http.ListenAndServe(":3000", pocketapp)
+	index, err := newIndexPage(conf, assets, db)
+	if err != nil {
+		events.Error(contexts, "main", err, "Failed to load templates from %s", conf.Static)
+		os.Exit(1)
+	}
+
+	index.Routes(pocketapp)
+
+	// Answer the batched coquery requests sent by client.Servo instances.
+	app.PageRoute(pocketapp, "POST", "/", resolver.Serve)
+
+	// Requests are served within a context ended once the server shuts down,
+	// which ends the update streams that would otherwise hold it open.
+	base, stopStreams := context.WithCancel(context.Background())
+
+	server := http.Server{
+		Addr:        conf.HTTP,
+		Handler:     newCORS(conf.Origins, newGzip(pocketapp)),
+		BaseContext: func(net.Listener) context.Context { return base },
+	}
+
+	server.RegisterOnShutdown(stopStreams)
+
+	serverErr := make(chan error, 1)
+
+	go func() {
+		events.Log(contexts, "main", "Listening on %s", conf.HTTP)
+		serverErr <- server.ListenAndServe()
+	}()
 
 	// Listen for an interrupt signal from the OS.
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt)
-	<-sigChan
+
+	select {
+	case err := <-serverErr:
+		events.Error(contexts, "main", err, "Failed to serve on %s", conf.HTTP)
+		os.Exit(1)
+	case <-sigChan:
+	}
+
+	// Stop accepting requests and wait for those in flight, then stop the
+	// scheduler and the webhooks, which queue mails, before delivering the
+	// mails still queued.
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(ctx); err != nil {
+		events.Error(contexts, "main", err, "Failed to finish requests in flight")
+	}
+
+	pockets.Close()
+	office.Close()
+
+	// Fold the journal of the file store into its snapshot.
+	if closer, ok := db.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			events.Error(contexts, "main", err, "Failed to close store[%s]", conf.Store.Backend)
+		}
+	}
+}
+
+// openStore returns the store for the configured backend.
+func openStore(conf StoreConfig) (store.Store, error) {
+	switch conf.Backend {
+	case MemoryBackend:
+		return store.NewMemory(), nil
+	case MongoBackend:
+		return mongo.Dial(conf.Mongo, conf.DB, 10*time.Second)
+	default:
+		return store.NewFile(conf.Path)
+	}
 }
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"errors"
+	"time"
+
+	"github.com/influx6/faux/context"
+	"github.com/influx6/pocket/api/budgets"
+	"github.com/influx6/pocket/api/queries"
+	"github.com/influx6/pocket/api/store"
+	"github.com/satori/go.uuid"
+)
+
+//==============================================================================
+
+// ErrUnknownNotification is returned when a notification which does not
+// exists is referenced.
+var ErrUnknownNotification = errors.New("Unknown Notification")
+
+//==============================================================================
+
+// queryNotifications resolves the `notifications` query, returning the
+// notifications of the caller, the newest first. Only the unread
+// notifications are returned with `unseen=true`.
+func (p *pocketData) queryNotifications(ctx context.Context, q queries.Query) (interface{}, error) {
+	user, ok := contextUser(ctx)
+	if !ok {
+		return nil, ErrInvalidSession
+	}
+
+	records, err := p.store.Notifications(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if q.Get("unseen") != "true" {
+		return records, nil
+	}
+
+	unseen := make([]budgets.NotificationRecord, 0, len(records))
+	for _, record := range records {
+		if !record.Read {
+			unseen = append(unseen, record)
+		}
+	}
+
+	return unseen, nil
+}
+
+// notify stores the notification for the user with the giving id, who is told
+// of it through the notifications key of the user.
+func (p *pocketData) notify(owner string, notice budgets.Notify) error {
+	record := budgets.NotificationRecord{
+		Notify: notice,
+		ID:     uuid.NewV4().String(),
+		Owner:  owner,
+		Time:   time.Now(),
+	}
+
+	if err := p.store.SaveNotification(record); err != nil {
+		return err
+	}
+
+	p.changes.Touch(record.ID, budgets.NotificationsKey(owner))
+
+	// The notification is already stored, so failing to mail it is only logged.
+	if err := p.mailNotification(record); err != nil {
+		events.Error(contexts, "pocketData.notify", err, "Failed to mail notification[%s]", record.ID)
+	}
+
+	return nil
+}
+
+// mailNotification mails the notification to its owner if they asked for
+// notifications of its severity by mail.
+func (p *pocketData) mailNotification(record budgets.NotificationRecord) error {
+	if p.mail == nil {
+		return nil
+	}
+
+	user, err := p.store.UserByID(record.Owner)
+	if err != nil {
+		return err
+	}
+
+	if !record.Severity.AtLeast(budgets.Severity(user.Preferences.MailAlerts)) {
+		return nil
+	}
+
+	return p.mail.Send("alert", user.Email, alertMail{Notice: record, Link: p.mail.Link(record.Link)})
+}
+
+//==============================================================================
+
+// ReadNotification marks the caller's notification referenced by the command
+// as read.
+func (p *pocketData) ReadNotification(ctx context.Context, rn budgets.ReadNotification) (budgets.NotificationRecord, error) {
+	user, ok := contextUser(ctx)
+	if !ok {
+		return budgets.NotificationRecord{}, ErrInvalidSession
+	}
+
+	record, err := p.store.Notification(rn.UUID)
+	if err != nil {
+		if err == store.ErrNotFound {
+			return record, ErrUnknownNotification
+		}
+
+		return record, err
+	}
+
+	if record.Owner != user.ID {
+		return budgets.NotificationRecord{}, ErrUnknownNotification
+	}
+
+	if record.Read {
+		return record, nil
+	}
+
+	record.Read = true
+
+	if err := p.store.SaveNotification(record); err != nil {
+		return record, err
+	}
+
+	p.changes.Touch(record.ID, budgets.NotificationsKey(user.ID))
+	return record, nil
+}
+
+// ReadAllNotifications marks every unread notification of the caller as read,
+// returning those marked.
+func (p *pocketData) ReadAllNotifications(ctx context.Context, ra budgets.ReadAllNotifications) ([]budgets.NotificationRecord, error) {
+	user, ok := contextUser(ctx)
+	if !ok {
+		return nil, ErrInvalidSession
+	}
+
+	records, err := p.store.Notifications(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := []string{budgets.NotificationsKey(user.ID)}
+	marked := make([]budgets.NotificationRecord, 0, len(records))
+
+	for _, record := range records {
+		if record.Read {
+			continue
+		}
+
+		record.Read = true
+
+		if err := p.store.SaveNotification(record); err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, record.ID)
+		marked = append(marked, record)
+	}
+
+	p.changes.Touch(keys...)
+	return marked, nil
+}
+
+//==============================================================================
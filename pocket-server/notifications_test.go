@@ -0,0 +1,142 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/influx6/pocket/api/budgets"
+)
+
+//==============================================================================
+
+// messages returns the messages of the notifications listed by a query.
+func messages(t *testing.T, list []interface{}) []string {
+	found := make([]string, 0, len(list))
+
+	for _, record := range list {
+		notice, _ := record.(map[string]interface{})
+		found = append(found, notice["message"].(string))
+	}
+
+	return found
+}
+
+//==============================================================================
+
+// TestNotifications checks notifications are stored for their owner alone and
+// listed newest first, and that reading one, or all of them, marks them read
+// only for their owner.
+func TestNotifications(t *testing.T) {
+	ts := newTestServer(t)
+	defer ts.Close()
+
+	token := ts.register(t, "owner@pocket.io")
+	other := ts.register(t, "other@pocket.io")
+
+	owner, err := ts.pockets.store.User("owner@pocket.io")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stranger, err := ts.pockets.store.User("other@pocket.io")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, message := range []string{"first", "second", "third"} {
+		if err := ts.pockets.notify(owner.ID, budgets.Notify{Message: message, Severity: budgets.SeverityWarning}); err != nil {
+			t.Fatal(err)
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	if err := ts.pockets.notify(stranger.ID, budgets.Notify{Message: "foreign", Severity: budgets.SeverityInfo}); err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := ts.pockets.store.Notifications(owner.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids := make(map[string]string, len(stored))
+	for _, record := range stored {
+		if record.Owner != owner.ID || record.Read || record.Time.IsZero() {
+			t.Fatalf("Expected an unread notification of the owner, got %+v", record)
+		}
+
+		ids[record.Message] = record.ID
+	}
+
+	foreign, err := ts.pockets.store.Notifications(stranger.ID)
+	if err != nil || len(foreign) != 1 {
+		t.Fatalf("Expected 1 notification of the other user, got %d: %v", len(foreign), err)
+	}
+
+	var read budgets.NotificationRecord
+
+	for index := 0; index < 2; index++ {
+		if status := ts.post(t, "/notifications/read", token, budgets.ReadNotification{UUID: ids["second"]}, &read); status != http.StatusOK {
+			t.Fatalf("Expected the notification to be read, got status %d", status)
+		}
+
+		if !read.Read || read.ID != ids["second"] {
+			t.Fatalf("Expected the notification marked read, got %+v", read)
+		}
+	}
+
+	for _, id := range []string{foreign[0].ID, "unknown"} {
+		if status := ts.post(t, "/notifications/read", token, budgets.ReadNotification{UUID: id}, nil); status != http.StatusBadRequest {
+			t.Fatalf("Expected reading notification %s to be refused, got status %d", id, status)
+		}
+	}
+
+	pack := ts.query(t, token, "notifications", "notifications?unseen=true")
+
+	tests := []struct {
+		name     string
+		found    []string
+		expected []string
+	}{
+		{"notifications", messages(t, records(t, pack.Results[0])), []string{"third", "second", "first"}},
+		{"unseen notifications", messages(t, records(t, pack.Results[1])), []string{"third", "first"}},
+	}
+
+	for _, test := range tests {
+		if len(test.found) != len(test.expected) {
+			t.Errorf("Expected %s %v, got %v", test.name, test.expected, test.found)
+			continue
+		}
+
+		for index, message := range test.expected {
+			if test.found[index] != message {
+				t.Errorf("Expected %s %v, got %v", test.name, test.expected, test.found)
+				break
+			}
+		}
+	}
+
+	var marked []budgets.NotificationRecord
+
+	if status := ts.post(t, "/notifications/read-all", token, budgets.ReadAllNotifications{}, &marked); status != http.StatusOK {
+		t.Fatalf("Expected the notifications to be read, got status %d", status)
+	}
+
+	if len(marked) != 2 {
+		t.Fatalf("Expected the 2 unread notifications to be marked, got %d", len(marked))
+	}
+
+	if status := ts.post(t, "/notifications/read-all", token, budgets.ReadAllNotifications{}, &marked); status != http.StatusOK || len(marked) != 0 {
+		t.Fatalf("Expected no notifications left to mark, got status %d and %d marked", status, len(marked))
+	}
+
+	if list := records(t, ts.query(t, token, "notifications?unseen=true").Results[0]); len(list) != 0 {
+		t.Fatalf("Expected no unseen notifications, got %d", len(list))
+	}
+
+	if list := records(t, ts.query(t, other, "notifications?unseen=true").Results[0]); len(list) != 1 {
+		t.Fatalf("Expected the other user's notification to stay unread, got %d", len(list))
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"time"
+
+	"github.com/influx6/faux/context"
+	"github.com/influx6/pocket/api/budgets"
+	"github.com/influx6/pocket/api/queries"
+)
+
+//==============================================================================
+
+// queryPeriods resolves the `periods?budget=<id>` query, returning the totals
+// of the budget within each of its periods, from its first through its current
+// period. Budgets without a period have no periods.
+func (p *pocketData) queryPeriods(ctx context.Context, q queries.Query) (interface{}, error) {
+	id, err := q.Require("budget")
+	if err != nil {
+		return nil, err
+	}
+
+	budget, err := p.ownedBudget(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if budget.Period.IsZero() {
+		return []budgets.PeriodTotal{}, nil
+	}
+
+	return p.budgetPeriods(budget, time.Now())
+}
+
+// budgetPeriods returns the totals of the budget within each of its periods
+// through the one holding the giving time, held in the currency of its pocket
+// and budgeting the price it held within each. Items in the trash are not
+// counted.
+func (p *pocketData) budgetPeriods(budget budgets.BudgetRecord, through time.Time) ([]budgets.PeriodTotal, error) {
+	items, err := p.store.Items(budget.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var spends []budgets.Spend
+
+	for _, item := range items {
+		if item.Deleted != nil {
+			continue
+		}
+
+		spends = append(spends, budgets.Spend{Time: item.Time, Amount: item.Price})
+	}
+
+	return budget.Period.Totals(budget.ID, budget.PriceHistory(), spends, through)
+}
+
+//==============================================================================
@@ -0,0 +1,649 @@
+package main
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/influx6/faux/context"
+	"github.com/influx6/pocket/api/budgets"
+	"github.com/influx6/pocket/api/currency"
+	"github.com/influx6/pocket/api/queries"
+	"github.com/influx6/pocket/api/rates"
+	"github.com/influx6/pocket/api/store"
+	"github.com/influx6/pocket/api/webhooks"
+	"github.com/satori/go.uuid"
+)
+
+//==============================================================================
+
+// ErrUnknownPocket is returned when a pocket which does not exists is referenced.
+var ErrUnknownPocket = errors.New("Unknown Pocket")
+
+// ErrUnknownBudget is returned when a budget which does not exists is referenced.
+var ErrUnknownBudget = errors.New("Unknown Budget")
+
+// ErrUnknownItem is returned when an item which does not exists is referenced.
+var ErrUnknownItem = errors.New("Unknown Item")
+
+// ErrDeletedRecord is returned when a budget or item in the trash is amended,
+// or an item is added into a budget in the trash.
+var ErrDeletedRecord = errors.New("Record is in the trash")
+
+//==============================================================================
+
+// pocketLocks holds a lock for every pocket changed, serialising the changes
+// made to the budgets and items of each pocket so none is lost to another
+// made from the same stored records.
+type pocketLocks struct {
+	rl   sync.Mutex
+	held map[string]*sync.Mutex
+}
+
+// lock takes the lock of the pocket with the giving id, returning the function
+// which releases it.
+func (l *pocketLocks) lock(pocket string) func() {
+	l.rl.Lock()
+
+	if l.held == nil {
+		l.held = make(map[string]*sync.Mutex)
+	}
+
+	held, ok := l.held[pocket]
+	if !ok {
+		held = new(sync.Mutex)
+		l.held[pocket] = held
+	}
+
+	l.rl.Unlock()
+
+	held.Lock()
+	return held.Unlock
+}
+
+//==============================================================================
+
+// pocketData provides the pockets, budgets and items served by the
+// pocket-server, backed by the provided store.
+type pocketData struct {
+	changes *queries.ChangeLog
+	store   store.Store
+	rates   rates.Provider
+	alerts  *alertBoard
+	mail    *postOffice
+	hooks   *webhooks.Dispatcher
+	home    string
+	locks   pocketLocks
+
+	// schedule serialises changes to recurring items with the scheduler
+	// writing their items, which runs until stop is closed.
+	schedule  sync.Mutex
+	scheduled sync.WaitGroup
+	stop      chan struct{}
+}
+
+// newPocketData returns a new instance of pocketData which records all changes
+// made to its records into the provided ChangeLog. Totals are converted with
+// the rates of the provider into the home currency of each user, else into the
+// giving default currency. Notifications are mailed through the giving
+// postOffice, if any, to users who asked for them, and changes are delivered
+// to webhooks as configured.
+func newPocketData(st store.Store, changes *queries.ChangeLog, rp rates.Provider, home string, office *postOffice, hooks WebhooksConfig) *pocketData {
+	pd := pocketData{
+		changes: changes,
+		store:   st,
+		rates:   rp,
+		alerts:  newAlertBoard(st),
+		mail:    office,
+		home:    home,
+		stop:    make(chan struct{}),
+	}
+
+	pd.hooks = pd.newHookDispatcher(hooks)
+
+	return &pd
+}
+
+// Close stops the scheduler of recurring items and waits for the webhook
+// deliveries already queued to be made.
+func (p *pocketData) Close() {
+	close(p.stop)
+	p.scheduled.Wait()
+
+	p.hooks.Close()
+}
+
+// Register adds the pocket queries into the provided resolver.
+func (p *pocketData) Register(rs *queries.Resolver) {
+	rs.Register("pockets", p.queryPockets)
+	rs.Register("budgets", p.queryBudgets)
+	rs.Register("items", p.queryItems)
+	rs.Register("totals", p.queryTotals)
+	rs.Register("categories", p.queryCategories)
+	rs.Register("spending", p.querySpending)
+	rs.Register("history", p.queryHistory)
+	rs.Register("periods", p.queryPeriods)
+	rs.Register("rules", p.queryRules)
+	rs.Register("alerts", p.queryAlerts)
+	rs.Register("notifications", p.queryNotifications)
+	rs.Register("webhooks", p.queryWebhooks)
+	rs.Register("deliveries", p.queryDeliveries)
+	rs.Register("recurring", p.queryRecurring)
+	rs.Register("upcoming", p.queryUpcoming)
+}
+
+// queryPockets resolves the `pockets` query, returning all pockets of the
+// caller.
+func (p *pocketData) queryPockets(ctx context.Context, q queries.Query) (interface{}, error) {
+	user, ok := contextUser(ctx)
+	if !ok {
+		return nil, ErrInvalidSession
+	}
+
+	return p.store.Pockets(user.ID)
+}
+
+// queryBudgets resolves the `budgets?pocket=<id>` query, returning the active
+// budgets within the giving pocket. Archived budgets are included with
+// `archived=true`, while `trash=true` returns only the budgets in the trash.
+// With `at=<date>` the budgets are returned as they stood at the date.
+func (p *pocketData) queryBudgets(ctx context.Context, q queries.Query) (interface{}, error) {
+	id, err := q.Require("pocket")
+	if err != nil {
+		return nil, err
+	}
+
+	pocket, err := p.ownedPocket(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	at, past, err := asOf(q)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []budgets.BudgetRecord
+
+	if past {
+		records, err = p.budgetsAt(pocket, at)
+	} else {
+		records, err = p.store.Budgets(pocket.ID)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	archived, trash := q.Get("archived") == "true", q.Get("trash") == "true"
+
+	listed := make([]budgets.BudgetRecord, 0, len(records))
+	for _, budget := range records {
+		if listState(budget.Archived, budget.Deleted != nil, archived, trash) {
+			listed = append(listed, budget)
+		}
+	}
+
+	return listed, nil
+}
+
+// listState returns true/false if a record of the giving state is listed when
+// archived records are requested alongside the active ones, or when only the
+// records in the trash are requested.
+func listState(isArchived bool, isDeleted bool, archived bool, trash bool) bool {
+	if trash || isDeleted {
+		return trash && isDeleted
+	}
+
+	return archived || !isArchived
+}
+
+// queryItems resolves the `items?budget=<id>&category=<id>&tag=<tag>` query,
+// returning the items written against the giving budget. Items are filtered to
+// those filed under the category or any category nested under it, and to those
+// carrying every tag given. Archived items and those in the trash, as well as
+// items as they stood at a date, are listed as for the `budgets` query.
+func (p *pocketData) queryItems(ctx context.Context, q queries.Query) (interface{}, error) {
+	id, err := q.Require("budget")
+	if err != nil {
+		return nil, err
+	}
+
+	budget, err := p.ownedBudget(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	at, past, err := asOf(q)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []budgets.ItemRecord
+
+	if past {
+		items, err = p.itemsAt(budget, at)
+	} else {
+		items, err = p.store.Items(budget.ID)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := p.itemFilter(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	archived, trash := q.Get("archived") == "true", q.Get("trash") == "true"
+
+	filtered := make([]budgets.ItemRecord, 0, len(items))
+	for _, item := range items {
+		if filter(item) && listState(item.Archived, item.Deleted != nil, archived, trash) {
+			filtered = append(filtered, item)
+		}
+	}
+
+	return filtered, nil
+}
+
+// ownedPocket returns the pocket with the giving id if it belongs to the
+// caller, else returns ErrUnknownPocket.
+func (p *pocketData) ownedPocket(ctx context.Context, id string) (budgets.PocketRecord, error) {
+	user, ok := contextUser(ctx)
+	if !ok {
+		return budgets.PocketRecord{}, ErrInvalidSession
+	}
+
+	pocket, err := p.store.Pocket(id)
+	if err != nil {
+		if err == store.ErrNotFound {
+			return pocket, ErrUnknownPocket
+		}
+
+		return pocket, err
+	}
+
+	if pocket.Owner != user.ID {
+		return budgets.PocketRecord{}, ErrUnknownPocket
+	}
+
+	return pocket, nil
+}
+
+// ownedBudget returns the budget with the giving id if its pocket belongs to
+// the caller, else returns ErrUnknownBudget.
+func (p *pocketData) ownedBudget(ctx context.Context, id string) (budgets.BudgetRecord, error) {
+	budget, err := p.store.Budget(id)
+	if err != nil {
+		if err == store.ErrNotFound {
+			return budget, ErrUnknownBudget
+		}
+
+		return budget, err
+	}
+
+	if _, err := p.ownedPocket(ctx, budget.Pocket); err != nil {
+		if err == ErrUnknownPocket {
+			return budgets.BudgetRecord{}, ErrUnknownBudget
+		}
+
+		return budgets.BudgetRecord{}, err
+	}
+
+	return budget, nil
+}
+
+// ownedItem returns the item with the giving id if the pocket of its budget
+// belongs to the caller, else returns ErrUnknownItem.
+func (p *pocketData) ownedItem(ctx context.Context, id string) (budgets.ItemRecord, error) {
+	item, err := p.store.Item(id)
+	if err != nil {
+		if err == store.ErrNotFound {
+			return item, ErrUnknownItem
+		}
+
+		return item, err
+	}
+
+	if _, err := p.ownedBudget(ctx, item.Budget); err != nil {
+		if err == ErrUnknownBudget {
+			return budgets.ItemRecord{}, ErrUnknownItem
+		}
+
+		return budgets.ItemRecord{}, err
+	}
+
+	return item, nil
+}
+
+//==============================================================================
+
+// AddPocket adds a new pocket owned by the caller from the provided command.
+func (p *pocketData) AddPocket(ctx context.Context, np budgets.NewPocket) (budgets.PocketRecord, error) {
+	user, ok := contextUser(ctx)
+	if !ok {
+		return budgets.PocketRecord{}, ErrInvalidSession
+	}
+
+	cu, err := currency.ISO4217.Find(np.Currency)
+	if err != nil {
+		return budgets.PocketRecord{}, err
+	}
+
+	pocket := budgets.PocketRecord{
+		ID:       uuid.NewV4().String(),
+		Owner:    user.ID,
+		Title:    np.Title,
+		Currency: cu.Code,
+	}
+
+	if err := p.store.SavePocket(pocket); err != nil {
+		return pocket, err
+	}
+
+	p.changes.Touch(pocket.ID, user.ID)
+	return pocket, nil
+}
+
+// AddBudget adds a new budget into the caller's pocket referenced by the
+// command.
+func (p *pocketData) AddBudget(ctx context.Context, nb budgets.NewBudget) (budgets.BudgetRecord, error) {
+	pocket, err := p.ownedPocket(ctx, nb.UUID)
+	if err != nil {
+		return budgets.BudgetRecord{}, err
+	}
+
+	if err := nb.Period.Validate(); err != nil {
+		return budgets.BudgetRecord{}, err
+	}
+
+	id := uuid.NewV4().String()
+	ledger := budgets.NewLedger(pocketCurrency(pocket))
+
+	defer p.locks.lock(pocket.ID)()
+
+	if err := p.commit(ctx, ledger, pocket, id, "", nb); err != nil {
+		return budgets.BudgetRecord{}, err
+	}
+
+	return ledger.Budgets[id], nil
+}
+
+// AddItem adds a new cost item into the caller's budget referenced by the
+// command, unless the budget is in the trash.
+func (p *pocketData) AddItem(ctx context.Context, ni budgets.NewBudgetItem) (budgets.ItemRecord, error) {
+	budget, err := p.ownedBudget(ctx, ni.UUID)
+	if err != nil {
+		return budgets.ItemRecord{}, err
+	}
+
+	defer p.locks.lock(budget.Pocket)()
+
+	// The budget may have been changed before the lock was taken.
+	if budget, err = p.store.Budget(budget.ID); err != nil {
+		return budgets.ItemRecord{}, err
+	}
+
+	if budget.Deleted != nil {
+		return budgets.ItemRecord{}, ErrDeletedRecord
+	}
+
+	pocket, err := p.store.Pocket(budget.Pocket)
+	if err != nil {
+		return budgets.ItemRecord{}, err
+	}
+
+	if ni.Category != "" {
+		if _, err := p.ownedCategory(ctx, ni.Category); err != nil {
+			return budgets.ItemRecord{}, err
+		}
+	}
+
+	id := uuid.NewV4().String()
+	ledger := budgets.NewLedger(pocketCurrency(pocket))
+
+	if err := p.commit(ctx, ledger, pocket, budget.ID, id, ni); err != nil {
+		return budgets.ItemRecord{}, err
+	}
+
+	return ledger.Items[id], nil
+}
+
+// AmendBudget changes the title, price and period of the caller's budget
+// referenced by the command, as far as they are given.
+func (p *pocketData) AmendBudget(ctx context.Context, ab budgets.AmendBudget) (budgets.BudgetRecord, error) {
+	return p.updateBudget(ctx, ab.UUID, ab, func(budget budgets.BudgetRecord) error {
+		if budget.Deleted != nil {
+			return ErrDeletedRecord
+		}
+
+		if ab.Period != nil {
+			return ab.Period.Validate()
+		}
+
+		return nil
+	})
+}
+
+// DeleteBudget moves the caller's budget referenced by the command into the
+// trash.
+func (p *pocketData) DeleteBudget(ctx context.Context, db budgets.DeleteBudget) (budgets.BudgetRecord, error) {
+	return p.updateBudget(ctx, db.UUID, db, nil)
+}
+
+// RestoreBudget restores the caller's budget referenced by the command from
+// the trash.
+func (p *pocketData) RestoreBudget(ctx context.Context, rb budgets.RestoreBudget) (budgets.BudgetRecord, error) {
+	return p.updateBudget(ctx, rb.UUID, rb, nil)
+}
+
+// ArchiveBudget archives or unarchives the caller's budget referenced by the
+// command.
+func (p *pocketData) ArchiveBudget(ctx context.Context, ab budgets.ArchiveBudget) (budgets.BudgetRecord, error) {
+	return p.updateBudget(ctx, ab.UUID, ab, nil)
+}
+
+// updateBudget applies the command to the caller's budget with the giving id
+// once it passes the check, if any is given, holding the lock of its pocket
+// from reading the budget until the change is stored.
+func (p *pocketData) updateBudget(ctx context.Context, id string, command interface{}, check func(budgets.BudgetRecord) error) (budgets.BudgetRecord, error) {
+	budget, err := p.ownedBudget(ctx, id)
+	if err != nil {
+		return budget, err
+	}
+
+	defer p.locks.lock(budget.Pocket)()
+
+	// The budget may have been changed before the lock was taken.
+	if budget, err = p.store.Budget(id); err != nil {
+		return budget, err
+	}
+
+	pocket, err := p.store.Pocket(budget.Pocket)
+	if err != nil {
+		return budget, err
+	}
+
+	if check != nil {
+		if err := check(budget); err != nil {
+			return budget, err
+		}
+	}
+
+	ledger := budgets.NewLedger(pocketCurrency(pocket))
+	ledger.Budgets[budget.ID] = budget
+
+	if err := p.commit(ctx, ledger, pocket, budget.ID, "", command); err != nil {
+		return budget, err
+	}
+
+	return ledger.Budgets[budget.ID], nil
+}
+
+// AmendItem changes the details of the caller's item referenced by the
+// command, as far as they are given.
+func (p *pocketData) AmendItem(ctx context.Context, ai budgets.AmendBudgetItem) (budgets.ItemRecord, error) {
+	return p.updateItem(ctx, ai.UUID, ai, func(item budgets.ItemRecord) error {
+		if item.Deleted != nil {
+			return ErrDeletedRecord
+		}
+
+		if ai.Category != nil && *ai.Category != "" {
+			if _, err := p.ownedCategory(ctx, *ai.Category); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// DeleteItem moves the caller's item referenced by the command into the trash.
+func (p *pocketData) DeleteItem(ctx context.Context, di budgets.DeleteBudgetItem) (budgets.ItemRecord, error) {
+	return p.updateItem(ctx, di.UUID, di, nil)
+}
+
+// RestoreItem restores the caller's item referenced by the command from the
+// trash.
+func (p *pocketData) RestoreItem(ctx context.Context, ri budgets.RestoreBudgetItem) (budgets.ItemRecord, error) {
+	return p.updateItem(ctx, ri.UUID, ri, nil)
+}
+
+// ArchiveItem archives or unarchives the caller's item referenced by the
+// command.
+func (p *pocketData) ArchiveItem(ctx context.Context, ai budgets.ArchiveBudgetItem) (budgets.ItemRecord, error) {
+	return p.updateItem(ctx, ai.UUID, ai, nil)
+}
+
+// updateItem applies the command to the caller's item with the giving id once
+// it passes the check, if any is given, holding the lock of its pocket from
+// reading the item until the change is stored.
+func (p *pocketData) updateItem(ctx context.Context, id string, command interface{}, check func(budgets.ItemRecord) error) (budgets.ItemRecord, error) {
+	item, err := p.ownedItem(ctx, id)
+	if err != nil {
+		return item, err
+	}
+
+	budget, err := p.store.Budget(item.Budget)
+	if err != nil {
+		return item, err
+	}
+
+	defer p.locks.lock(budget.Pocket)()
+
+	// The item may have been changed before the lock was taken.
+	if item, err = p.store.Item(id); err != nil {
+		return item, err
+	}
+
+	pocket, err := p.store.Pocket(budget.Pocket)
+	if err != nil {
+		return item, err
+	}
+
+	if check != nil {
+		if err := check(item); err != nil {
+			return item, err
+		}
+	}
+
+	ledger := budgets.NewLedger(pocketCurrency(pocket))
+	ledger.Items[item.ID] = item
+
+	if err := p.commit(ctx, ledger, pocket, budget.ID, item.ID, command); err != nil {
+		return item, err
+	}
+
+	return ledger.Items[item.ID], nil
+}
+
+// commit records the command made by the caller against the budget, or the
+// item if one is given, as an event within the log of the pocket. The event
+// is applied to the ledger, which must hold the budget or item changed, and
+// is then stored along with the changed record before the alert rules of the
+// budget are evaluated. Callers hold the lock of the pocket from reading the
+// records within the ledger.
+func (p *pocketData) commit(ctx context.Context, ledger *budgets.Ledger, pocket budgets.PocketRecord, budget string, item string, command interface{}) error {
+	user, ok := contextUser(ctx)
+	if !ok {
+		return ErrInvalidSession
+	}
+
+	event, err := budgets.NewEvent(uuid.NewV4().String(), user.ID, pocket.ID, budget, item, command)
+	if err != nil {
+		return err
+	}
+
+	if err := ledger.Apply(event); err != nil {
+		return err
+	}
+
+	payload := budgets.HookPayload{
+		Event:  event.Kind,
+		Pocket: pocket.ID,
+		By:     user.ID,
+		Time:   event.Time,
+	}
+
+	if item == "" {
+		record := ledger.Budgets[budget]
+		if err := p.store.CommitEvent(event, &record, nil); err != nil {
+			return err
+		}
+
+		payload.Budget = &record
+		p.emit(payload)
+
+		p.reviewRules(budget)
+		return p.touchPocket(pocket.ID)
+	}
+
+	record := ledger.Items[item]
+	if err := p.store.CommitEvent(event, nil, &record); err != nil {
+		return err
+	}
+
+	payload.Item = &record
+	p.emit(payload)
+
+	p.reviewRules(budget)
+	return p.touchPocket(pocket.ID, item)
+}
+
+// pocketCurrency returns the currency of the pocket, or a currency without a
+// code for a pocket of an unknown currency, which no amount is held in.
+func pocketCurrency(pocket budgets.PocketRecord) currency.Currency {
+	cu, err := currency.ISO4217.Find(pocket.Currency)
+	if err != nil {
+		return currency.Currency{}
+	}
+
+	return cu
+}
+
+// touchPocket records a change against the pocket, its owner and every budget
+// within it, alongside any extra keys provided. Clients only watch the keys of
+// records they have already received, so this allows a new budget to refresh
+// any listing of its siblings, and the owner's totals to be refreshed.
+func (p *pocketData) touchPocket(pocket string, keys ...string) error {
+	record, err := p.store.Pocket(pocket)
+	if err != nil {
+		return err
+	}
+
+	records, err := p.store.Budgets(pocket)
+	if err != nil {
+		return err
+	}
+
+	keys = append(keys, pocket, record.Owner)
+	for _, budget := range records {
+		keys = append(keys, budget.ID)
+	}
+
+	p.changes.Touch(keys...)
+	return nil
+}
+
+//==============================================================================
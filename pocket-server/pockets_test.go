@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/influx6/pocket/api/budgets"
+)
+
+//==============================================================================
+
+// addBudget creates a pocket and a budget of it at the giving price, returning
+// the budget.
+func (ts *testServer) addBudget(t *testing.T, token string, price string) budgets.BudgetRecord {
+	var pocket budgets.PocketRecord
+	if status := ts.post(t, "/pockets", token, budgets.NewPocket{Title: "Home", Currency: "USD"}, &pocket); status != http.StatusCreated {
+		t.Fatalf("Expected pocket to be created, got status %d", status)
+	}
+
+	var budget budgets.BudgetRecord
+	nb := map[string]interface{}{"UUID": pocket.ID, "Title": "Rent", "Price": price}
+	if status := ts.post(t, "/budgets", token, nb, &budget); status != http.StatusCreated {
+		t.Fatalf("Expected budget to be created, got status %d", status)
+	}
+
+	return budget
+}
+
+//==============================================================================
+
+// TestAmendBudgetPartial checks amending a budget changes only the fields sent
+// by the client.
+func TestAmendBudgetPartial(t *testing.T) {
+	ts := newTestServer(t)
+	defer ts.Close()
+
+	token := ts.register(t, "owner@pocket.io")
+	budget := ts.addBudget(t, token, "1200 USD")
+
+	ab := map[string]interface{}{"UUID": budget.ID, "Title": "Housing"}
+	if status := ts.post(t, "/budgets/amend", token, ab, nil); status != http.StatusOK {
+		t.Fatalf("Expected budget to be amended, got status %d", status)
+	}
+
+	amended, err := ts.pockets.store.Budget(budget.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if amended.Title != "Housing" || amended.Price != budget.Price {
+		t.Fatalf("Expected a title-only amend to keep the price %s, got %+v", budget.Price.Decimal(), amended)
+	}
+}
+
+// TestAddItemTrashedBudget checks items can not be added to a budget in the
+// trash.
+func TestAddItemTrashedBudget(t *testing.T) {
+	ts := newTestServer(t)
+	defer ts.Close()
+
+	token := ts.register(t, "owner@pocket.io")
+	budget := ts.addBudget(t, token, "1200 USD")
+
+	if status := ts.post(t, "/budgets/delete", token, budgets.DeleteBudget{UUID: budget.ID}, nil); status != http.StatusOK {
+		t.Fatalf("Expected budget to be deleted, got status %d", status)
+	}
+
+	ni := map[string]interface{}{"UUID": budget.ID, "Title": "March", "Price": "80 USD"}
+	if status := ts.post(t, "/items", token, ni, nil); status != http.StatusBadRequest {
+		t.Fatalf("Expected the item to be refused, got status %d", status)
+	}
+
+	if items, err := ts.pockets.store.Items(budget.ID); err != nil || len(items) != 0 {
+		t.Fatalf("Expected no item to be added to a budget in the trash, got %d: %v", len(items), err)
+	}
+}
+
+// TestAmendBudgetConcurrent checks concurrent amends of different fields of a
+// budget are all kept, none overwriting the others with the state it read.
+func TestAmendBudgetConcurrent(t *testing.T) {
+	ts := newTestServer(t)
+	defer ts.Close()
+
+	token := ts.register(t, "owner@pocket.io")
+	budget := ts.addBudget(t, token, "1200 USD")
+
+	const rounds = 20
+
+	var wg sync.WaitGroup
+
+	amend := func(field string, value func(int) string) {
+		defer wg.Done()
+
+		for round := 0; round < rounds; round++ {
+			ab := map[string]interface{}{"UUID": budget.ID, field: value(round)}
+			if status := ts.post(t, "/budgets/amend", token, ab, nil); status != http.StatusOK {
+				t.Errorf("Expected budget %s to be amended, got status %d", field, status)
+				return
+			}
+		}
+	}
+
+	wg.Add(2)
+	go amend("Title", func(round int) string { return fmt.Sprintf("Rent %d", round) })
+	go amend("Price", func(round int) string { return fmt.Sprintf("%d USD", 1300+round) })
+	wg.Wait()
+
+	amended, err := ts.pockets.store.Budget(budget.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	last := fmt.Sprintf("Rent %d", rounds-1)
+	if amended.Title != last || amended.Price.Decimal() != fmt.Sprintf("%d.00", 1300+rounds-1) {
+		t.Fatalf("Expected the last title and price to be kept, got %q at %s", amended.Title, amended.Price.Decimal())
+	}
+}
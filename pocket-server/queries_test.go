@@ -0,0 +1,287 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/influx6/coquery/data"
+	"github.com/influx6/faux/web/app"
+	"github.com/influx6/pocket/api/accounts"
+	"github.com/influx6/pocket/api/budgets"
+	"github.com/influx6/pocket/api/queries"
+	"github.com/influx6/pocket/api/rates"
+	"github.com/influx6/pocket/api/store"
+)
+
+//==============================================================================
+
+// testServer serves the pocket routes and queries over an in-memory store.
+type testServer struct {
+	*httptest.Server
+	pockets *pocketData
+}
+
+// newTestServer returns a testServer wired up as main does, without the
+// assets, index page and scheduler.
+func newTestServer(t *testing.T) *testServer {
+	events.level = SilentLevel
+
+	db := store.NewMemory()
+	conf := defaultConfig()
+
+	pocketapp := app.New(events, false, nil, rejectLarge, authenticate(db))
+
+	changes := queries.NewChangeLog()
+	pockets := newPocketData(db, changes, rates.NewTable("EUR"), conf.Currency, nil, conf.Webhooks)
+
+	resolver := queries.New(events, "id", changes)
+	pockets.Register(resolver)
+	pockets.Routes(pocketapp)
+
+	users := newAccountData(db, conf.SessionLifetime, nil)
+	users.Register(resolver)
+	users.Routes(pocketapp)
+
+	app.PageRoute(pocketapp, "POST", "/", resolver.Serve)
+
+	ts := testServer{
+		Server:  httptest.NewServer(pocketapp),
+		pockets: pockets,
+	}
+
+	return &ts
+}
+
+// Close stops the server and the pocketData behind it.
+func (ts *testServer) Close() {
+	ts.Server.Close()
+	ts.pockets.Close()
+}
+
+// post sends the body as JSON to the path with the session token, if any,
+// decoding the response into out, and returns the response status.
+func (ts *testServer) post(t *testing.T, path string, token string, body interface{}, out interface{}) int {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := http.NewRequest("POST", ts.URL+path, bytes.NewReader(payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer res.Body.Close()
+
+	if out != nil && res.StatusCode < 300 {
+		if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+			t.Fatalf("Failed to decode response of %s: %s", path, err)
+		}
+	}
+
+	return res.StatusCode
+}
+
+// register creates an account with the giving email, returning its token.
+func (ts *testServer) register(t *testing.T, email string) string {
+	var session accounts.Session
+
+	ru := accounts.RegisterUser{Email: email, Password: "pocket-password"}
+	if status := ts.post(t, "/accounts/register", "", ru, &session); status != http.StatusCreated {
+		t.Fatalf("Expected %s to be registered, got status %d", email, status)
+	}
+
+	return session.Token
+}
+
+// query sends the queries as a batch to the resolver, returning the pack.
+func (ts *testServer) query(t *testing.T, token string, queries ...string) data.ResponsePack {
+	var pack data.ResponsePack
+
+	rc := data.RequestContext{RequestID: "test", Queries: queries}
+	if status := ts.post(t, "/", token, rc, &pack); status != http.StatusOK {
+		t.Fatalf("Expected queries %v to be served, got status %d", queries, status)
+	}
+
+	if len(pack.Results) != len(queries) {
+		t.Fatalf("Expected %d results, got %d", len(queries), len(pack.Results))
+	}
+
+	return pack
+}
+
+// records returns the records of the result, failing the test if the query
+// failed.
+func records(t *testing.T, result data.Parameter) []interface{} {
+	if failed, _ := result["QueryFailed"].(bool); failed {
+		t.Fatalf("Expected query to succeed, got %v", result["Error"])
+	}
+
+	list, _ := result["data"].([]interface{})
+	return list
+}
+
+//==============================================================================
+
+// TestQueryRequiresSession checks queries are rejected without a session.
+func TestQueryRequiresSession(t *testing.T) {
+	ts := newTestServer(t)
+	defer ts.Close()
+
+	rc := data.RequestContext{Queries: []string{"pockets"}}
+	if status := ts.post(t, "/", "", rc, nil); status != http.StatusUnauthorized {
+		t.Fatalf("Expected status %d without a session, got %d", http.StatusUnauthorized, status)
+	}
+
+	if status := ts.post(t, "/", "unknown-token", rc, nil); status != http.StatusUnauthorized {
+		t.Fatalf("Expected status %d with an unknown token, got %d", http.StatusUnauthorized, status)
+	}
+}
+
+// TestQueryBatch checks a batch of queries resolves into results in the order
+// of its queries, with failures reported in their place.
+func TestQueryBatch(t *testing.T) {
+	ts := newTestServer(t)
+	defer ts.Close()
+
+	token := ts.register(t, "owner@pocket.io")
+
+	var pocket budgets.PocketRecord
+	if status := ts.post(t, "/pockets", token, budgets.NewPocket{Title: "Home", Currency: "USD"}, &pocket); status != http.StatusCreated {
+		t.Fatalf("Expected pocket to be created, got status %d", status)
+	}
+
+	var budget budgets.BudgetRecord
+	nb := map[string]interface{}{"UUID": pocket.ID, "Title": "Rent", "Price": "1200 USD"}
+	if status := ts.post(t, "/budgets", token, nb, &budget); status != http.StatusCreated {
+		t.Fatalf("Expected budget to be created, got status %d", status)
+	}
+
+	ni := map[string]interface{}{"UUID": budget.ID, "Title": "March", "Price": "350.50 USD"}
+	if status := ts.post(t, "/items", token, ni, nil); status != http.StatusCreated {
+		t.Fatalf("Expected item to be created, got status %d", status)
+	}
+
+	pack := ts.query(t, token,
+		"pockets",
+		"budgets?pocket="+pocket.ID,
+		"items?budget="+budget.ID,
+		"unknown",
+		"budgets",
+	)
+
+	if list := records(t, pack.Results[0]); len(list) != 1 {
+		t.Fatalf("Expected 1 pocket, got %d", len(list))
+	}
+
+	if list := records(t, pack.Results[1]); len(list) != 1 {
+		t.Fatalf("Expected 1 budget, got %d", len(list))
+	}
+
+	list := records(t, pack.Results[2])
+	if len(list) != 1 {
+		t.Fatalf("Expected 1 item, got %d", len(list))
+	}
+
+	if item, _ := list[0].(map[string]interface{}); item["price"] != "350.50 USD" {
+		t.Fatalf("Expected item price of 350.50 USD, got %v", item["price"])
+	}
+
+	for _, index := range []int{3, 4} {
+		if failed, _ := pack.Results[index]["QueryFailed"].(bool); !failed {
+			t.Fatalf("Expected query %d to fail, got %v", index, pack.Results[index])
+		}
+	}
+
+	if pack.RequestID != "test" || pack.DeltaID == "" {
+		t.Fatalf("Expected the request id and a delta id, got %q and %q", pack.RequestID, pack.DeltaID)
+	}
+}
+
+// TestQueryOwnership checks the records of a pocket are not served to other
+// users.
+func TestQueryOwnership(t *testing.T) {
+	ts := newTestServer(t)
+	defer ts.Close()
+
+	owner := ts.register(t, "owner@pocket.io")
+	other := ts.register(t, "other@pocket.io")
+
+	var pocket budgets.PocketRecord
+	if status := ts.post(t, "/pockets", owner, budgets.NewPocket{Title: "Home", Currency: "USD"}, &pocket); status != http.StatusCreated {
+		t.Fatalf("Expected pocket to be created, got status %d", status)
+	}
+
+	pack := ts.query(t, other, "pockets", "budgets?pocket="+pocket.ID)
+
+	if list := records(t, pack.Results[0]); len(list) != 0 {
+		t.Fatalf("Expected no pockets for another user, got %d", len(list))
+	}
+
+	if failed, _ := pack.Results[1]["QueryFailed"].(bool); !failed {
+		t.Fatalf("Expected the budgets of another user's pocket to be refused, got %v", pack.Results[1])
+	}
+}
+
+// TestQueryDeltas checks records changed since the delta id of a response are
+// reported on the next request which diffs against it.
+func TestQueryDeltas(t *testing.T) {
+	ts := newTestServer(t)
+	defer ts.Close()
+
+	token := ts.register(t, "owner@pocket.io")
+
+	first := ts.query(t, token, "pockets")
+
+	var pocket budgets.PocketRecord
+	if status := ts.post(t, "/pockets", token, budgets.NewPocket{Title: "Home", Currency: "USD"}, &pocket); status != http.StatusCreated {
+		t.Fatalf("Expected pocket to be created, got status %d", status)
+	}
+
+	var pack data.ResponsePack
+
+	rc := data.RequestContext{Queries: []string{"pockets"}, Diffs: true, DiffTag: first.DeltaID}
+	if status := ts.post(t, "/", token, rc, &pack); status != http.StatusOK {
+		t.Fatalf("Expected queries to be served, got status %d", status)
+	}
+
+	var found bool
+	for _, key := range pack.Deltas {
+		if key == pocket.ID {
+			found = true
+		}
+	}
+
+	if !found {
+		t.Fatalf("Expected pocket %s within the deltas, got %v", pocket.ID, pack.Deltas)
+	}
+
+	if pack.DeltaID == first.DeltaID {
+		t.Fatalf("Expected a new delta id after a change")
+	}
+}
+
+// TestQueryMalformed checks a request body which is not a RequestContext is
+// answered with a bad request.
+func TestQueryMalformed(t *testing.T) {
+	ts := newTestServer(t)
+	defer ts.Close()
+
+	token := ts.register(t, "owner@pocket.io")
+
+	if status := ts.post(t, "/", token, []string{"pockets"}, nil); status != http.StatusBadRequest {
+		t.Fatalf("Expected status %d for a malformed request, got %d", http.StatusBadRequest, status)
+	}
+}
@@ -0,0 +1,385 @@
+package main
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/influx6/faux/context"
+	"github.com/influx6/pocket/api/budgets"
+	"github.com/influx6/pocket/api/queries"
+	"github.com/influx6/pocket/api/store"
+	"github.com/satori/go.uuid"
+)
+
+//==============================================================================
+
+// ErrUnknownRecurring is returned when a recurring item which does not exists
+// is referenced.
+var ErrUnknownRecurring = errors.New("Unknown Recurring Item")
+
+// ErrInvalidDays is returned when the `upcoming` query is given days which are
+// not a positive count.
+var ErrInvalidDays = errors.New("Invalid Days")
+
+// upcomingDays defines the count of days the `upcoming` query looks ahead by
+// default.
+const upcomingDays = 30
+
+// scheduleInterval defines how often the scheduler writes the items of the
+// recurring items which fell due and evaluates the alert rules of budgets.
+const scheduleInterval = time.Minute
+
+//==============================================================================
+
+// queryRecurring resolves the `recurring?budget=<id>` query, returning the
+// recurring items written against the giving budget, ordered by title.
+func (p *pocketData) queryRecurring(ctx context.Context, q queries.Query) (interface{}, error) {
+	id, err := q.Require("budget")
+	if err != nil {
+		return nil, err
+	}
+
+	budget, err := p.ownedBudget(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.store.Recurrings(budget.ID)
+}
+
+// queryUpcoming resolves the `upcoming?pocket=<id>&days=<n>` query, returning
+// the occurrences of the recurring items of the budgets within the giving
+// pocket due within the next days, 30 by default, ordered by the day they are
+// due on. Occurrences already due are included until the scheduler writes
+// them.
+func (p *pocketData) queryUpcoming(ctx context.Context, q queries.Query) (interface{}, error) {
+	id, err := q.Require("pocket")
+	if err != nil {
+		return nil, err
+	}
+
+	pocket, err := p.ownedPocket(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	days := upcomingDays
+	if raw := q.Get("days"); raw != "" {
+		if days, err = strconv.Atoi(raw); err != nil || days < 1 {
+			return nil, ErrInvalidDays
+		}
+	}
+
+	records, err := p.store.Budgets(pocket.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	through := time.Now().AddDate(0, 0, days)
+	upcoming := make([]budgets.Occurrence, 0)
+
+	for _, budget := range records {
+		if budget.Deleted != nil {
+			continue
+		}
+
+		recurring, err := p.store.Recurrings(budget.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, record := range recurring {
+			upcoming = append(upcoming, record.Upcoming(through)...)
+		}
+	}
+
+	sort.SliceStable(upcoming, func(i, j int) bool {
+		return upcoming[i].Due.Before(upcoming[j].Due)
+	})
+
+	return upcoming, nil
+}
+
+// ownedRecurring returns the recurring item with the giving id if the pocket
+// of its budget belongs to the caller, else returns ErrUnknownRecurring.
+func (p *pocketData) ownedRecurring(ctx context.Context, id string) (budgets.RecurringRecord, error) {
+	recurring, err := p.store.Recurring(id)
+	if err != nil {
+		if err == store.ErrNotFound {
+			return recurring, ErrUnknownRecurring
+		}
+
+		return recurring, err
+	}
+
+	if _, err := p.ownedBudget(ctx, recurring.Budget); err != nil {
+		if err == ErrUnknownBudget {
+			return budgets.RecurringRecord{}, ErrUnknownRecurring
+		}
+
+		return budgets.RecurringRecord{}, err
+	}
+
+	return recurring, nil
+}
+
+//==============================================================================
+
+// AddRecurring adds a new recurring item written against the caller's budget
+// referenced by the command, its price held in the currency of the pocket of
+// the budget. Occurrences already due are written by the next run of the
+// scheduler.
+func (p *pocketData) AddRecurring(ctx context.Context, nr budgets.NewRecurring) (budgets.RecurringRecord, error) {
+	budget, err := p.ownedBudget(ctx, nr.UUID)
+	if err != nil {
+		return budgets.RecurringRecord{}, err
+	}
+
+	if budget.Deleted != nil {
+		return budgets.RecurringRecord{}, ErrDeletedRecord
+	}
+
+	pocket, err := p.store.Pocket(budget.Pocket)
+	if err != nil {
+		return budgets.RecurringRecord{}, err
+	}
+
+	if nr.Category != "" {
+		if _, err := p.ownedCategory(ctx, nr.Category); err != nil {
+			return budgets.RecurringRecord{}, err
+		}
+	}
+
+	price, err := nr.Price.In(pocketCurrency(pocket))
+	if err != nil {
+		return budgets.RecurringRecord{}, err
+	}
+
+	if nr.Schedule.Start.IsZero() {
+		nr.Schedule.Start = time.Now()
+	}
+
+	if err := nr.Schedule.Validate(); err != nil {
+		return budgets.RecurringRecord{}, err
+	}
+
+	recurring := budgets.RecurringRecord{
+		ID:       uuid.NewV4().String(),
+		Budget:   budget.ID,
+		Title:    nr.Title,
+		Desc:     nr.Desc,
+		Price:    price,
+		Category: nr.Category,
+		Tags:     nr.Tags,
+		Schedule: nr.Schedule,
+	}
+
+	recurring.Begin()
+
+	if err := p.store.SaveRecurring(recurring); err != nil {
+		return recurring, err
+	}
+
+	return recurring, p.touchPocket(pocket.ID, recurring.ID)
+}
+
+// RemoveRecurring removes the caller's recurring item referenced by the
+// command, leaving the items it wrote in place.
+func (p *pocketData) RemoveRecurring(ctx context.Context, dr budgets.DeleteRecurring) (budgets.RecurringRecord, error) {
+	p.schedule.Lock()
+	defer p.schedule.Unlock()
+
+	recurring, err := p.ownedRecurring(ctx, dr.UUID)
+	if err != nil {
+		return recurring, err
+	}
+
+	budget, err := p.store.Budget(recurring.Budget)
+	if err != nil {
+		return recurring, err
+	}
+
+	if err := p.store.DeleteRecurring(recurring.ID); err != nil {
+		return recurring, err
+	}
+
+	return recurring, p.touchPocket(budget.Pocket, recurring.ID)
+}
+
+// SkipOccurrence stops the occurrence of the caller's recurring item
+// referenced by the command from being written.
+func (p *pocketData) SkipOccurrence(ctx context.Context, so budgets.SkipOccurrence) (budgets.RecurringRecord, error) {
+	return p.updateRecurring(ctx, so.UUID, func(recurring *budgets.RecurringRecord) error {
+		return recurring.Skip(so.Date)
+	})
+}
+
+// PostponeOccurrence moves the occurrence of the caller's recurring item
+// referenced by the command to a later day.
+func (p *pocketData) PostponeOccurrence(ctx context.Context, po budgets.PostponeOccurrence) (budgets.RecurringRecord, error) {
+	return p.updateRecurring(ctx, po.UUID, func(recurring *budgets.RecurringRecord) error {
+		return recurring.Postpone(po.Date, po.To)
+	})
+}
+
+// updateRecurring applies the change to the caller's recurring item with the
+// giving id and stores it. Changes are serialised with the scheduler so none
+// are lost to an item being written.
+func (p *pocketData) updateRecurring(ctx context.Context, id string, change func(*budgets.RecurringRecord) error) (budgets.RecurringRecord, error) {
+	p.schedule.Lock()
+	defer p.schedule.Unlock()
+
+	recurring, err := p.ownedRecurring(ctx, id)
+	if err != nil {
+		return recurring, err
+	}
+
+	budget, err := p.store.Budget(recurring.Budget)
+	if err != nil {
+		return recurring, err
+	}
+
+	if err := change(&recurring); err != nil {
+		return recurring, err
+	}
+
+	if err := p.store.SaveRecurring(recurring); err != nil {
+		return recurring, err
+	}
+
+	return recurring, p.touchPocket(budget.Pocket, recurring.ID)
+}
+
+//==============================================================================
+
+// StartScheduler writes the items of recurring items as they fall due and
+// evaluates the alert rules of every budget, once now and then on every tick
+// of the giving interval, until the pocketData is closed.
+func (p *pocketData) StartScheduler(every time.Duration) {
+	p.tick(time.Now())
+
+	p.scheduled.Add(1)
+
+	go func() {
+		defer p.scheduled.Done()
+
+		ticker := time.NewTicker(every)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-p.stop:
+				return
+			case now := <-ticker.C:
+				p.tick(now)
+			}
+		}
+	}()
+}
+
+// tick runs the work of the scheduler at the giving time, writing the items
+// fallen due before evaluating the rules which may watch them.
+func (p *pocketData) tick(now time.Time) {
+	p.materialise(now)
+	p.watchRules(now)
+}
+
+// materialise writes an item for every occurrence of the recurring items due
+// through the giving time, as if its owner made it on the day it was due.
+// Items are written through the event log like any other, so alert rules,
+// webhooks and live updates see them. The scheduler runs unattended, so
+// failures are only logged and retried on its next run.
+func (p *pocketData) materialise(now time.Time) {
+	p.schedule.Lock()
+	defer p.schedule.Unlock()
+
+	due, err := p.store.DueRecurrings(now)
+	if err != nil {
+		events.Error(contexts, "pocketData.materialise", err, "Failed to load the recurring items due")
+		return
+	}
+
+	for _, recurring := range due {
+		if err := p.writeOccurrences(recurring, now); err != nil {
+			events.Error(contexts, "pocketData.materialise", err, "Failed to write the items of recurring item[%s]", recurring.ID)
+		}
+	}
+}
+
+// writeOccurrences writes the occurrences of the recurring item due through the
+// giving time into its budget, then marks them as written. Budgets in the trash
+// receive no items, though their occurrences are still passed.
+func (p *pocketData) writeOccurrences(recurring budgets.RecurringRecord, now time.Time) error {
+	budget, err := p.store.Budget(recurring.Budget)
+	if err != nil {
+		return err
+	}
+
+	pocket, err := p.store.Pocket(budget.Pocket)
+	if err != nil {
+		return err
+	}
+
+	defer p.locks.lock(pocket.ID)()
+
+	// The budget may have been changed before the lock was taken.
+	if budget, err = p.store.Budget(budget.ID); err != nil {
+		return err
+	}
+
+	owner, err := p.store.UserByID(pocket.Owner)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.New().WithValue(userKey, owner)
+
+	if budget.Deleted == nil {
+		for _, occurrence := range recurring.Upcoming(now) {
+			id := occurrenceID(occurrence)
+
+			// Occurrences written before a failure further on keep their
+			// item, so they are not written twice once retried.
+			if _, err := p.store.Item(id); err == nil {
+				continue
+			} else if err != store.ErrNotFound {
+				return err
+			}
+
+			ni := budgets.NewBudgetItem{
+				By:       owner.ID,
+				UUID:     budget.ID,
+				Title:    recurring.Title,
+				Desc:     recurring.Desc,
+				Price:    recurring.Price,
+				Category: recurring.Category,
+				Tags:     recurring.Tags,
+				Time:     occurrence.Due,
+			}
+
+			ledger := budgets.NewLedger(pocketCurrency(pocket))
+
+			if err := p.commit(ctx, ledger, pocket, budget.ID, id, ni); err != nil {
+				return err
+			}
+		}
+	}
+
+	recurring.Advance(now)
+
+	if err := p.store.SaveRecurring(recurring); err != nil {
+		return err
+	}
+
+	return p.touchPocket(pocket.ID, recurring.ID)
+}
+
+// occurrenceID returns the id of the item written for the occurrence, the same
+// for every attempt at writing it.
+func occurrenceID(occurrence budgets.Occurrence) string {
+	return uuid.NewV5(uuid.FromStringOrNil(occurrence.Recurring), occurrence.Date.Format("2006-01-02")).String()
+}
+
+//==============================================================================
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/influx6/pocket/api/budgets"
+)
+
+//==============================================================================
+
+// TestMaterialiseMissed checks every occurrence a recurring item missed while
+// the scheduler was not running is written once, however often it runs.
+func TestMaterialiseMissed(t *testing.T) {
+	ts := newTestServer(t)
+	defer ts.Close()
+
+	token := ts.register(t, "owner@pocket.io")
+	budget := ts.addBudget(t, token, "1200 USD")
+
+	now := time.Now().UTC()
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -3, 0)
+
+	nr := map[string]interface{}{
+		"UUID":     budget.ID,
+		"Title":    "Rent",
+		"Price":    "1200 USD",
+		"Schedule": budgets.Schedule{Kind: budgets.ScheduleMonthly, Day: 1, Start: start},
+	}
+
+	var recurring budgets.RecurringRecord
+	if status := ts.post(t, "/recurring", token, nr, &recurring); status != http.StatusCreated {
+		t.Fatalf("Expected recurring item to be created, got status %d", status)
+	}
+
+	due, err := ts.pockets.store.DueRecurrings(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(due) != 1 || due[0].ID != recurring.ID {
+		t.Fatalf("Expected the recurring item to be due once, got %+v", due)
+	}
+
+	// The first of this month and of the three months before it.
+	if upcoming := due[0].Upcoming(now); len(upcoming) != 4 {
+		t.Fatalf("Expected 4 missed occurrences, got %d", len(upcoming))
+	}
+
+	ts.pockets.materialise(now)
+	ts.pockets.materialise(now)
+
+	items, err := ts.pockets.store.Items(budget.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(items) != 4 {
+		t.Fatalf("Expected an item for each missed occurrence, got %d", len(items))
+	}
+
+	for index, item := range items {
+		for _, other := range items[index+1:] {
+			if item.Time.Equal(other.Time) {
+				t.Fatalf("Expected a single item due on %v", item.Time)
+			}
+		}
+	}
+
+	if due, err := ts.pockets.store.DueRecurrings(now); err != nil || len(due) != 0 {
+		t.Fatalf("Expected no recurring item due once written, got %+v: %v", due, err)
+	}
+}
@@ -0,0 +1,357 @@
+package main
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/influx6/faux/context"
+	"github.com/influx6/pocket/api/budgets"
+	"github.com/influx6/pocket/api/currency"
+	"github.com/influx6/pocket/api/queries"
+	"github.com/influx6/pocket/api/store"
+	"github.com/satori/go.uuid"
+)
+
+//==============================================================================
+
+// ErrUnknownRule is returned when an alert rule which does not exists is
+// referenced.
+var ErrUnknownRule = errors.New("Unknown Alert Rule")
+
+//==============================================================================
+
+// alertBoard tells the notifications raised by the rules of every budget apart
+// from those already raised, keeping the notifications last raised within the
+// store so none are raised again once the server restarts.
+type alertBoard struct {
+	rl    sync.Mutex
+	store store.Rules
+}
+
+// newAlertBoard returns a new alertBoard instance keeping its state within the
+// giving store.
+func newAlertBoard(st store.Rules) *alertBoard {
+	board := alertBoard{
+		store: st,
+	}
+
+	return &board
+}
+
+// update replaces the notifications raised by the rules of the giving budget,
+// returning those which were not raised before.
+func (a *alertBoard) update(budget string, notices []budgets.Notify) ([]budgets.Notify, error) {
+	a.rl.Lock()
+	defer a.rl.Unlock()
+
+	held, err := a.store.Alerts(budget)
+	if err != nil && err != store.ErrNotFound {
+		return nil, err
+	}
+
+	var raised []budgets.Notify
+
+	for _, notice := range notices {
+		if !holdsNotice(held.Notices, notice) {
+			raised = append(raised, notice)
+		}
+	}
+
+	// Nothing changed, so there is nothing to store.
+	if len(raised) == 0 && len(notices) == len(held.Notices) {
+		return nil, nil
+	}
+
+	if err := a.store.SaveAlerts(budgets.AlertRecord{Budget: budget, Notices: notices}); err != nil {
+		return nil, err
+	}
+
+	return raised, nil
+}
+
+// holdsNotice returns true/false if the list holds a notification raised by the
+// same rule for the same item as the giving notification.
+func holdsNotice(list []budgets.Notify, notice budgets.Notify) bool {
+	for _, held := range list {
+		if held.Type == notice.Type && held.Rule == notice.Rule && held.Item == notice.Item {
+			return true
+		}
+	}
+
+	return false
+}
+
+//==============================================================================
+
+// queryRules resolves the `rules?budget=<id>` query, returning the alert rules
+// watching the giving budget.
+func (p *pocketData) queryRules(ctx context.Context, q queries.Query) (interface{}, error) {
+	id, err := q.Require("budget")
+	if err != nil {
+		return nil, err
+	}
+
+	budget, err := p.ownedBudget(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.store.Rules(budget.ID)
+}
+
+// queryAlerts resolves the `alerts?pocket=<id>` query, returning the
+// notifications raised by the rules of the budgets within the pocket as they
+// stand now, or of a single budget given by `budget=<id>`. Budgets in the
+// trash raise no notifications. Rules are only evaluated, notifications being
+// raised as budgets change and on every run of the scheduler.
+func (p *pocketData) queryAlerts(ctx context.Context, q queries.Query) (interface{}, error) {
+	id, err := q.Require("pocket")
+	if err != nil {
+		return nil, err
+	}
+
+	pocket, err := p.ownedPocket(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := p.store.Budgets(pocket.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	only := q.Get("budget")
+	now := time.Now()
+
+	notices := make([]budgets.Notify, 0)
+
+	for _, budget := range records {
+		if only != "" && budget.ID != only {
+			continue
+		}
+
+		raised, err := p.evaluateRules(budget, now)
+		if err != nil {
+			return nil, err
+		}
+
+		notices = append(notices, raised...)
+	}
+
+	return notices, nil
+}
+
+// ownedRule returns the alert rule with the giving id if the pocket of its
+// budget belongs to the caller, else returns ErrUnknownRule.
+func (p *pocketData) ownedRule(ctx context.Context, id string) (budgets.RuleRecord, error) {
+	rule, err := p.store.Rule(id)
+	if err != nil {
+		if err == store.ErrNotFound {
+			return rule, ErrUnknownRule
+		}
+
+		return rule, err
+	}
+
+	if _, err := p.ownedBudget(ctx, rule.Budget); err != nil {
+		if err == ErrUnknownBudget {
+			return budgets.RuleRecord{}, ErrUnknownRule
+		}
+
+		return budgets.RuleRecord{}, err
+	}
+
+	return rule, nil
+}
+
+//==============================================================================
+
+// AddRule adds a new alert rule watching the caller's budget referenced by
+// the command, its amount held in the currency of the pocket of the budget.
+func (p *pocketData) AddRule(ctx context.Context, nr budgets.NewRule) (budgets.RuleRecord, error) {
+	budget, err := p.ownedBudget(ctx, nr.UUID)
+	if err != nil {
+		return budgets.RuleRecord{}, err
+	}
+
+	pocket, err := p.store.Pocket(budget.Pocket)
+	if err != nil {
+		return budgets.RuleRecord{}, err
+	}
+
+	// Only large-item rules are given an amount, the rest leave it unset.
+	amount := currency.NewMoney(0, pocketCurrency(pocket))
+
+	if nr.Amount != (currency.Money{}) {
+		if amount, err = nr.Amount.In(amount.Currency); err != nil {
+			return budgets.RuleRecord{}, err
+		}
+	}
+
+	rule := budgets.RuleRecord{
+		ID:       uuid.NewV4().String(),
+		Budget:   budget.ID,
+		Kind:     nr.Kind,
+		Percent:  nr.Percent,
+		Amount:   amount,
+		Days:     nr.Days,
+		Severity: nr.Severity,
+	}
+
+	if rule.Severity == "" {
+		rule.Severity = budgets.SeverityWarning
+	}
+
+	if err := rule.Validate(); err != nil {
+		return rule, err
+	}
+
+	if err := p.store.SaveRule(rule); err != nil {
+		return rule, err
+	}
+
+	p.reviewRules(budget.ID)
+	return rule, p.touchPocket(pocket.ID, rule.ID)
+}
+
+// RemoveRule removes the caller's alert rule referenced by the command.
+func (p *pocketData) RemoveRule(ctx context.Context, dr budgets.DeleteRule) (budgets.RuleRecord, error) {
+	rule, err := p.ownedRule(ctx, dr.UUID)
+	if err != nil {
+		return rule, err
+	}
+
+	budget, err := p.store.Budget(rule.Budget)
+	if err != nil {
+		return rule, err
+	}
+
+	if err := p.store.DeleteRule(rule.ID); err != nil {
+		return rule, err
+	}
+
+	p.reviewRules(budget.ID)
+	return rule, p.touchPocket(budget.Pocket, rule.ID)
+}
+
+//==============================================================================
+
+// reviewRules evaluates the rules of the budget with the giving id after a
+// change to it or its items. The change is already stored, so failures are
+// only logged.
+func (p *pocketData) reviewRules(id string) {
+	budget, err := p.store.Budget(id)
+	if err == nil {
+		_, err = p.checkRules(budget, time.Now())
+	}
+
+	if err != nil {
+		events.Error(contexts, "pocketData.reviewRules", err, "Failed to evaluate the alert rules of budget[%s]", id)
+	}
+}
+
+// watchRules evaluates the rules of every budget watched by some at the giving
+// time, raising the notifications of rules which depend on time passing, such
+// as no-activity rules, without waiting for a change to the budget. The
+// scheduler runs unattended, so failures are only logged.
+func (p *pocketData) watchRules(now time.Time) {
+	ids, err := p.store.WatchedBudgets()
+	if err != nil {
+		events.Error(contexts, "pocketData.watchRules", err, "Failed to load the budgets watched by alert rules")
+		return
+	}
+
+	for _, id := range ids {
+		budget, err := p.store.Budget(id)
+		if err == nil {
+			_, err = p.checkRules(budget, now)
+		}
+
+		if err != nil {
+			events.Error(contexts, "pocketData.watchRules", err, "Failed to evaluate the alert rules of budget[%s]", id)
+		}
+	}
+}
+
+// checkRules returns the notifications raised by the rules of the budget at
+// the giving time, raising those which were not raised before.
+func (p *pocketData) checkRules(budget budgets.BudgetRecord, now time.Time) ([]budgets.Notify, error) {
+	notices, err := p.evaluateRules(budget, now)
+	if err != nil {
+		return nil, err
+	}
+
+	raised, err := p.alerts.update(budget.ID, notices)
+	if err != nil || len(raised) == 0 {
+		return notices, err
+	}
+
+	pocket, err := p.store.Pocket(budget.Pocket)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, notice := range raised {
+		if err := p.notify(pocket.Owner, notice); err != nil {
+			return nil, err
+		}
+
+		alert := notice
+		p.emit(budgets.HookPayload{Event: budgets.HookAlert, Pocket: pocket.ID, Time: now, Alert: &alert})
+	}
+
+	return notices, nil
+}
+
+// evaluateRules returns the notifications raised by the rules of the budget
+// at the giving time. Periodic budgets are evaluated against their current
+// period, holding what was carried into it, while other budgets are evaluated
+// against all their items. Items in the trash are not counted.
+func (p *pocketData) evaluateRules(budget budgets.BudgetRecord, now time.Time) ([]budgets.Notify, error) {
+	if budget.Deleted != nil {
+		return nil, nil
+	}
+
+	rules, err := p.store.Rules(budget.ID)
+	if err != nil || len(rules) == 0 {
+		return nil, err
+	}
+
+	ev := budgets.Evaluation{Budget: budget, Budgeted: budget.Price, Now: now}
+
+	if !budget.Period.IsZero() {
+		totals, err := p.budgetPeriods(budget, now)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(totals) == 0 {
+			return nil, nil
+		}
+
+		current := totals[len(totals)-1]
+		if ev.Budgeted, err = current.Budgeted.Add(current.Carried); err != nil {
+			return nil, err
+		}
+
+		ev.Start, ev.End = current.Start, current.End
+	}
+
+	items, err := p.store.Items(budget.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range items {
+		if item.Deleted != nil {
+			continue
+		}
+
+		ev.Items = append(ev.Items, item)
+	}
+
+	return budgets.Evaluate(rules, ev)
+}
+
+//==============================================================================
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/influx6/pocket/api/budgets"
+	"github.com/influx6/pocket/api/queries"
+	"github.com/influx6/pocket/api/rates"
+)
+
+//==============================================================================
+
+// TestAlertsRaisedOnce checks notifications are raised once by their rules,
+// neither querying alerts nor a restart raising them again.
+func TestAlertsRaisedOnce(t *testing.T) {
+	ts := newTestServer(t)
+	defer ts.Close()
+
+	token := ts.register(t, "owner@pocket.io")
+
+	var pocket budgets.PocketRecord
+	if status := ts.post(t, "/pockets", token, budgets.NewPocket{Title: "Home", Currency: "USD"}, &pocket); status != http.StatusCreated {
+		t.Fatalf("Expected pocket to be created, got status %d", status)
+	}
+
+	var budget budgets.BudgetRecord
+	nb := map[string]interface{}{"UUID": pocket.ID, "Title": "Rent", "Price": "100 USD"}
+	if status := ts.post(t, "/budgets", token, nb, &budget); status != http.StatusCreated {
+		t.Fatalf("Expected budget to be created, got status %d", status)
+	}
+
+	nr := map[string]interface{}{"UUID": budget.ID, "Kind": budgets.RuleSpentPercent, "Percent": 50}
+	if status := ts.post(t, "/rules", token, nr, nil); status != http.StatusCreated {
+		t.Fatalf("Expected rule to be created, got status %d", status)
+	}
+
+	ni := map[string]interface{}{"UUID": budget.ID, "Title": "March", "Price": "80 USD"}
+	if status := ts.post(t, "/items", token, ni, nil); status != http.StatusCreated {
+		t.Fatalf("Expected item to be created, got status %d", status)
+	}
+
+	notices := func() int {
+		records, err := ts.pockets.store.Notifications(pocket.Owner)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return len(records)
+	}
+
+	if count := notices(); count != 1 {
+		t.Fatalf("Expected the rule to raise 1 notification, got %d", count)
+	}
+
+	for i := 0; i < 3; i++ {
+		pack := ts.query(t, token, "alerts?pocket="+pocket.ID)
+		if list := records(t, pack.Results[0]); len(list) != 1 {
+			t.Fatalf("Expected 1 alert, got %d", len(list))
+		}
+	}
+
+	if count := notices(); count != 1 {
+		t.Fatalf("Expected querying alerts to raise no notifications, got %d", count)
+	}
+
+	// A restarted server evaluates the rules on the first run of its
+	// scheduler, finding the notification already raised.
+	restarted := newPocketData(ts.pockets.store, queries.NewChangeLog(), rates.NewTable("EUR"), "USD", nil, defaultConfig().Webhooks)
+	defer restarted.Close()
+
+	restarted.tick(time.Now())
+
+	if count := notices(); count != 1 {
+		t.Fatalf("Expected a restart to raise no notifications again, got %d", count)
+	}
+}
+
+// TestAlertsOnTick checks rules which depend on time passing are raised by
+// the scheduler without a change to their budget.
+func TestAlertsOnTick(t *testing.T) {
+	ts := newTestServer(t)
+	defer ts.Close()
+
+	token := ts.register(t, "owner@pocket.io")
+
+	var pocket budgets.PocketRecord
+	if status := ts.post(t, "/pockets", token, budgets.NewPocket{Title: "Home", Currency: "USD"}, &pocket); status != http.StatusCreated {
+		t.Fatalf("Expected pocket to be created, got status %d", status)
+	}
+
+	var budget budgets.BudgetRecord
+	nb := map[string]interface{}{"UUID": pocket.ID, "Title": "Food", "Price": "100 USD"}
+	if status := ts.post(t, "/budgets", token, nb, &budget); status != http.StatusCreated {
+		t.Fatalf("Expected budget to be created, got status %d", status)
+	}
+
+	ni := map[string]interface{}{"UUID": budget.ID, "Title": "Groceries", "Price": "20 USD", "Time": time.Now().AddDate(0, 0, -1)}
+	if status := ts.post(t, "/items", token, ni, nil); status != http.StatusCreated {
+		t.Fatalf("Expected item to be created, got status %d", status)
+	}
+
+	nr := map[string]interface{}{"UUID": budget.ID, "Kind": budgets.RuleNoActivity, "Days": 2}
+	if status := ts.post(t, "/rules", token, nr, nil); status != http.StatusCreated {
+		t.Fatalf("Expected rule to be created, got status %d", status)
+	}
+
+	ts.pockets.tick(time.Now())
+
+	if records, err := ts.pockets.store.Notifications(pocket.Owner); err != nil || len(records) != 0 {
+		t.Fatalf("Expected no notifications while the budget is active, got %d: %v", len(records), err)
+	}
+
+	ts.pockets.tick(time.Now().AddDate(0, 0, 2))
+
+	records, err := ts.pockets.store.Notifications(pocket.Owner)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(records) != 1 || records[0].Type != budgets.NoActivity {
+		t.Fatalf("Expected the scheduler to raise a no-activity notification, got %+v", records)
+	}
+}
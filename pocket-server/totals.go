@@ -0,0 +1,176 @@
+package main
+
+import (
+	"time"
+
+	"github.com/influx6/faux/context"
+	"github.com/influx6/pocket/api/budgets"
+	"github.com/influx6/pocket/api/currency"
+	"github.com/influx6/pocket/api/queries"
+	"github.com/influx6/pocket/api/rates"
+)
+
+//==============================================================================
+
+// queryTotals resolves the `totals?currency=<code>` query, returning the net
+// total of all pockets of the caller converted into the giving currency, else
+// into the caller's home currency.
+func (p *pocketData) queryTotals(ctx context.Context, q queries.Query) (interface{}, error) {
+	user, ok := contextUser(ctx)
+	if !ok {
+		return nil, ErrInvalidSession
+	}
+
+	code := q.Get("currency")
+	if code == "" {
+		code = user.Currency
+	}
+
+	if code == "" {
+		code = p.home
+	}
+
+	home, err := currency.ISO4217.Find(code)
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := p.NetTotal(user.ID, home, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return []budgets.NetTotal{total}, nil
+}
+
+// NetTotal returns the totals of all the pockets of the owner converted into
+// the home currency as of the giving date. Each item is converted at the rate
+// valid on the day it was spent.
+func (p *pocketData) NetTotal(owner string, home currency.Currency, asOf time.Time) (budgets.NetTotal, error) {
+	total := budgets.NetTotal{
+		ID:       owner,
+		Currency: home.Code,
+		Date:     asOf,
+		Budgeted: currency.NewMoney(0, home),
+		Spent:    currency.NewMoney(0, home),
+	}
+
+	pockets, err := p.store.Pockets(owner)
+	if err != nil {
+		return total, err
+	}
+
+	for _, pocket := range pockets {
+		pt, err := p.pocketTotal(pocket, home, asOf)
+		if err != nil {
+			return total, err
+		}
+
+		if total.Budgeted, err = total.Budgeted.Add(pt.Budgeted); err != nil {
+			return total, err
+		}
+
+		if total.Spent, err = total.Spent.Add(pt.Spent); err != nil {
+			return total, err
+		}
+
+		total.Pockets = append(total.Pockets, pt)
+	}
+
+	total.Net, err = total.Budgeted.Sub(total.Spent)
+	return total, err
+}
+
+// pocketTotal returns the totals of the pocket converted into the home
+// currency as of the giving date. Budgets with a period are totalled within the
+// period holding the date.
+func (p *pocketData) pocketTotal(pocket budgets.PocketRecord, home currency.Currency, asOf time.Time) (budgets.PocketTotal, error) {
+	pt := budgets.PocketTotal{
+		ID:       pocket.ID,
+		Title:    pocket.Title,
+		Currency: pocket.Currency,
+		Budgeted: currency.NewMoney(0, home),
+		Spent:    currency.NewMoney(0, home),
+	}
+
+	records, err := p.store.Budgets(pocket.ID)
+	if err != nil {
+		return pt, err
+	}
+
+	for _, budget := range records {
+
+		// Archived records still count, only those in the trash are left out.
+		if budget.Deleted != nil {
+			continue
+		}
+
+		// Budgets with a period count only their current period, along with
+		// anything carried into it.
+		budgeted := budget.Price
+		var start, end time.Time
+
+		if !budget.Period.IsZero() {
+			periods, err := p.budgetPeriods(budget, asOf)
+			if err != nil {
+				return pt, err
+			}
+
+			// The budget held no period yet at the date.
+			if len(periods) == 0 {
+				continue
+			}
+
+			current := periods[len(periods)-1]
+			start, end = current.Start, current.End
+
+			if budgeted, err = current.Budgeted.Add(current.Carried); err != nil {
+				return pt, err
+			}
+		}
+
+		price, err := p.convert(budgeted, home, asOf)
+		if err != nil {
+			return pt, err
+		}
+
+		if pt.Budgeted, err = pt.Budgeted.Add(price); err != nil {
+			return pt, err
+		}
+
+		items, err := p.store.Items(budget.ID)
+		if err != nil {
+			return pt, err
+		}
+
+		for _, item := range items {
+			if item.Deleted != nil || item.Time.After(asOf) {
+				continue
+			}
+
+			if !budget.Period.IsZero() && (item.Time.Before(start) || !item.Time.Before(end)) {
+				continue
+			}
+
+			price, err := p.convert(item.Price, home, item.Time)
+			if err != nil {
+				return pt, err
+			}
+
+			if pt.Spent, err = pt.Spent.Add(price); err != nil {
+				return pt, err
+			}
+		}
+	}
+
+	pt.Net, err = pt.Budgeted.Sub(pt.Spent)
+	return pt, err
+}
+
+// convert returns the stored amount converted into the home currency at the
+// rate valid on the giving date.
+func (p *pocketData) convert(m currency.Money, home currency.Currency, on time.Time) (currency.Money, error) {
+	return rates.Convert(p.rates, m, home, on)
+}
+
+//==============================================================================
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/influx6/coquery/data"
+	"github.com/influx6/faux/context"
+	"github.com/influx6/faux/web/app"
+	"github.com/influx6/pocket/api/budgets"
+)
+
+//==============================================================================
+
+// ErrNoStreaming is returned when the connection of a request can not stream
+// its response.
+var ErrNoStreaming = errors.New("Streaming Unsupported")
+
+// keepAlive defines the interval at which idle update streams are written to,
+// so proxies do not close them.
+const keepAlive = 20 * time.Second
+
+// UpdateRoutes registers the route streaming changes to the caller's pockets.
+func (p *pocketData) UpdateRoutes(pa *app.App) {
+	app.PageRoute(pa, "GET", "/updates", p.streamUpdates)
+}
+
+// streamUpdates streams the changes made to the pockets listed by the `pocket`
+// parameters, or to all pockets of the caller when none is listed, as
+// Server-Sent Events. Each `delta` event carries a data.ResponsePack holding
+// the DeltaID and Deltas of the change. A stream resumed with the
+// Last-Event-ID header first receives the changes it missed, or every watched
+// key when the id was handed out before a restart of the server.
+func (p *pocketData) streamUpdates(ctx context.Context, rw *app.ResponseRequest, params app.Param) error {
+	user, ok := contextUser(ctx)
+	if !ok {
+		return ErrInvalidSession
+	}
+
+	flusher, ok := rw.ResponseWriter.(http.Flusher)
+	if !ok {
+		rw.RespondError(http.StatusInternalServerError, ErrNoStreaming)
+		return nil
+	}
+
+	pockets := rw.R.URL.Query()["pocket"]
+
+	if len(pockets) == 0 {
+		records, err := p.store.Pockets(user.ID)
+		if err != nil {
+			return err
+		}
+
+		for _, pocket := range records {
+			pockets = append(pockets, pocket.ID)
+		}
+	}
+
+	// New pockets are touched along with the user, so watching the user keeps
+	// the stream aware of them, as watching the notifications key does for new
+	// notifications.
+	watch := []string{user.ID, budgets.NotificationsKey(user.ID)}
+
+	for _, pocket := range pockets {
+		if _, err := p.ownedPocket(ctx, pocket); err != nil {
+			return err
+		}
+
+		watch = append(watch, pocket)
+	}
+
+	sub := p.changes.Subscribe(watch)
+	defer p.changes.Unsubscribe(sub)
+
+	rw.Header().Set("Content-Type", "text/event-stream")
+	rw.Header().Set("Cache-Control", "no-cache")
+	rw.Header().Set("X-Accel-Buffering", "no")
+	rw.WriteHeader(http.StatusOK)
+
+	if last := rw.R.Header.Get("Last-Event-ID"); last != "" {
+		keys, err := p.watchedKeys(watch, pockets)
+		if err != nil {
+			events.Error(contexts, "streamUpdates", err, "Failed to resume stream")
+			return nil
+		}
+
+		if deltas, deltaID := p.changes.Since(last, keys); len(deltas) > 0 {
+			if err := writeDelta(rw, deltaID, deltas); err != nil {
+				return nil
+			}
+		}
+	}
+
+	flusher.Flush()
+
+	ticker := time.NewTicker(keepAlive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-rw.R.Context().Done():
+			return nil
+
+		case <-ticker.C:
+			if _, err := fmt.Fprint(rw, ": keep-alive\n\n"); err != nil {
+				return nil
+			}
+
+		case <-sub.Ready():
+			deltas, deltaID := sub.Changes()
+			if err := writeDelta(rw, deltaID, deltas); err != nil {
+				return nil
+			}
+		}
+
+		flusher.Flush()
+	}
+}
+
+// watchedKeys returns the watched keys along with the keys of the budgets
+// within the giving pockets, which resumed streams report changes for.
+func (p *pocketData) watchedKeys(watch []string, pockets []string) ([]string, error) {
+	keys := append([]string(nil), watch...)
+
+	for _, pocket := range pockets {
+		records, err := p.store.Budgets(pocket)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, budget := range records {
+			keys = append(keys, budget.ID)
+		}
+	}
+
+	return keys, nil
+}
+
+// writeDelta writes a `delta` event for the giving change.
+func writeDelta(rw *app.ResponseRequest, deltaID string, deltas []string) error {
+	pack, err := json.Marshal(data.ResponsePack{
+		DeltaID: deltaID,
+		Deltas:  deltas,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(rw, "id: %s\nevent: delta\ndata: %s\n\n", deltaID, pack)
+	return err
+}
+
+//==============================================================================
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/influx6/faux/context"
+	"github.com/influx6/pocket/api/budgets"
+	"github.com/influx6/pocket/api/queries"
+	"github.com/influx6/pocket/api/store"
+	"github.com/influx6/pocket/api/webhooks"
+	"github.com/satori/go.uuid"
+)
+
+//==============================================================================
+
+// ErrUnknownWebhook is returned when a webhook which does not exists is
+// referenced.
+var ErrUnknownWebhook = errors.New("Unknown Webhook")
+
+// Sizing of the pool delivering webhooks and of its queue.
+const (
+	hookWorkers   = 4
+	hookQueueSize = 1024
+)
+
+// newHookDispatcher returns the dispatcher delivering the webhooks of the
+// pocketData as configured, logging every attempt into the delivery log of
+// its webhook. Deliveries only reach public addresses, so webhooks cannot be
+// pointed at the services next to the server.
+func (p *pocketData) newHookDispatcher(conf WebhooksConfig) *webhooks.Dispatcher {
+	client := webhooks.NewClient(conf.Timeout)
+
+	return webhooks.NewDispatcher(client, hookWorkers, hookQueueSize, conf.Retries, conf.Backoff, p.logDelivery)
+}
+
+// logDelivery stores the attempt into the delivery log of its webhook.
+func (p *pocketData) logDelivery(attempt webhooks.Attempt) {
+	record := budgets.DeliveryRecord{
+		ID:       uuid.NewV4().String(),
+		Webhook:  attempt.Request.Hook,
+		Delivery: attempt.Request.ID,
+		Event:    attempt.Request.Event,
+		Attempt:  attempt.Number,
+		Time:     attempt.Time,
+		Duration: attempt.Duration,
+		Status:   attempt.Status,
+	}
+
+	if attempt.Err != nil {
+		record.Error = attempt.Err.Error()
+	}
+
+	if err := p.store.SaveDelivery(record); err != nil {
+		events.Error(contexts, "pocketData.logDelivery", err, "Failed to log delivery[%s] of webhook[%s]", record.Delivery, record.Webhook)
+		return
+	}
+
+	p.changes.Touch(record.ID, record.Webhook)
+}
+
+// emit queues the payload for delivery to every webhook of its pocket which
+// receives its event. The change it tells of is already stored, so failures
+// are only logged.
+func (p *pocketData) emit(payload budgets.HookPayload) {
+	hooks, err := p.store.Webhooks(payload.Pocket)
+	if err != nil {
+		events.Error(contexts, "pocketData.emit", err, "Failed to load the webhooks of pocket[%s]", payload.Pocket)
+		return
+	}
+
+	for _, hook := range hooks {
+		if !hook.Wants(payload.Event) {
+			continue
+		}
+
+		payload.ID = uuid.NewV4().String()
+
+		body, err := json.Marshal(payload)
+		if err != nil {
+			events.Error(contexts, "pocketData.emit", err, "Failed to encode %s for webhook[%s]", payload.Event, hook.ID)
+			continue
+		}
+
+		req := webhooks.Request{
+			ID:     payload.ID,
+			Hook:   hook.ID,
+			URL:    hook.URL,
+			Secret: hook.Secret,
+			Event:  payload.Event,
+			Body:   body,
+		}
+
+		if err := p.hooks.Enqueue(req); err != nil {
+			events.Error(contexts, "pocketData.emit", err, "Failed to queue %s for webhook[%s]", payload.Event, hook.ID)
+		}
+	}
+}
+
+//==============================================================================
+
+// queryWebhooks resolves the `webhooks?pocket=<id>` query, returning the
+// webhooks of the giving pocket, the oldest first.
+func (p *pocketData) queryWebhooks(ctx context.Context, q queries.Query) (interface{}, error) {
+	id, err := q.Require("pocket")
+	if err != nil {
+		return nil, err
+	}
+
+	pocket, err := p.ownedPocket(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.store.Webhooks(pocket.ID)
+}
+
+// queryDeliveries resolves the `deliveries?webhook=<id>` query, returning the
+// delivery log of the giving webhook, the newest attempt first.
+func (p *pocketData) queryDeliveries(ctx context.Context, q queries.Query) (interface{}, error) {
+	id, err := q.Require("webhook")
+	if err != nil {
+		return nil, err
+	}
+
+	hook, err := p.ownedWebhook(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.store.Deliveries(hook.ID)
+}
+
+// ownedWebhook returns the webhook with the giving id if its pocket belongs to
+// the caller, else returns ErrUnknownWebhook.
+func (p *pocketData) ownedWebhook(ctx context.Context, id string) (budgets.WebhookRecord, error) {
+	hook, err := p.store.Webhook(id)
+	if err != nil {
+		if err == store.ErrNotFound {
+			return hook, ErrUnknownWebhook
+		}
+
+		return hook, err
+	}
+
+	if _, err := p.ownedPocket(ctx, hook.Pocket); err != nil {
+		if err == ErrUnknownPocket {
+			return budgets.WebhookRecord{}, ErrUnknownWebhook
+		}
+
+		return budgets.WebhookRecord{}, err
+	}
+
+	return hook, nil
+}
+
+//==============================================================================
+
+// AddWebhook adds a new webhook receiving the events of the caller's pocket
+// referenced by the command, signing its deliveries with a new secret.
+func (p *pocketData) AddWebhook(ctx context.Context, nw budgets.NewWebhook) (budgets.WebhookRecord, error) {
+	pocket, err := p.ownedPocket(ctx, nw.UUID)
+	if err != nil {
+		return budgets.WebhookRecord{}, err
+	}
+
+	secret, err := webhooks.NewSecret()
+	if err != nil {
+		return budgets.WebhookRecord{}, err
+	}
+
+	hook := budgets.WebhookRecord{
+		ID:      uuid.NewV4().String(),
+		Pocket:  pocket.ID,
+		URL:     nw.URL,
+		Secret:  secret,
+		Events:  nw.Events,
+		Created: time.Now(),
+	}
+
+	if err := hook.Validate(); err != nil {
+		return hook, err
+	}
+
+	if err := p.store.SaveWebhook(hook); err != nil {
+		return hook, err
+	}
+
+	return hook, p.touchPocket(pocket.ID, hook.ID)
+}
+
+// RemoveWebhook removes the caller's webhook referenced by the command along
+// with its delivery log.
+func (p *pocketData) RemoveWebhook(ctx context.Context, dw budgets.DeleteWebhook) (budgets.WebhookRecord, error) {
+	hook, err := p.ownedWebhook(ctx, dw.UUID)
+	if err != nil {
+		return hook, err
+	}
+
+	if err := p.store.DeleteWebhook(hook.ID); err != nil {
+		return hook, err
+	}
+
+	return hook, p.touchPocket(hook.Pocket, hook.ID)
+}
+
+//==============================================================================
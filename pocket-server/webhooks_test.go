@@ -0,0 +1,131 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/influx6/pocket/api/budgets"
+	"github.com/influx6/pocket/api/webhooks"
+)
+
+//==============================================================================
+
+// deliveries waits for the delivery log of the webhook to hold an attempt,
+// returning the log.
+func (ts *testServer) deliveries(t *testing.T, token string, hook string) []interface{} {
+	deadline := time.Now().Add(5 * time.Second)
+
+	for time.Now().Before(deadline) {
+		pack := ts.query(t, token, "deliveries?webhook="+hook)
+
+		if log := records(t, pack.Results[0]); len(log) > 0 {
+			return log
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatalf("Expected the delivery log of webhook %s to hold an attempt", hook)
+	return nil
+}
+
+// addHook creates a pocket and a webhook of it delivering to the giving URL,
+// returning both.
+func (ts *testServer) addHook(t *testing.T, token string, url string) (budgets.PocketRecord, budgets.WebhookRecord) {
+	var pocket budgets.PocketRecord
+	if status := ts.post(t, "/pockets", token, budgets.NewPocket{Title: "Home", Currency: "USD"}, &pocket); status != http.StatusCreated {
+		t.Fatalf("Expected pocket to be created, got status %d", status)
+	}
+
+	var hook budgets.WebhookRecord
+	nw := budgets.NewWebhook{UUID: pocket.ID, URL: url, Events: []string{budgets.EventNewBudget}}
+	if status := ts.post(t, "/webhooks", token, nw, &hook); status != http.StatusCreated {
+		t.Fatalf("Expected webhook to be created, got status %d", status)
+	}
+
+	return pocket, hook
+}
+
+//==============================================================================
+
+// TestWebhookDeliveryLog checks events are delivered signed to the webhooks of
+// their pocket, every attempt being logged.
+func TestWebhookDeliveryLog(t *testing.T) {
+	ts := newTestServer(t)
+	defer ts.Close()
+
+	received := make(chan *http.Request, 1)
+	bodies := make(chan []byte, 1)
+
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		received <- r
+		bodies <- body
+	}))
+	defer receiver.Close()
+
+	// The receiver listens on a loopback address, which the server refuses
+	// to deliver to, so the test delivers through a plain client.
+	ts.pockets.hooks.Close()
+	ts.pockets.hooks = webhooks.NewDispatcher(receiver.Client(), 1, 8, 0, time.Millisecond, ts.pockets.logDelivery)
+
+	token := ts.register(t, "owner@pocket.io")
+	pocket, hook := ts.addHook(t, token, receiver.URL)
+
+	nb := map[string]interface{}{"UUID": pocket.ID, "Title": "Rent", "Price": "1200 USD"}
+	if status := ts.post(t, "/budgets", token, nb, nil); status != http.StatusCreated {
+		t.Fatalf("Expected budget to be created, got status %d", status)
+	}
+
+	var req *http.Request
+	select {
+	case req = <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the webhook to receive the new budget")
+	}
+
+	if req.Header.Get(webhooks.EventHeader) != budgets.EventNewBudget {
+		t.Fatalf("Expected event %s, got %s", budgets.EventNewBudget, req.Header.Get(webhooks.EventHeader))
+	}
+
+	if !webhooks.Verify(hook.Secret, <-bodies, req.Header.Get(webhooks.SignatureHeader)) {
+		t.Fatal("Expected the delivery to be signed with the secret of the webhook")
+	}
+
+	log := ts.deliveries(t, token, hook.ID)
+
+	attempt, _ := log[0].(map[string]interface{})
+	if attempt["status"] != float64(http.StatusOK) || attempt["event"] != budgets.EventNewBudget {
+		t.Fatalf("Expected an accepted %s attempt within the log, got %v", budgets.EventNewBudget, attempt)
+	}
+}
+
+// TestWebhookPrivateAddress checks deliveries to addresses which are not
+// public are refused, the refusal being logged.
+func TestWebhookPrivateAddress(t *testing.T) {
+	ts := newTestServer(t)
+	defer ts.Close()
+
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Expected no delivery to reach a loopback address")
+	}))
+	defer receiver.Close()
+
+	token := ts.register(t, "owner@pocket.io")
+	pocket, hook := ts.addHook(t, token, receiver.URL)
+
+	nb := map[string]interface{}{"UUID": pocket.ID, "Title": "Rent", "Price": "1200 USD"}
+	if status := ts.post(t, "/budgets", token, nb, nil); status != http.StatusCreated {
+		t.Fatalf("Expected budget to be created, got status %d", status)
+	}
+
+	log := ts.deliveries(t, token, hook.ID)
+
+	attempt, _ := log[0].(map[string]interface{})
+	if attempt["status"] != float64(0) || attempt["error"] == nil {
+		t.Fatalf("Expected the delivery to be refused, got %v", attempt)
+	}
+}